@@ -19,6 +19,9 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -29,12 +32,15 @@ import (
 
 	"github.com/vmware/vic/cmd/tether/msgs"
 	"github.com/vmware/vic/lib/tether"
+	"github.com/vmware/vic/lib/tether/ps"
+	"github.com/vmware/vic/lib/tether/scp"
 	"github.com/vmware/vic/pkg/serial"
 	"github.com/vmware/vic/pkg/trace"
 )
 
 const (
-	attachChannelType = "attach"
+	attachChannelType  = "attach"
+	archiveChannelType = "archive"
 )
 
 // server is the singleton attachServer for the tether - there can be only one
@@ -350,7 +356,12 @@ func (t *attachServerSSH) run() error {
 		log.Println("ready to service attach requests")
 		// Service the incoming channels
 		for attachchan := range chans {
-			// The only channel type we'll support is attach
+			// archive channels are serviced independently of the sessions
+			if attachchan.ChannelType() == archiveChannelType {
+				go t.archiveChannel(attachchan)
+				continue
+			}
+
 			if attachchan.ChannelType() != attachChannelType {
 				detail := fmt.Sprintf("unknown channel type %s", attachchan.ChannelType())
 				log.Error(detail)
@@ -369,10 +380,18 @@ func (t *attachServerSSH) run() error {
 
 			sessionid := string(bytes)
 			session, ok := t.config.Sessions[sessionid]
+			if !ok {
+				session, ok = t.config.Execs[sessionid]
+			}
+
+			// sessions blocked waiting for an attach have their stdio prepared but no process yet
+			blocked := ok && session.RunBlock && session.Cmd.Process == nil && session.Outwriter != nil
 
 			reason := ""
 			if !ok {
 				reason = "is unknown"
+			} else if blocked {
+				// allow the attach so that it can unblock the launch
 			} else if session.Cmd.Process == nil {
 				reason = "process has not been launched"
 			} else if session.Cmd.Process.Signal(syscall.Signal(0)) != nil {
@@ -416,8 +435,10 @@ func (t *attachServerSSH) run() error {
 
 			// tty's merge stdout and stderr so we don't bind an additional reader in that case
 			// but we need to do so for non-tty
-			if session.Pty == nil {
-				session.Errwriter.Add(channel.Stderr())
+			if !session.Tty {
+				// the stderr writer of the channel has to be kept to remove it from the session
+				stderr := channel.Stderr()
+				session.Errwriter.Add(stderr)
 
 				// no good way to function chain, so reimplement appropriately
 				detach = func() {
@@ -425,7 +446,7 @@ func (t *attachServerSSH) run() error {
 
 					session.Outwriter.Remove(channel)
 					session.Reader.Remove(channel)
-					session.Errwriter.Remove(channel)
+					session.Errwriter.Remove(stderr)
 
 					channel.Close()
 
@@ -437,6 +458,15 @@ func (t *attachServerSSH) run() error {
 			log.Debugf("reader/writers bound for channel for %s", sessionid)
 
 			go t.channelMux(requests, session, detach)
+
+			if blocked {
+				select {
+				case <-session.ClearToLaunch:
+				default:
+					log.Infof("unblocking launch of %s", sessionid)
+					close(session.ClearToLaunch)
+				}
+			}
 		}
 
 		log.Info("incoming attach channel closed")
@@ -464,6 +494,41 @@ func (t *attachServerSSH) globalMux(reqchan <-chan *ssh.Request) {
 			}
 			msg := msgs.ContainersMsg{IDs: keys}
 			payload = msg.Marshal()
+		case msgs.StatReq:
+			msg := msgs.StatMsg{}
+			if err := msg.Unmarshal(req.Payload); err != nil {
+				ok = false
+				payload = []byte(err.Error())
+				break
+			}
+
+			reply, err := statPath(msg.Path)
+			if err != nil {
+				ok = false
+				payload = []byte(err.Error())
+				break
+			}
+			payload = reply.Marshal()
+		case msgs.TopReq:
+			msg := msgs.TopMsg{}
+			if err := msg.Unmarshal(req.Payload); err != nil {
+				ok = false
+				payload = []byte(err.Error())
+				break
+			}
+
+			// tether is an implementation detail of the containerVM so is left out of the listing
+			self := os.Getpid()
+			titles, processes, err := ps.Top(strings.Fields(msg.Args), func(p *ps.Process) bool {
+				return p.PID == self
+			})
+			if err != nil {
+				ok = false
+				payload = []byte(err.Error())
+				break
+			}
+
+			payload = msgs.NewTopResponseMsg(titles, processes).Marshal()
 		default:
 			ok = false
 			payload = []byte("unknown global request type: " + req.Type)
@@ -485,20 +550,93 @@ func (t *attachServerSSH) globalMux(reqchan <-chan *ssh.Request) {
 	}
 }
 
+// archiveChannel services a request to copy a tar archive to or from the container filesystem
+func (t *attachServerSSH) archiveChannel(newchan ssh.NewChannel) {
+	defer trace.End(trace.Begin("archive channel"))
+
+	msg := msgs.ArchiveMsg{}
+	if err := msg.Unmarshal(newchan.ExtraData()); err != nil {
+		detail := fmt.Sprintf("archive channel requires request in ExtraData: %s", err)
+		log.Error(detail)
+		newchan.Reject(ssh.Prohibited, detail)
+		return
+	}
+
+	req := &scp.Request{}
+
+	var ok bool
+	var payload []byte
+	switch msg.Op {
+	case msgs.ArchiveExport:
+		ok, payload = req.ArchiveSource(msg.Path)
+	case msgs.ArchiveImport:
+		ok, payload = req.ArchiveDestination(msg.Path, msg.NoOverwriteDirNonDir)
+	default:
+		payload = []byte(fmt.Sprintf("unknown archive operation %q", msg.Op))
+	}
+
+	if !ok {
+		detail := fmt.Sprintf("archive %s of %s: %s", msg.Op, msg.Path, string(payload))
+		log.Error(detail)
+		newchan.Reject(ssh.Prohibited, detail)
+		return
+	}
+
+	channel, requests, err := newchan.Accept()
+	if err != nil {
+		log.Errorf("could not accept archive channel: %s", err)
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	log.Infof("archive %s of %s started", msg.Op, msg.Path)
+	req.SetChannel(&channel)
+	req.GetPendingWork()()
+	log.Infof("archive %s of %s complete", msg.Op, msg.Path)
+}
+
+// statPath returns the details of the filesystem resource at path in the form docker expects
+func statPath(path string) (*msgs.StatResponseMsg, error) {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return &msgs.StatResponseMsg{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &msgs.StatResponseMsg{
+		Exists: true,
+		Name:   filepath.Base(path),
+		Size:   uint64(info.Size()),
+		Mode:   uint32(info.Mode()),
+		Mtime:  uint64(info.ModTime().UnixNano()),
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		if reply.LinkTarget, err = filepath.EvalSymlinks(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return reply, nil
+}
+
 func (t *attachServerSSH) channelMux(in <-chan *ssh.Request, session *tether.SessionConfig, detach func()) {
 	defer trace.End(trace.Begin("attach server channel request handler"))
 
 	// detach
 	defer detach()
 
-	process := session.Cmd.Process
-	pty := session.Pty
-
 	var err error
 	for req := range in {
 		var pendingFn func()
 		ok := true
 
+		// these are looked up per request as the process may have been launched after the attach
+		process := session.Cmd.Process
+		pty := session.Pty
+
 		switch req.Type {
 		case msgs.WindowChangeReq:
 			msg := msgs.WindowChangeMsg{}
@@ -517,6 +655,9 @@ func (t *attachServerSSH) channelMux(in <-chan *ssh.Request, session *tether.Ses
 			if err = msg.Unmarshal(req.Payload); err != nil {
 				ok = false
 				log.Errorf(err.Error())
+			} else if process == nil {
+				ok = false
+				log.Errorf("illegal signal request for unlaunched process")
 			} else {
 				log.Infof("Sending signal %s to container process, pid=%d\n", string(msg.Signal), process.Pid)
 				err = signalProcess(process, msg.Signal)
@@ -142,3 +142,119 @@ func (s *ContainersMsg) Marshal() []byte {
 func (s *ContainersMsg) Unmarshal(payload []byte) error {
 	return ssh.Unmarshal(payload, s)
 }
+
+// Archive operations
+const (
+	ArchiveExport = "export"
+	ArchiveImport = "import"
+)
+
+// ArchiveMsg is passed as the ExtraData of an archive channel and describes the requested transfer
+type ArchiveMsg struct {
+	Op                   string
+	Path                 string
+	NoOverwriteDirNonDir bool
+}
+
+func (s *ArchiveMsg) Marshal() []byte {
+	return ssh.Marshal(*s)
+}
+
+func (s *ArchiveMsg) Unmarshal(payload []byte) error {
+	return ssh.Unmarshal(payload, s)
+}
+
+// StatMsg
+const StatReq = "stat"
+
+type StatMsg struct {
+	Path string
+}
+
+func (s *StatMsg) RequestType() string {
+	return StatReq
+}
+
+func (s *StatMsg) Marshal() []byte {
+	return ssh.Marshal(*s)
+}
+
+func (s *StatMsg) Unmarshal(payload []byte) error {
+	return ssh.Unmarshal(payload, s)
+}
+
+// StatResponseMsg is the reply to a StatMsg. Exists is false if there is nothing at the
+// requested path.
+type StatResponseMsg struct {
+	Exists     bool
+	Name       string
+	Size       uint64
+	Mode       uint32
+	Mtime      uint64
+	LinkTarget string
+}
+
+func (s *StatResponseMsg) Marshal() []byte {
+	return ssh.Marshal(*s)
+}
+
+func (s *StatResponseMsg) Unmarshal(payload []byte) error {
+	return ssh.Unmarshal(payload, s)
+}
+
+// TopMsg
+const TopReq = "top"
+
+// TopMsg requests a process listing. Args are the ps arguments, separated by spaces.
+type TopMsg struct {
+	Args string
+}
+
+func (t *TopMsg) RequestType() string {
+	return TopReq
+}
+
+func (t *TopMsg) Marshal() []byte {
+	return ssh.Marshal(*t)
+}
+
+func (t *TopMsg) Unmarshal(payload []byte) error {
+	return ssh.Unmarshal(payload, t)
+}
+
+// TopResponseMsg is the reply to a TopMsg. ssh marshalling has no support for nested slices
+// so the values of each process are carried in Fields, len(Titles) values per process.
+type TopResponseMsg struct {
+	Titles []string
+	Fields []string
+}
+
+// NewTopResponseMsg returns a TopResponseMsg carrying the supplied titles and process rows
+func NewTopResponseMsg(titles []string, processes [][]string) *TopResponseMsg {
+	t := &TopResponseMsg{Titles: titles}
+	for _, process := range processes {
+		t.Fields = append(t.Fields, process...)
+	}
+	return t
+}
+
+// Processes returns the values of each process, one row per process
+func (t *TopResponseMsg) Processes() [][]string {
+	if len(t.Titles) == 0 {
+		return nil
+	}
+
+	var processes [][]string
+	for i := 0; i+len(t.Titles) <= len(t.Fields); i += len(t.Titles) {
+		processes = append(processes, t.Fields[i:i+len(t.Titles)])
+	}
+	return processes
+}
+
+func (t *TopResponseMsg) Marshal() []byte {
+	return ssh.Marshal(*t)
+}
+
+func (t *TopResponseMsg) Unmarshal(payload []byte) error {
+	return ssh.Unmarshal(payload, t)
+}
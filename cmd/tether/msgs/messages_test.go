@@ -69,3 +69,58 @@ func TestContainers(t *testing.T) {
 
 	assert.Equal(t, s, out)
 }
+
+func TestArchive(t *testing.T) {
+	s := &ArchiveMsg{Op: ArchiveImport, Path: "/tmp", NoOverwriteDirNonDir: true}
+
+	tmp := s.Marshal()
+	out := &ArchiveMsg{}
+	out.Unmarshal(tmp)
+
+	assert.Equal(t, s, out)
+}
+
+func TestStat(t *testing.T) {
+	s := &StatMsg{Path: "/etc/hosts"}
+
+	assert.Equal(t, s.RequestType(), StatReq)
+
+	tmp := s.Marshal()
+	out := &StatMsg{}
+	out.Unmarshal(tmp)
+
+	assert.Equal(t, s, out)
+
+	r := &StatResponseMsg{Exists: true, Name: "hosts", Size: 128, Mode: 0644, Mtime: 1, LinkTarget: ""}
+
+	tmp = r.Marshal()
+	rout := &StatResponseMsg{}
+	rout.Unmarshal(tmp)
+
+	assert.Equal(t, r, rout)
+}
+
+func TestTop(t *testing.T) {
+	s := &TopMsg{Args: "-ef"}
+
+	assert.Equal(t, s.RequestType(), TopReq)
+
+	tmp := s.Marshal()
+	out := &TopMsg{}
+	out.Unmarshal(tmp)
+
+	assert.Equal(t, s, out)
+
+	processes := [][]string{
+		{"12", "?", "00:00:00", "/bin/sh -c top"},
+		{"13", "pts/0", "00:00:01", "top -b"},
+	}
+	r := NewTopResponseMsg([]string{"PID", "TTY", "TIME", "CMD"}, processes)
+
+	tmp = r.Marshal()
+	rout := &TopResponseMsg{}
+	rout.Unmarshal(tmp)
+
+	assert.Equal(t, r, rout)
+	assert.Equal(t, processes, rout.Processes())
+}
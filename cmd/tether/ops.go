@@ -14,7 +14,17 @@
 
 package main
 
-import "github.com/vmware/vic/lib/tether"
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/vmware/vic/lib/tether"
+	"github.com/vmware/vic/pkg/dio"
+	"github.com/vmware/vic/pkg/log/forward"
+	"github.com/vmware/vic/pkg/log/jsonlog"
+)
 
 // pathPrefix is present to allow the various files referenced by tether to be placed
 // in specific directories, primarily for testing.
@@ -28,6 +38,10 @@ func (t *operations) Apply(endpoint *tether.NetworkEndpoint) error {
 	return t.BaseOperations.Apply(endpoint)
 }
 
+func (t *operations) Unapply(endpoint *tether.NetworkEndpoint) error {
+	return t.BaseOperations.Unapply(endpoint)
+}
+
 // HandleSessionExit controls the behaviour on session exit - for the tether if the session exiting
 // is the primary session (i.e. SessionID matches ExecutorID) then we exit everything.
 func (t *operations) HandleSessionExit(config *tether.ExecutorConfig, session *tether.SessionConfig) func() {
@@ -38,3 +52,39 @@ func (t *operations) HandleSessionExit(config *tether.ExecutorConfig, session *t
 		}
 	}
 }
+
+// sessionWriters returns the writers for the session output. The output is recorded on log, if
+// there is one, in the json-file format so that the streams can be told apart and filtered by
+// time, is forwarded with the session log driver if one is configured, and is passed to the
+// screen as is.
+func sessionWriters(session *tether.SessionConfig, log io.Writer) (dio.DynamicMultiWriter, dio.DynamicMultiWriter, error) {
+	var stdout, stderr []io.Writer
+
+	if log != nil {
+		output := jsonlog.New(log)
+		stdout = append(stdout, output.Stream(jsonlog.Stdout))
+		stderr = append(stderr, output.Stream(jsonlog.Stderr))
+	}
+
+	if driver := session.Logging.Driver; forward.IsForwarding(driver) {
+		f, err := forward.New(driver, session.Logging.Options, forward.Info{
+			ID:         session.ID,
+			Name:       session.Name,
+			Entrypoint: session.Cmd.Path,
+			Args:       session.Cmd.Args,
+			Created:    time.Unix(session.CreateTime, 0),
+			Env:        session.Cmd.Env,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to start %s log driver: %s", driver, err)
+		}
+
+		stdout = append(stdout, f.Stream(jsonlog.Stdout))
+		stderr = append(stderr, f.Stream(jsonlog.Stderr))
+	}
+
+	stdout = append(stdout, os.Stdout)
+	stderr = append(stderr, os.Stderr)
+
+	return dio.MultiWriter(stdout...), dio.MultiWriter(stderr...), nil
+}
@@ -51,29 +51,39 @@ func (t *operations) Log() (io.Writer, error) {
 	return out, nil
 }
 
-// sessionLogWriter returns a writer that will persist the session output
-func (t *operations) SessionLog(session *tether.SessionConfig) (dio.DynamicMultiWriter, error) {
+// SessionLog returns the writers that will persist the session output
+func (t *operations) SessionLog(session *tether.SessionConfig) (dio.DynamicMultiWriter, dio.DynamicMultiWriter, error) {
 	defer trace.End(trace.Begin("configure session log writer"))
 
 	if t.logging {
 		detail := "unable to log more than one session concurrently"
 		log.Error(detail)
-		return nil, errors.New(detail)
+		return nil, nil, errors.New(detail)
 	}
 
 	t.logging = true
 
-	// open SttyS2 for session logging
-	log.Info("opening ttyS2 for session logging")
-	f, err := os.OpenFile(pathPrefix+"/ttyS2", os.O_RDWR|os.O_SYNC|syscall.O_NOCTTY, 777)
+	// the output log is not present if the output is only forwarded
+	var output io.Writer
+	if !session.Logging.ForwardOnly {
+		// open SttyS2 for session logging
+		log.Info("opening ttyS2 for session logging")
+		f, err := os.OpenFile(pathPrefix+"/ttyS2", os.O_RDWR|os.O_SYNC|syscall.O_NOCTTY, 777)
+		if err != nil {
+			detail := fmt.Sprintf("failed to open serial port for session log: %s", err)
+			log.Error(detail)
+			return nil, nil, errors.New(detail)
+		}
+		output = f
+	}
+
+	stdout, stderr, err := sessionWriters(session, output)
 	if err != nil {
-		detail := fmt.Sprintf("failed to open serial port for session log: %s", err)
-		log.Error(detail)
-		return nil, errors.New(detail)
+		log.Error(err)
+		return nil, nil, err
 	}
 
-	// use multi-writer so it goes to both screen and session log
-	return dio.MultiWriter(f, os.Stdout), nil
+	return stdout, stderr, nil
 }
 
 func (t *operations) Setup(sink tether.Config) error {
@@ -114,8 +114,8 @@ func (t *operations) Log() (io.Writer, error) {
 	return out, nil
 }
 
-// sessionLogWriter returns a writer that will persist the session output
-func (t *operations) SessionLog(session *tether.SessionConfig) (dio.DynamicMultiWriter, error) {
+// SessionLog returns the writers that will persist the session output
+func (t *operations) SessionLog(session *tether.SessionConfig) (dio.DynamicMultiWriter, dio.DynamicMultiWriter, error) {
 	com := "COM3"
 
 	defer trace.End(trace.Begin("configure session log writer"))
@@ -123,22 +123,31 @@ func (t *operations) SessionLog(session *tether.SessionConfig) (dio.DynamicMulti
 	if t.logging {
 		detail := "unable to log more than one session concurrently"
 		log.Error(detail)
-		return nil, errors.New(detail)
+		return nil, nil, errors.New(detail)
 	}
 
 	t.logging = true
 
-	// redirect backchannel to the serial connection
-	log.Infof("opening %s%s for session logging", pathPrefix, com)
-	f, err := OpenPort(fmt.Sprintf("%s%s", pathPrefix, com))
+	var output io.Writer
+	if !session.Logging.ForwardOnly {
+		// redirect backchannel to the serial connection
+		log.Infof("opening %s%s for session logging", pathPrefix, com)
+		f, err := OpenPort(fmt.Sprintf("%s%s", pathPrefix, com))
+		if err != nil {
+			detail := fmt.Sprintf("failed to open serial port for session log: %s", err)
+			log.Error(detail)
+			return nil, nil, errors.New(detail)
+		}
+		output = f
+	}
+
+	stdout, stderr, err := sessionWriters(session, output)
 	if err != nil {
-		detail := fmt.Sprintf("failed to open serial port for session log: %s", err)
-		log.Error(detail)
-		return nil, errors.New(detail)
+		log.Error(err)
+		return nil, nil, err
 	}
 
-	// use multi-writer so it goes to both screen and session log
-	return dio.MultiWriter(f, os.Stdout), nil
+	return stdout, stderr, nil
 }
 
 func (t *operations) Setup(sink tether.Config) error {
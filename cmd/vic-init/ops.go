@@ -87,6 +87,10 @@ func (t *operations) Apply(endpoint *tether.NetworkEndpoint) error {
 	return t.BaseOperations.Apply(endpoint)
 }
 
+func (t *operations) Unapply(endpoint *tether.NetworkEndpoint) error {
+	return t.BaseOperations.Unapply(endpoint)
+}
+
 func (t *operations) Log() (io.Writer, error) {
 	defer trace.End(trace.Begin("operations.Log"))
 
@@ -108,7 +112,7 @@ func (t *operations) Log() (io.Writer, error) {
 }
 
 // sessionLogWriter returns a writer that will persist the session output
-func (t *operations) SessionLog(session *tether.SessionConfig) (dio.DynamicMultiWriter, error) {
+func (t *operations) SessionLog(session *tether.SessionConfig) (dio.DynamicMultiWriter, dio.DynamicMultiWriter, error) {
 	defer trace.End(trace.Begin("configure session log writer"))
 
 	name := session.ID
@@ -124,9 +128,11 @@ func (t *operations) SessionLog(session *tether.SessionConfig) (dio.DynamicMulti
 	if err != nil {
 		detail := fmt.Sprintf("failed to open file for session log: %s", err)
 		log.Error(detail)
-		return nil, errors.New(detail)
+		return nil, nil, errors.New(detail)
 	}
 
-	// use multi-writer so it goes to both screen and session log
-	return dio.MultiWriter(f, os.Stdout), nil
+	// the logs of appliance services are kept as plain text for the log bundles, so both
+	// streams share a writer that goes to both screen and session log
+	w := dio.MultiWriter(f, os.Stdout)
+	return w, w, nil
 }
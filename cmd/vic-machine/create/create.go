@@ -32,6 +32,7 @@ import (
 
 	"github.com/urfave/cli"
 
+	"github.com/vmware/vic/lib/config"
 	"github.com/vmware/vic/lib/install/data"
 	"github.com/vmware/vic/lib/install/management"
 	"github.com/vmware/vic/lib/install/validate"
@@ -39,6 +40,7 @@ import (
 	"github.com/vmware/vic/pkg/errors"
 	"github.com/vmware/vic/pkg/flags"
 	"github.com/vmware/vic/pkg/ip"
+	"github.com/vmware/vic/pkg/registry"
 	"github.com/vmware/vic/pkg/trace"
 
 	"golang.org/x/net/context"
@@ -96,6 +98,8 @@ type Create struct {
 	containerNetworksDNS      cli.StringSlice
 	volumeStores              cli.StringSlice
 	insecureRegistries        cli.StringSlice
+	whitelistRegistries       cli.StringSlice
+	blacklistRegistries       cli.StringSlice
 	dns                       cli.StringSlice
 	clientNetworkName         string
 	clientNetworkGateway      string
@@ -149,11 +153,19 @@ func (c *Create) Flags() []cli.Flag {
 			Hidden:      true,
 		},
 
+		// pause
+		cli.StringFlag{
+			Name:        "container-pause-mode",
+			Value:       config.PauseFreeze,
+			Usage:       "How containers are paused: \"freeze\" stops the container processes, \"suspend\" suspends the containerVM",
+			Destination: &c.PauseMode,
+		},
+
 		// volume
 		cli.StringSliceFlag{
 			Name:  "volume-store, vs",
 			Value: &c.volumeStores,
-			Usage: "Specify a list of location and label for volume store, e.g. \"datastore/path:label\" or \"datastore:label\".",
+			Usage: "Specify a list of location and label for volume store, e.g. \"datastore/path:label\" or \"datastore:label\", or an NFS export for volumes shared between containers, e.g. \"nfs://host/path:label\".",
 		},
 
 		// bridge
@@ -384,6 +396,16 @@ func (c *Create) Flags() []cli.Flag {
 			Value: &c.insecureRegistries,
 			Usage: "Specify a list of permitted insecure registry server URLs",
 		},
+		cli.StringSliceFlag{
+			Name:  "whitelist-registry, wr",
+			Value: &c.whitelistRegistries,
+			Usage: "Specify a list of registries the VCH may use, as hostnames with optional port, wildcards (*.example.com) or CIDRs (10.0.0.0/8). Other registries are refused",
+		},
+		cli.StringSliceFlag{
+			Name:  "blacklist-registry, br",
+			Value: &c.blacklistRegistries,
+			Usage: "Specify a list of registries the VCH may not use, as hostnames with optional port, wildcards (*.example.com) or CIDRs (10.0.0.0/8)",
+		},
 	}
 
 	util := []cli.Flag{
@@ -497,6 +519,14 @@ func (c *Create) processParams() error {
 		return err
 	}
 
+	if err := c.processRegistryLists(); err != nil {
+		return err
+	}
+
+	if c.PauseMode != config.PauseFreeze && c.PauseMode != config.PauseSuspend {
+		return cli.NewExitError(fmt.Sprintf("--container-pause-mode must be %q or %q", config.PauseFreeze, config.PauseSuspend), 1)
+	}
+
 	return nil
 }
 
@@ -713,11 +743,12 @@ func (c *Create) processVolumeStores() error {
 	defer trace.End(trace.Begin(""))
 	c.VolumeLocations = make(map[string]string)
 	for _, arg := range c.volumeStores {
-		splitMeta := strings.SplitN(arg, ":", 2)
-		if len(splitMeta) != 2 {
-			return errors.New("Volume store input must be in format datastore/path:label")
+		// the label follows the last colon, as nfs URLs contain colons
+		i := strings.LastIndex(arg, ":")
+		if i <= 0 || i == len(arg)-1 {
+			return errors.New("Volume store input must be in format datastore/path:label or nfs://host/path:label")
 		}
-		c.VolumeLocations[splitMeta[1]] = splitMeta[0]
+		c.VolumeLocations[arg[i+1:]] = arg[:i]
 	}
 
 	return nil
@@ -735,6 +766,33 @@ func (c *Create) processInsecureRegistries() error {
 	return nil
 }
 
+func (c *Create) processRegistryLists() error {
+	var err error
+	if c.RegistryWhitelist, err = parseRegistryList(c.whitelistRegistries); err != nil {
+		return cli.NewExitError(fmt.Sprintf("Invalid registry whitelist: %s", err), 1)
+	}
+	if c.RegistryBlacklist, err = parseRegistryList(c.blacklistRegistries); err != nil {
+		return cli.NewExitError(fmt.Sprintf("Invalid registry blacklist: %s", err), 1)
+	}
+
+	return nil
+}
+
+// parseRegistryList validates the registry entries and returns them in their canonical form,
+// stored as the host of each URL
+func parseRegistryList(entries []string) ([]url.URL, error) {
+	var urls []url.URL
+	for _, e := range entries {
+		entry, err := registry.ParseEntry(e)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, url.URL{Host: entry.String()})
+	}
+
+	return urls, nil
+}
+
 func (c *Create) loadCertificates() ([]byte, *certificate.KeyPair, error) {
 	defer trace.End(trace.Begin(""))
 
@@ -943,6 +1001,9 @@ func (c *Create) Run(cliContext *cli.Context) (err error) {
 	vConfig.BootstrapISO = path.Base(c.BootstrapISO)
 
 	vchConfig.InsecureRegistries = c.Data.InsecureRegistries
+	vchConfig.RegistryWhitelist = c.Data.RegistryWhitelist
+	vchConfig.RegistryBlacklist = c.Data.RegistryBlacklist
+	vchConfig.PauseMode = c.Data.PauseMode
 
 	if validator.Session.IsVC() { // create certificates for VCH extension
 		var certbuffer, keybuffer bytes.Buffer
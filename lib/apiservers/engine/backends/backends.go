@@ -39,6 +39,7 @@ import (
 	"github.com/vmware/vic/lib/config"
 	"github.com/vmware/vic/lib/imagec"
 	"github.com/vmware/vic/pkg/errors"
+	vicregistry "github.com/vmware/vic/pkg/registry"
 	"github.com/vmware/vic/pkg/vsphere/sys"
 )
 
@@ -60,6 +61,8 @@ var (
 	vchConfig *config.VirtualContainerHostConfigSpec
 
 	insecureRegistries []string
+	registryWhitelist  vicregistry.Set
+	registryBlacklist  vicregistry.Set
 	RegistryService    *registry.Service
 )
 
@@ -97,6 +100,9 @@ func Init(portLayerAddr, product string, config *config.VirtualContainerHostConf
 		return err
 	}
 
+	// relay the port layer events once the container cache can supply their attributes
+	go monitorEvents(&SystemProxy{})
+
 	log.Info("Creating image store")
 	if err := createImageStore(); err != nil {
 		log.Errorf("Failed to create image store")
@@ -113,9 +119,28 @@ func Init(portLayerAddr, product string, config *config.VirtualContainerHostConf
 	log.Debugf("New registry service with options %#v", serviceOptions)
 	RegistryService = registry.NewService(serviceOptions)
 
+	if config != nil {
+		if registryWhitelist, err = parseRegistryList(config.RegistryWhitelist); err != nil {
+			return fmt.Errorf("Invalid registry whitelist: %s", err)
+		}
+		if registryBlacklist, err = parseRegistryList(config.RegistryBlacklist); err != nil {
+			return fmt.Errorf("Invalid registry blacklist: %s", err)
+		}
+	}
+
 	return nil
 }
 
+// parseRegistryList parses the registry entries, which vic-machine stores as the host of each URL
+func parseRegistryList(urls []url.URL) (vicregistry.Set, error) {
+	entries := make([]string, len(urls))
+	for i := range urls {
+		entries[i] = urls[i].Host
+	}
+
+	return vicregistry.ParseSet(entries)
+}
+
 func hydrateCaches() error {
 
 	const waiters = 3
@@ -245,8 +270,44 @@ func createImageStore() error {
 	return nil
 }
 
+// RegistryWhitelist returns the registries the VCH is restricted to, if any
+func RegistryWhitelist() []string {
+	return registryWhitelist.Strings()
+}
+
+// RegistryBlacklist returns the registries the VCH may not use
+func RegistryBlacklist() []string {
+	return registryBlacklist.Strings()
+}
+
+// CheckRegistryAccess returns an error if the registry at host, which may include a port, is
+// not in the registry whitelist of the VCH or is in its blacklist
+func CheckRegistryAccess(host string) error {
+	if isDockerHub(host) {
+		host = registry.IndexName
+	}
+
+	if len(registryWhitelist) > 0 && !registryWhitelist.Match(host) {
+		return ForbiddenError(fmt.Sprintf("Access denied to unauthorized registry (%s) while VCH is in whitelist mode", host))
+	}
+	if registryBlacklist.Match(host) {
+		return ForbiddenError(fmt.Sprintf("Access denied to blacklisted registry (%s)", host))
+	}
+
+	return nil
+}
+
+// isDockerHub returns true if host is one of the names the docker hub is known by
+func isDockerHub(host string) bool {
+	switch host {
+	case "", IndexServerAddress, "index.docker.io", registry.IndexName:
+		return true
+	}
+	return false
+}
+
 func InsecureRegistries() []string {
-	registries := make([]string, len(insecureRegistries))
+	registries := make([]string, 0, len(insecureRegistries))
 	for _, reg := range insecureRegistries {
 		registries = append(registries, reg)
 	}
@@ -268,6 +329,21 @@ func syncContainerCache() error {
 	}
 
 	log.Debugf("Found %d containers", len(containme.Payload))
+
+	// only the port bindings of running containers are mapped again
+	if err = loadPortAllocations(client); err != nil {
+		return errors.Errorf("Failed to load port bindings: %s", err)
+	}
+	running := make(map[string]bool)
+	for _, info := range containme.Payload {
+		if info.ContainerConfig.State != nil && *info.ContainerConfig.State == "Running" {
+			running[*info.ContainerConfig.ContainerID] = true
+		}
+	}
+	if err = allocations.prune(func(id string) bool { return running[id] }); err != nil {
+		log.Warnf("Unable to save port bindings: %s", err)
+	}
+
 	cc := cache.ContainerCache()
 	var errs []string
 	for _, info := range containme.Payload {
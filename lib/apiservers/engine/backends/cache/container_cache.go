@@ -15,6 +15,7 @@
 package cache
 
 import (
+	"fmt"
 	"sync"
 
 	log "github.com/Sirupsen/logrus"
@@ -28,18 +29,20 @@ import (
 type CCache struct {
 	m sync.RWMutex
 
-	idIndex          *truncindex.TruncIndex
-	containersByID   map[string]*container.VicContainer
-	containersByName map[string]*container.VicContainer
+	idIndex            *truncindex.TruncIndex
+	containersByID     map[string]*container.VicContainer
+	containersByName   map[string]*container.VicContainer
+	containersByExecID map[string]*container.VicContainer
 }
 
 var containerCache *CCache
 
 func init() {
 	containerCache = &CCache{
-		idIndex:          truncindex.NewTruncIndex([]string{}),
-		containersByID:   make(map[string]*container.VicContainer),
-		containersByName: make(map[string]*container.VicContainer),
+		idIndex:            truncindex.NewTruncIndex([]string{}),
+		containersByID:     make(map[string]*container.VicContainer),
+		containersByName:   make(map[string]*container.VicContainer),
+		containersByExecID: make(map[string]*container.VicContainer),
 	}
 }
 
@@ -95,7 +98,59 @@ func (cc *CCache) DeleteContainer(nameOrID string) {
 	delete(cc.containersByID, container.ContainerID)
 	delete(cc.containersByName, container.Name)
 
+	for eid, vc := range cc.containersByExecID {
+		if vc == container {
+			delete(cc.containersByExecID, eid)
+		}
+	}
+
 	if err := cc.idIndex.Delete(container.ContainerID); err != nil {
 		log.Warnf("Error deleting ID from index: %s", err)
 	}
 }
+
+// UpdateContainerName changes the name the container is cached under. It fails if another
+// container is already cached under the new name.
+func (cc *CCache) UpdateContainerName(oldName, newName string) error {
+	cc.m.Lock()
+	defer cc.m.Unlock()
+
+	container := cc.getContainer(oldName)
+	if container == nil {
+		return fmt.Errorf("No such container: %s", oldName)
+	}
+
+	if other, exists := cc.containersByName[newName]; exists {
+		return fmt.Errorf("The name %q is already in use by container %s", newName, other.ContainerID)
+	}
+
+	delete(cc.containersByName, container.Name)
+	container.Name = newName
+	cc.containersByName[newName] = container
+
+	return nil
+}
+
+// AddExecToContainer records that the exec session eid belongs to container
+func (cc *CCache) AddExecToContainer(container *container.VicContainer, eid string) {
+	cc.m.Lock()
+	defer cc.m.Unlock()
+
+	cc.containersByExecID[eid] = container
+}
+
+// RemoveExec forgets the exec session eid
+func (cc *CCache) RemoveExec(eid string) {
+	cc.m.Lock()
+	defer cc.m.Unlock()
+
+	delete(cc.containersByExecID, eid)
+}
+
+// GetContainerFromExec returns the container hosting the exec session eid
+func (cc *CCache) GetContainerFromExec(eid string) *container.VicContainer {
+	cc.m.RLock()
+	defer cc.m.RUnlock()
+
+	return cc.containersByExecID[eid]
+}
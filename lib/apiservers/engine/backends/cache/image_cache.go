@@ -207,6 +207,15 @@ func (ic *ICache) Add(imageConfig *metadata.ImageConfig) {
 	ic.m.Lock()
 	defer ic.m.Unlock()
 
+	imageID := prefixImageID(imageConfig.ImageID)
+
+	// untagged images, e.g. from a commit without a repository, are only known by ID
+	if imageConfig.Name == "" {
+		ic.iDIndex.Add(imageConfig.ImageID)
+		ic.cacheByID[imageID] = imageConfig
+		return
+	}
+
 	// Normalize the name stored in imageConfig using Docker's reference code
 	ref, err := reference.WithName(imageConfig.Name)
 	if err != nil {
@@ -214,7 +223,6 @@ func (ic *ICache) Add(imageConfig *metadata.ImageConfig) {
 		return
 	}
 
-	imageID := prefixImageID(imageConfig.ImageID)
 	ic.iDIndex.Add(imageConfig.ImageID)
 	ic.cacheByID[imageID] = imageConfig
 
@@ -15,8 +15,10 @@
 package backends
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"sort"
 	"strconv"
@@ -35,13 +37,14 @@ import (
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/docker/pkg/version"
 	"github.com/docker/docker/reference"
+	"github.com/docker/docker/utils"
 	"github.com/docker/engine-api/types"
 	containertypes "github.com/docker/engine-api/types/container"
 	dnetwork "github.com/docker/engine-api/types/network"
 	timetypes "github.com/docker/engine-api/types/time"
 	"github.com/docker/go-connections/nat"
 	"github.com/docker/go-units"
-	"github.com/docker/libnetwork/portallocator"
+	"github.com/go-swagger/go-swagger/swag"
 	"github.com/vishvananda/netlink"
 
 	"github.com/vmware/vic/lib/apiservers/engine/backends/cache"
@@ -52,12 +55,16 @@ import (
 	"github.com/vmware/vic/lib/apiservers/portlayer/client/scopes"
 	"github.com/vmware/vic/lib/apiservers/portlayer/models"
 	"github.com/vmware/vic/lib/metadata"
+	"github.com/vmware/vic/pkg/log/forward"
 	"github.com/vmware/vic/pkg/trace"
 	"github.com/vmware/vic/pkg/uid"
 )
 
 const (
 	bridgeIfaceName = "bridge"
+
+	// minMemory is the smallest memory limit docker accepts
+	minMemory = 4 << 20
 )
 
 var (
@@ -70,15 +77,11 @@ var (
 
 	portMapper portmap.PortMapper
 
-	cbpLock         sync.Mutex
-	containerByPort map[string]string // port:containerID
-
 	ctx = context.TODO()
 )
 
 func init() {
 	portMapper = portmap.NewPortMapper()
-	containerByPort = make(map[string]string)
 
 	l, err := netlink.LinkByName(externalIfaceName)
 	if l == nil {
@@ -136,32 +139,178 @@ func NewContainerBackend() *Container {
 
 // ContainerExecCreate sets up an exec in a running container.
 func (c *Container) ContainerExecCreate(config *types.ExecConfig) (string, error) {
-	return "", fmt.Errorf("%s does not implement container.ContainerExecCreate", ProductName())
+	defer trace.End(trace.Begin(config.Container))
+
+	// Look up the container name in the metadata cache to get long ID
+	vc := cache.ContainerCache().GetContainer(config.Container)
+	if vc == nil {
+		return "", NotFoundError(config.Container)
+	}
+	id := vc.ContainerID
+
+	state, err := c.containerProxy.State(vc)
+	if err != nil {
+		return "", err
+	}
+	if !state.Running {
+		return "", ConflictError(fmt.Sprintf("Container %s is not running", id))
+	}
+	if state.Paused {
+		return "", ConflictError(fmt.Sprintf("Container %s is paused, unpause the container before exec", id))
+	}
+
+	handle, err := c.Handle(id, vc.Name)
+	if err != nil {
+		return "", err
+	}
+
+	// exec runs as the user of the container unless another is specified
+	if config.User == "" && vc.Config != nil {
+		config.User = vc.Config.User
+	}
+
+	eid, err := c.containerProxy.CreateExecTask(handle, config)
+	if err != nil {
+		return "", err
+	}
+
+	cache.ContainerCache().AddExecToContainer(vc, eid)
+	return eid, nil
 }
 
 // ContainerExecInspect returns low-level information about the exec
 // command. An error is returned if the exec cannot be found.
-func (c *Container) ContainerExecInspect(id string) (*backend.ExecInspect, error) {
-	return nil, fmt.Errorf("%s does not implement container.ContainerExecInspect", ProductName())
+func (c *Container) ContainerExecInspect(eid string) (*backend.ExecInspect, error) {
+	defer trace.End(trace.Begin(eid))
+
+	vc := cache.ContainerCache().GetContainerFromExec(eid)
+	if vc == nil {
+		return nil, ExecNotFoundError(eid)
+	}
+
+	handle, err := c.Handle(vc.ContainerID, vc.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	ec, err := c.containerProxy.InspectExecTask(handle, eid)
+	if err != nil {
+		return nil, err
+	}
+
+	inspect := &backend.ExecInspect{
+		ID:      eid,
+		Running: swag.BoolValue(ec.Running),
+		ProcessConfig: &backend.ExecProcessConfig{
+			Tty:        swag.BoolValue(ec.Tty),
+			Entrypoint: swag.StringValue(ec.Path),
+			Arguments:  ec.Args,
+			User:       swag.StringValue(ec.User),
+		},
+		OpenStdin:   swag.BoolValue(ec.OpenStdin),
+		OpenStdout:  swag.BoolValue(ec.Attach),
+		OpenStderr:  swag.BoolValue(ec.Attach),
+		ContainerID: vc.ContainerID,
+	}
+
+	// the exit code is only meaningful once the process has been launched and has exited
+	if swag.StringValue(ec.Started) != "" && !swag.BoolValue(ec.Running) {
+		exitCode := int(swag.Int32Value(ec.ExitCode))
+		inspect.ExitCode = &exitCode
+
+		// the session is of no further use once its exit code has been collected, so it is
+		// removed rather than left to accumulate in the container config
+		if err := c.containerProxy.RemoveExecTask(handle, eid); err != nil {
+			log.Warnf("Unable to remove exec %s from container %s: %s", eid, vc.ContainerID, err)
+		} else {
+			cache.ContainerCache().RemoveExec(eid)
+		}
+	}
+
+	return inspect, nil
 }
 
 // ContainerExecResize changes the size of the TTY of the process
 // running in the exec with the given name to the given height and
 // width.
-func (c *Container) ContainerExecResize(name string, height, width int) error {
-	return fmt.Errorf("%s does not implement container.ContainerExecResize", ProductName())
+func (c *Container) ContainerExecResize(eid string, height, width int) error {
+	defer trace.End(trace.Begin(eid))
+
+	if cache.ContainerCache().GetContainerFromExec(eid) == nil {
+		return ExecNotFoundError(eid)
+	}
+
+	return c.containerProxy.ResizeExec(eid, int32(height), int32(width))
 }
 
 // ContainerExecStart starts a previously set up exec instance. The
 // std streams are set up.
-func (c *Container) ContainerExecStart(name string, stdin io.ReadCloser, stdout io.Writer, stderr io.Writer) error {
-	return fmt.Errorf("%s does not implement container.ContainerExecStart", ProductName())
+func (c *Container) ContainerExecStart(eid string, stdin io.ReadCloser, stdout io.Writer, stderr io.Writer) error {
+	defer trace.End(trace.Begin(eid))
+
+	vc := cache.ContainerCache().GetContainerFromExec(eid)
+	if vc == nil {
+		return ExecNotFoundError(eid)
+	}
+
+	handle, err := c.Handle(vc.ContainerID, vc.Name)
+	if err != nil {
+		return err
+	}
+
+	ec, err := c.containerProxy.InspectExecTask(handle, eid)
+	if err != nil {
+		return err
+	}
+
+	if swag.StringValue(ec.Started) != "" {
+		return ConflictError(fmt.Sprintf("Exec %s has already been started", eid))
+	}
+
+	if err := c.containerProxy.StartExecTask(handle, eid); err != nil {
+		return err
+	}
+
+	// detached exec - nothing to stream
+	if !swag.BoolValue(ec.Attach) || (stdout == nil && stderr == nil) {
+		return nil
+	}
+
+	if stdin == nil {
+		stdin = ioutil.NopCloser(strings.NewReader(""))
+	}
+
+	ac := &AttachConfig{
+		ContainerAttachConfig: &backend.ContainerAttachConfig{
+			UseStdin:  swag.BoolValue(ec.OpenStdin),
+			UseStdout: stdout != nil,
+			// tty sessions merge stderr into stdout
+			UseStderr: stderr != nil && !swag.BoolValue(ec.Tty),
+		},
+		ID:         eid,
+		UseTty:     swag.BoolValue(ec.Tty),
+		CloseStdin: true,
+	}
+
+	err = c.containerProxy.AttachStreams(context.Background(), ac, stdin, stdout, stderr)
+	if _, ok := err.(DetachError); ok {
+		log.Infof("Detached from exec %s", eid)
+		return nil
+	}
+
+	return err
 }
 
 // ExecExists looks up the exec instance and returns a bool if it exists or not.
 // It will also return the error produced by `getConfig`
-func (c *Container) ExecExists(name string) (bool, error) {
-	return false, fmt.Errorf("%s does not implement container.ExecExists", ProductName())
+func (c *Container) ExecExists(eid string) (bool, error) {
+	defer trace.End(trace.Begin(eid))
+
+	if cache.ContainerCache().GetContainerFromExec(eid) == nil {
+		return false, ExecNotFoundError(eid)
+	}
+
+	return true, nil
 }
 
 // docker's container.copyBackend
@@ -170,19 +319,50 @@ func (c *Container) ExecExists(name string) (bool, error) {
 // specified path in the container identified by the given name. Returns a
 // tar archive of the resource and whether it was a directory or a single file.
 func (c *Container) ContainerArchivePath(name string, path string) (content io.ReadCloser, stat *types.ContainerPathStat, err error) {
-	return nil, nil, fmt.Errorf("%s does not implement container.ContainerArchivePath", ProductName())
+	defer trace.End(trace.Begin(name))
+
+	vc := cache.ContainerCache().GetContainer(name)
+	if vc == nil {
+		return nil, nil, NotFoundError(name)
+	}
+
+	if stat, err = c.containerProxy.StatPath(vc, path); err != nil {
+		return nil, nil, err
+	}
+
+	if content, err = c.containerProxy.ArchivePath(vc, path); err != nil {
+		return nil, nil, err
+	}
+
+	return content, stat, nil
 }
 
 // ContainerCopy performs a deprecated operation of archiving the resource at
 // the specified path in the container identified by the given name.
 func (c *Container) ContainerCopy(name string, res string) (io.ReadCloser, error) {
-	return nil, fmt.Errorf("%s does not implement container.ContainerCopy", ProductName())
+	defer trace.End(trace.Begin(name))
+
+	content, _, err := c.ContainerArchivePath(name, res)
+	return content, err
 }
 
 // ContainerExport writes the contents of the container to the given
 // writer. An error is returned if the container cannot be found.
 func (c *Container) ContainerExport(name string, out io.Writer) error {
-	return fmt.Errorf("%s does not implement container.ContainerExport", ProductName())
+	defer trace.End(trace.Begin(name))
+
+	vc := cache.ContainerCache().GetContainer(name)
+	if vc == nil {
+		return NotFoundError(name)
+	}
+
+	if err := c.containerProxy.Export(vc, out); err != nil {
+		return err
+	}
+
+	logContainerEvent(vc, "export")
+
+	return nil
 }
 
 // ContainerExtractToDir extracts the given archive to the specified location
@@ -192,13 +372,27 @@ func (c *Container) ContainerExport(name string, out io.Writer) error {
 // be an error if unpacking the given content would cause an existing directory
 // to be replaced with a non-directory and vice versa.
 func (c *Container) ContainerExtractToDir(name, path string, noOverwriteDirNonDir bool, content io.Reader) error {
-	return fmt.Errorf("%s does not implement container.ContainerExtractToDir", ProductName())
+	defer trace.End(trace.Begin(name))
+
+	vc := cache.ContainerCache().GetContainer(name)
+	if vc == nil {
+		return NotFoundError(name)
+	}
+
+	return c.containerProxy.ExtractToDir(vc, path, noOverwriteDirNonDir, content)
 }
 
 // ContainerStatPath stats the filesystem resource at the specified path in the
 // container identified by the given name.
 func (c *Container) ContainerStatPath(name string, path string) (stat *types.ContainerPathStat, err error) {
-	return nil, fmt.Errorf("%s does not implement container.ContainerStatPath", ProductName())
+	defer trace.End(trace.Begin(name))
+
+	vc := cache.ContainerCache().GetContainer(name)
+	if vc == nil {
+		return nil, NotFoundError(name)
+	}
+
+	return c.containerProxy.StatPath(vc, path)
 }
 
 // docker's container.stateBackend
@@ -324,14 +518,112 @@ func (c *Container) ContainerKill(name string, sig uint64) error {
 
 // ContainerPause pauses a container
 func (c *Container) ContainerPause(name string) error {
-	return fmt.Errorf("%s does not implement container.ContainerPause", ProductName())
+	defer trace.End(trace.Begin(name))
+
+	// Look up the container name in the metadata cache to get long ID
+	vc := cache.ContainerCache().GetContainer(name)
+	if vc == nil {
+		return NotFoundError(name)
+	}
+	id := vc.ContainerID
+
+	client := c.containerProxy.Client()
+	_, err := client.Containers.ContainerPause(containers.NewContainerPauseParamsWithContext(ctx).WithID(id))
+	if err != nil {
+		switch err := err.(type) {
+		case *containers.ContainerPauseNotFound:
+			cache.ContainerCache().DeleteContainer(id)
+			return NotFoundError(name)
+		case *containers.ContainerPauseConflict:
+			return derr.NewRequestConflictError(fmt.Errorf(err.Payload.Message))
+		case *containers.ContainerPauseInternalServerError:
+			return InternalServerError(err.Payload.Message)
+		default:
+			return InternalServerError(err.Error())
+		}
+	}
+
+	return nil
 }
 
 // ContainerRename changes the name of a container, using the oldName
 // to find the container. An error is returned if newName is already
 // reserved.
 func (c *Container) ContainerRename(oldName, newName string) error {
-	return fmt.Errorf("%s does not implement container.ContainerRename", ProductName())
+	defer trace.End(trace.Begin(oldName))
+
+	if oldName == "" || newName == "" {
+		return derr.NewBadRequestError(fmt.Errorf("Neither old nor new names may be empty"))
+	}
+
+	if !utils.RestrictedNamePattern.MatchString(newName) {
+		return derr.NewBadRequestError(fmt.Errorf("Invalid container name (%s), only %s are allowed", newName, utils.RestrictedNameChars))
+	}
+	newName = strings.TrimPrefix(newName, "/")
+
+	// Look up the container name in the metadata cache to get long ID
+	vc := cache.ContainerCache().GetContainer(oldName)
+	if vc == nil {
+		return NotFoundError(oldName)
+	}
+	id := vc.ContainerID
+	oldName = vc.Name
+
+	if oldName == newName {
+		return derr.NewBadRequestError(fmt.Errorf("Renaming a container with the same name as its current name"))
+	}
+
+	// claim the new name in the cache first so that it cannot be taken by a concurrent create or rename
+	if err := cache.ContainerCache().UpdateContainerName(oldName, newName); err != nil {
+		return derr.NewRequestConflictError(fmt.Errorf("Error when allocating new name: %s", err))
+	}
+
+	var err error
+	defer func() {
+		if err != nil {
+			cache.ContainerCache().UpdateContainerName(newName, oldName)
+		}
+	}()
+
+	client := c.containerProxy.Client()
+
+	handle, err := c.Handle(id, oldName)
+	if err != nil {
+		return err
+	}
+
+	var renameRes *containers.ContainerRenameOK
+	renameRes, err = client.Containers.ContainerRename(containers.NewContainerRenameParamsWithContext(ctx).WithHandle(handle).WithName(newName))
+	if err != nil {
+		switch err := err.(type) {
+		case *containers.ContainerRenameNotFound:
+			cache.ContainerCache().DeleteContainer(id)
+			return NotFoundError(oldName)
+		case *containers.ContainerRenameDefault:
+			return InternalServerError(err.Payload.Message)
+		default:
+			return InternalServerError(err.Error())
+		}
+	}
+
+	// commit the handle; this updates the guest, the VM display name and the name resolution
+	// of the container
+	_, err = client.Containers.Commit(containers.NewCommitParamsWithContext(ctx).WithHandle(renameRes.Payload))
+	if err != nil {
+		switch err := err.(type) {
+		case *containers.CommitNotFound:
+			cache.ContainerCache().DeleteContainer(id)
+			return NotFoundError(oldName)
+		case *containers.CommitConflict:
+			return ConflictError(err.Error())
+		case *containers.CommitDefault:
+			return InternalServerError(err.Payload.Message)
+		default:
+			return InternalServerError(err.Error())
+		}
+	}
+
+	return nil
 }
 
 // ContainerResize changes the size of the TTY of the process running
@@ -422,36 +714,38 @@ func (c *Container) ContainerRm(name string, config *types.ContainerRmConfig) er
 // cleanupPortBindings gets port bindings for the container and
 // unmaps ports if the cVM that previously bound them isn't powered on
 func (c *Container) cleanupPortBindings(vc *viccontainer.VicContainer) error {
+	// the container is being started, so any ports still mapped for it are stale
+	if err := c.unmapPorts(vc.ContainerID); err != nil {
+		return fmt.Errorf("Failed to unmap host ports for container %q: %s", vc.ContainerID, err)
+	}
+
 	for ctrPort, hostPorts := range vc.HostConfig.PortBindings {
 		for _, hostPort := range hostPorts {
 			hPort := hostPort.HostPort
 
-			cbpLock.Lock()
-			mappedCtr, mapped := containerByPort[hPort]
-			cbpLock.Unlock()
-			if !mapped {
+			allocations.Lock()
+			mappedCtr := allocations.owner(ctrPort, hostPort)
+			allocations.Unlock()
+			if mappedCtr == "" {
 				continue
 			}
 
 			log.Debugf("Container %q maps host port %s to container port %s", mappedCtr, hPort, ctrPort)
-			// check state of the previously bound container with PL
-			cc := cache.ContainerCache().GetContainer(mappedCtr)
-			if cc == nil {
-				return fmt.Errorf("Unable to find container %q in the cache, unable to get power state", mappedCtr)
-			}
-			running, err := c.containerProxy.IsRunning(cc)
-			if err != nil {
-				return fmt.Errorf("Failed to get container %q power state: %s",
-					mappedCtr, err)
-			}
-			if running {
-				log.Debugf("Running container %q still holds port %s", mappedCtr, hPort)
-				continue
+			// check state of the previously bound container with PL, which may have been removed
+			if cc := cache.ContainerCache().GetContainer(mappedCtr); cc != nil {
+				running, err := c.containerProxy.IsRunning(cc)
+				if err != nil {
+					return fmt.Errorf("Failed to get container %q power state: %s",
+						mappedCtr, err)
+				}
+				if running {
+					log.Debugf("Running container %q still holds port %s", mappedCtr, hPort)
+					continue
+				}
 			}
 
 			log.Debugf("Unmapping ports for powered off container %q", mappedCtr)
-			err = c.unmapPorts(cc.HostConfig)
-			if err != nil {
+			if err := c.unmapPorts(mappedCtr); err != nil {
 				return fmt.Errorf("Failed to unmap host port %s for container %q: %s",
 					hPort, mappedCtr, err)
 			}
@@ -558,7 +852,7 @@ func (c *Container) containerStart(name string, hostConfig *containertypes.HostC
 
 		defer func() {
 			if err != nil {
-				c.unmapPorts(hostConfig)
+				c.unmapPorts(id)
 			}
 		}()
 	}
@@ -582,60 +876,6 @@ func (c *Container) containerStart(name string, hostConfig *containertypes.HostC
 	return nil
 }
 
-// requestHostPort finds a free port on the host
-func requestHostPort(proto string) (int, error) {
-	pa := portallocator.Get()
-	return pa.RequestPortInRange(nil, proto, 0, 0)
-}
-
-type portMapping struct {
-	intHostPort int
-	strHostPort string
-	portProto   nat.Port
-}
-
-// unrollPortMap processes config for mapping/unmapping ports e.g. from hostconfig.PortBindings
-func unrollPortMap(portMap nat.PortMap) ([]*portMapping, error) {
-	var portMaps []*portMapping
-	for i, pb := range portMap {
-
-		proto, port := nat.SplitProtoPort(string(i))
-		nport, err := nat.NewPort(proto, port)
-		if err != nil {
-			return nil, err
-		}
-
-		// iterate over all the ports in pb []nat.PortBinding
-		for _, p := range pb {
-			var hostPort int
-			var hPort string
-			if p.HostPort == "" {
-				// use a random port since no host port is specified
-				hostPort, err = requestHostPort(proto)
-				if err != nil {
-					log.Errorf("could not find available port on host")
-					return nil, err
-				}
-				// update the hostconfig
-				p.HostPort = strconv.Itoa(hostPort)
-
-			} else {
-				hostPort, err = strconv.Atoi(p.HostPort)
-				if err != nil {
-					return nil, err
-				}
-			}
-			hPort = strconv.Itoa(hostPort)
-			portMaps = append(portMaps, &portMapping{
-				intHostPort: hostPort,
-				strHostPort: hPort,
-				portProto:   nport,
-			})
-		}
-	}
-	return portMaps, nil
-}
-
 // mapPorts maps ports defined in hostconfig for containerID
 func (c *Container) mapPorts(hostconfig *containertypes.HostConfig, endpoint *models.EndpointConfig, containerID string) error {
 	log.Debugf("mapPorts for %q: %v", containerID, hostconfig.PortBindings)
@@ -653,55 +893,72 @@ func (c *Container) mapPorts(hostconfig *containertypes.HostConfig, endpoint *mo
 		return fmt.Errorf("invalid endpoint address %s", endpoint.Address)
 	}
 
-	portMap, err := unrollPortMap(hostconfig.PortBindings)
+	allocations.Lock()
+	defer allocations.Unlock()
+
+	// ports are still recorded for a running container when the appliance restarts
+	var err error
+	ports, restored := allocations.containers[containerID]
+	if restored {
+		err = reservePorts(ports)
+	} else {
+		ports, err = allocatePorts(hostconfig.PortBindings)
+	}
 	if err != nil {
 		return err
 	}
 
-	cbpLock.Lock()
-	defer cbpLock.Unlock()
-	for _, p := range portMap {
-		if err = portMapper.MapPort(nil, p.intHostPort, p.portProto.Proto(), containerIP.String(), p.portProto.Int(), externalIfaceName, bridgeIfaceName); err != nil {
+	var mapped []portRange
+	for _, r := range portRanges(ports) {
+		if err = mapPortRange(r, containerIP); err != nil {
+			for _, m := range mapped {
+				unmapPortRange(m)
+			}
+			releasePorts(ports)
 			return err
 		}
+		mapped = append(mapped, r)
+	}
 
-		// update mapped ports
-		containerByPort[p.strHostPort] = containerID
-		log.Debugf("mapped port %s for container %s", p.strHostPort, containerID)
+	// update mapped ports
+	allocations.containers[containerID] = ports
+	if err = allocations.save(); err != nil {
+		log.Warnf("Unable to save port bindings: %s", err)
 	}
+	log.Debugf("mapped ports %v for container %s", ports, containerID)
+
 	return nil
 }
 
-// unmapPorts unmaps ports defined in hostconfig
-func (c *Container) unmapPorts(hostconfig *containertypes.HostConfig) error {
-	log.Debugf("unmapPorts: %v", hostconfig.PortBindings)
+// unmapPorts unmaps the host ports mapped for containerID
+func (c *Container) unmapPorts(containerID string) error {
+	allocations.Lock()
+	defer allocations.Unlock()
 
-	if len(hostconfig.PortBindings) == 0 {
+	// check if we should actually unmap based on current mappings
+	ports, mapped := allocations.containers[containerID]
+	if !mapped {
+		log.Debugf("skipping already unmapped ports for %s", containerID)
 		return nil
 	}
+	log.Debugf("unmapPorts for %q: %v", containerID, ports)
 
-	portMap, err := unrollPortMap(hostconfig.PortBindings)
-	if err != nil {
-		return err
-	}
-
-	cbpLock.Lock()
-	defer cbpLock.Unlock()
-	for _, p := range portMap {
-		// check if we should actually unmap based on current mappings
-		_, mapped := containerByPort[p.strHostPort]
-		if !mapped {
-			log.Debugf("skipping already unmapped %s", p.strHostPort)
-			continue
+	var errs []string
+	for _, r := range portRanges(ports) {
+		if err := unmapPortRange(r); err != nil {
+			errs = append(errs, err.Error())
 		}
+	}
 
-		if err = portMapper.UnmapPort(nil, p.intHostPort, p.portProto.Proto(), p.portProto.Int(), externalIfaceName, bridgeIfaceName); err != nil {
-			return err
-		}
+	// update mapped ports
+	releasePorts(ports)
+	delete(allocations.containers, containerID)
+	if err := allocations.save(); err != nil {
+		log.Warnf("Unable to save port bindings: %s", err)
+	}
 
-		// update mapped ports
-		delete(containerByPort, p.strHostPort)
-		log.Debugf("unmapped port %s", p.strHostPort)
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, ", "))
 	}
 	return nil
 }
@@ -794,6 +1051,9 @@ func (c *Container) containerStop(name string, seconds int, unbound bool) error
 	if *infoResponse.Payload.ContainerConfig.State == "Stopped" || *infoResponse.Payload.ContainerConfig.State == "Created" {
 		return nil
 	}
+	if isPaused(*infoResponse.Payload.ContainerConfig.State) {
+		return derr.NewRequestConflictError(fmt.Errorf("Container %s is paused. Unpause the container before stopping", id))
+	}
 
 	if unbound {
 		ub, err := client.Scopes.UnbindContainer(scopes.NewUnbindContainerParamsWithContext(ctx).WithHandle(handle))
@@ -812,7 +1072,7 @@ func (c *Container) containerStop(name string, seconds int, unbound bool) error
 		}
 
 		// unmap ports
-		if err = c.unmapPorts(vc.HostConfig); err != nil {
+		if err = c.unmapPorts(id); err != nil {
 			return err
 		}
 	}
@@ -855,12 +1115,115 @@ func (c *Container) containerStop(name string, seconds int, unbound bool) error
 
 // ContainerUnpause unpauses a container
 func (c *Container) ContainerUnpause(name string) error {
-	return fmt.Errorf("%s does not implement container.ContainerUnpause", ProductName())
+	defer trace.End(trace.Begin(name))
+
+	// Look up the container name in the metadata cache to get long ID
+	vc := cache.ContainerCache().GetContainer(name)
+	if vc == nil {
+		return NotFoundError(name)
+	}
+	id := vc.ContainerID
+
+	client := c.containerProxy.Client()
+	_, err := client.Containers.ContainerUnpause(containers.NewContainerUnpauseParamsWithContext(ctx).WithID(id))
+	if err != nil {
+		switch err := err.(type) {
+		case *containers.ContainerUnpauseNotFound:
+			cache.ContainerCache().DeleteContainer(id)
+			return NotFoundError(name)
+		case *containers.ContainerUnpauseConflict:
+			return derr.NewRequestConflictError(fmt.Errorf(err.Payload.Message))
+		case *containers.ContainerUnpauseInternalServerError:
+			return InternalServerError(err.Payload.Message)
+		default:
+			return InternalServerError(err.Error())
+		}
+	}
+
+	return nil
 }
 
 // ContainerUpdate updates configuration of the container
 func (c *Container) ContainerUpdate(name string, hostConfig *containertypes.HostConfig) ([]string, error) {
-	return make([]string, 0, 0), fmt.Errorf("%s does not implement container.ContainerUpdate", ProductName())
+	defer trace.End(trace.Begin(name))
+
+	if hostConfig == nil {
+		return nil, derr.NewBadRequestError(fmt.Errorf("no update configuration provided"))
+	}
+
+	// Look up the container name in the metadata cache to get long ID
+	vc := cache.ContainerCache().GetContainer(name)
+	if vc == nil {
+		return nil, NotFoundError(name)
+	}
+	id := vc.ContainerID
+
+	resources, warnings, err := resourcesToPortlayer(hostConfig.Resources)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &models.ContainerUpdateConfig{
+		Resources: resources,
+	}
+
+	// the restart policy is only changed if one is given
+	if hostConfig.RestartPolicy.Name != "" {
+		if vc.HostConfig != nil && vc.HostConfig.AutoRemove {
+			return nil, derr.NewBadRequestError(fmt.Errorf("Restart policy cannot be updated because AutoRemove is enabled for the container"))
+		}
+		if err := validateRestartPolicy(hostConfig); err != nil {
+			return nil, err
+		}
+
+		config.RestartPolicy = &models.RestartPolicy{
+			Name:              swag.String(hostConfig.RestartPolicy.Name),
+			MaximumRetryCount: swag.Int32(int32(hostConfig.RestartPolicy.MaximumRetryCount)),
+		}
+	}
+
+	client := c.containerProxy.Client()
+
+	handle, err := c.Handle(id, name)
+	if err != nil {
+		return nil, err
+	}
+
+	updateRes, err := client.Containers.ContainerUpdate(containers.NewContainerUpdateParamsWithContext(ctx).WithHandle(handle).WithConfig(config))
+	if err != nil {
+		switch err := err.(type) {
+		case *containers.ContainerUpdateNotFound:
+			cache.ContainerCache().DeleteContainer(id)
+			return nil, NotFoundError(name)
+		case *containers.ContainerUpdateConflict:
+			return nil, derr.NewRequestConflictError(fmt.Errorf(err.Payload.Message))
+		case *containers.ContainerUpdateDefault:
+			return nil, InternalServerError(err.Payload.Message)
+		default:
+			return nil, InternalServerError(err.Error())
+		}
+	}
+
+	_, err = client.Containers.Commit(containers.NewCommitParamsWithContext(ctx).WithHandle(updateRes.Payload))
+	if err != nil {
+		switch err := err.(type) {
+		case *containers.CommitNotFound:
+			cache.ContainerCache().DeleteContainer(id)
+			return nil, NotFoundError(name)
+		case *containers.CommitConflict:
+			return nil, ConflictError(err.Error())
+		case *containers.CommitDefault:
+			return nil, InternalServerError(err.Payload.Message)
+		default:
+			return nil, InternalServerError(err.Error())
+		}
+	}
+
+	if config.RestartPolicy != nil && vc.HostConfig != nil {
+		vc.HostConfig.RestartPolicy = hostConfig.RestartPolicy
+	}
+
+	return warnings, nil
 }
 
 // ContainerWait stops processing until the given container is
@@ -906,6 +1269,11 @@ func dockerStatus(exitCode int, status string, state string, started time.Time,
 		if !started.IsZero() {
 			dockStatus = fmt.Sprintf("Up %s", units.HumanDuration(time.Now().UTC().Sub(started)))
 		}
+	case "Paused", "Suspended":
+		dockStatus = "Paused"
+		if !started.IsZero() {
+			dockStatus = fmt.Sprintf("Up %s (Paused)", units.HumanDuration(time.Now().UTC().Sub(started)))
+		}
 	case "Stopped":
 		// if we don't have a finished date then don't process exitCode and return "Stopped" for the status
 		if !finished.IsZero() {
@@ -935,6 +1303,12 @@ func dockerStatus(exitCode int, status string, state string, started time.Time,
 
 // docker's container.monitorBackend
 
+// isPaused returns true if the port layer state is that of a paused container. A containerVM
+// suspended out-of-band is treated as paused, as unpause resumes it.
+func isPaused(state string) bool {
+	return state == "Paused" || state == "Suspended"
+}
+
 // ContainerChanges returns a list of container fs changes
 func (c *Container) ContainerChanges(name string) ([]archive.Change, error) {
 	return make([]archive.Change, 0, 0), fmt.Errorf("%s does not implement container.ContainerChanges", ProductName())
@@ -1001,12 +1375,87 @@ func (c *Container) ContainerInspect(name string, size bool, version version.Ver
 		log.Debugf("Docker inspect - network settings = null")
 	}
 
-	return inspectJSON, nil
+	return withHealth(inspectJSON, results.Payload.ContainerConfig.Health), nil
+}
+
+// containerJSON is the inspect output of a container with its health, as the vendored
+// engine-api types predate healthchecks. Its State field shadows that of ContainerJSON.
+type containerJSON struct {
+	*types.ContainerJSON
+	State *containerState
+}
+
+type containerState struct {
+	*types.ContainerState
+	Health *containerHealth `json:",omitempty"`
+}
+
+type containerHealth struct {
+	Status        string
+	FailingStreak int
+	Log           []healthcheckResult
+}
+
+type healthcheckResult struct {
+	Start    time.Time
+	End      time.Time
+	ExitCode int
+	Output   string
+}
+
+// withHealth adds the health reported by the port layer to the inspect output of a container
+// with a healthcheck
+func withHealth(inspectJSON *types.ContainerJSON, health *models.HealthState) interface{} {
+	if health == nil || health.Status == nil || inspectJSON.ContainerJSONBase == nil {
+		return inspectJSON
+	}
+
+	h := &containerHealth{Status: *health.Status}
+	if health.FailingStreak != nil {
+		h.FailingStreak = int(*health.FailingStreak)
+	}
+	for _, result := range health.Log {
+		var r healthcheckResult
+		if result.Start != nil {
+			r.Start = time.Unix(0, *result.Start)
+		}
+		if result.End != nil {
+			r.End = time.Unix(0, *result.End)
+		}
+		if result.ExitCode != nil {
+			r.ExitCode = int(*result.ExitCode)
+		}
+		if result.Output != nil {
+			r.Output = *result.Output
+		}
+		h.Log = append(h.Log, r)
+	}
+
+	return &containerJSON{
+		ContainerJSON: inspectJSON,
+		State: &containerState{
+			ContainerState: inspectJSON.State,
+			Health:         h,
+		},
+	}
+}
+
+// healthSuffix returns the health annotation docker adds to the status of a running container
+// in the container list
+func healthSuffix(health *models.HealthState) string {
+	if health == nil || health.Status == nil {
+		return ""
+	}
+
+	if *health.Status == "starting" {
+		return " (health: starting)"
+	}
+	return fmt.Sprintf(" (%s)", *health.Status)
 }
 
 // ContainerLogs hooks up a container's stdout and stderr streams
 // configured with the given struct.
-func (c *Container) ContainerLogs(name string, config *backend.ContainerLogsConfig, started chan struct{}) error {
+func (c *Container) ContainerLogs(name string, config *backend.ContainerLogsConfig, started chan struct{}) (err error) {
 	defer trace.End(trace.Begin(""))
 
 	// Look up the container name in the metadata cache to get long ID
@@ -1028,21 +1477,82 @@ func (c *Container) ContainerLogs(name string, config *backend.ContainerLogsConf
 
 	wf.Flush()
 
+	// the port layer multiplexes stdout and stderr as the CLI expects, other than for a tty
 	outStream := io.Writer(wf)
-	if !vc.Config.Tty {
-		outStream = stdcopy.NewStdWriter(outStream, stdcopy.Stdout)
+	if vc.Config.Tty {
+		demux, wait := demuxWriter(outStream)
+		defer func() {
+			if werr := wait(); err == nil {
+				err = werr
+			}
+		}()
+		outStream = demux
 	}
 
 	// Make a call to our proxy to handle the remoting
-	err = c.containerProxy.StreamContainerLogs(name, outStream, started, config.Timestamps, config.Follow, since, tailLines)
+	err = c.containerProxy.StreamContainerLogs(name, outStream, started, config.Timestamps, config.Follow, config.ShowStdout, config.ShowStderr, since, tailLines)
 
 	return err
 }
 
+// demuxWriter returns a writer that strips the stream headers from multiplexed output before
+// writing it to out, along with a function that waits for the output to be written
+func demuxWriter(out io.Writer) (io.Writer, func() error) {
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+
+	go func() {
+		_, err := stdcopy.StdCopy(out, out, pr)
+		pr.CloseWithError(err)
+		done <- err
+	}()
+
+	return pw, func() error {
+		pw.Close()
+		return <-done
+	}
+}
+
 // ContainerStats writes information about the container to the stream
 // given in the config object.
 func (c *Container) ContainerStats(name string, config *backend.ContainerStatsConfig) error {
-	return fmt.Errorf("%s does not implement container.ContainerStats", ProductName())
+	defer trace.End(trace.Begin(name))
+
+	vc := cache.ContainerCache().GetContainer(name)
+	if vc == nil {
+		return NotFoundError(name)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out := make(chan *types.StatsJSON)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- c.containerProxy.StreamContainerStats(ctx, vc, out)
+		close(out)
+	}()
+
+	encoder := json.NewEncoder(config.OutStream)
+
+	for {
+		select {
+		case <-config.Stop:
+			return nil
+		case stats, ok := <-out:
+			if !ok {
+				return <-errs
+			}
+
+			if err := encoder.Encode(stats); err != nil {
+				return err
+			}
+
+			if !config.Stream {
+				return nil
+			}
+		}
+	}
 }
 
 // ContainerTop lists the processes running inside of the given
@@ -1051,7 +1561,26 @@ func (c *Container) ContainerStats(name string, config *backend.ContainerStatsCo
 // is not found, or is not running, or if there are any problems
 // running ps, or parsing the output.
 func (c *Container) ContainerTop(name string, psArgs string) (*types.ContainerProcessList, error) {
-	return nil, fmt.Errorf("%s does not implement container.ContainerTop", ProductName())
+	defer trace.End(trace.Begin(name))
+
+	vc := cache.ContainerCache().GetContainer(name)
+	if vc == nil {
+		return nil, NotFoundError(name)
+	}
+
+	running, err := c.containerProxy.IsRunning(vc)
+	if err != nil {
+		return nil, err
+	}
+	if !running {
+		return nil, ConflictError(fmt.Sprintf("Container %s is not running", name))
+	}
+
+	if psArgs == "" {
+		psArgs = "-ef"
+	}
+
+	return c.containerProxy.Top(vc, psArgs)
 }
 
 // Containers returns the list of containers to show given the user's filtering.
@@ -1092,6 +1621,9 @@ func (c *Container) Containers(config *types.ContainerListOptions) ([]*types.Con
 		}
 		// get the docker friendly status
 		_, status := dockerStatus(int(*t.ProcessConfig.ExitCode), *t.ProcessConfig.Status, *t.ContainerConfig.State, started, stopped)
+		if *t.ContainerConfig.State == "Running" {
+			status += healthSuffix(t.ContainerConfig.Health)
+		}
 
 		ips, err := externalIPv4Addrs()
 		var ports []types.Port
@@ -1196,7 +1728,14 @@ func (c *Container) ContainerAttach(name string, ca *backend.ContainerAttachConf
 		}
 	}
 
-	err = c.containerProxy.AttachStreams(context.Background(), vc, clStdin, clStdout, clStderr, ca)
+	ac := &AttachConfig{
+		ContainerAttachConfig: ca,
+		ID:                    id,
+		UseTty:                vc.Config.Tty,
+		CloseStdin:            vc.Config.StdinOnce,
+	}
+
+	err = c.containerProxy.AttachStreams(context.Background(), ac, clStdin, clStdout, clStderr)
 	if err != nil {
 		if _, ok := err.(DetachError); ok {
 			log.Infof("Detach detected, tearing down connection")
@@ -1270,6 +1809,9 @@ func setCreateConfigOptions(config, imageConfig *containertypes.Config) {
 	if config.WorkingDir == "" {
 		config.WorkingDir = imageConfig.WorkingDir
 	}
+	if config.User == "" {
+		config.User = imageConfig.User
+	}
 	if len(config.Entrypoint) == 0 {
 		config.Entrypoint = imageConfig.Entrypoint
 	}
@@ -1358,45 +1900,24 @@ func validateCreateConfig(config *types.ContainerCreateConfig) error {
 	}
 
 	// validate port bindings
-	if config.HostConfig != nil {
-		var ips []string
-		if addrs, err := externalIPv4Addrs(); err != nil {
-			log.Warnf("could not get address for external interface: %s", err)
-		} else {
-			ips = make([]string, len(addrs))
-			for i := range addrs {
-				ips[i] = addrs[i].IP.String()
+	for _, pbs := range config.HostConfig.PortBindings {
+		for _, pb := range pbs {
+			if _, err := hostIPInterface(pb.HostIP); err != nil {
+				return BadRequestError(err.Error())
 			}
-		}
 
-		for _, pbs := range config.HostConfig.PortBindings {
-			for _, pb := range pbs {
-				if pb.HostIP != "" && pb.HostIP != "0.0.0.0" {
-					// check if specified host ip equals any of the addresses on the "client" interface
-					found := false
-					for _, i := range ips {
-						if i == pb.HostIP {
-							found = true
-							break
-						}
-					}
-					if !found {
-						return InternalServerError("host IP for port bindings is only supported for 0.0.0.0 and the external interface IP address")
-					}
-				}
-
-				start, end, _ := nat.ParsePortRangeToInt(pb.HostPort)
-				if start != end {
-					return InternalServerError("host port ranges are not supported for port bindings")
-				}
+			if _, _, err := nat.ParsePortRangeToInt(pb.HostPort); err != nil {
+				return BadRequestError(fmt.Sprintf("invalid host port %q for port bindings: %s", pb.HostPort, err))
 			}
 		}
 	}
 
-	// TODO(jzt): users other than root are not currently supported
-	// We should check for USER in config.Config.Env once we support Dockerfiles.
-	if config.Config.User != "" && config.Config.User != "root" {
-		return InternalServerError("Failed to create container - users other than root are not currently supported")
+	if err := validateRestartPolicy(config.HostConfig); err != nil {
+		return err
+	}
+
+	if _, _, err := forward.ParseConfig(config.HostConfig.LogConfig.Type, config.HostConfig.LogConfig.Config); err != nil {
+		return BadRequestError(err.Error())
 	}
 
 	// https://github.com/vmware/vic/issues/1378
@@ -1414,11 +1935,91 @@ func validateCreateConfig(config *types.ContainerCreateConfig) error {
 	return nil
 }
 
+// resourcesToPortlayer() maps the docker resources onto the containerVM. The memory limit sizes the
+// containerVM and the memory reservation and CPU shares become its allocations from the host.
+// Resources that have no equivalent are discarded with a warning, as docker does for those the
+// kernel does not support.
+func resourcesToPortlayer(res containertypes.Resources) (*models.ContainerResources, []string, error) {
+	var warnings []string
+	config := &models.ContainerResources{}
+
+	if res.Memory != 0 && res.Memory < minMemory {
+		return nil, nil, derr.NewBadRequestError(fmt.Errorf("Minimum memory limit allowed is 4MB"))
+	}
+	if res.Memory > 0 && res.MemoryReservation > res.Memory {
+		return nil, nil, derr.NewBadRequestError(fmt.Errorf("Minimum memory limit can not be less than memory reservation limit, see usage"))
+	}
+
+	if res.Memory > 0 {
+		config.MemoryMB = swag.Int64(toMemoryMB(res.Memory))
+	}
+	if res.MemoryReservation > 0 {
+		config.MemoryReservation = swag.Int64(toMemoryMB(res.MemoryReservation))
+	}
+	if res.CPUShares > 0 {
+		config.CPUShares = swag.Int32(int32(res.CPUShares))
+	}
+	if res.CPUCount > 0 {
+		config.NumCpus = swag.Int32(int32(res.CPUCount))
+	}
+
+	discarded := []struct {
+		set  bool
+		name string
+	}{
+		{res.CPUPeriod != 0 || res.CPUQuota != 0, "CPU cfs period and quota"},
+		{res.CpusetCpus != "" || res.CpusetMems != "", "cpuset"},
+		{res.BlkioWeight != 0, "Block I/O weight"},
+		{res.KernelMemory != 0, "kernel memory limit"},
+		{res.MemorySwap != 0, "swap limit"},
+	}
+	for _, d := range discarded {
+		if d.set {
+			warnings = append(warnings, fmt.Sprintf("%s does not support updating the %s. Value discarded.", ProductName(), d.name))
+		}
+	}
+
+	return config, warnings, nil
+}
+
+// toMemoryMB() converts bytes to MB, rounding up to the 4MB granularity of containerVM memory
+func toMemoryMB(bytes int64) int64 {
+	mb := (bytes + 1<<20 - 1) >> 20
+	return (mb + 3) &^ 3
+}
+
+// validateRestartPolicy() checks the restart policy in the same way as the docker daemon
+func validateRestartPolicy(hostConfig *containertypes.HostConfig) error {
+	p := hostConfig.RestartPolicy
+
+	switch p.Name {
+	case "always", "unless-stopped", "no":
+		if p.MaximumRetryCount != 0 {
+			return derr.NewBadRequestError(fmt.Errorf("maximum retry count cannot be used with restart policy '%s'", p.Name))
+		}
+	case "on-failure":
+		if p.MaximumRetryCount < 0 {
+			return derr.NewBadRequestError(fmt.Errorf("maximum retry count cannot be negative"))
+		}
+	case "":
+		// no restart policy
+	default:
+		return derr.NewBadRequestError(fmt.Errorf("invalid restart policy '%s'", p.Name))
+	}
+
+	if hostConfig.AutoRemove && !p.IsNone() && p.Name != "" {
+		return derr.NewBadRequestError(fmt.Errorf("can't create 'AutoRemove' container with restart policy"))
+	}
+
+	return nil
+}
+
 func copyConfigOverrides(vc *viccontainer.VicContainer, config types.ContainerCreateConfig) {
 	// Copy the create overrides to our new container
 	vc.Name = config.Name
 	vc.Config.Cmd = config.Config.Cmd
 	vc.Config.WorkingDir = config.Config.WorkingDir
+	vc.Config.User = config.Config.User
 	vc.Config.Entrypoint = config.Config.Entrypoint
 	vc.Config.Env = config.Config.Env
 	vc.Config.AttachStdin = config.Config.AttachStdin
@@ -1448,47 +2049,60 @@ func externalIPv4Addrs() ([]netlink.Addr, error) {
 // returns port bindings as a slice of Docker Ports for return to the client
 // returns empty slice on error
 func portInformation(t *models.ContainerInfo, ips []netlink.Addr) []types.Port {
-	// create a port for each IP on the interface (usually only 1, but could be more)
-	// (works with both IPv4 and IPv6 addresses)
-	var ports []types.Port
+	var resultPorts []types.Port
 
 	container := cache.ContainerCache().GetContainer(*t.ContainerConfig.ContainerID)
 	if container == nil {
 		log.Errorf("Could not find container with ID %s", *t.ContainerConfig.ContainerID)
-		return ports
+		return resultPorts
 	}
 
-	for _, ip := range ips {
-		ports = append(ports, types.Port{IP: ip.IP.String()})
+	// the host ports mapped for the container are reported in preference to the requested
+	// bindings, as a binding may be to any port in a range
+	portBindings := container.HostConfig.PortBindings
+	if ports := allocations.get(container.ContainerID); len(ports) > 0 {
+		portBindings = portBindingsOf(ports)
 	}
 
-	portBindings := container.HostConfig.PortBindings
-	var resultPorts []types.Port
-	var err error
+	for portBindingPrivatePort, hostPortBindings := range portBindings {
+		portAndType := strings.SplitN(string(portBindingPrivatePort), "/", 2)
+		privatePort, err := strconv.Atoi(portAndType[0])
+		if err != nil {
+			log.Infof("Got an error trying to convert private port number to an int")
+			continue
+		}
 
-	for _, port := range ports {
-		for portBindingPrivatePort, hostPortBindings := range portBindings {
-			portAndType := strings.SplitN(string(portBindingPrivatePort), "/", 2)
-			port.PrivatePort, err = strconv.Atoi(portAndType[0])
+		for _, binding := range hostPortBindings {
+			publicPort, err := strconv.Atoi(binding.HostPort)
 			if err != nil {
-				log.Infof("Got an error trying to convert private port number to an int")
+				log.Infof("Got an error trying to convert public port number to an int")
+				continue
+			}
+			// sanity check -- sometimes these come back as 0 when no binding actually exists
+			// that doesn't make sense, so in that case we don't want to report these bindings
+			if publicPort == 0 || privatePort == 0 {
 				continue
 			}
-			port.Type = portAndType[1]
 
-			for i := 0; i < len(hostPortBindings); i++ {
-				newport := port
-				newport.PublicPort, err = strconv.Atoi(hostPortBindings[i].HostPort)
-				if err != nil {
-					log.Infof("Got an error trying to convert public port number to an int")
-					continue
-				}
-				// sanity check -- sometimes these come back as 0 when no binding actually exists
-				// that doesn't make sense, so in that case we don't want to report these bindings
-				if newport.PublicPort != 0 && newport.PrivatePort != 0 {
-					resultPorts = append(resultPorts, newport)
+			// create a port for each IP on the interface (usually only 1, but could be more)
+			// unless the binding is to a specific address
+			var addrs []string
+			if ip := hostBindingIP(binding.HostIP); ip != "" {
+				addrs = append(addrs, ip)
+			} else {
+				for _, ip := range ips {
+					addrs = append(addrs, ip.IP.String())
 				}
 			}
+
+			for _, ip := range addrs {
+				resultPorts = append(resultPorts, types.Port{
+					IP:          ip,
+					PrivatePort: privatePort,
+					PublicPort:  publicPort,
+					Type:        portAndType[1],
+				})
+			}
 		}
 	}
 	return resultPorts
@@ -1508,8 +2122,12 @@ func (c *Container) validateContainerLogsConfig(vc *viccontainer.VicContainer, c
 		return 0, 0, fmt.Errorf("You must choose at least one stream")
 	}
 
-	unsupported := func(opt string) (int64, int64, error) {
-		return 0, 0, fmt.Errorf("%s does not yet support '--%s'", ProductName(), opt)
+	// output that is only forwarded cannot be read back
+	if vc.HostConfig != nil {
+		lc := vc.HostConfig.LogConfig
+		if _, datastore, err := forward.ParseConfig(lc.Type, lc.Config); err == nil && !datastore {
+			return 0, 0, fmt.Errorf("configured logging driver does not support reading")
+		}
 	}
 
 	tailLines := int64(-1)
@@ -1521,22 +2139,14 @@ func (c *Container) validateContainerLogsConfig(vc *viccontainer.VicContainer, c
 		tailLines = n
 	}
 
-	var since time.Time
+	var since int64
 	if config.Since != "" {
-		s, n, err := timetypes.ParseTimestamps(config.Since, 0)
+		s, _, err := timetypes.ParseTimestamps(config.Since, 0)
 		if err != nil {
 			return 0, 0, err
 		}
-		since = time.Unix(s, n)
-	}
-
-	if config.Timestamps {
-		return unsupported("timestamps")
-	}
-
-	if config.Since != "" {
-		return unsupported("since")
+		since = s
 	}
 
-	return tailLines, since.Unix(), nil
+	return tailLines, since, nil
 }
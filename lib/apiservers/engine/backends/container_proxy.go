@@ -36,7 +36,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
 	"syscall"
@@ -71,8 +73,10 @@ import (
 	"github.com/vmware/vic/lib/apiservers/portlayer/client/logging"
 	"github.com/vmware/vic/lib/apiservers/portlayer/client/scopes"
 	"github.com/vmware/vic/lib/apiservers/portlayer/client/storage"
+	"github.com/vmware/vic/lib/apiservers/portlayer/client/tasks"
 	"github.com/vmware/vic/lib/apiservers/portlayer/models"
 	"github.com/vmware/vic/lib/metadata"
+	"github.com/vmware/vic/pkg/log/forward"
 	"github.com/vmware/vic/pkg/trace"
 	"github.com/vmware/vic/pkg/vsphere/sys"
 )
@@ -85,17 +89,45 @@ type VicContainerProxy interface {
 	AddLoggingToContainer(handle string, config types.ContainerCreateConfig) (string, error)
 	AddInteractionToContainer(handle string, config types.ContainerCreateConfig) (string, error)
 	CommitContainerHandle(handle, imageID string) error
-	StreamContainerLogs(name string, out io.Writer, started chan struct{}, showTimestamps bool, followLogs bool, since int64, tailLines int64) error
+	StreamContainerLogs(name string, out io.Writer, started chan struct{}, showTimestamps bool, followLogs bool, showStdout bool, showStderr bool, since int64, tailLines int64) error
+	StreamContainerStats(ctx context.Context, vc *viccontainer.VicContainer, out chan<- *types.StatsJSON) error
 
 	IsRunning(vc *viccontainer.VicContainer) (bool, error)
+	State(vc *viccontainer.VicContainer) (*types.ContainerState, error)
 	Wait(vc *viccontainer.VicContainer, timeout time.Duration) (exitCode int32, processStatus string, containerState string, reterr error)
 	Signal(vc *viccontainer.VicContainer, sig uint64) error
 	Resize(vc *viccontainer.VicContainer, height, width int32) error
-	AttachStreams(ctx context.Context, vc *viccontainer.VicContainer, clStdin io.ReadCloser, clStdout, clStderr io.Writer, ca *backend.ContainerAttachConfig) error
+	AttachStreams(ctx context.Context, ac *AttachConfig, clStdin io.ReadCloser, clStdout, clStderr io.Writer) error
+
+	CreateExecTask(handle string, config *types.ExecConfig) (string, error)
+	StartExecTask(handle string, eid string) error
+	InspectExecTask(handle string, eid string) (*models.TaskInspectResponse, error)
+	RemoveExecTask(handle string, eid string) error
+	ResizeExec(eid string, height, width int32) error
+
+	StatPath(vc *viccontainer.VicContainer, path string) (*types.ContainerPathStat, error)
+	ArchivePath(vc *viccontainer.VicContainer, path string) (io.ReadCloser, error)
+	ExtractToDir(vc *viccontainer.VicContainer, path string, noOverwriteDirNonDir bool, content io.Reader) error
+	Export(vc *viccontainer.VicContainer, out io.Writer) error
+	Top(vc *viccontainer.VicContainer, psArgs string) (*types.ContainerProcessList, error)
 
 	Client() *client.PortLayer
 }
 
+// AttachConfig wraps backend.ContainerAttachConfig with the details of the session
+// that the streams are attached to
+type AttachConfig struct {
+	*backend.ContainerAttachConfig
+
+	// ID of the session to attach to - this is either a container or an exec ID
+	ID string
+
+	// Tty reflects whether the session was created with a tty
+	UseTty bool
+	// StdinOnce reflects whether stdin should be closed after the first attach detaches
+	CloseStdin bool
+}
+
 // ContainerProxy struct
 type ContainerProxy struct {
 	client        *client.PortLayer
@@ -120,6 +152,8 @@ const (
 	forceLogType                         = "json-file" //Use in inspect to allow docker logs to work
 	annotationKeyLabels                  = "docker.labels"
 	killWaitForExit        time.Duration = 2 * time.Second
+	vicNetworkStatsName                  = "eth0"           //containerVM network stats are reported for a single interface
+	vicStatsInterval       time.Duration = 20 * time.Second //the vSphere realtime interval each stats sample is averaged over
 
 	DriverArgFlagKey      = "flags"
 	DriverArgContainerKey = "Container"
@@ -157,6 +191,11 @@ func (c *ContainerProxy) CreateContainerHandle(imageID string, config types.Cont
 	}
 
 	plCreateParams := dockerContainerCreateParamsToPortlayer(config, imageID, host)
+
+	// the vendored container config predates healthchecks, so the image supplies the healthcheck
+	if image, err := cache.ImageCache().Get(config.Config.Image); err == nil && image.Healthcheck != nil {
+		plCreateParams.CreateConfig.Healthcheck = healthcheckToPortlayer(image.Healthcheck)
+	}
 	createResults, err := c.client.Containers.Create(plCreateParams)
 	if err != nil {
 		if _, ok := err.(*containers.CreateNotFound); ok {
@@ -321,10 +360,29 @@ func (c *ContainerProxy) AddLoggingToContainer(handle string, config types.Conta
 		return "", InternalServerError("ContainerProxy.AddLoggingToContainer failed to get the portlayer client")
 	}
 
-	response, err := c.client.Logging.LoggingJoin(logging.NewLoggingJoinParamsWithContext(ctx).
-		WithConfig(&models.LoggingJoinConfig{
-			Handle: handle,
-		}))
+	join := &models.LoggingJoinConfig{
+		Handle: handle,
+	}
+
+	// the datastore log is the default, other drivers are passed on for the tether
+	if config.HostConfig != nil {
+		lc := config.HostConfig.LogConfig
+		options, datastore, err := forward.ParseConfig(lc.Type, lc.Config)
+		if err != nil {
+			return "", BadRequestError(err.Error())
+		}
+
+		if lc.Type != "" && lc.Type != forward.JSONFile {
+			forwardOnly := !datastore
+			join.LogConfig = &models.LogConfig{
+				Driver:      &lc.Type,
+				Options:     options,
+				ForwardOnly: &forwardOnly,
+			}
+		}
+	}
+
+	response, err := c.client.Logging.LoggingJoin(logging.NewLoggingJoinParamsWithContext(ctx).WithConfig(join))
 	if err != nil {
 		return "", InternalServerError(err.Error())
 	}
@@ -384,9 +442,374 @@ func (c *ContainerProxy) CommitContainerHandle(handle, imageID string) error {
 	return nil
 }
 
+// CreateExecTask adds an exec session, described by config, to the container referenced by
+// the handle and commits it. The session is not launched until StartExecTask is called.
+//
+// returns:
+//	(exec id, error)
+func (c *ContainerProxy) CreateExecTask(handle string, config *types.ExecConfig) (string, error) {
+	defer trace.End(trace.Begin(handle))
+
+	if c.client == nil {
+		return "", InternalServerError("ContainerProxy.CreateExecTask failed to get the portlayer client")
+	}
+
+	if len(config.Cmd) == 0 {
+		return "", BadRequestError("No exec command specified")
+	}
+
+	joinconfig := &models.TaskJoinConfig{
+		Handle:    handle,
+		ID:        stringid.GenerateRandomID(),
+		Path:      config.Cmd[0],
+		Args:      config.Cmd[1:],
+		User:      swag.String(config.User),
+		Tty:       swag.Bool(config.Tty),
+		Attach:    swag.Bool(config.AttachStdin || config.AttachStdout || config.AttachStderr),
+		OpenStdin: swag.Bool(config.AttachStdin),
+	}
+
+	response, err := c.client.Tasks.TaskJoin(tasks.NewTaskJoinParamsWithContext(ctx).WithConfig(joinconfig))
+	if err != nil {
+		return "", InternalServerError(err.Error())
+	}
+	handle, ok := response.Payload.Handle.(string)
+	if !ok {
+		return "", InternalServerError(fmt.Sprintf("Type assertion failed for %#+v", handle))
+	}
+
+	if err := c.commitHandle(handle, config.Container); err != nil {
+		return "", err
+	}
+
+	return response.Payload.ID, nil
+}
+
+// StartExecTask activates the exec session eid in the container referenced by the handle and
+// commits it, causing the session to be launched.
+func (c *ContainerProxy) StartExecTask(handle string, eid string) error {
+	defer trace.End(trace.Begin(eid))
+
+	if c.client == nil {
+		return InternalServerError("ContainerProxy.StartExecTask failed to get the portlayer client")
+	}
+
+	response, err := c.client.Tasks.TaskBind(tasks.NewTaskBindParamsWithContext(ctx).
+		WithConfig(&models.TaskBindConfig{
+			Handle: handle,
+			ID:     eid,
+		}))
+	if err != nil {
+		switch err := err.(type) {
+		case *tasks.TaskBindNotFound:
+			return ExecNotFoundError(eid)
+		case *tasks.TaskBindInternalServerError:
+			return InternalServerError(err.Payload.Message)
+		default:
+			return InternalServerError(err.Error())
+		}
+	}
+	handle, ok := response.Payload.Handle.(string)
+	if !ok {
+		return InternalServerError(fmt.Sprintf("Type assertion failed for %#+v", handle))
+	}
+
+	// exec sessions are serviced over the container's attach connection so ensure it's available
+	bind, err := c.client.Interaction.InteractionBind(interaction.NewInteractionBindParamsWithContext(ctx).
+		WithConfig(&models.InteractionBindConfig{
+			Handle: handle,
+		}))
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	handle, ok = bind.Payload.Handle.(string)
+	if !ok {
+		return InternalServerError(fmt.Sprintf("Type assertion failed for %#+v", handle))
+	}
+
+	return c.commitHandle(handle, eid)
+}
+
+// InspectExecTask returns the state of the exec session eid in the container referenced by the handle
+func (c *ContainerProxy) InspectExecTask(handle string, eid string) (*models.TaskInspectResponse, error) {
+	defer trace.End(trace.Begin(eid))
+
+	if c.client == nil {
+		return nil, InternalServerError("ContainerProxy.InspectExecTask failed to get the portlayer client")
+	}
+
+	response, err := c.client.Tasks.TaskInspect(tasks.NewTaskInspectParamsWithContext(ctx).
+		WithConfig(&models.TaskInspectConfig{
+			Handle: handle,
+			ID:     eid,
+		}))
+	if err != nil {
+		switch err := err.(type) {
+		case *tasks.TaskInspectNotFound:
+			return nil, ExecNotFoundError(eid)
+		case *tasks.TaskInspectInternalServerError:
+			return nil, InternalServerError(err.Payload.Message)
+		default:
+			return nil, InternalServerError(err.Error())
+		}
+	}
+
+	return response.Payload, nil
+}
+
+// RemoveExecTask removes the finished exec session eid from the container referenced by the
+// handle and commits it
+func (c *ContainerProxy) RemoveExecTask(handle string, eid string) error {
+	defer trace.End(trace.Begin(eid))
+
+	if c.client == nil {
+		return InternalServerError("ContainerProxy.RemoveExecTask failed to get the portlayer client")
+	}
+
+	response, err := c.client.Tasks.TaskRemove(tasks.NewTaskRemoveParamsWithContext(ctx).
+		WithConfig(&models.TaskRemoveConfig{
+			Handle: handle,
+			ID:     eid,
+		}))
+	if err != nil {
+		switch err := err.(type) {
+		case *tasks.TaskRemoveNotFound:
+			return ExecNotFoundError(eid)
+		case *tasks.TaskRemoveInternalServerError:
+			return InternalServerError(err.Payload.Message)
+		default:
+			return InternalServerError(err.Error())
+		}
+	}
+	handle, ok := response.Payload.Handle.(string)
+	if !ok {
+		return InternalServerError(fmt.Sprintf("Type assertion failed for %#+v", handle))
+	}
+
+	return c.commitHandle(handle, eid)
+}
+
+// StatPath returns the details of the filesystem resource at path in the container
+func (c *ContainerProxy) StatPath(vc *viccontainer.VicContainer, path string) (*types.ContainerPathStat, error) {
+	defer trace.End(trace.Begin(vc.ContainerID))
+
+	if c.client == nil {
+		return nil, InternalServerError("ContainerProxy.StatPath failed to get the portlayer client")
+	}
+
+	if err := c.bindInteraction(vc); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Interaction.ContainerStatPath(interaction.NewContainerStatPathParamsWithContext(ctx).
+		WithID(vc.ContainerID).
+		WithPath(path))
+	if err != nil {
+		switch err := err.(type) {
+		case *interaction.ContainerStatPathNotFound:
+			return nil, PathNotFoundError(vc.Name, path)
+		case *interaction.ContainerStatPathInternalServerError:
+			return nil, InternalServerError(err.Payload.Message)
+		default:
+			return nil, InternalServerError(err.Error())
+		}
+	}
+
+	stat := resp.Payload
+	return &types.ContainerPathStat{
+		Name:       swag.StringValue(stat.Name),
+		Size:       swag.Int64Value(stat.Size),
+		Mode:       os.FileMode(swag.Int64Value(stat.Mode)),
+		Mtime:      time.Unix(0, swag.Int64Value(stat.Mtime)),
+		LinkTarget: swag.StringValue(stat.LinkTarget),
+	}, nil
+}
+
+// ArchivePath returns a tar archive of the filesystem resource at path in the container
+func (c *ContainerProxy) ArchivePath(vc *viccontainer.VicContainer, path string) (io.ReadCloser, error) {
+	defer trace.End(trace.Begin(vc.ContainerID))
+
+	if err := c.bindInteraction(vc); err != nil {
+		return nil, err
+	}
+
+	plClient, transport := c.createNewAttachClientWithTimeouts(attachConnectTimeout, 0, attachAttemptTimeout)
+
+	params := interaction.NewContainerGetArchiveParamsWithContext(ctx).
+		WithID(vc.ContainerID).
+		WithPath(path)
+
+	reader, writer := io.Pipe()
+	go func() {
+		defer transport.Close()
+
+		_, err := plClient.Interaction.ContainerGetArchive(params, writer)
+		if err != nil {
+			switch err := err.(type) {
+			case *interaction.ContainerGetArchiveNotFound:
+				writer.CloseWithError(PathNotFoundError(vc.Name, path))
+			case *interaction.ContainerGetArchiveInternalServerError:
+				writer.CloseWithError(InternalServerError(err.Payload.Message))
+			default:
+				writer.CloseWithError(InternalServerError(err.Error()))
+			}
+			return
+		}
+
+		writer.Close()
+	}()
+
+	return reader, nil
+}
+
+// Export writes a tar archive of the complete filesystem of the container to out
+func (c *ContainerProxy) Export(vc *viccontainer.VicContainer, out io.Writer) error {
+	defer trace.End(trace.Begin(vc.ContainerID))
+
+	plClient, transport := c.createNewAttachClientWithTimeouts(attachConnectTimeout, 0, attachAttemptTimeout)
+	defer transport.Close()
+
+	params := storage.NewExportContainerParamsWithContext(ctx).WithContainerID(vc.ContainerID)
+	if _, err := plClient.Storage.ExportContainer(params, out); err != nil {
+		switch err := err.(type) {
+		case *storage.ExportContainerNotFound:
+			return NotFoundError(vc.Name)
+		case *storage.ExportContainerDefault:
+			return InternalServerError(err.Payload.Message)
+		default:
+			return InternalServerError(err.Error())
+		}
+	}
+
+	return nil
+}
+
+// ExtractToDir unpacks the tar archive content into the directory at path in the container
+func (c *ContainerProxy) ExtractToDir(vc *viccontainer.VicContainer, path string, noOverwriteDirNonDir bool, content io.Reader) error {
+	defer trace.End(trace.Begin(vc.ContainerID))
+
+	if err := c.bindInteraction(vc); err != nil {
+		return err
+	}
+
+	plClient, transport := c.createNewAttachClientWithTimeouts(attachConnectTimeout, 0, attachAttemptTimeout)
+	defer transport.Close()
+
+	params := interaction.NewContainerPutArchiveParamsWithContext(ctx).
+		WithID(vc.ContainerID).
+		WithPath(path).
+		WithNoOverwriteDirNonDir(&noOverwriteDirNonDir).
+		WithArchive(ioutil.NopCloser(content))
+
+	_, err := plClient.Interaction.ContainerPutArchive(params)
+	if err != nil {
+		switch err := err.(type) {
+		case *interaction.ContainerPutArchiveNotFound:
+			return PathNotFoundError(vc.Name, path)
+		case *interaction.ContainerPutArchiveConflict:
+			return derr.NewBadRequestError(fmt.Errorf("extraction point is not a directory"))
+		case *interaction.ContainerPutArchiveInternalServerError:
+			return InternalServerError(err.Payload.Message)
+		default:
+			return InternalServerError(err.Error())
+		}
+	}
+
+	return nil
+}
+
+// Top returns the processes running in the container, listed using the ps arguments psArgs
+func (c *ContainerProxy) Top(vc *viccontainer.VicContainer, psArgs string) (*types.ContainerProcessList, error) {
+	defer trace.End(trace.Begin(vc.ContainerID))
+
+	if c.client == nil {
+		return nil, InternalServerError("ContainerProxy.Top failed to get the portlayer client")
+	}
+
+	if err := c.bindInteraction(vc); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Interaction.ContainerTop(interaction.NewContainerTopParamsWithContext(ctx).
+		WithID(vc.ContainerID).
+		WithPsArgs(&psArgs))
+	if err != nil {
+		switch err := err.(type) {
+		case *interaction.ContainerTopNotFound:
+			return nil, NotFoundError(vc.Name)
+		case *interaction.ContainerTopConflict:
+			return nil, ConflictError(fmt.Sprintf("Container %s is not running", vc.Name))
+		case *interaction.ContainerTopInternalServerError:
+			return nil, InternalServerError(err.Payload.Message)
+		default:
+			return nil, InternalServerError(err.Error())
+		}
+	}
+
+	return &types.ContainerProcessList{
+		Titles:    resp.Payload.Titles,
+		Processes: resp.Payload.Processes,
+	}, nil
+}
+
+// bindInteraction ensures the port layer has a connection to a running container, over which its
+// filesystem and processes are accessed. The filesystem of a container that is not running is
+// accessed directly by the port layer.
+func (c *ContainerProxy) bindInteraction(vc *viccontainer.VicContainer) error {
+	running, err := c.IsRunning(vc)
+	if err != nil || !running {
+		return err
+	}
+
+	resp, err := c.client.Containers.Get(containers.NewGetParamsWithContext(ctx).WithID(vc.ContainerID))
+	if err != nil {
+		switch err := err.(type) {
+		case *containers.GetNotFound:
+			return NotFoundError(vc.Name)
+		case *containers.GetDefault:
+			return InternalServerError(err.Payload.Message)
+		default:
+			return InternalServerError(err.Error())
+		}
+	}
+
+	bind, err := c.client.Interaction.InteractionBind(interaction.NewInteractionBindParamsWithContext(ctx).
+		WithConfig(&models.InteractionBindConfig{
+			Handle: resp.Payload,
+		}))
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	handle, ok := bind.Payload.Handle.(string)
+	if !ok {
+		return InternalServerError(fmt.Sprintf("Type assertion failed for %#+v", handle))
+	}
+
+	return c.commitHandle(handle, vc.Name)
+}
+
+// commitHandle commits the handle, mapping failures to docker errors
+func (c *ContainerProxy) commitHandle(handle, name string) error {
+	_, err := c.client.Containers.Commit(containers.NewCommitParamsWithContext(ctx).WithHandle(handle))
+	if err != nil {
+		switch err := err.(type) {
+		case *containers.CommitNotFound:
+			return NotFoundError(name)
+		case *containers.CommitConflict:
+			return ConflictError(err.Error())
+		case *containers.CommitDefault:
+			return InternalServerError(err.Payload.Message)
+		default:
+			return InternalServerError(err.Error())
+		}
+	}
+
+	return nil
+}
+
 // StreamContainerLogs reads the log stream from the portlayer rest server and writes
 // it directly to the io.Writer that is passed in.
-func (c *ContainerProxy) StreamContainerLogs(name string, out io.Writer, started chan struct{}, showTimestamps bool, followLogs bool, since int64, tailLines int64) error {
+func (c *ContainerProxy) StreamContainerLogs(name string, out io.Writer, started chan struct{}, showTimestamps bool, followLogs bool, showStdout bool, showStderr bool, since int64, tailLines int64) error {
 	defer trace.End(trace.Begin(""))
 
 	plClient, transport := c.createNewAttachClientWithTimeouts(attachConnectTimeout, 0, attachAttemptTimeout)
@@ -397,6 +820,8 @@ func (c *ContainerProxy) StreamContainerLogs(name string, out io.Writer, started
 		WithID(name).
 		WithFollow(&followLogs).
 		WithTimestamp(&showTimestamps).
+		WithStdout(&showStdout).
+		WithStderr(&showStderr).
 		WithSince(&since).
 		WithTaillines(&tailLines)
 	_, err := plClient.Containers.GetContainerLogs(params, out)
@@ -420,33 +845,101 @@ func (c *ContainerProxy) StreamContainerLogs(name string, out io.Writer, started
 	return nil
 }
 
-// IsRunning returns true if the given container is running
+// StreamContainerStats reads the stats stream from the portlayer rest server, converts each sample
+// to docker stats and sends it to out. It returns when ctx is cancelled or the stream ends.
+func (c *ContainerProxy) StreamContainerStats(ctx context.Context, vc *viccontainer.VicContainer, out chan<- *types.StatsJSON) error {
+	defer trace.End(trace.Begin(vc.ContainerID))
+
+	plClient, transport := c.createNewAttachClientWithTimeouts(attachConnectTimeout, 0, attachAttemptTimeout)
+	defer transport.Close()
+
+	params := containers.NewGetContainerStatsParamsWithContext(ctx).WithID(vc.ContainerID)
+
+	reader, writer := io.Pipe()
+	defer reader.Close()
+
+	go func() {
+		_, err := plClient.Containers.GetContainerStats(params, writer)
+		if err != nil {
+			switch err := err.(type) {
+			case *containers.GetContainerStatsNotFound:
+				writer.CloseWithError(NotFoundError(vc.Name))
+			case *containers.GetContainerStatsInternalServerError:
+				writer.CloseWithError(InternalServerError(err.Payload.Message))
+			default:
+				writer.CloseWithError(InternalServerError(err.Error()))
+			}
+			return
+		}
+
+		writer.Close()
+	}()
+
+	decoder := json.NewDecoder(reader)
+	converter := &statsConverter{}
+
+	for {
+		var stats models.ContainerStats
+		if err := decoder.Decode(&stats); err != nil {
+			if err == io.EOF || ctx.Err() != nil {
+				return nil
+			}
+
+			switch err.(type) {
+			case *json.SyntaxError, *json.UnmarshalTypeError:
+				return InternalServerError(fmt.Sprintf("Unable to decode stats for container %s: %s", vc.ContainerID, err))
+			default:
+				return err
+			}
+		}
+
+		select {
+		case out <- converter.convert(&stats):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// IsRunning returns true if the given container is running. A paused container is running.
 func (c *ContainerProxy) IsRunning(vc *viccontainer.VicContainer) (bool, error) {
 	defer trace.End(trace.Begin(""))
 
+	state, err := c.State(vc)
+	if err != nil {
+		return false, err
+	}
+
+	return state.Running, nil
+}
+
+// State returns the docker state of the given container
+func (c *ContainerProxy) State(vc *viccontainer.VicContainer) (*types.ContainerState, error) {
+	defer trace.End(trace.Begin(""))
+
 	if c.client == nil {
-		return false, InternalServerError("ContainerProxy.CommitContainerHandle failed to create a portlayer client")
+		return nil, InternalServerError("ContainerProxy.State failed to create a portlayer client")
 	}
 
 	results, err := c.client.Containers.GetContainerInfo(containers.NewGetContainerInfoParamsWithContext(ctx).WithID(vc.ContainerID))
 	if err != nil {
 		switch err := err.(type) {
 		case *containers.GetContainerInfoNotFound:
-			return false, NotFoundError(fmt.Sprintf("No such container: %s", vc.ContainerID))
+			return nil, NotFoundError(fmt.Sprintf("No such container: %s", vc.ContainerID))
 		case *containers.GetContainerInfoInternalServerError:
-			return false, InternalServerError(err.Payload.Message)
+			return nil, InternalServerError(err.Payload.Message)
 		default:
-			return false, InternalServerError(fmt.Sprintf("Unknown error from the interaction port layer: %s", err))
+			return nil, InternalServerError(fmt.Sprintf("Unknown error from the interaction port layer: %s", err))
 		}
 	}
 
 	inspectJSON, err := ContainerInfoToDockerContainerInspect(vc, results.Payload, c.portlayerName)
 	if err != nil {
 		log.Errorf("containerInfoToDockerContainerInspect failed with %s", err)
-		return false, err
+		return nil, err
 	}
 
-	return inspectJSON.State.Running, nil
+	return inspectJSON.State, nil
 }
 
 func (c *ContainerProxy) Wait(vc *viccontainer.VicContainer, timeout time.Duration) (exitCode int32, processStatus string, containerState string, reterr error) {
@@ -584,10 +1077,36 @@ func (c *ContainerProxy) Resize(vc *viccontainer.VicContainer, height, width int
 	return nil
 }
 
+// ResizeExec resizes the tty of the exec session eid
+func (c *ContainerProxy) ResizeExec(eid string, height, width int32) error {
+	defer trace.End(trace.Begin(eid))
+
+	if c.client == nil {
+		return InternalServerError("ContainerProxy.ResizeExec failed to get the portlayer client")
+	}
+
+	plResizeParam := interaction.NewContainerResizeParamsWithContext(ctx).
+		WithID(eid).
+		WithHeight(height).
+		WithWidth(width)
+
+	_, err := c.client.Interaction.ContainerResize(plResizeParam)
+	if err != nil {
+		if _, isa := err.(*interaction.ContainerResizeNotFound); isa {
+			return ExecNotFoundError(eid)
+		}
+
+		// If we get here, most likely something went wrong with the port layer API server
+		return InternalServerError(err.Error())
+	}
+
+	return nil
+}
+
 // attacheStreams takes the the hijacked connections from the calling client and attaches
 // them to the 3 streams from the portlayer's rest server.
 // clStdin, clStdout, clStderr are the hijacked connection
-func (c *ContainerProxy) AttachStreams(ctx context.Context, vc *viccontainer.VicContainer, clStdin io.ReadCloser, clStdout, clStderr io.Writer, ca *backend.ContainerAttachConfig) error {
+func (c *ContainerProxy) AttachStreams(ctx context.Context, ac *AttachConfig, clStdin io.ReadCloser, clStdout, clStderr io.Writer) error {
 	defer clStdin.Close()
 
 	// Cancel will close the child connections.
@@ -601,15 +1120,15 @@ func (c *ContainerProxy) AttachStreams(ctx context.Context, vc *viccontainer.Vic
 	plClient, transport := createNewAttachClientWithTimeouts(attachConnectTimeout, 0, attachAttemptTimeout)
 	defer transport.Close()
 
-	if ca.UseStdin {
+	if ac.UseStdin {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			err := copyStdIn(ctx, plClient, vc, clStdin, ca.DetachKeys)
+			err := copyStdIn(ctx, plClient, ac, clStdin)
 			if err != nil {
-				log.Errorf("container attach: stdin (%s): %s", vc.ContainerID, err.Error())
+				log.Errorf("container attach: stdin (%s): %s", ac.ID, err.Error())
 			} else {
-				log.Infof("container attach: stdin (%s) done: %s", vc.ContainerID)
+				log.Infof("container attach: stdin (%s) done", ac.ID)
 			}
 
 			// no need to take action if we are canceled
@@ -624,15 +1143,15 @@ func (c *ContainerProxy) AttachStreams(ctx context.Context, vc *viccontainer.Vic
 		}()
 	}
 
-	if ca.UseStdout {
+	if ac.UseStdout {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			err := copyStdOut(ctx, plClient, attachAttemptTimeout, vc, clStdout)
+			err := copyStdOut(ctx, plClient, attachAttemptTimeout, ac.ID, clStdout)
 			if err != nil {
-				log.Errorf("container attach: stdout (%s): %s", vc.ContainerID, err.Error())
+				log.Errorf("container attach: stdout (%s): %s", ac.ID, err.Error())
 			} else {
-				log.Infof("container attach: stdout (%s) done: %s", vc.ContainerID)
+				log.Infof("container attach: stdout (%s) done", ac.ID)
 			}
 
 			// no need to take action if we are canceled
@@ -647,15 +1166,15 @@ func (c *ContainerProxy) AttachStreams(ctx context.Context, vc *viccontainer.Vic
 		}()
 	}
 
-	if ca.UseStderr {
+	if ac.UseStderr {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			err := copyStdErr(ctx, plClient, vc, clStderr)
+			err := copyStdErr(ctx, plClient, ac.ID, clStderr)
 			if err != nil {
-				log.Errorf("container attach: stderr (%s): %s", vc.ContainerID, err.Error())
+				log.Errorf("container attach: stderr (%s): %s", ac.ID, err.Error())
 			} else {
-				log.Infof("container attach: stderr (%s) done: %s", vc.ContainerID)
+				log.Infof("container attach: stderr (%s) done", ac.ID)
 			}
 
 			// no need to take action if we are canceled
@@ -676,7 +1195,7 @@ func (c *ContainerProxy) AttachStreams(ctx context.Context, vc *viccontainer.Vic
 	// close the channel so that we don't leak (if there is an error)/or get blocked (if there are no errors)
 	close(errors)
 
-	log.Infof("cleaned up connections to %s. Checking errors", vc.ContainerID)
+	log.Infof("cleaned up connections to %s. Checking errors", ac.ID)
 	for err := range errors {
 		if err != nil {
 			// check if we got DetachError
@@ -704,6 +1223,16 @@ func (c *ContainerProxy) AttachStreams(ctx context.Context, vc *viccontainer.Vic
 // Utility Functions
 //----------
 
+// healthcheckToPortlayer converts the healthcheck of an image to the port layer model
+func healthcheckToPortlayer(hc *metadata.HealthConfig) *models.HealthConfig {
+	return &models.HealthConfig{
+		Test:     hc.Test,
+		Interval: swag.Int64(int64(hc.Interval)),
+		Timeout:  swag.Int64(int64(hc.Timeout)),
+		Retries:  swag.Int32(int32(hc.Retries)),
+	}
+}
+
 func dockerContainerCreateParamsToPortlayer(cc types.ContainerCreateConfig, layerID string, imageStore string) *containers.CreateParams {
 	config := &models.ContainerCreateConfig{}
 
@@ -747,6 +1276,9 @@ func dockerContainerCreateParamsToPortlayer(cc types.ContainerCreateConfig, laye
 	// working dir
 	config.WorkingDir = swag.String(cc.Config.WorkingDir)
 
+	// user, resolved against the container filesystem when the process is launched
+	config.User = swag.String(cc.Config.User)
+
 	// attach
 	config.Attach = swag.Bool(cc.Config.AttachStdin || cc.Config.AttachStdout || cc.Config.AttachStderr)
 
@@ -756,6 +1288,14 @@ func dockerContainerCreateParamsToPortlayer(cc types.ContainerCreateConfig, laye
 	// container stop signal
 	config.StopSignal = swag.String(cc.Config.StopSignal)
 
+	// restart policy
+	if cc.HostConfig != nil {
+		config.RestartPolicy = &models.RestartPolicy{
+			Name:              swag.String(cc.HostConfig.RestartPolicy.Name),
+			MaximumRetryCount: swag.Int32(int32(cc.HostConfig.RestartPolicy.MaximumRetryCount)),
+		}
+	}
+
 	// Stuff the Docker labels into VIC container annotations
 	annotationsFromLabels(config, cc.Config.Labels)
 
@@ -779,6 +1319,9 @@ func toModelsNetworkConfig(cc types.ContainerCreateConfig) *models.NetworkConfig
 		if ok {
 			if es.IPAMConfig != nil {
 				nc.Address = &es.IPAMConfig.IPv4Address
+				if es.IPAMConfig.IPv6Address != "" {
+					nc.Address6 = &es.IPAMConfig.IPv6Address
+				}
 			}
 
 			// Docker copies Links to NetworkConfig only if it is a UserDefined network, handle that
@@ -813,7 +1356,9 @@ func fromPortbinding(port nat.Port, binding []nat.PortBinding) []string {
 	proto, privatePort := nat.SplitProtoPort(string(port))
 	for _, bind := range binding {
 		var portMap string
-		if bind.HostPort != "" {
+		if ip := hostBindingIP(bind.HostIP); ip != "" {
+			portMap = fmt.Sprintf("%s:%s:%s/%s", ip, bind.HostPort, privatePort, proto)
+		} else if bind.HostPort != "" {
 			portMap = fmt.Sprintf("%s:%s/%s", bind.HostPort, privatePort, proto)
 		} else {
 			portMap = string(port)
@@ -985,6 +1530,11 @@ func ContainerInfoToDockerContainerInspect(vc *viccontainer.VicContainer, info *
 			if containerState.Status == "running" {
 				containerState.Running = true
 			}
+			if isPaused(*info.ContainerConfig.State) {
+				containerState.Status = "paused"
+				containerState.Running = true
+				containerState.Paused = true
+			}
 		}
 		if info.ContainerConfig.LayerID != nil {
 			inspectJSON.Image = *info.ContainerConfig.LayerID
@@ -1020,24 +1570,18 @@ func hostConfigFromContainerInfo(vc *viccontainer.VicContainer, info *models.Con
 	// container create
 	hostConfig := *vc.HostConfig
 
-	// Resources don't really map well to VIC so we leave most of them empty. If we look
-	// at the struct in engine-api/types/container/host_config.go, Microsoft added
-	// additional attributes to the struct that are applicable to Windows containers.
-	// If understanding VIC's host resources are desirable, we should go down this
-	// same route.
-	//
-	// The values we fill out below is an abridged list of the original struct.
-	resourceConfig := container.Resources{
-	// Applicable to all platforms
-	//			CPUShares int64 `json:"CpuShares"` // CPU shares (relative weight vs. other containers)
-	//			Memory    int64 // Memory limit (in bytes)
-
-	//			// Applicable to UNIX platforms
-	//			DiskQuota            int64           // Disk limit (in bytes)
+	// Resources don't really map well to VIC so we only fill out those that docker update
+	// maps onto the containerVM
+	hostConfig.Resources = container.Resources{}
+	if info.ContainerConfig != nil && info.ContainerConfig.Resources != nil {
+		res := info.ContainerConfig.Resources
+		hostConfig.Memory = swag.Int64Value(res.MemoryMB) << 20
+		hostConfig.MemoryReservation = swag.Int64Value(res.MemoryReservation) << 20
+		hostConfig.CPUShares = int64(swag.Int32Value(res.CPUShares))
+		hostConfig.CPUCount = int64(swag.Int32Value(res.NumCpus))
 	}
 
 	hostConfig.VolumeDriver = portlayerName
-	hostConfig.Resources = resourceConfig
 
 	if len(info.ScopeConfig) > 0 {
 		if info.ScopeConfig[0].DNS != nil {
@@ -1047,8 +1591,30 @@ func hostConfigFromContainerInfo(vc *viccontainer.VicContainer, info *models.Con
 		hostConfig.NetworkMode = container.NetworkMode(info.ScopeConfig[0].ScopeType)
 	}
 
-	// Set this to json-file to force the docker CLI to allow us to use docker logs
+	if info.ContainerConfig != nil && info.ContainerConfig.RestartPolicy != nil {
+		rp := info.ContainerConfig.RestartPolicy
+		if rp.Name != nil {
+			hostConfig.RestartPolicy.Name = *rp.Name
+		}
+		if rp.MaximumRetryCount != nil {
+			hostConfig.RestartPolicy.MaximumRetryCount = int(*rp.MaximumRetryCount)
+		}
+	}
+
+	// Set this to json-file to force the docker CLI to allow us to use docker logs, unless the
+	// output is not recorded on the datastore and cannot be shown
 	hostConfig.LogConfig.Type = forceLogType
+	if info.ProcessConfig != nil && info.ProcessConfig.LogConfig != nil && swag.BoolValue(info.ProcessConfig.LogConfig.ForwardOnly) {
+		lc := info.ProcessConfig.LogConfig
+		hostConfig.LogConfig.Type = swag.StringValue(lc.Driver)
+
+		if forward.IsForwarding(hostConfig.LogConfig.Type) {
+			hostConfig.LogConfig.Config = map[string]string{forward.DatastoreCopy: "false"}
+			for k, v := range lc.Options {
+				hostConfig.LogConfig.Config[k] = v
+			}
+		}
+	}
 
 	var err error
 	_, hostConfig.PortBindings, err = nat.ParsePortSpecs(info.HostConfig.Ports)
@@ -1138,6 +1704,10 @@ func containerConfigFromContainerInfo(vc *viccontainer.VicContainer, info *model
 		container.WorkingDir = *info.ProcessConfig.WorkingDir // Current directory (PWD) in the command will be launched
 	}
 
+	if info.ProcessConfig.User != nil {
+		container.User = *info.ProcessConfig.User // User that will run the command(s) inside the container
+	}
+
 	// Fill in information about the container network
 	if info.ScopeConfig == nil {
 		container.NetworkDisabled = true
@@ -1230,6 +1800,14 @@ func portMapFromVicContainer(vc *viccontainer.VicContainer) nat.PortMap {
 		}
 	}
 
+	// Report the host ports mapped for the container, which are only chosen when it is
+	// started for bindings without a host port or with a range of them
+	if ports := allocations.get(vc.ContainerID); len(ports) > 0 {
+		for port, bindings := range portBindingsOf(ports) {
+			portMap[port] = bindings
+		}
+	}
+
 	// Iterate over the container's original image config.  This is the set of
 	// exposed ports.  For ports that were not in hostConfig, we assign value of
 	// nil.  This appears to be the behavior of regular docker.
@@ -1330,6 +1908,112 @@ func labelsFromAnnotations(config *container.Config, annotations map[string]stri
 	return err
 }
 
+//-----------------------------------
+// ContainerStats() Utility Functions
+//-----------------------------------
+
+// statsConverter turns the point in time samples from the portlayer into docker stats. Docker
+// reports cumulative counters, so the rates in each sample are accumulated over the interval
+// since the previous sample. The counters start from zero at the beginning of the interval
+// covered by the first sample.
+type statsConverter struct {
+	previous *types.StatsJSON
+}
+
+func (s *statsConverter) convert(sample *models.ContainerStats) *types.StatsJSON {
+	stats := &types.StatsJSON{
+		Networks: make(map[string]types.NetworkStats),
+	}
+
+	if sample.Read != nil {
+		stats.Read = time.Time(*sample.Read)
+	} else {
+		stats.Read = time.Now().UTC()
+	}
+
+	cpus := uint64(swag.Int32Value(sample.CPUCount))
+	if cpus == 0 {
+		cpus = 1
+	}
+
+	prevNet := types.NetworkStats{}
+	var prevReadBytes, prevWriteBytes, prevReadOps, prevWriteOps uint64
+
+	// the first sample stands for the realtime interval that vSphere averaged it over
+	interval := vicStatsInterval
+	if s.previous != nil {
+		interval = stats.Read.Sub(s.previous.Read)
+		if interval < 0 {
+			interval = 0
+		}
+
+		stats.PreCPUStats = s.previous.CPUStats
+		stats.CPUStats = s.previous.CPUStats
+		stats.MemoryStats.MaxUsage = s.previous.MemoryStats.MaxUsage
+		prevNet = s.previous.Networks[vicNetworkStatsName]
+		prevReadBytes, prevWriteBytes = blkioValues(s.previous.BlkioStats.IoServiceBytesRecursive)
+		prevReadOps, prevWriteOps = blkioValues(s.previous.BlkioStats.IoServicedRecursive)
+	}
+
+	// vSphere reports the CPU in use and available in MHz - the fraction of the available CPU
+	// used over the interval gives the CPU time consumed across all of the vCPUs
+	system := uint64(interval.Nanoseconds()) * cpus
+	var used uint64
+	if limit := swag.Int64Value(sample.CPULimit); limit > 0 {
+		used = uint64(float64(system) * float64(swag.Int64Value(sample.CPUUsage)) / float64(limit))
+	}
+
+	stats.CPUStats.SystemUsage += system
+	stats.CPUStats.CPUUsage.TotalUsage += used
+	stats.CPUStats.CPUUsage.PercpuUsage = make([]uint64, cpus)
+	for i := range stats.CPUStats.CPUUsage.PercpuUsage {
+		stats.CPUStats.CPUUsage.PercpuUsage[i] = stats.CPUStats.CPUUsage.TotalUsage / cpus
+	}
+
+	stats.MemoryStats.Usage = uint64(swag.Int64Value(sample.MemoryUsage))
+	stats.MemoryStats.Limit = uint64(swag.Int64Value(sample.MemoryLimit))
+	if stats.MemoryStats.Usage > stats.MemoryStats.MaxUsage {
+		stats.MemoryStats.MaxUsage = stats.MemoryStats.Usage
+	}
+
+	seconds := interval.Seconds()
+	accumulate := func(total uint64, rate *int64) uint64 {
+		return total + uint64(float64(swag.Int64Value(rate))*seconds)
+	}
+
+	stats.Networks[vicNetworkStatsName] = types.NetworkStats{
+		RxBytes:   accumulate(prevNet.RxBytes, sample.NetworkRxBytes),
+		RxPackets: accumulate(prevNet.RxPackets, sample.NetworkRxPackets),
+		TxBytes:   accumulate(prevNet.TxBytes, sample.NetworkTxBytes),
+		TxPackets: accumulate(prevNet.TxPackets, sample.NetworkTxPackets),
+	}
+
+	stats.BlkioStats.IoServiceBytesRecursive = []types.BlkioStatEntry{
+		{Op: "Read", Value: accumulate(prevReadBytes, sample.StorageReadBytes)},
+		{Op: "Write", Value: accumulate(prevWriteBytes, sample.StorageWriteBytes)},
+	}
+	stats.BlkioStats.IoServicedRecursive = []types.BlkioStatEntry{
+		{Op: "Read", Value: accumulate(prevReadOps, sample.StorageReadOps)},
+		{Op: "Write", Value: accumulate(prevWriteOps, sample.StorageWriteOps)},
+	}
+
+	s.previous = stats
+	return stats
+}
+
+// blkioValues returns the read and write values from a set of blkio entries
+func blkioValues(entries []types.BlkioStatEntry) (read, write uint64) {
+	for _, entry := range entries {
+		switch entry.Op {
+		case "Read":
+			read = entry.Value
+		case "Write":
+			write = entry.Value
+		}
+	}
+	return read, write
+}
+
 //------------------------------------
 // ContainerAttach() Utility Functions
 //------------------------------------
@@ -1350,7 +2034,7 @@ func createNewAttachClientWithTimeouts(connectTimeout, responseTimeout, response
 	return plClient, transport
 }
 
-func copyStdIn(ctx context.Context, pl *client.PortLayer, vc *viccontainer.VicContainer, clStdin io.ReadCloser, keys []byte) error {
+func copyStdIn(ctx context.Context, pl *client.PortLayer, ac *AttachConfig, clStdin io.ReadCloser) error {
 	// Pipe for stdin so we can interject and watch the input streams for detach keys.
 	stdinReader, stdinWriter := io.Pipe()
 	defer stdinWriter.Close()
@@ -1369,8 +2053,8 @@ func copyStdIn(ctx context.Context, pl *client.PortLayer, vc *viccontainer.VicCo
 		// he/she is using.
 		log.Debugf("copyStdIn writing primer bytes")
 		stdinWriter.Write([]byte(attachStdinInitString))
-		if vc.Config.Tty {
-			_, err = copyEscapable(stdinWriter, clStdin, keys)
+		if ac.UseTty {
+			_, err = copyEscapable(stdinWriter, clStdin, ac.DetachKeys)
 		} else {
 			_, err = io.Copy(stdinWriter, clStdin)
 		}
@@ -1387,11 +2071,11 @@ func copyStdIn(ctx context.Context, pl *client.PortLayer, vc *viccontainer.VicCo
 
 	// Swagger wants an io.reader so give it the reader pipe.  Also, the swagger call
 	// to set the stdin is synchronous so we need to run in a goroutine
-	setStdinParams := interaction.NewContainerSetStdinParamsWithContext(ctx).WithID(vc.ContainerID)
+	setStdinParams := interaction.NewContainerSetStdinParamsWithContext(ctx).WithID(ac.ID)
 	setStdinParams = setStdinParams.WithRawStream(stdinReader)
 
 	_, err := pl.Interaction.ContainerSetStdin(setStdinParams)
-	if vc.Config.StdinOnce && !vc.Config.Tty {
+	if ac.CloseStdin && !ac.UseTty {
 		// Close the stdin connection.  Mimicing Docker's behavior.
 		// FIXME: If we close this stdin connection.  The portlayer does
 		// not really close stdin.  This is diff from current Docker
@@ -1409,8 +2093,7 @@ func copyStdIn(ctx context.Context, pl *client.PortLayer, vc *viccontainer.VicCo
 	return err
 }
 
-func copyStdOut(ctx context.Context, pl *client.PortLayer, attemptTimeout time.Duration, vc *viccontainer.VicContainer, clStdout io.Writer) error {
-	id := vc.ContainerID
+func copyStdOut(ctx context.Context, pl *client.PortLayer, attemptTimeout time.Duration, id string, clStdout io.Writer) error {
 	//Calculate how much time to let portlayer attempt
 	plAttemptTimeout := attemptTimeout - attachPLAttemptDiff //assumes personality deadline longer than portlayer's deadline
 	plAttemptDeadline := time.Now().Add(plAttemptTimeout)
@@ -1433,8 +2116,7 @@ func copyStdOut(ctx context.Context, pl *client.PortLayer, attemptTimeout time.D
 	return nil
 }
 
-func copyStdErr(ctx context.Context, pl *client.PortLayer, vc *viccontainer.VicContainer, clStderr io.Writer) error {
-	name := vc.ContainerID
+func copyStdErr(ctx context.Context, pl *client.PortLayer, name string, clStderr io.Writer) error {
 	getStderrParams := interaction.NewContainerGetStderrParamsWithContext(ctx).WithID(name)
 
 	_, err := pl.Interaction.ContainerGetStderr(getStderrParams, clStderr)
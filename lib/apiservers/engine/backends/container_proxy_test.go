@@ -16,9 +16,14 @@ package backends
 
 import (
 	"testing"
+	"time"
 
 	"github.com/docker/go-connections/nat"
+	"github.com/go-swagger/go-swagger/strfmt"
+	"github.com/go-swagger/go-swagger/swag"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware/vic/lib/apiservers/portlayer/models"
 )
 
 func TestProcessVolumeParams(t *testing.T) {
@@ -81,3 +86,47 @@ func TestPort(t *testing.T) {
 
 	}
 }
+
+func TestStatsConverter(t *testing.T) {
+	start := time.Now().UTC()
+	sample := func(offset time.Duration) *models.ContainerStats {
+		read := strfmt.DateTime(start.Add(offset))
+		return &models.ContainerStats{
+			Read:             &read,
+			CPUCount:         swag.Int32(2),
+			CPUUsage:         swag.Int64(1000),
+			CPULimit:         swag.Int64(4000),
+			MemoryUsage:      swag.Int64(512),
+			MemoryLimit:      swag.Int64(2048),
+			NetworkRxBytes:   swag.Int64(100),
+			StorageWriteOps:  swag.Int64(10),
+			StorageReadBytes: swag.Int64(50),
+		}
+	}
+
+	converter := &statsConverter{}
+
+	// the first sample covers the realtime interval
+	first := converter.convert(sample(0))
+	assert.Equal(t, uint64(2*vicStatsInterval), first.CPUStats.SystemUsage)
+	assert.Equal(t, uint64(vicStatsInterval/2), first.CPUStats.CPUUsage.TotalUsage)
+	assert.Len(t, first.CPUStats.CPUUsage.PercpuUsage, 2)
+	assert.Equal(t, uint64(512), first.MemoryStats.Usage)
+	assert.Equal(t, uint64(2048), first.MemoryStats.Limit)
+	assert.Equal(t, uint64(100*vicStatsInterval.Seconds()), first.Networks[vicNetworkStatsName].RxBytes)
+
+	second := converter.convert(sample(2 * time.Second))
+	assert.Equal(t, first.CPUStats, second.PreCPUStats)
+	// two vCPUs for two seconds
+	assert.Equal(t, uint64(4*time.Second), second.CPUStats.SystemUsage-first.CPUStats.SystemUsage)
+	// a quarter of the available CPU was in use
+	assert.Equal(t, uint64(time.Second), second.CPUStats.CPUUsage.TotalUsage-first.CPUStats.CPUUsage.TotalUsage)
+	assert.Equal(t, uint64(200), second.Networks[vicNetworkStatsName].RxBytes-first.Networks[vicNetworkStatsName].RxBytes)
+
+	firstRead, _ := blkioValues(first.BlkioStats.IoServiceBytesRecursive)
+	read, _ := blkioValues(second.BlkioStats.IoServiceBytesRecursive)
+	assert.Equal(t, uint64(100), read-firstRead)
+	_, firstWrites := blkioValues(first.BlkioStats.IoServicedRecursive)
+	_, writes := blkioValues(second.BlkioStats.IoServicedRecursive)
+	assert.Equal(t, uint64(20), writes-firstWrites)
+}
@@ -16,6 +16,7 @@ package backends
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -26,12 +27,14 @@ import (
 
 	"github.com/docker/docker/api/types/backend"
 	derr "github.com/docker/docker/errors"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/docker/reference"
 	"github.com/docker/engine-api/types"
 	"github.com/docker/engine-api/types/container"
 	dnetwork "github.com/docker/engine-api/types/network"
 	"github.com/docker/go-connections/nat"
 	"github.com/go-swagger/go-swagger/client"
+	"github.com/go-swagger/go-swagger/swag"
 	"github.com/stretchr/testify/assert"
 	"github.com/vishvananda/netlink"
 
@@ -261,11 +264,13 @@ func (m *MockContainerProxy) Client() *plclient.PortLayer {
 	return nil
 }
 
-func (m *MockContainerProxy) StreamContainerLogs(name string, out io.Writer, started chan struct{}, showTimestamps bool, followLogs bool, since int64, tailLines int64) error {
+func (m *MockContainerProxy) StreamContainerLogs(name string, out io.Writer, started chan struct{}, showTimestamps bool, followLogs bool, showStdout bool, showStderr bool, since int64, tailLines int64) error {
 	var lineCount int64 = 10
 
 	close(started)
 
+	// the port layer multiplexes the streams
+	out = stdcopy.NewStdWriter(out, stdcopy.Stdout)
 	for i := int64(0); i < lineCount; i++ {
 		if !followLogs && i > tailLines {
 			break
@@ -292,6 +297,15 @@ func (m *MockContainerProxy) IsRunning(vc *viccontainer.VicContainer) (bool, err
 	return true, nil
 }
 
+func (m *MockContainerProxy) State(vc *viccontainer.VicContainer) (*types.ContainerState, error) {
+	running, err := m.IsRunning(vc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.ContainerState{Running: running}, nil
+}
+
 func (m *MockContainerProxy) Wait(vc *viccontainer.VicContainer, timeout time.Duration) (exitCode int32, processStatus string, containerState string, reterr error) {
 	return 0, "", "", nil
 }
@@ -304,7 +318,51 @@ func (m *MockContainerProxy) Resize(vc *viccontainer.VicContainer, height, width
 	return nil
 }
 
-func (m *MockContainerProxy) AttachStreams(ctx context.Context, vc *viccontainer.VicContainer, clStdin io.ReadCloser, clStdout, clStderr io.Writer, ca *backend.ContainerAttachConfig) error {
+func (m *MockContainerProxy) AttachStreams(ctx context.Context, ac *AttachConfig, clStdin io.ReadCloser, clStdout, clStderr io.Writer) error {
+	return nil
+}
+
+func (m *MockContainerProxy) CreateExecTask(handle string, config *types.ExecConfig) (string, error) {
+	return "", nil
+}
+
+func (m *MockContainerProxy) StartExecTask(handle string, eid string) error {
+	return nil
+}
+
+func (m *MockContainerProxy) InspectExecTask(handle string, eid string) (*plmodels.TaskInspectResponse, error) {
+	return nil, nil
+}
+
+func (m *MockContainerProxy) RemoveExecTask(handle string, eid string) error {
+	return nil
+}
+
+func (m *MockContainerProxy) ResizeExec(eid string, height, width int32) error {
+	return nil
+}
+
+func (m *MockContainerProxy) StreamContainerStats(ctx context.Context, vc *viccontainer.VicContainer, out chan<- *types.StatsJSON) error {
+	return nil
+}
+
+func (m *MockContainerProxy) Top(vc *viccontainer.VicContainer, psArgs string) (*types.ContainerProcessList, error) {
+	return nil, nil
+}
+
+func (m *MockContainerProxy) StatPath(vc *viccontainer.VicContainer, path string) (*types.ContainerPathStat, error) {
+	return nil, nil
+}
+
+func (m *MockContainerProxy) ArchivePath(vc *viccontainer.VicContainer, path string) (io.ReadCloser, error) {
+	return nil, nil
+}
+
+func (m *MockContainerProxy) Export(vc *viccontainer.VicContainer, out io.Writer) error {
+	return nil
+}
+
+func (m *MockContainerProxy) ExtractToDir(vc *viccontainer.VicContainer, path string, noOverwriteDirNonDir bool, content io.Reader) error {
 	return nil
 }
 
@@ -567,6 +625,29 @@ func TestContainerLogs(t *testing.T) {
 			ExpectedSuccess: true,
 			ExpectedFollow:  true,
 		},
+		{
+			Config: backend.ContainerLogsConfig{
+				ContainerLogsOptions: types.ContainerLogsOptions{
+					ShowStdout: true,
+					Since:      "1479200000",
+					Timestamps: true,
+				},
+				OutStream: &writer,
+			},
+			ExpectedSuccess: true,
+			ExpectedFollow:  false,
+		},
+		{
+			Config: backend.ContainerLogsConfig{
+				ContainerLogsOptions: types.ContainerLogsOptions{
+					ShowStdout: true,
+					Since:      "not a time",
+				},
+				OutStream: &writer,
+			},
+			ExpectedSuccess: false,
+			ExpectedFollow:  false,
+		},
 	}
 
 	for _, containerID := range dummyContainers {
@@ -656,3 +737,139 @@ func TestPortInformation(t *testing.T) {
 	ports = portInformation(mockContainerInfo, ips)
 	assert.Equal(t, len(ports), 2, "Expected 2 port binding, found %d", len(ports))
 }
+
+func TestPortRanges(t *testing.T) {
+	port := func(p string) nat.Port {
+		n, _ := nat.NewPort(nat.SplitProtoPort(p))
+		return n
+	}
+
+	ports := []hostPort{
+		{Port: 8002, ContainerPort: port("8002/tcp")},
+		{Port: 8000, ContainerPort: port("8000/tcp")},
+		{Port: 8001, ContainerPort: port("8001/tcp")},
+		{Port: 8001, ContainerPort: port("8001/udp")},
+		{Port: 9000, ContainerPort: port("80/tcp")},
+		{Port: 9001, ContainerPort: port("9001/tcp")},
+		{IP: "10.0.0.1", Port: 8003, ContainerPort: port("8003/tcp")},
+	}
+
+	expected := []portRange{
+		{proto: "tcp", start: 8000, end: 8002, containerPort: 8000},
+		{proto: "tcp", start: 9000, end: 9000, containerPort: 80},
+		{proto: "tcp", start: 9001, end: 9001, containerPort: 9001},
+		{proto: "udp", start: 8001, end: 8001, containerPort: 8001},
+		{ip: "10.0.0.1", proto: "tcp", start: 8003, end: 8003, containerPort: 8003},
+	}
+	assert.Equal(t, expected, portRanges(ports))
+
+	bindings := portBindingsOf(ports[4:])
+	assert.Equal(t, []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "9000"}}, bindings[port("80/tcp")])
+	assert.Equal(t, []nat.PortBinding{{HostIP: "10.0.0.1", HostPort: "8003"}}, bindings[port("8003/tcp")])
+}
+
+func TestValidateRestartPolicy(t *testing.T) {
+	valid := []container.RestartPolicy{
+		{},
+		{Name: "no"},
+		{Name: "always"},
+		{Name: "unless-stopped"},
+		{Name: "on-failure"},
+		{Name: "on-failure", MaximumRetryCount: 5},
+	}
+	for _, p := range valid {
+		assert.NoError(t, validateRestartPolicy(&container.HostConfig{RestartPolicy: p}), "%#v", p)
+	}
+
+	invalid := []container.RestartPolicy{
+		{Name: "sometimes"},
+		{Name: "always", MaximumRetryCount: 1},
+		{Name: "on-failure", MaximumRetryCount: -1},
+	}
+	for _, p := range invalid {
+		assert.Error(t, validateRestartPolicy(&container.HostConfig{RestartPolicy: p}), "%#v", p)
+	}
+
+	autoRemove := &container.HostConfig{AutoRemove: true, RestartPolicy: container.RestartPolicy{Name: "always"}}
+	assert.Error(t, validateRestartPolicy(autoRemove))
+}
+
+func TestWithHealth(t *testing.T) {
+	inspectJSON := &types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			State: &types.ContainerState{Status: "running", Running: true},
+		},
+	}
+
+	// containers without a healthcheck are left as they are
+	assert.Equal(t, inspectJSON, withHealth(inspectJSON, nil))
+
+	start := time.Now()
+	health := &plmodels.HealthState{
+		Status:        swag.String("unhealthy"),
+		FailingStreak: swag.Int32(3),
+		Log: []*plmodels.HealthResult{
+			{
+				Start:    swag.Int64(start.UnixNano()),
+				End:      swag.Int64(start.Add(time.Second).UnixNano()),
+				ExitCode: swag.Int32(1),
+				Output:   swag.String("failed"),
+			},
+		},
+	}
+
+	out, err := json.Marshal(withHealth(inspectJSON, health))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result struct {
+		State struct {
+			Running bool
+			Health  containerHealth
+		}
+	}
+	if err = json.Unmarshal(out, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, result.State.Running)
+	assert.Equal(t, "unhealthy", result.State.Health.Status)
+	assert.Equal(t, 3, result.State.Health.FailingStreak)
+	if assert.Len(t, result.State.Health.Log, 1) {
+		assert.Equal(t, 1, result.State.Health.Log[0].ExitCode)
+		assert.Equal(t, "failed", result.State.Health.Log[0].Output)
+		assert.Equal(t, time.Second, result.State.Health.Log[0].End.Sub(result.State.Health.Log[0].Start))
+	}
+
+	assert.Equal(t, "", healthSuffix(nil))
+	assert.Equal(t, " (health: starting)", healthSuffix(&plmodels.HealthState{Status: swag.String("starting")}))
+	assert.Equal(t, " (unhealthy)", healthSuffix(health))
+}
+
+func TestResourcesToPortlayer(t *testing.T) {
+	res := container.Resources{
+		Memory:            1000 << 20,
+		MemoryReservation: 512<<20 + 1,
+		CPUShares:         512,
+	}
+
+	config, warnings, err := resourcesToPortlayer(res)
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, int64(1000), swag.Int64Value(config.MemoryMB))
+	assert.Equal(t, int64(516), swag.Int64Value(config.MemoryReservation))
+	assert.Equal(t, int32(512), swag.Int32Value(config.CPUShares))
+	assert.Nil(t, config.NumCpus)
+
+	res.CPUQuota = 50000
+	_, warnings, err = resourcesToPortlayer(res)
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 1)
+
+	_, _, err = resourcesToPortlayer(container.Resources{Memory: 1 << 20})
+	assert.Error(t, err)
+
+	_, _, err = resourcesToPortlayer(container.Resources{Memory: 64 << 20, MemoryReservation: 128 << 20})
+	assert.Error(t, err)
+}
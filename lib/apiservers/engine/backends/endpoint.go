@@ -85,7 +85,7 @@ func (e *endpoint) Iface() libnetwork.InterfaceInfo {
 // Gateway returns the IPv4 gateway assigned by the driver.
 // This will only return a valid value if a container has joined the endpoint.
 func (e *endpoint) Gateway() net.IP {
-	if e.sc.Gateway != nil {
+	if e.sc.Gateway != nil && !isIPv6(e.sc.Subnet) {
 		return net.ParseIP(*e.sc.Gateway)
 	}
 
@@ -95,6 +95,15 @@ func (e *endpoint) Gateway() net.IP {
 // GatewayIPv6 returns the IPv6 gateway assigned by the driver.
 // This will only return a valid value if a container has joined the endpoint.
 func (e *endpoint) GatewayIPv6() net.IP {
+	// the primary gateway of an IPv6 only network is IPv6
+	if isIPv6(e.sc.Subnet) && e.sc.Gateway != nil {
+		return net.ParseIP(*e.sc.Gateway)
+	}
+
+	if e.sc.Gateway6 != nil {
+		return net.ParseIP(*e.sc.Gateway6)
+	}
+
 	return nil
 }
 
@@ -116,24 +125,40 @@ func (e *endpoint) MacAddress() net.HardwareAddr {
 
 // Address returns the IPv4 address assigned to the endpoint.
 func (e *endpoint) Address() *net.IPNet {
-	ip := net.ParseIP(e.ep.Address)
+	if isIPv6(e.sc.Subnet) {
+		return nil
+	}
+
+	return endpointAddress(e.ep.Address, e.sc.Subnet)
+}
+
+// AddressIPv6 returns the IPv6 address assigned to the endpoint.
+func (e *endpoint) AddressIPv6() *net.IPNet {
+	if isIPv6(e.sc.Subnet) {
+		return endpointAddress(e.ep.Address, e.sc.Subnet)
+	}
+
+	if e.ep.Address6 == nil {
+		return nil
+	}
+
+	return endpointAddress(*e.ep.Address6, e.sc.Subnet6)
+}
+
+func endpointAddress(addr string, subnet *string) *net.IPNet {
+	ip := net.ParseIP(addr)
 	if ip == nil {
 		return nil
 	}
 
-	if e.sc.Subnet == nil {
+	if subnet == nil {
 		return nil
 	}
 
-	_, snet, err := net.ParseCIDR(*e.sc.Subnet)
+	_, snet, err := net.ParseCIDR(*subnet)
 	if err != nil {
 		return nil
 	}
 
 	return &net.IPNet{IP: ip, Mask: snet.Mask}
 }
-
-// AddressIPv6 returns the IPv6 address assigned to the endpoint.
-func (e *endpoint) AddressIPv6() *net.IPNet {
-	return nil
-}
@@ -57,11 +57,21 @@ func ResourceNotFoundError(cid, res string) error {
 	return derr.NewRequestNotFoundError(fmt.Errorf("No such %s for container: %s", res, cid))
 }
 
+// ExecNotFoundError returns a 404 docker error when an exec instance is not found.
+func ExecNotFoundError(eid string) error {
+	return derr.NewRequestNotFoundError(fmt.Errorf("No such exec instance '%s' found in daemon", eid))
+}
+
 // NotFoundError returns a 404 docker error when a container is not found.
 func NotFoundError(msg string) error {
 	return derr.NewRequestNotFoundError(fmt.Errorf("No such container: %s", msg))
 }
 
+// PathNotFoundError returns a 404 docker error when a path is not found in a container.
+func PathNotFoundError(name, path string) error {
+	return derr.NewRequestNotFoundError(fmt.Errorf("Could not find the file %s in container %s", path, name))
+}
+
 // InternalServerError returns a 500 docker error on a portlayer error.
 func InternalServerError(msg string) error {
 	return derr.NewErrorWithStatusCode(fmt.Errorf("Server error from portlayer: %s", msg), http.StatusInternalServerError)
@@ -72,6 +82,11 @@ func BadRequestError(msg string) error {
 	return derr.NewErrorWithStatusCode(fmt.Errorf("Bad request error from portlayer: %s", msg), http.StatusBadRequest)
 }
 
+// ForbiddenError returns a 403 docker error when an operation is not permitted by the VCH.
+func ForbiddenError(msg string) error {
+	return derr.NewErrorWithStatusCode(fmt.Errorf("%s", msg), http.StatusForbidden)
+}
+
 func ConflictError(msg string) error {
 	return derr.NewRequestConflictError(fmt.Errorf("Conflict error from portlayer: %s", msg))
 }
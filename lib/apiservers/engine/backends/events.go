@@ -0,0 +1,138 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backends
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/docker/docker/daemon/events"
+	eventtypes "github.com/docker/engine-api/types/events"
+
+	"github.com/vmware/vic/lib/apiservers/engine/backends/cache"
+	viccontainer "github.com/vmware/vic/lib/apiservers/engine/backends/container"
+	"github.com/vmware/vic/lib/apiservers/portlayer/models"
+)
+
+// eventService buffers the docker events of the VCH and publishes them to subscribers
+var eventService = events.New()
+
+// portLayerActions maps the port layer events for each type to the docker actions they represent.
+// Image events are absent as the port layer reports individual layers, so the image backend logs
+// the docker image events itself.
+var portLayerActions = map[string]map[string][]string{
+	eventtypes.ContainerEventType: {
+		"Created":    {"create"},
+		"Started":    {"start"},
+		"PoweredOn":  {"start"},
+		"Stopped":    {"die", "stop"},
+		"PoweredOff": {"die"},
+		"Suspended":  {"pause"},
+		"Resumed":    {"unpause"},
+		"Paused":     {"pause"},
+		"Unpaused":   {"unpause"},
+		"Renamed":    {"rename"},
+		"Removed":    {"destroy"},
+		"Healthy":    {"health_status: healthy"},
+		"Unhealthy":  {"health_status: unhealthy"},
+	},
+	eventtypes.VolumeEventType: {
+		"Created": {"create"},
+		"Removed": {"destroy"},
+	},
+	eventtypes.NetworkEventType: {
+		"Created":      {"create"},
+		"Removed":      {"destroy"},
+		"Connected":    {"connect"},
+		"Disconnected": {"disconnect"},
+	},
+}
+
+// monitorEvents relays the port layer event stream to the event service, reconnecting
+// whenever the stream ends
+func monitorEvents(proxy VicSystemProxy) {
+	stream := make(chan *models.Event)
+	go func() {
+		for e := range stream {
+			eventType, actions, actor := dockerEvent(e)
+			for _, action := range actions {
+				eventService.Log(action, eventType, actor)
+			}
+		}
+	}()
+
+	for {
+		if err := proxy.StreamEvents(context.Background(), stream); err != nil {
+			log.Warnf("Port layer event stream ended: %s", err)
+		}
+		time.Sleep(RetryTimeSeconds * time.Second)
+	}
+}
+
+// dockerEvent translates a port layer event to the docker event type, the docker actions it
+// represents and the actor that generated it
+func dockerEvent(e *models.Event) (string, []string, eventtypes.Actor) {
+	actor := eventtypes.Actor{
+		ID:         e.ID,
+		Attributes: make(map[string]string),
+	}
+
+	switch e.Type {
+	case eventtypes.ContainerEventType:
+		if vc := cache.ContainerCache().GetContainer(e.ID); vc != nil {
+			containerAttributes(vc, actor.Attributes)
+		}
+	case eventtypes.NetworkEventType:
+		for k, v := range e.Attributes {
+			actor.Attributes[k] = v
+		}
+	}
+
+	return e.Type, portLayerActions[e.Type][e.Event], actor
+}
+
+// logImageEvent logs an image event with the reference it was made against
+func logImageEvent(imageID, refName, action string) {
+	eventService.Log(action, eventtypes.ImageEventType, eventtypes.Actor{
+		ID:         imageID,
+		Attributes: map[string]string{"name": refName},
+	})
+}
+
+// containerAttributes adds the event attributes of a container, its labels along with the
+// name and image, to attributes
+func containerAttributes(vc *viccontainer.VicContainer, attributes map[string]string) {
+	if vc.Config != nil {
+		for k, v := range vc.Config.Labels {
+			attributes[k] = v
+		}
+		attributes["image"] = vc.Config.Image
+	}
+	attributes["name"] = vc.Name
+}
+
+// logContainerEvent logs a container event that has no port layer counterpart
+func logContainerEvent(vc *viccontainer.VicContainer, action string) {
+	actor := eventtypes.Actor{
+		ID:         vc.ContainerID,
+		Attributes: make(map[string]string),
+	}
+	containerAttributes(vc, actor.Attributes)
+
+	eventService.Log(action, eventtypes.ContainerEventType, actor)
+}
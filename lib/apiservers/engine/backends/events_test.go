@@ -0,0 +1,68 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backends
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	containertypes "github.com/docker/engine-api/types/container"
+	eventtypes "github.com/docker/engine-api/types/events"
+
+	"github.com/vmware/vic/lib/apiservers/engine/backends/cache"
+	viccontainer "github.com/vmware/vic/lib/apiservers/engine/backends/container"
+	"github.com/vmware/vic/lib/apiservers/portlayer/models"
+)
+
+func TestDockerEvent(t *testing.T) {
+	vc := viccontainer.NewVicContainer()
+	vc.ContainerID = "abc123"
+	vc.Name = "web"
+	vc.Config = &containertypes.Config{
+		Image:  "busybox",
+		Labels: map[string]string{"tier": "frontend"},
+	}
+	cache.ContainerCache().AddContainer(vc)
+	defer cache.ContainerCache().DeleteContainer(vc.ContainerID)
+
+	eventType, actions, actor := dockerEvent(&models.Event{Type: "container", Event: "Stopped", ID: "abc123"})
+	assert.Equal(t, eventtypes.ContainerEventType, eventType)
+	assert.Equal(t, []string{"die", "stop"}, actions)
+	assert.Equal(t, "abc123", actor.ID)
+	assert.Equal(t, map[string]string{"tier": "frontend", "name": "web", "image": "busybox"}, actor.Attributes)
+
+	_, actions, _ = dockerEvent(&models.Event{Type: "container", Event: "PoweredOff", ID: "abc123"})
+	assert.Equal(t, []string{"die"}, actions)
+
+	eventType, actions, actor = dockerEvent(&models.Event{
+		Type:       "network",
+		Event:      "Connected",
+		ID:         "net1",
+		Attributes: map[string]string{"name": "backend", "type": "bridge", "container": "abc123"},
+	})
+	assert.Equal(t, eventtypes.NetworkEventType, eventType)
+	assert.Equal(t, []string{"connect"}, actions)
+	assert.Equal(t, "backend", actor.Attributes["name"])
+	assert.Equal(t, "abc123", actor.Attributes["container"])
+
+	// layer events are not docker image events
+	_, actions, _ = dockerEvent(&models.Event{Type: "image", Event: "Created", ID: "layer"})
+	assert.Empty(t, actions)
+
+	// events with no docker equivalent are dropped
+	_, actions, _ = dockerEvent(&models.Event{Type: "container", Event: "Reconfigured", ID: "abc123"})
+	assert.Empty(t, actions)
+}
@@ -15,20 +15,33 @@
 package backends
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/url"
 	"os"
+	"runtime"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/go-swagger/go-swagger/swag"
 
 	"golang.org/x/net/context"
 
+	"github.com/docker/docker/dockerversion"
+	docker "github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/httputils"
+	"github.com/docker/docker/pkg/progress"
 	"github.com/docker/docker/pkg/streamformatter"
+	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/reference"
 	"github.com/docker/engine-api/types"
 	"github.com/docker/engine-api/types/container"
+	eventtypes "github.com/docker/engine-api/types/events"
 	"github.com/docker/engine-api/types/registry"
 
 	"github.com/vmware/vic/lib/apiservers/engine/backends/cache"
@@ -48,11 +61,199 @@ func (r byCreated) Len() int           { return len(r) }
 func (r byCreated) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
 func (r byCreated) Less(i, j int) bool { return r[i].Created < r[j].Created }
 
+// searchTimeout is how long a search of each registry may take. The registries are searched
+// concurrently.
+const searchTimeout = 30 * time.Second
+
 type Image struct {
 }
 
+// Commit creates a new image from the changes a container has made to its image
 func (i *Image) Commit(name string, config *types.ContainerCommitConfig) (imageID string, err error) {
-	return "", fmt.Errorf("%s does not implement image.Commit", ProductName())
+	defer trace.End(trace.Begin(name))
+
+	vc := cache.ContainerCache().GetContainer(name)
+	if vc == nil {
+		return "", NotFoundError(name)
+	}
+
+	newConfig := config.Config
+	if newConfig == nil {
+		newConfig = &container.Config{}
+	}
+	if config.MergeConfigs && vc.Config != nil {
+		mergeCommitConfig(newConfig, vc.Config)
+	}
+
+	// the layer chain of the new image, leaf first
+	layers, err := imagec.LayerCache().Chain(vc.ImageID)
+	if err != nil || len(layers) == 0 {
+		return "", InternalServerError(fmt.Sprintf("Unable to find the layers of image %s", vc.ImageID))
+	}
+	parent := layers[0]
+
+	layerID := stringid.GenerateRandomID()
+	v1 := docker.V1Image{
+		ID:            layerID,
+		Parent:        parent.ID,
+		Comment:       config.Comment,
+		Created:       time.Now().UTC(),
+		Container:     vc.ContainerID,
+		DockerVersion: dockerversion.Version,
+		Author:        config.Author,
+		Config:        newConfig,
+		Architecture:  runtime.GOARCH,
+		OS:            "linux",
+	}
+	if vc.Config != nil {
+		v1.ContainerConfig = *vc.Config
+	}
+
+	meta, err := json.Marshal(v1)
+	if err != nil {
+		return "", InternalServerError(fmt.Sprintf("Unable to marshal image metadata: %s", err))
+	}
+
+	host, err := sys.UUID()
+	if err != nil {
+		return "", InternalServerError(err.Error())
+	}
+
+	params := storage.NewCommitImageParamsWithContext(ctx).
+		WithStoreName(host).
+		WithContainerID(vc.ContainerID).
+		WithImageID(layerID).
+		WithMetadatakey(swag.String(metadata.MetaDataKey)).
+		WithMetadataval(swag.String(string(meta))).
+		WithPause(swag.Bool(config.Pause))
+	res, err := PortLayerClient().Storage.CommitImage(params)
+	if err != nil {
+		switch err := err.(type) {
+		case *storage.CommitImageNotFound:
+			return "", NotFoundError(name)
+		case *storage.CommitImageConflict:
+			return "", ConflictError(err.Payload.Message)
+		case *storage.CommitImageDefault:
+			return "", InternalServerError(err.Payload.Message)
+		default:
+			return "", InternalServerError(err.Error())
+		}
+	}
+
+	layer := &imagec.ImageWithMeta{
+		Image:  res.Payload.Image,
+		DiffID: res.Payload.DiffID,
+		Meta:   string(meta),
+		Size:   swag.Int64Value(res.Payload.Size),
+	}
+	imagec.LayerCache().Commit(layer)
+	layers = append([]*imagec.ImageWithMeta{layer}, layers...)
+
+	imageConfig, err := imagec.ImageConfigFromLayers(layers)
+	if err != nil {
+		return "", InternalServerError(err.Error())
+	}
+
+	if config.Repo != "" {
+		ref, err := reference.WithName(config.Repo)
+		if err != nil {
+			return "", err
+		}
+		if config.Tag != "" {
+			if ref, err = reference.WithTag(ref, config.Tag); err != nil {
+				return "", err
+			}
+		}
+		ref = reference.WithDefaultTag(ref)
+
+		imageConfig.Name = ref.Name()
+		imageConfig.Tags = []string{ref.(reference.NamedTagged).Tag()}
+		imageConfig.Reference = ref.String()
+
+		if err = cache.RepositoryCache().AddReference(ref, imageConfig.ImageID, true, layerID, true); err != nil {
+			return "", InternalServerError(fmt.Sprintf("Unable to add image reference %s: %s", ref, err))
+		}
+	}
+
+	cache.ImageCache().Add(&imageConfig)
+	if err = cache.ImageCache().Save(); err != nil {
+		return "", InternalServerError(fmt.Sprintf("Unable to save image cache: %s", err))
+	}
+
+	eventService.Log("commit", eventtypes.ContainerEventType, eventtypes.Actor{
+		ID: vc.ContainerID,
+		Attributes: map[string]string{
+			"name":    vc.Name,
+			"comment": config.Comment,
+		},
+	})
+
+	return "sha256:" + imageConfig.ImageID, nil
+}
+
+// mergeCommitConfig fills in the parts of the user supplied config that are left unset
+// from the config of the container being committed
+func mergeCommitConfig(userConf, containerConf *container.Config) {
+	if userConf.User == "" {
+		userConf.User = containerConf.User
+	}
+
+	if len(userConf.ExposedPorts) == 0 {
+		userConf.ExposedPorts = containerConf.ExposedPorts
+	} else {
+		for port := range containerConf.ExposedPorts {
+			userConf.ExposedPorts[port] = struct{}{}
+		}
+	}
+
+	// user supplied variables take precedence over those of the same name
+	for _, env := range containerConf.Env {
+		key := strings.SplitN(env, "=", 2)[0]
+		found := false
+		for _, userEnv := range userConf.Env {
+			if strings.SplitN(userEnv, "=", 2)[0] == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			userConf.Env = append(userConf.Env, env)
+		}
+	}
+
+	labels := make(map[string]string)
+	for k, v := range containerConf.Labels {
+		labels[k] = v
+	}
+	for k, v := range userConf.Labels {
+		labels[k] = v
+	}
+	userConf.Labels = labels
+
+	if len(userConf.Entrypoint) == 0 {
+		if len(userConf.Cmd) == 0 {
+			userConf.Cmd = containerConf.Cmd
+		}
+		if userConf.Entrypoint == nil {
+			userConf.Entrypoint = containerConf.Entrypoint
+		}
+	}
+
+	if userConf.WorkingDir == "" {
+		userConf.WorkingDir = containerConf.WorkingDir
+	}
+
+	if len(userConf.Volumes) == 0 {
+		userConf.Volumes = containerConf.Volumes
+	} else {
+		for k, v := range containerConf.Volumes {
+			userConf.Volumes[k] = v
+		}
+	}
+
+	if userConf.StopSignal == "" {
+		userConf.StopSignal = containerConf.StopSignal
+	}
 }
 
 func (i *Image) Exists(containerName string) bool {
@@ -132,6 +333,7 @@ func (i *Image) ImageDelete(imageRef string, force, prune bool) ([]types.ImageDe
 		refNamed, _ := cache.RepositoryCache().Remove(tags[i], false)
 		dd := types.ImageDelete{Untagged: refNamed}
 		deleted = append(deleted, dd)
+		logImageEvent(img.ImageID, tags[i], "untag")
 	}
 
 	// save repo now -- this will limit the number of PL
@@ -144,13 +346,85 @@ func (i *Image) ImageDelete(imageRef string, force, prune bool) ([]types.ImageDe
 	if imageRemoved {
 		imageDeleted := types.ImageDelete{Deleted: img.ImageID}
 		deleted = append(deleted, imageDeleted)
+		logImageEvent(img.ImageID, img.ImageID, "delete")
 	}
 
 	return deleted, err
 }
 
+// ImageHistory returns the history of each layer of the image, newest first. The layer chain
+// is walked through the image store as the image cache only keeps the ID of the top layer.
 func (i *Image) ImageHistory(imageName string) ([]*types.ImageHistory, error) {
-	return nil, fmt.Errorf("%s does not implement image.History", ProductName())
+	defer trace.End(trace.Begin(imageName))
+
+	imageConfig, err := cache.ImageCache().Get(imageName)
+	if err != nil {
+		return nil, err
+	}
+
+	host, err := sys.UUID()
+	if err != nil {
+		return nil, InternalServerError(err.Error())
+	}
+
+	var history []*types.ImageHistory
+	for id := imageConfig.ID; id != "" && id != "scratch"; {
+		layer, err := PortLayerClient().Storage.GetImage(storage.NewGetImageParamsWithContext(ctx).WithStoreName(host).WithID(id))
+		if err != nil {
+			switch err := err.(type) {
+			case *storage.GetImageNotFound:
+				return nil, InternalServerError(fmt.Sprintf("Layer %s of image %s not found: %s", id, imageName, err.Payload.Message))
+			default:
+				return nil, InternalServerError(err.Error())
+			}
+		}
+
+		h, err := layerHistory(layer.Payload.ID, layer.Payload.Metadata[metadata.MetaDataKey])
+		if err != nil {
+			return nil, InternalServerError(err.Error())
+		}
+		if id == imageConfig.ID {
+			h.ID = "sha256:" + imageConfig.ImageID
+			h.Tags = imageConfig.Tags
+		}
+		history = append(history, h)
+
+		id = ""
+		if layer.Payload.Parent != nil {
+			id = *layer.Payload.Parent
+		}
+	}
+
+	return history, nil
+}
+
+// layerHistory builds the history entry of a layer from its v1 metadata. Layers that are the
+// top layer of an image are reported with the ID and tags of that image.
+func layerHistory(layerID, meta string) (*types.ImageHistory, error) {
+	var v1 docker.V1Image
+	if meta != "" {
+		if err := json.Unmarshal([]byte(meta), &v1); err != nil {
+			return nil, fmt.Errorf("Failed to unmarshal metadata of layer %s: %s", layerID, err)
+		}
+	}
+
+	h := &types.ImageHistory{
+		ID:        "<missing>",
+		Created:   v1.Created.Unix(),
+		CreatedBy: strings.Join(v1.ContainerConfig.Cmd, " "),
+		Comment:   v1.Comment,
+	}
+
+	if layer, err := imagec.LayerCache().Get(layerID); err == nil {
+		h.Size = layer.Size
+	}
+
+	if imageID := cache.RepositoryCache().GetImageID(layerID); imageID != "" {
+		h.ID = "sha256:" + imageID
+		h.Tags = cache.RepositoryCache().Tags(imageID)
+	}
+
+	return h, nil
 }
 
 func (i *Image) Images(filterArgs string, filter string, all bool) ([]*types.Image, error) {
@@ -183,20 +457,245 @@ func (i *Image) LookupImage(name string) (*types.ImageInspect, error) {
 	return imageConfigToDockerImageInspect(imageConfig, ProductName()), nil
 }
 
+// TagImage adds newTag as a reference to the image, replacing the image it referred to if any
 func (i *Image) TagImage(newTag reference.Named, imageName string) error {
-	return fmt.Errorf("%s does not implement image.Tag", ProductName())
+	defer trace.End(trace.Begin(imageName))
+
+	imageConfig, err := cache.ImageCache().Get(imageName)
+	if err != nil {
+		return err
+	}
+
+	newTag = reference.WithDefaultTag(newTag)
+
+	// the image that owns the tag before it moves, if any
+	prevID, _ := cache.RepositoryCache().Get(newTag)
+
+	if err = cache.RepositoryCache().AddReference(newTag, imageConfig.ImageID, true, imageConfig.ID, true); err != nil {
+		return InternalServerError(fmt.Sprintf("Unable to add image reference %s: %s", newTag, err))
+	}
+
+	imageConfig.Name = newTag.Name()
+	imageConfig.Tags = []string{newTag.(reference.NamedTagged).Tag()}
+	imageConfig.Reference = newTag.String()
+	cache.ImageCache().Add(imageConfig)
+
+	if prevID != "" && prevID != imageConfig.ImageID {
+		if prevConfig, err := cache.ImageCache().Get(prevID); err == nil {
+			untagImageConfig(prevConfig)
+			cache.ImageCache().Add(prevConfig)
+		}
+	}
+
+	if err = cache.ImageCache().Save(); err != nil {
+		return InternalServerError(fmt.Sprintf("Unable to save image cache: %s", err))
+	}
+
+	id := "sha256:" + imageConfig.ImageID
+	logImageEvent(id, newTag.String(), "tag")
+	return nil
+}
+
+// untagImageConfig points an image config that lost its tag at one of the
+// remaining tagged references of the image, or leaves it known only by ID
+func untagImageConfig(imageConfig *metadata.ImageConfig) {
+	imageConfig.Name = ""
+	imageConfig.Tags = nil
+	imageConfig.Reference = ""
+
+	for _, ref := range cache.RepositoryCache().References(imageConfig.ImageID) {
+		if tagged, ok := ref.(reference.NamedTagged); ok {
+			imageConfig.Name = tagged.Name()
+			imageConfig.Tags = []string{tagged.Tag()}
+			imageConfig.Reference = tagged.String()
+			return
+		}
+	}
 }
 
+// LoadImage writes the images in a docker save archive to the image store
 func (i *Image) LoadImage(inTar io.ReadCloser, outStream io.Writer, quiet bool) error {
-	return fmt.Errorf("%s does not implement image.LoadImage", ProductName())
+	defer trace.End(trace.Begin(""))
+
+	host, err := sys.UUID()
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	sf := streamformatter.NewJSONStreamFormatter()
+	progressOutput := sf.NewProgressOutput(ioutil.Discard, false)
+	if !quiet {
+		progressOutput = sf.NewProgressOutput(outStream, false)
+		outStream = &streamformatter.StdoutFormatter{Writer: outStream, StreamFormatter: sf}
+	}
+
+	loaded, err := imagec.LoadImages(PortLayerServer(), host, inTar, progressOutput)
+	if err != nil {
+		return err
+	}
+
+	for _, image := range loaded {
+		id := "sha256:" + image.Image.ImageID
+		if len(image.Refs) == 0 {
+			fmt.Fprintf(outStream, "Loaded image ID: %s\n", id)
+			logImageEvent(id, id, "load")
+			continue
+		}
+
+		for _, ref := range image.Refs {
+			fmt.Fprintf(outStream, "Loaded image: %s\n", ref.String())
+			logImageEvent(id, ref.String(), "load")
+		}
+	}
+
+	return nil
 }
 
+// ImportImage creates a single layer image from a filesystem tarball read from inConfig if
+// src is "-", or downloaded from the URL in src otherwise
 func (i *Image) ImportImage(src string, newRef reference.Named, msg string, inConfig io.ReadCloser, outStream io.Writer, config *container.Config) error {
-	return fmt.Errorf("%s does not implement image.ImportImage", ProductName())
+	defer trace.End(trace.Begin(src))
+
+	sf := streamformatter.NewJSONStreamFormatter()
+
+	var rc io.ReadCloser
+	if src == "-" {
+		rc = inConfig
+	} else {
+		inConfig.Close()
+
+		u, err := url.Parse(src)
+		if err != nil {
+			return err
+		}
+		if u.Scheme == "" {
+			u.Scheme = "http"
+			u.Host = src
+			u.Path = ""
+		}
+
+		outStream.Write(sf.FormatStatus("", "Downloading from %s", u))
+		resp, err := httputils.Download(u.String())
+		if err != nil {
+			return err
+		}
+
+		progressOutput := sf.NewProgressOutput(outStream, true)
+		rc = progress.NewProgressReader(resp.Body, progressOutput, resp.ContentLength, "", "Importing")
+	}
+	defer rc.Close()
+
+	if msg == "" {
+		msg = "Imported from " + src
+	}
+
+	host, err := sys.UUID()
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	imageConfig, err := imagec.ImportImage(PortLayerServer(), host, rc, docker.V1Image{
+		DockerVersion: dockerversion.Version,
+		Config:        config,
+		Architecture:  runtime.GOARCH,
+		OS:            "linux",
+		Created:       time.Now().UTC(),
+		Comment:       msg,
+	})
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	if newRef != nil {
+		ref := reference.WithDefaultTag(newRef)
+
+		imageConfig.Name = ref.Name()
+		imageConfig.Tags = []string{ref.(reference.NamedTagged).Tag()}
+		imageConfig.Reference = ref.String()
+
+		if err = cache.RepositoryCache().AddReference(ref, imageConfig.ImageID, true, imageConfig.ID, true); err != nil {
+			return InternalServerError(fmt.Sprintf("Unable to add image reference %s: %s", ref, err))
+		}
+	}
+
+	cache.ImageCache().Add(imageConfig)
+	if err = cache.ImageCache().Save(); err != nil {
+		return InternalServerError(fmt.Sprintf("Unable to save image cache: %s", err))
+	}
+
+	id := "sha256:" + imageConfig.ImageID
+	logImageEvent(id, id, "import")
+	outStream.Write(sf.FormatStatus("", id))
+
+	return nil
 }
 
+// ExportImage writes the named images to outStream in the docker save format
 func (i *Image) ExportImage(names []string, outStream io.Writer) error {
-	return fmt.Errorf("%s does not implement image.ExportImage", ProductName())
+	defer trace.End(trace.Begin(strings.Join(names, ",")))
+
+	var images []imagec.ArchivedImage
+	index := make(map[string]int)
+
+	add := func(imageConfig *metadata.ImageConfig, ref reference.NamedTagged) {
+		n, ok := index[imageConfig.ImageID]
+		if !ok {
+			n = len(images)
+			index[imageConfig.ImageID] = n
+			images = append(images, imagec.ArchivedImage{Image: imageConfig})
+		}
+		if ref != nil {
+			images[n].Refs = append(images[n].Refs, ref)
+		}
+	}
+
+	for _, name := range names {
+		ref, err := reference.ParseNamed(name)
+
+		// every tag of the repository is saved when no tag is given
+		if err == nil && reference.IsNameOnly(ref) {
+			found := false
+			for _, association := range cache.RepositoryCache().ReferencesByName(ref) {
+				tagged, ok := association.Ref.(reference.NamedTagged)
+				if !ok {
+					continue
+				}
+				imageConfig, err := cache.ImageCache().Get(tagged.String())
+				if err != nil {
+					return err
+				}
+				add(imageConfig, tagged)
+				found = true
+			}
+			if found {
+				continue
+			}
+		}
+
+		imageConfig, err := cache.ImageCache().Get(name)
+		if err != nil {
+			return err
+		}
+
+		tagged, _ := ref.(reference.NamedTagged)
+		add(imageConfig, tagged)
+	}
+
+	host, err := sys.UUID()
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	if err = imagec.SaveImages(PortLayerServer(), host, images, outStream); err != nil {
+		return err
+	}
+
+	for _, image := range images {
+		id := "sha256:" + image.Image.ImageID
+		logImageEvent(id, id, "save")
+	}
+
+	return nil
 }
 
 func (i *Image) PullImage(ctx context.Context, ref reference.Named, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) error {
@@ -204,6 +703,10 @@ func (i *Image) PullImage(ctx context.Context, ref reference.Named, metaHeaders
 
 	log.Debugf("PullImage: ref = %+v, metaheaders = %+v\n", ref, metaHeaders)
 
+	if err := CheckRegistryAccess(ref.Hostname()); err != nil {
+		return err
+	}
+
 	options := imagec.Options{
 		Destination: os.TempDir(),
 		Reference:   ref.String(),
@@ -245,15 +748,195 @@ func (i *Image) PullImage(ctx context.Context, ref reference.Named, metaHeaders
 		return err
 	}
 
+	logImageEvent(imageEventID(ref), ref.String(), "pull")
 	return nil
 }
 
+// imageEventID returns the ID of the image ref refers to for use in events, falling back to the
+// reference if the image cannot be found
+func imageEventID(ref reference.Named) string {
+	imageConfig, err := cache.ImageCache().Get(ref.String())
+	if err != nil {
+		log.Warnf("Unable to find image %s for event: %s", ref.String(), err)
+		return ref.String()
+	}
+
+	return "sha256:" + imageConfig.ImageID
+}
+
 func (i *Image) PushImage(ctx context.Context, ref reference.Named, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) error {
-	return fmt.Errorf("%s does not implement image.PushImage", ProductName())
+	defer trace.End(trace.Begin(ref.String()))
+
+	// every tag of the repository is pushed when no tag is given
+	refs := []reference.Named{ref}
+	if reference.IsNameOnly(ref) {
+		refs = nil
+		for _, association := range cache.RepositoryCache().ReferencesByName(ref) {
+			if _, ok := association.Ref.(reference.NamedTagged); ok {
+				refs = append(refs, association.Ref)
+			}
+		}
+		if len(refs) == 0 {
+			return fmt.Errorf("An image does not exist locally with the tag: %s", ref.Name())
+		}
+	}
+
+	if err := CheckRegistryAccess(ref.Hostname()); err != nil {
+		return err
+	}
+
+	for _, r := range refs {
+		options := imagec.Options{
+			Destination: os.TempDir(),
+			Reference:   r.String(),
+			Timeout:     imagec.DefaultHTTPTimeout,
+			Outstream:   outStream,
+			Host:        PortLayerServer(),
+		}
+
+		if authConfig != nil {
+			options.Username = authConfig.Username
+			options.Password = authConfig.Password
+		}
+
+		for _, registry := range InsecureRegistries() {
+			if registry == r.Hostname() {
+				options.InsecureAllowHTTP = true
+				break
+			}
+		}
+
+		log.Infof("PushImage: reference: %s, portlayer: %s", options.Reference, options.Host)
+
+		ic := imagec.NewImageC(options, streamformatter.NewJSONStreamFormatter())
+		if err := ic.PushImage(); err != nil {
+			return err
+		}
+
+		logImageEvent(imageEventID(r), r.String(), "push")
+	}
+
+	return nil
 }
 
+// SearchRegistryForImages searches the registry named in the term or, if there is none, the
+// docker hub along with the whitelisted and insecure registries the VCH may use
 func (i *Image) SearchRegistryForImages(ctx context.Context, term string, authConfig *types.AuthConfig, metaHeaders map[string][]string) (*registry.SearchResults, error) {
-	return nil, fmt.Errorf("%s does not implement image.SearchRegistryForImages", ProductName())
+	defer trace.End(trace.Begin(term))
+
+	hosts, query, err := searchRegistries(term)
+	if err != nil {
+		return nil, err
+	}
+
+	// the credentials are those of the registry named in the term, or of the docker hub
+	index := imagec.DefaultIndexURL
+	if query != term {
+		index = hosts[0]
+	}
+
+	// the registries are searched concurrently, keeping the results in the order of hosts
+	found := make([][]registry.SearchResult, len(hosts))
+	errs := make([]error, len(hosts))
+
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		options := imagec.Options{
+			Registry: host,
+			Timeout:  searchTimeout,
+		}
+		if isDockerHub(host) {
+			options.Registry = imagec.DefaultIndexURL
+		}
+
+		if authConfig != nil && host == index {
+			options.Username = authConfig.Username
+			options.Password = authConfig.Password
+		}
+
+		for _, registry := range InsecureRegistries() {
+			if registry == host {
+				options.InsecureAllowHTTP = true
+				options.InsecureSkipVerify = true
+				break
+			}
+		}
+
+		wg.Add(1)
+		go func(i int, options imagec.Options) {
+			defer wg.Done()
+
+			found[i], errs[i] = imagec.SearchRegistry(ctx, options, query, imagec.DefaultSearchLimit)
+		}(i, options)
+	}
+	wg.Wait()
+
+	results := &registry.SearchResults{Query: term}
+	var failed int
+	var lastErr error
+	for i, host := range hosts {
+		if errs[i] != nil {
+			log.Warnf("Unable to search registry %s: %s", host, errs[i])
+			failed++
+			lastErr = errs[i]
+			continue
+		}
+
+		for _, result := range found[i] {
+			if !isDockerHub(host) {
+				result.Name = host + "/" + result.Name
+			}
+			results.Results = append(results.Results, result)
+		}
+	}
+
+	if failed == len(hosts) {
+		return nil, InternalServerError(lastErr.Error())
+	}
+
+	results.NumResults = len(results.Results)
+	return results, nil
+}
+
+// searchRegistries returns the registries to search for term, along with the term without any
+// registry prefix. Registries the VCH may not use are not searched.
+func searchRegistries(term string) ([]string, string, error) {
+	// a term qualified by a registry searches only that registry, as with docker
+	if i := strings.Index(term, "/"); i > 0 {
+		prefix := term[:i]
+		if strings.ContainsAny(prefix, ".:") || prefix == "localhost" {
+			if err := CheckRegistryAccess(prefix); err != nil {
+				return nil, "", err
+			}
+			return []string{prefix}, term[i+1:], nil
+		}
+	}
+
+	hubErr := CheckRegistryAccess(imagec.DefaultIndexURL)
+
+	var hosts []string
+	if hubErr == nil {
+		hosts = append(hosts, imagec.DefaultIndexURL)
+	}
+
+	seen := make(map[string]bool)
+	// only whitelist entries naming a single registry can be searched
+	for _, host := range append(RegistryWhitelist(), InsecureRegistries()...) {
+		if host == "" || seen[host] || isDockerHub(host) || strings.ContainsAny(host, "*/") {
+			continue
+		}
+		seen[host] = true
+
+		if CheckRegistryAccess(host) == nil {
+			hosts = append(hosts, host)
+		}
+	}
+
+	if len(hosts) == 0 {
+		return nil, "", hubErr
+	}
+
+	return hosts, term, nil
 }
 
 // Utility functions
@@ -25,6 +25,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/vmware/vic/lib/metadata"
+	vicregistry "github.com/vmware/vic/pkg/registry"
 )
 
 func TestConvertV1ImageToDockerImage(t *testing.T) {
@@ -60,3 +61,108 @@ func TestConvertV1ImageToDockerImage(t *testing.T) {
 	assert.Equal(t, image.Digests[0], dockerImage.RepoDigests[0], "Error: expected digest %s, got %s", image.Digests[0], dockerImage.RepoDigests[0])
 	assert.Equal(t, image.Tags[0], dockerImage.RepoTags[0], "Error: expected tag %s, got %s", image.Tags[0], dockerImage.RepoTags[0])
 }
+
+func TestMergeCommitConfig(t *testing.T) {
+	containerConf := &container.Config{
+		User:       "nobody",
+		Env:        []string{"PATH=/bin", "HOME=/root"},
+		Labels:     map[string]string{"a": "container", "b": "container"},
+		Cmd:        []string{"/bin/sh"},
+		WorkingDir: "/root",
+	}
+
+	userConf := &container.Config{
+		Env:    []string{"HOME=/home"},
+		Labels: map[string]string{"b": "user"},
+	}
+	mergeCommitConfig(userConf, containerConf)
+
+	assert.Equal(t, "nobody", userConf.User)
+	assert.Equal(t, []string{"HOME=/home", "PATH=/bin"}, userConf.Env)
+	assert.Equal(t, map[string]string{"a": "container", "b": "user"}, userConf.Labels)
+	assert.Equal(t, []string{"/bin/sh"}, []string(userConf.Cmd))
+	assert.Equal(t, "/root", userConf.WorkingDir)
+
+	// a user supplied entrypoint replaces the command of the container
+	userConf = &container.Config{Entrypoint: []string{"/bin/true"}}
+	mergeCommitConfig(userConf, containerConf)
+	assert.Empty(t, userConf.Cmd)
+}
+
+func TestLayerHistory(t *testing.T) {
+	created := time.Unix(1480000000, 0).UTC()
+	meta := `{"id":"layer1","created":"` + created.Format(time.RFC3339Nano) + `","comment":"imported","container_config":{"Cmd":["/bin/sh","-c","#(nop) ADD file in /"]}}`
+
+	h, err := layerHistory("layer1", meta)
+	assert.NoError(t, err)
+	assert.Equal(t, "<missing>", h.ID)
+	assert.Equal(t, created.Unix(), h.Created)
+	assert.Equal(t, "/bin/sh -c #(nop) ADD file in /", h.CreatedBy)
+	assert.Equal(t, "imported", h.Comment)
+
+	_, err = layerHistory("layer1", "{")
+	assert.Error(t, err)
+}
+
+func TestCheckRegistryAccess(t *testing.T) {
+	defer func() {
+		registryWhitelist = nil
+		registryBlacklist = nil
+	}()
+
+	assert.NoError(t, CheckRegistryAccess("registry.example.com"), "no lists")
+
+	registryWhitelist, _ = vicregistry.ParseSet([]string{"*.example.com", "docker.io", "10.0.0.0/8"})
+	registryBlacklist, _ = vicregistry.ParseSet([]string{"bad.example.com"})
+
+	allowed := []string{"registry.example.com:5000", "docker.io", IndexServerAddress, "index.docker.io", "", "10.1.2.3:443"}
+	for _, host := range allowed {
+		assert.NoError(t, CheckRegistryAccess(host), host)
+	}
+
+	denied := []string{"bad.example.com", "quay.io", "192.168.1.1:5000"}
+	for _, host := range denied {
+		assert.Error(t, CheckRegistryAccess(host), host)
+	}
+}
+
+func TestSearchRegistries(t *testing.T) {
+	defer func() {
+		registryWhitelist = nil
+		registryBlacklist = nil
+		insecureRegistries = nil
+	}()
+
+	insecureRegistries = []string{"insecure.example.com:5000", "bad.example.com"}
+	registryWhitelist, _ = vicregistry.ParseSet([]string{"*.example.com", "registry.example.com", "docker.io", "10.0.0.0/8"})
+	registryBlacklist, _ = vicregistry.ParseSet([]string{"bad.example.com"})
+
+	hosts, term, err := searchRegistries("busybox")
+	assert.NoError(t, err)
+	assert.Equal(t, "busybox", term)
+	assert.Equal(t, []string{"index.docker.io", "registry.example.com", "insecure.example.com:5000"}, hosts)
+
+	hosts, term, err = searchRegistries("registry.example.com/test/busybox")
+	assert.NoError(t, err)
+	assert.Equal(t, "test/busybox", term)
+	assert.Equal(t, []string{"registry.example.com"}, hosts)
+
+	// an unqualified repository is not a registry
+	_, term, err = searchRegistries("test/busybox")
+	assert.NoError(t, err)
+	assert.Equal(t, "test/busybox", term)
+
+	_, _, err = searchRegistries("bad.example.com/busybox")
+	assert.Error(t, err)
+
+	// only the registries in the whitelist are searched
+	registryWhitelist, _ = vicregistry.ParseSet([]string{"registry.example.com"})
+	hosts, _, err = searchRegistries("busybox")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"registry.example.com"}, hosts)
+
+	registryWhitelist, _ = vicregistry.ParseSet([]string{"*.example.com"})
+	insecureRegistries = nil
+	_, _, err = searchRegistries("busybox")
+	assert.Error(t, err)
+}
@@ -106,26 +106,44 @@ func (n *Network) GetAllNetworks() []libnetwork.Network {
 }
 
 func (n *Network) CreateNetwork(name, driver string, ipam apinet.IPAM, options map[string]string, labels map[string]string, internal bool, enableIPv6 bool) (libnetwork.Network, error) {
-	if len(ipam.Config) > 1 {
-		return nil, fmt.Errorf("at most one ipam config supported")
+	if len(ipam.Config) > 2 {
+		return nil, fmt.Errorf("at most two ipam configs supported")
 	}
 
-	var gateway, subnet *string
-	var pools []string
-	if len(ipam.Config) > 0 {
-		if ipam.Config[0].Gateway != "" {
-			gateway = new(string)
-			*gateway = ipam.Config[0].Gateway
+	// at most one config of each address family; with both, the IPv4 config is the primary
+	var v4, v6 *apinet.IPAMConfig
+	for i := range ipam.Config {
+		c := &ipam.Config[i]
+		if !isIPv6Config(c) {
+			if v4 != nil {
+				return nil, fmt.Errorf("at most one IPv4 ipam config supported")
+			}
+			v4 = c
+			continue
 		}
 
-		if ipam.Config[0].Subnet != "" {
-			subnet = new(string)
-			*subnet = ipam.Config[0].Subnet
+		if !enableIPv6 {
+			return nil, fmt.Errorf("IPv6 ipam config requires IPv6 to be enabled on network %s", name)
 		}
-
-		if ipam.Config[0].IPRange != "" {
-			pools = append(pools, ipam.Config[0].IPRange)
+		if v6 != nil {
+			return nil, fmt.Errorf("at most one IPv6 ipam config supported")
 		}
+		v6 = c
+	}
+
+	if enableIPv6 && (v6 == nil || v6.Subnet == "") {
+		return nil, fmt.Errorf("an IPv6 subnet is required to enable IPv6 on network %s", name)
+	}
+
+	primary := v4
+	if primary == nil {
+		primary = v6
+	}
+
+	var gateway, subnet *string
+	var pools []string
+	if primary != nil {
+		gateway, subnet, pools = fromIPAMConfig(primary)
 	}
 
 	if driver == "" {
@@ -138,6 +156,11 @@ func (n *Network) CreateNetwork(name, driver string, ipam apinet.IPAM, options m
 		ScopeType: driver,
 		Subnet:    subnet,
 		IPAM:      pools,
+		IPV6:      &enableIPv6,
+	}
+
+	if v4 != nil && v6 != nil {
+		cfg.Gateway6, cfg.Subnet6, cfg.Ipam6 = fromIPAMConfig(v6)
 	}
 
 	created, err := PortLayerClient().Scopes.CreateScope(scopes.NewCreateScopeParamsWithContext(ctx).WithConfig(cfg))
@@ -157,6 +180,40 @@ func (n *Network) CreateNetwork(name, driver string, ipam apinet.IPAM, options m
 	return &network{cfg: created.Payload}, nil
 }
 
+// isIPv6Config returns true if the addresses of the ipam config are IPv6
+func isIPv6Config(c *apinet.IPAMConfig) bool {
+	for _, a := range []string{c.Subnet, c.IPRange} {
+		if ip, _, err := net.ParseCIDR(a); err == nil {
+			return ip.To4() == nil
+		}
+	}
+
+	if ip := net.ParseIP(c.Gateway); ip != nil {
+		return ip.To4() == nil
+	}
+
+	return false
+}
+
+// fromIPAMConfig converts an ipam config to the gateway, subnet and pools of a scope config
+func fromIPAMConfig(c *apinet.IPAMConfig) (gateway, subnet *string, pools []string) {
+	if c.Gateway != "" {
+		gateway = new(string)
+		*gateway = c.Gateway
+	}
+
+	if c.Subnet != "" {
+		subnet = new(string)
+		*subnet = c.Subnet
+	}
+
+	if c.IPRange != "" {
+		pools = append(pools, c.IPRange)
+	}
+
+	return gateway, subnet, pools
+}
+
 func (n *Network) ConnectContainerToNetwork(containerName, networkName string, endpointConfig *apinet.EndpointSettings) error {
 	vc := cache.ContainerCache().GetContainer(containerName)
 	if vc != nil {
@@ -181,9 +238,13 @@ func (n *Network) ConnectContainerToNetwork(containerName, networkName string, e
 	h := getRes.Payload
 	nc := &models.NetworkConfig{NetworkName: networkName}
 	if endpointConfig != nil {
-		if endpointConfig.IPAMConfig != nil && endpointConfig.IPAMConfig.IPv4Address != "" {
-			nc.Address = &endpointConfig.IPAMConfig.IPv4Address
-
+		if endpointConfig.IPAMConfig != nil {
+			if endpointConfig.IPAMConfig.IPv4Address != "" {
+				nc.Address = &endpointConfig.IPAMConfig.IPv4Address
+			}
+			if endpointConfig.IPAMConfig.IPv6Address != "" {
+				nc.Address6 = &endpointConfig.IPAMConfig.IPv6Address
+			}
 		}
 
 		// Pass Links and Aliases to PL
@@ -229,7 +290,8 @@ func (n *Network) ConnectContainerToNetwork(containerName, networkName string, e
 
 	h = getStateRes.Payload.Handle
 	if getStateRes.Payload.State == "RUNNING" {
-		bindRes, err := client.Scopes.BindContainer(scopes.NewBindContainerParamsWithContext(ctx).WithHandle(h))
+		var bindRes *scopes.BindContainerOK
+		bindRes, err = client.Scopes.BindContainer(scopes.NewBindContainerParamsWithContext(ctx).WithHandle(h))
 		if err != nil {
 			switch err := err.(type) {
 			case *scopes.BindContainerNotFound:
@@ -243,16 +305,18 @@ func (n *Network) ConnectContainerToNetwork(containerName, networkName string, e
 			}
 		}
 
+		h = bindRes.Payload.Handle
+
+		// the container stays bound to its other networks if the commit fails, so only the
+		// new endpoint is rolled back
 		defer func() {
 			if err == nil {
 				return
 			}
-			if _, err2 := client.Scopes.UnbindContainer(scopes.NewUnbindContainerParamsWithContext(ctx).WithHandle(h)); err2 != nil {
+			if _, err2 := client.Scopes.RemoveContainer(scopes.NewRemoveContainerParamsWithContext(ctx).WithHandle(h).WithScope(networkName)); err2 != nil {
 				log.Warnf("failed bind container rollback: %s", err2)
 			}
 		}()
-
-		h = bindRes.Payload.Handle
 	}
 
 	// commit handle
@@ -278,7 +342,56 @@ func (n *Network) DisconnectContainerFromNetwork(containerName string, network l
 	if vc != nil {
 		containerName = vc.ContainerID
 	}
-	return fmt.Errorf("%s does not implement network.DisconnectContainerFromNetwork", ProductName())
+
+	client := PortLayerClient()
+	getRes, err := client.Containers.Get(containers.NewGetParamsWithContext(ctx).WithID(containerName))
+	if err != nil {
+		switch err := err.(type) {
+		case *containers.GetNotFound:
+			return derr.NewRequestNotFoundError(fmt.Errorf(err.Payload.Message))
+
+		case *containers.GetDefault:
+			return derr.NewErrorWithStatusCode(fmt.Errorf(err.Payload.Message), http.StatusInternalServerError)
+
+		default:
+			return derr.NewErrorWithStatusCode(err, http.StatusInternalServerError)
+		}
+	}
+
+	// the port layer unbinds the endpoint of a running container, and the vNIC is removed
+	// on commit if no other network is using it
+	removeRes, err := client.Scopes.RemoveContainer(scopes.NewRemoveContainerParamsWithContext(ctx).
+		WithHandle(getRes.Payload).
+		WithScope(network.Name()))
+	if err != nil {
+		switch err := err.(type) {
+		case *scopes.RemoveContainerNotFound:
+			return derr.NewRequestNotFoundError(fmt.Errorf(err.Payload.Message))
+
+		case *scopes.RemoveContainerInternalServerError:
+			return derr.NewErrorWithStatusCode(fmt.Errorf(err.Payload.Message), http.StatusInternalServerError)
+
+		default:
+			return derr.NewErrorWithStatusCode(err, http.StatusInternalServerError)
+		}
+	}
+
+	// commit handle
+	_, err = client.Containers.Commit(containers.NewCommitParamsWithContext(ctx).WithHandle(removeRes.Payload))
+	if err != nil {
+		switch err := err.(type) {
+		case *containers.CommitNotFound:
+			return derr.NewRequestNotFoundError(fmt.Errorf(err.Payload.Message))
+
+		case *containers.CommitDefault:
+			return derr.NewErrorWithStatusCode(fmt.Errorf(err.Payload.Message), http.StatusInternalServerError)
+
+		default:
+			return derr.NewErrorWithStatusCode(err, http.StatusInternalServerError)
+		}
+	}
+
+	return nil
 }
 
 func (n *Network) DeleteNetwork(name string) error {
@@ -383,33 +496,60 @@ func (n *network) IpamConfig() (string, map[string]string, []*libnetwork.IpamCon
 	n.Lock()
 	defer n.Unlock()
 
-	confs := make([]*libnetwork.IpamConf, len(n.cfg.IPAM))
-	for j, i := range n.cfg.IPAM {
+	confs := ipamConfs(n.cfg.Subnet, n.cfg.Gateway, n.cfg.IPAM)
+	confs6 := ipamConfs(n.cfg.Subnet6, n.cfg.Gateway6, n.cfg.Ipam6)
+
+	// the primary subnet of an IPv6 only network is IPv6
+	if isIPv6(n.cfg.Subnet) {
+		confs, confs6 = nil, confs
+	}
+
+	return "", make(map[string]string), confs, confs6
+}
+
+func ipamConfs(subnet, gateway *string, pools []string) []*libnetwork.IpamConf {
+	if subnet == nil {
+		return nil
+	}
+
+	confs := make([]*libnetwork.IpamConf, len(pools))
+	for j, i := range pools {
 		conf := &libnetwork.IpamConf{
-			PreferredPool: *n.cfg.Subnet,
+			PreferredPool: *subnet,
 			Gateway:       "",
 		}
 
-		if i != *n.cfg.Subnet {
+		if i != *subnet {
 			conf.SubPool = i
 		}
 
-		if n.cfg.Gateway != nil {
-			conf.Gateway = *n.cfg.Gateway
+		if gateway != nil {
+			conf.Gateway = *gateway
 		}
 
 		confs[j] = conf
 	}
 
-	return "", make(map[string]string), confs, nil
+	return confs
 }
 
 func (n *network) IpamInfo() ([]*libnetwork.IpamInfo, []*libnetwork.IpamInfo) {
 	n.Lock()
 	defer n.Unlock()
 
+	infos := ipamInfos(n.cfg.Gateway, n.cfg.IPAM)
+	infos6 := ipamInfos(n.cfg.Gateway6, n.cfg.Ipam6)
+
+	if isIPv6(n.cfg.Subnet) {
+		infos, infos6 = nil, infos
+	}
+
+	return infos, infos6
+}
+
+func ipamInfos(gateway *string, pools []string) []*libnetwork.IpamInfo {
 	var infos []*libnetwork.IpamInfo
-	for _, i := range n.cfg.IPAM {
+	for _, i := range pools {
 		_, pool, err := net.ParseCIDR(i)
 		if err != nil {
 			continue
@@ -420,15 +560,26 @@ func (n *network) IpamInfo() ([]*libnetwork.IpamInfo, []*libnetwork.IpamInfo) {
 		}
 
 		info.Pool = pool
-		if n.cfg.Gateway != nil {
-			info.Gateway = &net.IPNet{IP: net.ParseIP(*n.cfg.Gateway), Mask: net.CIDRMask(32, 32)}
+		if gateway != nil {
+			bits := len(pool.Mask) * 8
+			info.Gateway = &net.IPNet{IP: net.ParseIP(*gateway), Mask: net.CIDRMask(bits, bits)}
 		}
 
 		info.AuxAddresses = make(map[string]*net.IPNet)
 		infos = append(infos, info)
 	}
 
-	return infos, nil
+	return infos
+}
+
+// isIPv6 returns true if the subnet is an IPv6 CIDR
+func isIPv6(subnet *string) bool {
+	if subnet == nil {
+		return false
+	}
+
+	ip, _, err := net.ParseCIDR(*subnet)
+	return err == nil && ip.To4() == nil
 }
 
 func (n *network) DriverOptions() map[string]string {
@@ -440,7 +591,10 @@ func (n *network) Scope() string {
 }
 
 func (n *network) IPv6Enabled() bool {
-	return false
+	n.Lock()
+	defer n.Unlock()
+
+	return (n.cfg.IPV6 != nil && *n.cfg.IPV6) || isIPv6(n.cfg.Subnet)
 }
 
 func (n *network) Internal() bool {
@@ -0,0 +1,331 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backends
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/libnetwork/portallocator"
+	"github.com/vishvananda/netlink"
+
+	"github.com/vmware/vic/lib/apiservers/engine/backends/kv"
+	"github.com/vmware/vic/lib/apiservers/portlayer/client"
+)
+
+const portBindingsKey = "portbindings"
+
+// hostPort is a host port allocated to a port of a container
+type hostPort struct {
+	// IP is the host address the port is bound on, empty for all addresses
+	IP            string   `json:"ip,omitempty"`
+	Port          int      `json:"port"`
+	ContainerPort nat.Port `json:"containerPort"`
+}
+
+func (h hostPort) hostIP() net.IP {
+	return net.ParseIP(h.IP)
+}
+
+// portAllocations records the host ports mapped for each container. It is persisted in the
+// port layer k/v store so the mappings can be restored when the appliance restarts.
+type portAllocations struct {
+	sync.Mutex
+
+	client     *client.PortLayer
+	containers map[string][]hostPort // containerID:ports
+}
+
+var allocations = &portAllocations{containers: make(map[string][]hostPort)}
+
+// loadPortAllocations rehydrates the port allocations from the port layer k/v store
+func loadPortAllocations(client *client.PortLayer) error {
+	allocations.Lock()
+	defer allocations.Unlock()
+
+	allocations.client = client
+
+	val, err := kv.Get(client, portBindingsKey)
+	if err != nil && err != kv.ErrKeyNotFound {
+		return err
+	}
+	if val != "" {
+		if err = json.Unmarshal([]byte(val), &allocations.containers); err != nil {
+			return fmt.Errorf("Failed to unmarshal port bindings: %s", err)
+		}
+		log.Infof("found port bindings for %d containers", len(allocations.containers))
+	}
+
+	return nil
+}
+
+// save persists the allocations, the caller must hold the lock
+func (a *portAllocations) save() error {
+	if a.client == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(a.containers)
+	if err != nil {
+		return fmt.Errorf("Unable to marshal port bindings: %s", err)
+	}
+
+	return kv.Put(a.client, portBindingsKey, string(b))
+}
+
+// get returns the host ports mapped for the container
+func (a *portAllocations) get(containerID string) []hostPort {
+	a.Lock()
+	defer a.Unlock()
+
+	return a.containers[containerID]
+}
+
+// owner returns the container with a host port of the port binding mapped, the caller must
+// hold the lock
+func (a *portAllocations) owner(port nat.Port, binding nat.PortBinding) string {
+	start, end, err := nat.ParsePortRangeToInt(binding.HostPort)
+	if err != nil || start == 0 {
+		return ""
+	}
+
+	ip := hostBindingIP(binding.HostIP)
+	for id, ports := range a.containers {
+		for _, p := range ports {
+			if p.ContainerPort.Proto() != port.Proto() || p.Port < start || p.Port > end {
+				continue
+			}
+
+			if ip == "" || p.IP == "" || ip == p.IP {
+				return id
+			}
+		}
+	}
+
+	return ""
+}
+
+// prune drops the allocations of the containers for which keep returns false
+func (a *portAllocations) prune(keep func(containerID string) bool) error {
+	a.Lock()
+	defer a.Unlock()
+
+	pruned := false
+	for id := range a.containers {
+		if !keep(id) {
+			delete(a.containers, id)
+			pruned = true
+		}
+	}
+
+	if !pruned {
+		return nil
+	}
+	return a.save()
+}
+
+// hostBindingIP returns the address of a port binding, empty for all addresses
+func hostBindingIP(hostIP string) string {
+	ip := net.ParseIP(hostIP)
+	if ip == nil || ip.IsUnspecified() {
+		return ""
+	}
+	return ip.String()
+}
+
+// allocatePorts allocates the host ports for the port bindings. A binding without a host port
+// is given a free port and one with a range of host ports the first free port in the range.
+func allocatePorts(portMap nat.PortMap) ([]hostPort, error) {
+	pa := portallocator.Get()
+
+	var ports []hostPort
+	for port, bindings := range portMap {
+		for _, b := range bindings {
+			start, end, err := nat.ParsePortRangeToInt(b.HostPort)
+			if err != nil {
+				releasePorts(ports)
+				return nil, err
+			}
+
+			ip := hostBindingIP(b.HostIP)
+			hport, err := pa.RequestPortInRange(net.ParseIP(ip), port.Proto(), start, end)
+			if err != nil {
+				releasePorts(ports)
+				return nil, fmt.Errorf("could not allocate host port for %s: %s", port, err)
+			}
+
+			ports = append(ports, hostPort{IP: ip, Port: hport, ContainerPort: port})
+		}
+	}
+
+	return ports, nil
+}
+
+// reservePorts marks previously allocated host ports as in use
+func reservePorts(ports []hostPort) error {
+	pa := portallocator.Get()
+
+	for i, p := range ports {
+		if _, err := pa.RequestPort(p.hostIP(), p.ContainerPort.Proto(), p.Port); err != nil {
+			releasePorts(ports[:i])
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releasePorts returns host ports to the allocator
+func releasePorts(ports []hostPort) {
+	pa := portallocator.Get()
+
+	for _, p := range ports {
+		pa.ReleasePort(p.hostIP(), p.ContainerPort.Proto(), p.Port)
+	}
+}
+
+// portRange is a range of host ports mapped onto container ports
+type portRange struct {
+	ip            string
+	proto         string
+	start, end    int
+	containerPort int
+}
+
+// type and funcs to provide sorting by address, protocol and port
+type hostPortsByAddr []hostPort
+
+func (r hostPortsByAddr) Len() int      { return len(r) }
+func (r hostPortsByAddr) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r hostPortsByAddr) Less(i, j int) bool {
+	if r[i].IP != r[j].IP {
+		return r[i].IP < r[j].IP
+	}
+	if r[i].ContainerPort.Proto() != r[j].ContainerPort.Proto() {
+		return r[i].ContainerPort.Proto() < r[j].ContainerPort.Proto()
+	}
+	return r[i].Port < r[j].Port
+}
+
+// portRanges coalesces host ports mapped onto the same consecutive container ports into ranges,
+// so a range published with -p is forwarded by a single set of rules
+func portRanges(ports []hostPort) []portRange {
+	sorted := make(hostPortsByAddr, len(ports))
+	copy(sorted, ports)
+	sort.Sort(sorted)
+
+	var ranges []portRange
+	for _, p := range sorted {
+		cport := p.ContainerPort.Int()
+
+		if n := len(ranges); n > 0 {
+			last := &ranges[n-1]
+			if last.ip == p.IP && last.proto == p.ContainerPort.Proto() &&
+				last.start == last.containerPort && p.Port == cport && p.Port == last.end+1 {
+				last.end = p.Port
+				continue
+			}
+		}
+
+		ranges = append(ranges, portRange{
+			ip:            p.IP,
+			proto:         p.ContainerPort.Proto(),
+			start:         p.Port,
+			end:           p.Port,
+			containerPort: cport,
+		})
+	}
+
+	return ranges
+}
+
+// hostIPInterface returns the name of the appliance interface that the host address of a port
+// binding is on. Bindings on all addresses are on the external interface.
+func hostIPInterface(hostIP string) (string, error) {
+	if hostBindingIP(hostIP) == "" {
+		return externalIfaceName, nil
+	}
+
+	ip := net.ParseIP(hostIP)
+	if ip.To4() == nil {
+		return "", fmt.Errorf("IPv6 host IP %s is not supported for port bindings", hostIP)
+	}
+
+	links, err := netlink.LinkList()
+	if err != nil {
+		return "", err
+	}
+
+	for _, l := range links {
+		name := l.Attrs().Name
+		if name == bridgeIfaceName || l.Attrs().Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		addrs, err := netlink.AddrList(l, netlink.FAMILY_V4)
+		if err != nil {
+			return "", err
+		}
+
+		for _, addr := range addrs {
+			if addr.IP.Equal(ip) {
+				return name, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("host IP %s for port bindings is not an address of the virtual container host", hostIP)
+}
+
+// portBindingsOf returns the port bindings of the host ports mapped for a container
+func portBindingsOf(ports []hostPort) nat.PortMap {
+	portMap := make(nat.PortMap)
+	for _, p := range ports {
+		ip := p.IP
+		if ip == "" {
+			ip = "0.0.0.0"
+		}
+		portMap[p.ContainerPort] = append(portMap[p.ContainerPort], nat.PortBinding{HostIP: ip, HostPort: strconv.Itoa(p.Port)})
+	}
+
+	return portMap
+}
+
+// mapPortRange forwards a range of host ports onto the container
+func mapPortRange(r portRange, containerIP net.IP) error {
+	iface, err := hostIPInterface(r.ip)
+	if err != nil {
+		return err
+	}
+
+	if r.start == r.end {
+		return portMapper.MapPort(net.ParseIP(r.ip), r.start, r.proto, containerIP.String(), r.containerPort, iface, bridgeIfaceName)
+	}
+	return portMapper.MapPortRange(net.ParseIP(r.ip), r.start, r.end, r.proto, containerIP.String(), iface, bridgeIfaceName)
+}
+
+// unmapPortRange removes the forwarding of a range of host ports
+func unmapPortRange(r portRange) error {
+	if r.start == r.end {
+		return portMapper.UnmapPort(net.ParseIP(r.ip), r.start, r.proto, r.containerPort, externalIfaceName, bridgeIfaceName)
+	}
+	return portMapper.UnmapPortRange(net.ParseIP(r.ip), r.start, r.end, r.proto, externalIfaceName, bridgeIfaceName)
+}
@@ -34,11 +34,18 @@ const (
 type PortMapper interface {
 	MapPort(ip net.IP, port int, proto string, destIP string, destPort int, srcIface, destIface string) error
 	UnmapPort(ip net.IP, port int, proto string, destPort int, srcIface, destIface string) error
+
+	// MapPortRange maps the host ports from start to end onto the same ports of destIP
+	MapPortRange(ip net.IP, start, end int, proto string, destIP string, srcIface, destIface string) error
+	UnmapPortRange(ip net.IP, start, end int, proto string, srcIface, destIface string) error
 }
 
+// bindKey identifies the host ports of a mapping. An empty ip is all addresses.
 type bindKey struct {
-	ip   string
-	port int
+	ip    string
+	proto string
+	start int
+	end   int
 }
 
 type portMapper struct {
@@ -51,28 +58,50 @@ func NewPortMapper() PortMapper {
 	return &portMapper{bindings: make(map[bindKey][][]string)}
 }
 
-func (p *portMapper) isPortAvailable(proto string, ip net.IP, port int) bool {
+func newBindKey(ip net.IP, proto string, start, end int) bindKey {
 	addr := ""
 	if ip != nil && !ip.IsUnspecified() {
 		addr = ip.String()
 	}
 
-	if _, ok := p.bindings[bindKey{addr, port}]; ok {
+	return bindKey{ip: addr, proto: proto, start: start, end: end}
+}
+
+// isBound checks whether any of the ports of key are already mapped, on the same address
+// or on all addresses
+func (p *portMapper) isBound(key bindKey) bool {
+	for k := range p.bindings {
+		if k.proto != key.proto || k.end < key.start || k.start > key.end {
+			continue
+		}
+
+		if k.ip == key.ip || k.ip == "" || key.ip == "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *portMapper) isPortAvailable(key bindKey) bool {
+	if p.isBound(key) {
 		return false
 	}
 
-	c, err := net.Dial(proto, net.JoinHostPort(addr, strconv.Itoa(port)))
-	defer func() {
-		if c != nil {
+	// udp is connectionless so only tcp ports in use on the host can be detected
+	if key.proto != "tcp" {
+		return true
+	}
+
+	for port := key.start; port <= key.end; port++ {
+		c, err := net.Dial(key.proto, net.JoinHostPort(key.ip, strconv.Itoa(port)))
+		if err == nil {
 			c.Close()
+			return false
 		}
-	}()
-
-	if err != nil {
-		return true
 	}
 
-	return false
+	return true
 }
 
 func (p *portMapper) MapPort(ip net.IP, port int, proto string, destIP string, destPort int, srcIface, destIface string) error {
@@ -80,7 +109,8 @@ func (p *portMapper) MapPort(ip net.IP, port int, proto string, destIP string, d
 	defer p.Unlock()
 
 	// check if port is available
-	if !p.isPortAvailable(proto, ip, port) {
+	key := newBindKey(ip, proto, port, port)
+	if !p.isPortAvailable(key) {
 		return fmt.Errorf("port %d is not available", port)
 	}
 
@@ -93,7 +123,7 @@ func (p *portMapper) MapPort(ip net.IP, port int, proto string, destIP string, d
 		destPort = port
 	}
 
-	return p.forward(iptables.Append, ip, port, proto, destIP, destPort, srcIface, destIface)
+	return p.forward(iptables.Append, key, destIP, destPort, srcIface, destIface)
 }
 
 func (p *portMapper) UnmapPort(ip net.IP, port int, proto string, destPort int, srcIface, destIface string) error {
@@ -109,7 +139,34 @@ func (p *portMapper) UnmapPort(ip net.IP, port int, proto string, destPort int,
 		destPort = port
 	}
 
-	return p.forward(iptables.Delete, ip, port, proto, "", destPort, srcIface, destIface)
+	return p.forward(iptables.Delete, newBindKey(ip, proto, port, port), "", destPort, srcIface, destIface)
+}
+
+func (p *portMapper) MapPortRange(ip net.IP, start, end int, proto string, destIP string, srcIface, destIface string) error {
+	p.Lock()
+	defer p.Unlock()
+
+	if start <= 0 || end < start {
+		return fmt.Errorf("invalid source port range %d-%d", start, end)
+	}
+
+	key := newBindKey(ip, proto, start, end)
+	if !p.isPortAvailable(key) {
+		return fmt.Errorf("ports %d-%d are not available", start, end)
+	}
+
+	return p.forward(iptables.Append, key, destIP, start, srcIface, destIface)
+}
+
+func (p *portMapper) UnmapPortRange(ip net.IP, start, end int, proto string, srcIface, destIface string) error {
+	p.Lock()
+	defer p.Unlock()
+
+	if start <= 0 || end < start {
+		return fmt.Errorf("invalid source port range %d-%d", start, end)
+	}
+
+	return p.forward(iptables.Delete, newBindKey(ip, proto, start, end), "", start, srcIface, destIface)
 }
 
 // iptablesRunAndCheck runs an iptables command with the provided args
@@ -139,42 +196,49 @@ func iptablesDelete(args [][]string) error {
 }
 
 // adapted from https://github.com/docker/libnetwork/blob/master/iptables/iptables.go
-func (p *portMapper) forward(action iptables.Action, ip net.IP, port int, proto, destAddr string, destPort int, srcIface, destIface string) error {
-	daddr := ip.String()
-	if ip == nil || ip.IsUnspecified() {
+//
+// A range of ports is forwarded onto the same number of ports starting at destPort.
+func (p *portMapper) forward(action iptables.Action, key bindKey, destAddr string, destPort int, srcIface, destIface string) error {
+	daddr := key.ip
+	if daddr == "" {
 		// iptables interprets "0.0.0.0" as "0.0.0.0/32", whereas we
 		// want "0.0.0.0/0". "0/0" is correctly interpreted as "any
 		// value" by both iptables and ip6tables.
 		daddr = "0/0"
 	}
-	ipStr := ""
-	if ip != nil && !ip.IsUnspecified() {
-		ipStr = ip.String()
+
+	// iptables takes port ranges as first:last, and a DNAT without a port leaves the
+	// destination port unchanged
+	dport := strconv.Itoa(key.start)
+	toDest := net.JoinHostPort(destAddr, strconv.Itoa(destPort))
+	toDport := strconv.Itoa(destPort)
+	if key.end != key.start {
+		dport = fmt.Sprintf("%d:%d", key.start, key.end)
+		toDest = destAddr
+		toDport = fmt.Sprintf("%d:%d", destPort, destPort+key.end-key.start)
 	}
 
-	key := bindKey{ip: ipStr, port: port}
 	switch action {
 	case iptables.Delete:
-		//val := p.bindings[key] // lookup commands to reverse
 		if args, ok := p.bindings[key]; ok {
 			if err := iptablesDelete(args); err != nil {
 				return err
 			}
-			delete(p.bindings, bindKey{ipStr, port})
+			delete(p.bindings, key)
 			return nil
 		}
-		return fmt.Errorf("Failed to find unmap data for %s:%d", ipStr, port)
+		return fmt.Errorf("Failed to find unmap data for %s:%s", key.ip, dport)
 
 	case iptables.Append:
 		var savedArgs [][]string
 
 		args := []string{"VIC", "-t", string(iptables.Nat),
 			"-i", srcIface,
-			"-p", proto,
+			"-p", key.proto,
 			"-d", daddr,
-			"--dport", strconv.Itoa(port),
+			"--dport", dport,
 			"-j", "DNAT",
-			"--to-destination", net.JoinHostPort(destAddr, strconv.Itoa(destPort))}
+			"--to-destination", toDest}
 		if err := iptablesRunAndCheck(action, args); err != nil {
 			return err
 		}
@@ -184,9 +248,9 @@ func (p *portMapper) forward(action iptables.Action, ip net.IP, port int, proto,
 		args = []string{"VIC", "-t", string(iptables.Filter),
 			"-i", srcIface,
 			"-o", destIface,
-			"-p", proto,
+			"-p", key.proto,
 			"-d", destAddr,
-			"--dport", strconv.Itoa(destPort),
+			"--dport", toDport,
 			"-j", "ACCEPT"}
 		if err := iptablesRunAndCheck(action, args); err != nil {
 			return err
@@ -195,9 +259,9 @@ func (p *portMapper) forward(action iptables.Action, ip net.IP, port int, proto,
 		p.bindings[key] = savedArgs
 
 		args = []string{"POSTROUTING", "-t", string(iptables.Nat),
-			"-p", proto,
+			"-p", key.proto,
 			"-d", destAddr,
-			"--dport", strconv.Itoa(destPort),
+			"--dport", toDport,
 			"-j", "MASQUERADE"}
 		if err := iptablesRunAndCheck(action, args); err != nil {
 			return err
@@ -44,9 +44,10 @@ import (
 	"github.com/vmware/vic/pkg/trace"
 	"github.com/vmware/vic/pkg/version"
 
+	"github.com/docker/docker/daemon/events"
 	"github.com/docker/docker/pkg/platform"
 	"github.com/docker/engine-api/types"
-	"github.com/docker/engine-api/types/events"
+	eventtypes "github.com/docker/engine-api/types/events"
 	"github.com/docker/engine-api/types/filters"
 	"github.com/docker/go-units"
 )
@@ -56,13 +57,15 @@ type System struct {
 }
 
 const (
-	systemStatusMhz    = " VCH mhz limit"
-	systemStatusMemory = " VCH memory limit"
-	systemOS           = " VMware OS"
-	systemOSVersion    = " VMware OS version"
-	systemProductName  = " VMware Product"
-	volumeStoresID     = "VolumeStores"
-	loginTimeout       = 20 * time.Second
+	systemStatusMhz     = " VCH mhz limit"
+	systemStatusMemory  = " VCH memory limit"
+	systemOS            = " VMware OS"
+	systemOSVersion     = " VMware OS version"
+	systemProductName   = " VMware Product"
+	volumeStoresID      = "VolumeStores"
+	registryWhitelistID = "Registry Whitelist"
+	registryBlacklistID = "Registry Blacklist"
+	loginTimeout        = 20 * time.Second
 )
 
 func NewSystemBackend() *System {
@@ -101,9 +104,7 @@ func (s *System) SystemInfo() (*types.Info, error) {
 		DockerRootDir:      "",
 		ClusterStore:       "",
 		ClusterAdvertise:   "",
-
-		// FIXME: Get this info once we have event listening service
-		//	NEventsListener    int
+		NEventsListener:    eventService.SubscribersCount(),
 
 		// These are system related.  Some refer to cgroup info.  Others are
 		// retrieved from the port layer and are information about the resource
@@ -146,6 +147,15 @@ func (s *System) SystemInfo() (*types.Info, error) {
 		info.SystemStatus = append(info.SystemStatus, customInfo)
 	}
 
+	if whitelist := RegistryWhitelist(); len(whitelist) > 0 {
+		customInfo := [2]string{registryWhitelistID, strings.Join(whitelist, " ")}
+		info.SystemStatus = append(info.SystemStatus, customInfo)
+	}
+	if blacklist := RegistryBlacklist(); len(blacklist) > 0 {
+		customInfo := [2]string{registryBlacklistID, strings.Join(blacklist, " ")}
+		info.SystemStatus = append(info.SystemStatus, customInfo)
+	}
+
 	if s.systemProxy.PingPortlayer() {
 		status := [2]string{PortLayerName(), "RUNNING"}
 		info.SystemStatus = append(info.SystemStatus, status)
@@ -235,12 +245,20 @@ func (s *System) SystemVersion() types.Version {
 	return version
 }
 
-func (s *System) SubscribeToEvents(since, sinceNano int64, ef filters.Args) ([]events.Message, chan interface{}) {
-	return make([]events.Message, 0, 0), make(chan interface{})
+// SubscribeToEvents returns the buffered events since the given time that match the filter,
+// along with a channel on which the matching events that follow are delivered
+func (s *System) SubscribeToEvents(since, sinceNano int64, filter filters.Args) ([]eventtypes.Message, chan interface{}) {
+	defer trace.End(trace.Begin(""))
+
+	ef := events.NewFilter(filter)
+	return eventService.SubscribeTopic(since, sinceNano, ef)
 }
 
-func (s *System) UnsubscribeFromEvents(chan interface{}) {
+// UnsubscribeFromEvents stops the delivery of events to the listener
+func (s *System) UnsubscribeFromEvents(listener chan interface{}) {
+	defer trace.End(trace.Begin(""))
 
+	eventService.Evict(listener)
 }
 
 func (s *System) AuthenticateToRegistry(ctx context.Context, authConfig *types.AuthConfig) (string, string, error) {
@@ -265,6 +283,10 @@ func (s *System) AuthenticateToRegistry(ctx context.Context, authConfig *types.A
 		return msg, "", err
 	}
 
+	if err := CheckRegistryAccess(registryURL.Host); err != nil {
+		return "", "", err
+	}
+
 	// Check if requested registry is in our list of allowed insecure registries
 	var insecureOk bool
 	insecureRegistries := InsecureRegistries()
@@ -293,7 +315,7 @@ func (s *System) AuthenticateToRegistry(ctx context.Context, authConfig *types.A
 		log.Debugf("logging onto %s", authURL.String())
 
 		// Just check if we get a token back.
-		token, err := fetcher.FetchAuthToken(authURL)
+		token, err := fetcher.FetchAuthToken(ctx, authURL)
 		if err != nil || token.Token == "" {
 			log.Errorf("Fetch auth token failed: %s", err)
 			return "", err
@@ -32,13 +32,22 @@ package backends
 //		- DO USE the aliased docker error package 'derr'
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 
+	"golang.org/x/net/context"
+
 	log "github.com/Sirupsen/logrus"
 	derr "github.com/docker/docker/errors"
+	"github.com/go-swagger/go-swagger/httpkit"
+	httptransport "github.com/go-swagger/go-swagger/httpkit/client"
+	"github.com/mreiferson/go-httpclient"
 
+	"github.com/vmware/vic/lib/apiservers/portlayer/client"
 	"github.com/vmware/vic/lib/apiservers/portlayer/client/containers"
+	"github.com/vmware/vic/lib/apiservers/portlayer/client/events"
 	"github.com/vmware/vic/lib/apiservers/portlayer/client/misc"
 	"github.com/vmware/vic/lib/apiservers/portlayer/models"
 	"github.com/vmware/vic/pkg/trace"
@@ -48,6 +57,7 @@ type VicSystemProxy interface {
 	PingPortlayer() bool
 	ContainerCount() (int, int, int, error)
 	VCHInfo() (*models.VCHInfo, error)
+	StreamEvents(ctx context.Context, out chan<- *models.Event) error
 }
 
 type SystemProxy struct{}
@@ -96,6 +106,8 @@ func (s *SystemProxy) ContainerCount() (int, int, int, error) {
 	for _, t := range containList.Payload {
 		if *t.ContainerConfig.State == "Running" {
 			running++
+		} else if isPaused(*t.ContainerConfig.State) {
+			paused++
 		} else if *t.ContainerConfig.State == "Stopped" || *t.ContainerConfig.State == "Created" {
 			stopped++
 		}
@@ -124,3 +136,63 @@ func (s *SystemProxy) VCHInfo() (*models.VCHInfo, error) {
 
 	return resp.Payload, nil
 }
+
+// StreamEvents reads the event stream from the portlayer rest server and sends each event to
+// out. It returns when ctx is cancelled or the stream ends.
+func (s *SystemProxy) StreamEvents(ctx context.Context, out chan<- *models.Event) error {
+	defer trace.End(trace.Begin(""))
+
+	// the stream is long lived so only the connection and response header are timed
+	runtime := httptransport.New(PortLayerServer(), "/", []string{"http"})
+	transport := &httpclient.Transport{
+		ConnectTimeout:        attachConnectTimeout,
+		ResponseHeaderTimeout: attachAttemptTimeout,
+	}
+	defer transport.Close()
+	runtime.Transport = transport
+	runtime.Consumers["application/octet-stream"] = httpkit.ByteStreamConsumer()
+	plClient := client.New(runtime, nil)
+
+	params := events.NewGetEventsParamsWithContext(ctx)
+
+	reader, writer := io.Pipe()
+	defer reader.Close()
+
+	go func() {
+		_, err := plClient.Events.GetEvents(params, writer)
+		if err != nil {
+			switch err := err.(type) {
+			case *events.GetEventsInternalServerError:
+				writer.CloseWithError(InternalServerError(err.Payload.Message))
+			default:
+				writer.CloseWithError(InternalServerError(err.Error()))
+			}
+			return
+		}
+
+		writer.Close()
+	}()
+
+	decoder := json.NewDecoder(reader)
+	for {
+		event := &models.Event{}
+		if err := decoder.Decode(event); err != nil {
+			if err == io.EOF || ctx.Err() != nil {
+				return nil
+			}
+
+			switch err.(type) {
+			case *json.SyntaxError, *json.UnmarshalTypeError:
+				return InternalServerError(fmt.Sprintf("Unable to decode port layer event: %s", err))
+			default:
+				return err
+			}
+		}
+
+		select {
+		case out <- event:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
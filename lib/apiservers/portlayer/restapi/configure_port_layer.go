@@ -51,6 +51,8 @@ var portlayerhandlers = []handler{
 	&handlers.InteractionHandlersImpl{},
 	&handlers.LoggingHandlersImpl{},
 	&handlers.KvHandlersImpl{},
+	&handlers.TaskHandlersImpl{},
+	&handlers.EventsHandlersImpl{},
 }
 
 func configureFlags(api *operations.PortLayerAPI) {
@@ -105,6 +107,8 @@ func configureAPI(api *operations.PortLayerAPI) http.Handler {
 
 	api.BinConsumer = httpkit.ByteStreamConsumer()
 
+	api.BinProducer = httpkit.ByteStreamProducer()
+
 	api.JSONConsumer = httpkit.JSONConsumer()
 
 	api.JSONProducer = httpkit.JSONProducer()
@@ -18,32 +18,43 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"time"
 
+	"github.com/go-swagger/go-swagger/httpkit"
 	middleware "github.com/go-swagger/go-swagger/httpkit/middleware"
+	"github.com/go-swagger/go-swagger/strfmt"
+	"github.com/go-swagger/go-swagger/swag"
 	"golang.org/x/net/context"
 
 	log "github.com/Sirupsen/logrus"
 
 	"net/http"
 
+	"github.com/vmware/govmomi/vim25/types"
 	"github.com/vmware/vic/lib/apiservers/portlayer/models"
 	"github.com/vmware/vic/lib/apiservers/portlayer/restapi/operations"
 	"github.com/vmware/vic/lib/apiservers/portlayer/restapi/operations/containers"
 	"github.com/vmware/vic/lib/config/executor"
 	"github.com/vmware/vic/lib/portlayer/exec"
+	"github.com/vmware/vic/lib/portlayer/metrics"
+	"github.com/vmware/vic/lib/portlayer/network"
+	"github.com/vmware/vic/pkg/log/jsonlog"
 	"github.com/vmware/vic/pkg/trace"
 	"github.com/vmware/vic/pkg/uid"
 	"github.com/vmware/vic/pkg/version"
 )
 
-const containerWaitTimeout = 3 * time.Minute
+const (
+	containerWaitTimeout = 3 * time.Minute
+)
 
 // ContainersHandlersImpl is the receiver for all of the exec handler methods
 type ContainersHandlersImpl struct {
 	handlerCtx *HandlerContext
+	collector  *metrics.Collector
 }
 
 // Configure assigns functions to all the exec api handlers
@@ -57,10 +68,16 @@ func (handler *ContainersHandlersImpl) Configure(api *operations.PortLayerAPI, h
 	api.ContainersGetContainerInfoHandler = containers.GetContainerInfoHandlerFunc(handler.GetContainerInfoHandler)
 	api.ContainersGetContainerListHandler = containers.GetContainerListHandlerFunc(handler.GetContainerListHandler)
 	api.ContainersContainerSignalHandler = containers.ContainerSignalHandlerFunc(handler.ContainerSignalHandler)
+	api.ContainersContainerUpdateHandler = containers.ContainerUpdateHandlerFunc(handler.ContainerUpdateHandler)
+	api.ContainersContainerRenameHandler = containers.ContainerRenameHandlerFunc(handler.ContainerRenameHandler)
+	api.ContainersContainerPauseHandler = containers.ContainerPauseHandlerFunc(handler.ContainerPauseHandler)
+	api.ContainersContainerUnpauseHandler = containers.ContainerUnpauseHandlerFunc(handler.ContainerUnpauseHandler)
 	api.ContainersGetContainerLogsHandler = containers.GetContainerLogsHandlerFunc(handler.GetContainerLogsHandler)
+	api.ContainersGetContainerStatsHandler = containers.GetContainerStatsHandlerFunc(handler.GetContainerStatsHandler)
 	api.ContainersContainerWaitHandler = containers.ContainerWaitHandlerFunc(handler.ContainerWaitHandler)
 
 	handler.handlerCtx = handlerCtx
+	handler.collector = metrics.NewCollector(handlerCtx.Session)
 }
 
 // CreateHandler creates a new container
@@ -77,6 +94,7 @@ func (handler *ContainersHandlersImpl) CreateHandler(params containers.CreatePar
 	log.Debugf("Args: %#v", params.CreateConfig.Args)
 	log.Debugf("Env: %#v", params.CreateConfig.Env)
 	log.Debugf("WorkingDir: %#v", params.CreateConfig.WorkingDir)
+	log.Debugf("User: %#v", params.CreateConfig.User)
 	id := uid.New().String()
 
 	// Init key for tether
@@ -119,6 +137,28 @@ func (handler *ContainersHandlersImpl) CreateHandler(params containers.CreatePar
 		RepoName: *params.CreateConfig.RepoName,
 	}
 
+	if params.CreateConfig.User != nil {
+		m.Sessions[id].User = *params.CreateConfig.User
+	}
+
+	if rp := params.CreateConfig.RestartPolicy; rp != nil {
+		m.RestartPolicy = convertRestartPolicy(rp)
+	}
+
+	if hc := params.CreateConfig.Healthcheck; hc != nil && len(hc.Test) > 0 {
+		healthcheck := &m.Sessions[id].Healthcheck
+		healthcheck.Test = hc.Test
+		if hc.Interval != nil {
+			healthcheck.Interval = time.Duration(*hc.Interval)
+		}
+		if hc.Timeout != nil {
+			healthcheck.Timeout = time.Duration(*hc.Timeout)
+		}
+		if hc.Retries != nil {
+			healthcheck.Retries = int(*hc.Retries)
+		}
+	}
+
 	if params.CreateConfig.Annotations != nil && len(params.CreateConfig.Annotations) > 0 {
 		m.Annotations = make(map[string]string)
 		for k, v := range params.CreateConfig.Annotations {
@@ -170,6 +210,44 @@ func (handler *ContainersHandlersImpl) StateChangeHandler(params containers.Stat
 	return containers.NewStateChangeOK().WithPayload(h.String())
 }
 
+// ContainerUpdateHandler changes the resources and restart policy of the container on the handle
+func (handler *ContainersHandlersImpl) ContainerUpdateHandler(params containers.ContainerUpdateParams) middleware.Responder {
+	defer trace.End(trace.Begin(fmt.Sprintf("handle(%s)", params.Handle)))
+
+	h := exec.GetHandle(params.Handle)
+	if h == nil {
+		return containers.NewContainerUpdateNotFound().WithPayload(&models.Error{Message: "container not found"})
+	}
+
+	if r := params.Config.Resources; r != nil {
+		if err := h.SetResources(convertResources(r)); err != nil {
+			if _, ok := err.(exec.InvalidStateError); ok {
+				return containers.NewContainerUpdateConflict().WithPayload(&models.Error{Message: err.Error()})
+			}
+			return containers.NewContainerUpdateDefault(http.StatusInternalServerError).WithPayload(&models.Error{Message: err.Error()})
+		}
+	}
+
+	if rp := params.Config.RestartPolicy; rp != nil {
+		h.SetRestartPolicy(convertRestartPolicy(rp))
+	}
+
+	return containers.NewContainerUpdateOK().WithPayload(h.String())
+}
+
+// ContainerRenameHandler changes the name of the container on the handle
+func (handler *ContainersHandlersImpl) ContainerRenameHandler(params containers.ContainerRenameParams) middleware.Responder {
+	defer trace.End(trace.Begin(fmt.Sprintf("handle(%s)", params.Handle)))
+
+	h := exec.GetHandle(params.Handle)
+	if h == nil {
+		return containers.NewContainerRenameNotFound().WithPayload(&models.Error{Message: "container not found"})
+	}
+
+	h.Rename(params.Name)
+	return containers.NewContainerRenameOK().WithPayload(h.String())
+}
+
 func (handler *ContainersHandlersImpl) GetStateHandler(params containers.GetStateParams) middleware.Responder {
 	defer trace.End(trace.Begin(fmt.Sprintf("handle(%s)", params.Handle)))
 
@@ -196,6 +274,9 @@ func (handler *ContainersHandlersImpl) GetStateHandler(params containers.GetStat
 	case exec.StateCreated:
 		state = "CREATED"
 
+	case exec.StatePaused, exec.StateSuspended:
+		state = "PAUSED"
+
 	default:
 		return containers.NewGetStateDefault(http.StatusServiceUnavailable)
 	}
@@ -222,6 +303,27 @@ func (handler *ContainersHandlersImpl) CommitHandler(params containers.CommitPar
 		return containers.NewCommitNotFound().WithPayload(&models.Error{Message: "container not found"})
 	}
 
+	var committed bool
+
+	// a rename takes effect in the scopes as part of the commit, so that a name that is already
+	// resolvable fails the commit and a failed commit restores the old name
+	if c := exec.Containers.Container(h.ExecConfig.ID); c != nil && h.Renamed() && network.DefaultContext != nil {
+		id := uid.Parse(h.ExecConfig.ID)
+		old := c.Info().ExecConfig.Name
+		if err := network.DefaultContext.RenameContainer(id, h.ExecConfig.Name); err != nil {
+			log.Errorf("CommitHandler error on handle(%s) for %s: %s", h.String(), h.ExecConfig.ID, err)
+			return containers.NewCommitConflict().WithPayload(&models.Error{Message: err.Error()})
+		}
+
+		defer func() {
+			if !committed {
+				if err := network.DefaultContext.RenameContainer(id, old); err != nil {
+					log.Errorf("Unable to restore name %s of container %s in scopes: %s", old, h.ExecConfig.ID, err)
+				}
+			}
+		}()
+	}
+
 	if err := h.Commit(context.Background(), handler.handlerCtx.Session, params.WaitTime); err != nil {
 		log.Errorf("CommitHandler error on handle(%s) for %s: %#v", h.String(), h.ExecConfig.ID, err)
 		switch err := err.(type) {
@@ -231,6 +333,7 @@ func (handler *ContainersHandlersImpl) CommitHandler(params containers.CommitPar
 			return containers.NewCommitDefault(http.StatusServiceUnavailable).WithPayload(&models.Error{Message: err.Error()})
 		}
 	}
+	committed = true
 
 	return containers.NewCommitOK()
 }
@@ -283,16 +386,17 @@ func (handler *ContainersHandlersImpl) GetContainerInfoHandler(params containers
 func (handler *ContainersHandlersImpl) GetContainerListHandler(params containers.GetContainerListParams) middleware.Responder {
 	defer trace.End(trace.Begin(""))
 
-	var state *exec.State
-	if params.All != nil && !*params.All {
-		state = new(exec.State)
-		*state = exec.StateRunning
-	}
+	all := params.All == nil || *params.All
 
-	containerVMs := exec.Containers.Containers(state)
+	containerVMs := exec.Containers.Containers(nil)
 	containerList := make([]*models.ContainerInfo, 0, len(containerVMs))
 
 	for _, container := range containerVMs {
+		// paused containers are listed along with the running ones, as docker does
+		if !all && !container.CurrentState().Running() {
+			continue
+		}
+
 		// convert to return model
 		info := convertContainerToContainerInfo(container.Info())
 		containerList = append(containerList, info)
@@ -321,6 +425,44 @@ func (handler *ContainersHandlersImpl) ContainerSignalHandler(params containers.
 	return containers.NewContainerSignalOK()
 }
 
+// ContainerPauseHandler pauses a running container
+func (handler *ContainersHandlersImpl) ContainerPauseHandler(params containers.ContainerPauseParams) middleware.Responder {
+	defer trace.End(trace.Begin(params.ID))
+
+	container := exec.Containers.Container(params.ID)
+	if container == nil {
+		return containers.NewContainerPauseNotFound().WithPayload(&models.Error{Message: fmt.Sprintf("container %s not found", params.ID)})
+	}
+
+	if err := container.Pause(context.Background()); err != nil {
+		if _, ok := err.(exec.InvalidStateError); ok {
+			return containers.NewContainerPauseConflict().WithPayload(&models.Error{Message: err.Error()})
+		}
+		return containers.NewContainerPauseInternalServerError().WithPayload(&models.Error{Message: err.Error()})
+	}
+
+	return containers.NewContainerPauseOK()
+}
+
+// ContainerUnpauseHandler unpauses a paused container
+func (handler *ContainersHandlersImpl) ContainerUnpauseHandler(params containers.ContainerUnpauseParams) middleware.Responder {
+	defer trace.End(trace.Begin(params.ID))
+
+	container := exec.Containers.Container(params.ID)
+	if container == nil {
+		return containers.NewContainerUnpauseNotFound().WithPayload(&models.Error{Message: fmt.Sprintf("container %s not found", params.ID)})
+	}
+
+	if err := container.Unpause(context.Background()); err != nil {
+		if _, ok := err.(exec.InvalidStateError); ok {
+			return containers.NewContainerUnpauseConflict().WithPayload(&models.Error{Message: err.Error()})
+		}
+		return containers.NewContainerUnpauseInternalServerError().WithPayload(&models.Error{Message: err.Error()})
+	}
+
+	return containers.NewContainerUnpauseOK()
+}
+
 func (handler *ContainersHandlersImpl) GetContainerLogsHandler(params containers.GetContainerLogsParams) middleware.Responder {
 	defer trace.End(trace.Begin(params.ID))
 
@@ -342,16 +484,57 @@ func (handler *ContainersHandlersImpl) GetContainerLogsHandler(params containers
 		tail = int(*params.Taillines)
 	}
 
-	reader, err := container.LogReader(context.Background(), tail, follow)
+	options := jsonlog.ReaderOptions{
+		Stdout: params.Stdout == nil || *params.Stdout,
+		Stderr: params.Stderr == nil || *params.Stderr,
+	}
+
+	if params.Timestamp != nil {
+		options.Timestamps = *params.Timestamp
+	}
+
+	if params.Since != nil && *params.Since > 0 {
+		options.Since = time.Unix(*params.Since, 0)
+	}
+
+	reader, err := container.LogReader(context.Background(), tail, follow, options)
 	if err != nil {
 		return containers.NewGetContainerLogsInternalServerError().WithPayload(&models.Error{Message: err.Error()})
 	}
 
-	detachableOut := NewFlushingReader(reader)
+	detachableOut := NewFlushingReader(jsonlog.NewReader(reader, options))
 
 	return NewContainerOutputHandler("logs").WithPayload(detachableOut, params.ID)
 }
 
+// GetContainerStatsHandler streams the resource usage of the container
+func (handler *ContainersHandlersImpl) GetContainerStatsHandler(params containers.GetContainerStatsParams) middleware.Responder {
+	defer trace.End(trace.Begin(params.ID))
+
+	container := exec.Containers.Container(params.ID)
+	if container == nil {
+		return containers.NewGetContainerStatsNotFound().WithPayload(&models.Error{
+			Message: fmt.Sprintf("container %s not found", params.ID),
+		})
+	}
+
+	// streams for the same container share the samples from a single poller
+	subscription := handler.collector.Subscribe(container.VMReference())
+
+	// fail early rather than after the stream has been started
+	sample, ok := <-subscription.C
+	if !ok {
+		subscription.Close()
+		return containers.NewGetContainerStatsInternalServerError().WithPayload(&models.Error{Message: subscription.Err().Error()})
+	}
+
+	return &ContainerStatsHandler{
+		subscription: subscription,
+		container:    container,
+		first:        sample,
+	}
+}
+
 func (handler *ContainersHandlersImpl) ContainerWaitHandler(params containers.ContainerWaitParams) middleware.Responder {
 	defer trace.End(trace.Begin(fmt.Sprintf("%s:%d", params.ID, params.Timeout)))
 
@@ -405,6 +588,12 @@ func convertContainerToContainerInfo(container *exec.ContainerInfo) *models.Cont
 	restart := int32(container.ExecConfig.Diagnostics.ResurrectionCount)
 	info.ContainerConfig.RestartCount = &restart
 
+	retries := int32(container.ExecConfig.RestartPolicy.MaximumRetryCount)
+	info.ContainerConfig.RestartPolicy = &models.RestartPolicy{
+		Name:              &container.ExecConfig.RestartPolicy.Name,
+		MaximumRetryCount: &retries,
+	}
+
 	tty := container.ExecConfig.Sessions[ccid].Tty
 	info.ContainerConfig.Tty = &tty
 
@@ -415,6 +604,14 @@ func convertContainerToContainerInfo(container *exec.ContainerInfo) *models.Cont
 
 	info.ContainerConfig.StorageSize = &container.VMUnsharedDisk
 
+	if container.Config != nil {
+		info.ContainerConfig.Resources = convertVMResources(container.Config)
+	}
+
+	if health := container.ExecConfig.Sessions[ccid].Health; health.Status != "" {
+		info.ContainerConfig.Health = convertHealthState(health)
+	}
+
 	if container.ExecConfig.Annotations != nil && len(container.ExecConfig.Annotations) > 0 {
 		info.ContainerConfig.Annotations = make(map[string]string)
 
@@ -429,6 +626,17 @@ func convertContainerToContainerInfo(container *exec.ContainerInfo) *models.Cont
 	dir := container.ExecConfig.Sessions[ccid].Cmd.Dir
 	info.ProcessConfig.WorkingDir = &dir
 
+	user := container.ExecConfig.Sessions[ccid].User
+	info.ProcessConfig.User = &user
+
+	if logging := container.ExecConfig.Sessions[ccid].Logging; logging.Driver != "" {
+		info.ProcessConfig.LogConfig = &models.LogConfig{
+			Driver:      &logging.Driver,
+			Options:     logging.Options,
+			ForwardOnly: &logging.ForwardOnly,
+		}
+	}
+
 	info.ProcessConfig.ExecArgs = container.ExecConfig.Sessions[ccid].Cmd.Args
 	info.ProcessConfig.Env = container.ExecConfig.Sessions[ccid].Cmd.Env
 
@@ -454,3 +662,168 @@ func convertContainerToContainerInfo(container *exec.ContainerInfo) *models.Cont
 	}
 	return info
 }
+
+// ContainerStatsHandler is a custom return handler that writes a ContainerStats object to the
+// client each sampling interval until the client goes away
+type ContainerStatsHandler struct {
+	subscription *metrics.Subscription
+	container    *exec.Container
+	first        *metrics.Sample
+}
+
+// WriteResponse to the client
+func (c *ContainerStatsHandler) WriteResponse(rw http.ResponseWriter, producer httpkit.Producer) {
+	defer c.subscription.Close()
+
+	id := c.container.ExecConfig.ID
+
+	var closed <-chan bool
+	if notifier, ok := rw.(http.CloseNotifier); ok {
+		closed = notifier.CloseNotify()
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	flusher, _ := rw.(http.Flusher)
+	encoder := json.NewEncoder(rw)
+
+	sample := c.first
+	for {
+		if err := encoder.Encode(toModelsContainerStats(sample)); err != nil {
+			log.Debugf("Finished streaming stats for container %s: %s", id, err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		var ok bool
+		select {
+		case sample, ok = <-c.subscription.C:
+			if !ok {
+				log.Errorf("Unable to sample stats for container %s: %s", id, c.subscription.Err())
+				return
+			}
+		case <-closed:
+			log.Debugf("Finished streaming stats for container %s: client went away", id)
+			return
+		}
+	}
+}
+
+func toModelsContainerStats(sample *metrics.Sample) *models.ContainerStats {
+	read := strfmt.DateTime(sample.Read)
+
+	return &models.ContainerStats{
+		Read:              &read,
+		CPUCount:          swag.Int32(sample.CPUCount),
+		CPUUsage:          swag.Int64(sample.CPUUsage),
+		CPULimit:          swag.Int64(sample.CPULimit),
+		MemoryUsage:       swag.Int64(sample.MemoryUsage),
+		MemoryLimit:       swag.Int64(sample.MemoryLimit),
+		NetworkRxBytes:    swag.Int64(sample.NetworkRxBytes),
+		NetworkTxBytes:    swag.Int64(sample.NetworkTxBytes),
+		NetworkRxPackets:  swag.Int64(sample.NetworkRxPackets),
+		NetworkTxPackets:  swag.Int64(sample.NetworkTxPackets),
+		StorageReadBytes:  swag.Int64(sample.StorageReadBytes),
+		StorageWriteBytes: swag.Int64(sample.StorageWriteBytes),
+		StorageReadOps:    swag.Int64(sample.StorageReadOps),
+		StorageWriteOps:   swag.Int64(sample.StorageWriteOps),
+	}
+}
+
+// convertHealthState converts the health state of a session to the return model, with probe
+// times in nanoseconds since the epoch
+func convertHealthState(health executor.HealthState) *models.HealthState {
+	streak := int32(health.FailingStreak)
+	state := &models.HealthState{
+		Status:        &health.Status,
+		FailingStreak: &streak,
+	}
+
+	for i := range health.Log {
+		result := health.Log[i]
+
+		start := result.Start.UnixNano()
+		end := result.End.UnixNano()
+		exitCode := int32(result.ExitCode)
+		state.Log = append(state.Log, &models.HealthResult{
+			Start:    &start,
+			End:      &end,
+			ExitCode: &exitCode,
+			Output:   &result.Output,
+		})
+	}
+
+	return state
+}
+
+// convertRestartPolicy converts the restart policy from the API model
+func convertRestartPolicy(rp *models.RestartPolicy) executor.RestartPolicy {
+	var policy executor.RestartPolicy
+	if rp.Name != nil {
+		policy.Name = *rp.Name
+	}
+	if rp.MaximumRetryCount != nil {
+		policy.MaximumRetryCount = int(*rp.MaximumRetryCount)
+	}
+
+	return policy
+}
+
+// convertResources converts the resources from the API model, leaving the allocations nil
+// unless one of their fields is set
+func convertResources(r *models.ContainerResources) exec.Resources {
+	return exec.Resources{
+		NumCPUs:  swag.Int32Value(r.NumCpus),
+		MemoryMB: swag.Int64Value(r.MemoryMB),
+		CPU:      convertAllocation(r.CPUReservation, r.CPULimit, r.CPUShares),
+		Memory:   convertAllocation(r.MemoryReservation, r.MemoryLimit, r.MemoryShares),
+	}
+}
+
+func convertAllocation(reservation, limit *int64, shares *int32) *types.ResourceAllocationInfo {
+	if reservation == nil && limit == nil && shares == nil {
+		return nil
+	}
+
+	alloc := &types.ResourceAllocationInfo{
+		Reservation: swag.Int64Value(reservation),
+		Limit:       swag.Int64Value(limit),
+	}
+	if shares != nil {
+		alloc.Shares = &types.SharesInfo{
+			Level:  types.SharesLevelCustom,
+			Shares: *shares,
+		}
+	}
+
+	return alloc
+}
+
+// convertVMResources returns the current resources of the containerVM
+func convertVMResources(config *types.VirtualMachineConfigInfo) *models.ContainerResources {
+	res := &models.ContainerResources{
+		NumCpus:  swag.Int32(config.Hardware.NumCPU),
+		MemoryMB: swag.Int64(int64(config.Hardware.MemoryMB)),
+	}
+
+	if config.CpuAllocation != nil {
+		cpu := config.CpuAllocation.GetResourceAllocationInfo()
+		res.CPUReservation = swag.Int64(cpu.Reservation)
+		res.CPULimit = swag.Int64(cpu.Limit)
+		if cpu.Shares != nil {
+			res.CPUShares = swag.Int32(cpu.Shares.Shares)
+		}
+	}
+
+	if config.MemoryAllocation != nil {
+		memory := config.MemoryAllocation.GetResourceAllocationInfo()
+		res.MemoryReservation = swag.Int64(memory.Reservation)
+		res.MemoryLimit = swag.Int64(memory.Limit)
+		if memory.Shares != nil {
+			res.MemoryShares = swag.Int32(memory.Shares.Shares)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,146 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/go-swagger/go-swagger/httpkit"
+	"github.com/go-swagger/go-swagger/httpkit/middleware"
+	"github.com/go-swagger/go-swagger/strfmt"
+	"github.com/go-swagger/go-swagger/swag"
+
+	"github.com/vmware/vic/lib/apiservers/portlayer/models"
+	"github.com/vmware/vic/lib/apiservers/portlayer/restapi/operations"
+	"github.com/vmware/vic/lib/apiservers/portlayer/restapi/operations/events"
+	"github.com/vmware/vic/lib/portlayer/event"
+	plevents "github.com/vmware/vic/lib/portlayer/event/events"
+	"github.com/vmware/vic/lib/portlayer/exec"
+	"github.com/vmware/vic/pkg/trace"
+)
+
+// eventsBufferSize is the number of events held for a client that is slow to read them
+const eventsBufferSize = 64
+
+// eventTypes maps the topics streamed to clients to the type reported in the event
+var eventTypes = map[string]string{
+	plevents.NewEventType(plevents.ContainerEvent{}).Topic(): "container",
+	plevents.NewEventType(plevents.ImageEvent{}).Topic():     "image",
+	plevents.NewEventType(plevents.VolumeEvent{}).Topic():    "volume",
+	plevents.NewEventType(plevents.NetworkEvent{}).Topic():   "network",
+}
+
+// EventsHandlersImpl is the receiver for all of the events handler methods
+type EventsHandlersImpl struct{}
+
+// Configure assigns functions to all the events api handlers
+func (handler *EventsHandlersImpl) Configure(api *operations.PortLayerAPI, handlerCtx *HandlerContext) {
+	api.EventsGetEventsHandler = events.GetEventsHandlerFunc(handler.GetEventsHandler)
+}
+
+// GetEventsHandler streams the port layer events to the client
+func (handler *EventsHandlersImpl) GetEventsHandler() middleware.Responder {
+	defer trace.End(trace.Begin(""))
+
+	if exec.Config.EventManager == nil {
+		return events.NewGetEventsInternalServerError().WithPayload(&models.Error{
+			Code:    swag.Int64(http.StatusInternalServerError),
+			Message: "event manager is not available",
+		})
+	}
+
+	return &EventsHandler{em: exec.Config.EventManager}
+}
+
+// EventsHandler is a custom return handler that writes each event published to the port
+// layer to the client until the client goes away
+type EventsHandler struct {
+	em event.EventManager
+}
+
+// WriteResponse to the client
+func (h *EventsHandler) WriteResponse(rw http.ResponseWriter, producer httpkit.Producer) {
+	ch := make(chan *models.Event, eventsBufferSize)
+
+	// the callbacks hand events to the buffer without waiting, so that a client that is slow to
+	// read does not hold up the other subscribers
+	sub := fmt.Sprintf("events(%p)", h)
+	for topic, kind := range eventTypes {
+		kind := kind
+		h.em.Subscribe(topic, sub, func(e plevents.Event) {
+			select {
+			case ch <- toModelsEvent(kind, e):
+			default:
+				log.Warnf("Dropping %s event %s for slow events client", kind, e.String())
+			}
+		})
+		defer h.em.Unsubscribe(topic, sub)
+	}
+
+	var closed <-chan bool
+	if notifier, ok := rw.(http.CloseNotifier); ok {
+		closed = notifier.CloseNotify()
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	flusher, _ := rw.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	encoder := json.NewEncoder(rw)
+
+	for {
+		select {
+		case e := <-ch:
+			if err := encoder.Encode(e); err != nil {
+				log.Debugf("Finished streaming events: %s", err)
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		case <-closed:
+			log.Debugf("Finished streaming events: client went away")
+			return
+		}
+	}
+}
+
+func toModelsEvent(kind string, e plevents.Event) *models.Event {
+	created := strfmt.DateTime(e.Created())
+
+	m := &models.Event{
+		Type:    kind,
+		Event:   e.String(),
+		ID:      e.Reference(),
+		Detail:  swag.String(e.Message()),
+		Created: &created,
+	}
+
+	if ne, ok := e.(*plevents.NetworkEvent); ok {
+		m.Attributes = map[string]string{
+			"name": ne.Name,
+			"type": ne.ScopeType,
+		}
+		if ne.Container != "" {
+			m.Attributes["container"] = ne.Container
+		}
+	}
+
+	return m
+}
@@ -0,0 +1,63 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware/vic/lib/portlayer/event/events"
+)
+
+func TestToModelsEvent(t *testing.T) {
+	now := time.Now().UTC()
+
+	ce := &events.ContainerEvent{
+		BaseEvent: &events.BaseEvent{
+			Ref:         "abc123",
+			CreatedTime: now,
+			Event:       events.ContainerStarted,
+			Detail:      "Container abc123 Started",
+		},
+	}
+	kind, ok := eventTypes[ce.Topic()]
+	assert.True(t, ok)
+
+	m := toModelsEvent(kind, ce)
+	assert.Equal(t, "container", m.Type)
+	assert.Equal(t, events.ContainerStarted, m.Event)
+	assert.Equal(t, "abc123", m.ID)
+	assert.Equal(t, "Container abc123 Started", *m.Detail)
+	assert.Nil(t, m.Attributes)
+
+	ne := &events.NetworkEvent{
+		BaseEvent: &events.BaseEvent{
+			Ref:         "net1",
+			CreatedTime: now,
+			Event:       events.NetworkConnected,
+		},
+		Name:      "backend",
+		ScopeType: "bridge",
+		Container: "abc123",
+	}
+	kind, ok = eventTypes[ne.Topic()]
+	assert.True(t, ok)
+
+	m = toModelsEvent(kind, ne)
+	assert.Equal(t, "network", m.Type)
+	assert.Equal(t, map[string]string{"name": "backend", "type": "bridge", "container": "abc123"}, m.Attributes)
+}
@@ -19,27 +19,34 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"time"
 
+	"golang.org/x/crypto/ssh"
 	"golang.org/x/net/context"
 
 	log "github.com/Sirupsen/logrus"
 
 	"github.com/go-swagger/go-swagger/httpkit"
 	middleware "github.com/go-swagger/go-swagger/httpkit/middleware"
+	"github.com/go-swagger/go-swagger/swag"
 
+	"github.com/vmware/vic/cmd/tether/msgs"
 	"github.com/vmware/vic/lib/apiservers/portlayer/models"
 	"github.com/vmware/vic/lib/apiservers/portlayer/restapi/operations"
 	"github.com/vmware/vic/lib/apiservers/portlayer/restapi/operations/interaction"
 	"github.com/vmware/vic/lib/portlayer/attach"
 	"github.com/vmware/vic/lib/portlayer/constants"
 	"github.com/vmware/vic/lib/portlayer/exec"
+	"github.com/vmware/vic/lib/portlayer/storage/vsphere"
+	"github.com/vmware/vic/lib/tether/scp"
 	"github.com/vmware/vic/pkg/trace"
 )
 
 // InteractionHandlersImpl is the receiver for all of the interaction handler methods
 type InteractionHandlersImpl struct {
-	attachServer *attach.Server
+	attachServer   *attach.Server
+	containerStore *vsphere.ContainerStore
 }
 
 const (
@@ -47,7 +54,7 @@ const (
 	attachStdinInitString               = "v1c#>"
 )
 
-func (i *InteractionHandlersImpl) Configure(api *operations.PortLayerAPI, _ *HandlerContext) {
+func (i *InteractionHandlersImpl) Configure(api *operations.PortLayerAPI, handlerCtx *HandlerContext) {
 
 	api.InteractionInteractionJoinHandler = interaction.InteractionJoinHandlerFunc(i.JoinHandler)
 	api.InteractionInteractionBindHandler = interaction.InteractionBindHandlerFunc(i.BindHandler)
@@ -60,6 +67,18 @@ func (i *InteractionHandlersImpl) Configure(api *operations.PortLayerAPI, _ *Han
 
 	api.InteractionContainerCloseStdinHandler = interaction.ContainerCloseStdinHandlerFunc(i.ContainerCloseStdinHandler)
 
+	api.InteractionContainerStatPathHandler = interaction.ContainerStatPathHandlerFunc(i.ContainerStatPathHandler)
+	api.InteractionContainerGetArchiveHandler = interaction.ContainerGetArchiveHandlerFunc(i.ContainerGetArchiveHandler)
+	api.InteractionContainerPutArchiveHandler = interaction.ContainerPutArchiveHandlerFunc(i.ContainerPutArchiveHandler)
+
+	api.InteractionContainerTopHandler = interaction.ContainerTopHandlerFunc(i.ContainerTopHandler)
+
+	store, err := vsphere.NewContainerStore(trace.NewOperation(context.Background(), "configure"), handlerCtx.Session)
+	if err != nil {
+		log.Fatalf("Container store unable to start: %s", err)
+	}
+	i.containerStore = store
+
 	i.attachServer = attach.NewAttachServer(constants.ManagementHostName, 0)
 
 	if err := i.attachServer.Start(false); err != nil {
@@ -331,6 +350,343 @@ func (i *InteractionHandlersImpl) ContainerGetStderrHandler(params interaction.C
 	)
 }
 
+// ContainerStatPathHandler returns the details of the filesystem resource at path in the container
+func (i *InteractionHandlersImpl) ContainerStatPathHandler(params interaction.ContainerStatPathParams) middleware.Responder {
+	defer trace.End(trace.Begin(params.ID))
+
+	c := exec.Containers.Container(params.ID)
+	if c == nil {
+		return interaction.NewContainerStatPathNotFound().WithPayload(
+			&models.Error{Message: fmt.Sprintf("container %s not found", params.ID)},
+		)
+	}
+
+	var stat *models.ContainerPathStat
+	var err error
+
+	if c.CurrentState().Running() {
+		var client *ssh.Client
+		var msg *msgs.StatResponseMsg
+
+		client, err = i.attachServer.Client(context.Background(), params.ID, interactionTimeout)
+		if err == nil {
+			msg, err = attach.SSHStat(client, params.Path)
+		}
+		if msg != nil {
+			stat = &models.ContainerPathStat{
+				Name:       swag.String(msg.Name),
+				Size:       swag.Int64(int64(msg.Size)),
+				Mode:       swag.Int64(int64(msg.Mode)),
+				Mtime:      swag.Int64(int64(msg.Mtime)),
+				LinkTarget: swag.String(msg.LinkTarget),
+			}
+		}
+	} else {
+		op := trace.NewOperation(context.Background(), fmt.Sprintf("StatPath(%s)", params.ID))
+		err = i.withContainerFilesystem(op, c, func(root string) error {
+			var serr error
+			stat, serr = statPath(root, params.Path)
+			return serr
+		})
+	}
+
+	if err != nil {
+		log.Errorf("%s", err.Error())
+
+		return interaction.NewContainerStatPathInternalServerError().WithPayload(
+			&models.Error{Message: err.Error()},
+		)
+	}
+
+	if stat == nil {
+		return interaction.NewContainerStatPathNotFound().WithPayload(
+			&models.Error{Message: fmt.Sprintf("no such file or directory: %s", params.Path)},
+		)
+	}
+
+	return interaction.NewContainerStatPathOK().WithPayload(stat)
+}
+
+// ContainerGetArchiveHandler streams a tar archive of the filesystem resource at path in the container
+func (i *InteractionHandlersImpl) ContainerGetArchiveHandler(params interaction.ContainerGetArchiveParams) middleware.Responder {
+	defer trace.End(trace.Begin(params.ID))
+
+	c := exec.Containers.Container(params.ID)
+	if c == nil {
+		return interaction.NewContainerGetArchiveNotFound().WithPayload(
+			&models.Error{Message: fmt.Sprintf("container %s not found", params.ID)},
+		)
+	}
+
+	if c.CurrentState().Running() {
+		client, err := i.attachServer.Client(context.Background(), params.ID, interactionTimeout)
+		if err != nil {
+			log.Errorf("%s", err.Error())
+
+			return interaction.NewContainerGetArchiveInternalServerError().WithPayload(
+				&models.Error{Message: err.Error()},
+			)
+		}
+
+		stat, err := attach.SSHStat(client, params.Path)
+		if err != nil {
+			log.Errorf("%s", err.Error())
+
+			return interaction.NewContainerGetArchiveInternalServerError().WithPayload(
+				&models.Error{Message: err.Error()},
+			)
+		}
+		if stat == nil {
+			return interaction.NewContainerGetArchiveNotFound().WithPayload(
+				&models.Error{Message: fmt.Sprintf("no such file or directory: %s", params.Path)},
+			)
+		}
+
+		archive, err := attach.SSHArchive(client, params.Path)
+		if err != nil {
+			log.Errorf("%s", err.Error())
+
+			return interaction.NewContainerGetArchiveInternalServerError().WithPayload(
+				&models.Error{Message: err.Error()},
+			)
+		}
+
+		return NewArchiveHandler(params.ID, func(w io.Writer) error {
+			_, err := io.Copy(w, archive)
+			return err
+		}, func() { archive.Close() })
+	}
+
+	op := trace.NewOperation(context.Background(), fmt.Sprintf("GetArchive(%s)", params.ID))
+
+	diskURI, err := vsphere.ContainerDiskURI(c.Info().Config)
+	if err != nil {
+		log.Errorf("%s", err.Error())
+
+		return interaction.NewContainerGetArchiveInternalServerError().WithPayload(
+			&models.Error{Message: err.Error()},
+		)
+	}
+
+	root, unmount, err := i.containerStore.Mount(op, diskURI)
+	if err != nil {
+		log.Errorf("%s", err.Error())
+
+		return interaction.NewContainerGetArchiveInternalServerError().WithPayload(
+			&models.Error{Message: err.Error()},
+		)
+	}
+
+	path, err := scp.ResolvePath(root, params.Path)
+	if err == nil {
+		_, err = os.Lstat(path)
+	}
+	if err != nil {
+		unmount()
+
+		if os.IsNotExist(err) {
+			return interaction.NewContainerGetArchiveNotFound().WithPayload(
+				&models.Error{Message: fmt.Sprintf("no such file or directory: %s", params.Path)},
+			)
+		}
+		return interaction.NewContainerGetArchiveInternalServerError().WithPayload(
+			&models.Error{Message: err.Error()},
+		)
+	}
+
+	// the disk stays mounted until the archive has been streamed or the client has gone away
+	return NewArchiveHandler(params.ID, func(w io.Writer) error {
+		return scp.Archive(w, path)
+	}, unmount)
+}
+
+// ContainerPutArchiveHandler extracts the supplied tar archive into the directory at path in the container
+func (i *InteractionHandlersImpl) ContainerPutArchiveHandler(params interaction.ContainerPutArchiveParams) middleware.Responder {
+	defer trace.End(trace.Begin(params.ID))
+
+	defer params.Archive.Close()
+
+	c := exec.Containers.Container(params.ID)
+	if c == nil {
+		return interaction.NewContainerPutArchiveNotFound().WithPayload(
+			&models.Error{Message: fmt.Sprintf("container %s not found", params.ID)},
+		)
+	}
+
+	noOverwrite := swag.BoolValue(params.NoOverwriteDirNonDir)
+
+	var stat *models.ContainerPathStat
+	var err error
+
+	if c.CurrentState().Running() {
+		var client *ssh.Client
+		var msg *msgs.StatResponseMsg
+
+		client, err = i.attachServer.Client(context.Background(), params.ID, interactionTimeout)
+		if err == nil {
+			msg, err = attach.SSHStat(client, params.Path)
+		}
+		if err == nil && msg != nil {
+			stat = &models.ContainerPathStat{Mode: swag.Int64(int64(msg.Mode))}
+			if os.FileMode(msg.Mode).IsDir() {
+				err = attach.SSHExtract(client, params.Path, noOverwrite, params.Archive)
+			}
+		}
+	} else {
+		op := trace.NewOperation(context.Background(), fmt.Sprintf("PutArchive(%s)", params.ID))
+		err = i.withContainerFilesystem(op, c, func(root string) error {
+			var serr error
+			if stat, serr = statPath(root, params.Path); serr != nil || stat == nil {
+				return serr
+			}
+			if !os.FileMode(swag.Int64Value(stat.Mode)).IsDir() {
+				return nil
+			}
+			return scp.Extract(params.Archive, root, params.Path, noOverwrite)
+		})
+	}
+
+	if err != nil {
+		log.Errorf("%s", err.Error())
+
+		return interaction.NewContainerPutArchiveInternalServerError().WithPayload(
+			&models.Error{Message: err.Error()},
+		)
+	}
+
+	if stat == nil {
+		return interaction.NewContainerPutArchiveNotFound().WithPayload(
+			&models.Error{Message: fmt.Sprintf("no such file or directory: %s", params.Path)},
+		)
+	}
+
+	if !os.FileMode(swag.Int64Value(stat.Mode)).IsDir() {
+		return interaction.NewContainerPutArchiveConflict().WithPayload(
+			&models.Error{Message: fmt.Sprintf("%s: %s", scp.ErrNotDirectory, params.Path)},
+		)
+	}
+
+	return interaction.NewContainerPutArchiveOK()
+}
+
+// ContainerTopHandler lists the processes running in the container
+func (i *InteractionHandlersImpl) ContainerTopHandler(params interaction.ContainerTopParams) middleware.Responder {
+	defer trace.End(trace.Begin(params.ID))
+
+	c := exec.Containers.Container(params.ID)
+	if c == nil {
+		return interaction.NewContainerTopNotFound().WithPayload(
+			&models.Error{Message: fmt.Sprintf("container %s not found", params.ID)},
+		)
+	}
+
+	if !c.CurrentState().Running() {
+		return interaction.NewContainerTopConflict().WithPayload(
+			&models.Error{Message: fmt.Sprintf("container %s is not running", params.ID)},
+		)
+	}
+
+	client, err := i.attachServer.Client(context.Background(), params.ID, interactionTimeout)
+	if err != nil {
+		log.Errorf("%s", err.Error())
+
+		return interaction.NewContainerTopInternalServerError().WithPayload(
+			&models.Error{Message: err.Error()},
+		)
+	}
+
+	titles, processes, err := attach.SSHTop(client, swag.StringValue(params.PsArgs))
+	if err != nil {
+		log.Errorf("%s", err.Error())
+
+		return interaction.NewContainerTopInternalServerError().WithPayload(
+			&models.Error{Message: err.Error()},
+		)
+	}
+
+	return interaction.NewContainerTopOK().WithPayload(&models.ContainerTopResponse{
+		Titles:    titles,
+		Processes: processes,
+	})
+}
+
+// withContainerFilesystem mounts the root filesystem of a container that is not running and
+// calls fn with the location of the mount
+func (i *InteractionHandlersImpl) withContainerFilesystem(op trace.Operation, c *exec.Container, fn func(root string) error) error {
+	diskURI, err := vsphere.ContainerDiskURI(c.Info().Config)
+	if err != nil {
+		return err
+	}
+
+	root, unmount, err := i.containerStore.Mount(op, diskURI)
+	if err != nil {
+		return err
+	}
+	defer unmount()
+
+	return fn(root)
+}
+
+// statPath returns the details of path in the container filesystem mounted at root, or nil
+// if it does not exist
+func statPath(root, path string) (*models.ContainerPathStat, error) {
+	target, err := scp.ResolvePath(root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Lstat(target)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err = os.Readlink(target); err != nil {
+			return nil, err
+		}
+	}
+
+	return &models.ContainerPathStat{
+		Name:       swag.String(info.Name()),
+		Size:       swag.Int64(info.Size()),
+		Mode:       swag.Int64(int64(info.Mode())),
+		Mtime:      swag.Int64(info.ModTime().UnixNano()),
+		LinkTarget: swag.String(link),
+	}, nil
+}
+
+// ArchiveHandler streams a tar archive to the client. The archive is written directly to the
+// response so that a client going away ends the stream, and release is called once the stream
+// has ended to free whatever the archive was read from.
+type ArchiveHandler struct {
+	name    string
+	archive func(w io.Writer) error
+	release func()
+}
+
+// NewArchiveHandler creates an ArchiveHandler for the archive of the named container or image
+func NewArchiveHandler(name string, archive func(w io.Writer) error, release func()) *ArchiveHandler {
+	return &ArchiveHandler{
+		name:    name,
+		archive: archive,
+		release: release,
+	}
+}
+
+// WriteResponse to the client
+func (a *ArchiveHandler) WriteResponse(rw http.ResponseWriter, producer httpkit.Producer) {
+	defer a.release()
+
+	rw.WriteHeader(http.StatusOK)
+	if err := a.archive(rw); err != nil {
+		log.Errorf("Error streaming archive of %s: %s", a.name, err)
+	}
+}
+
 // GenericFlusher is a custom reader to allow us to detach cleanly during an io.Copy
 type GenericFlusher interface {
 	Flush()
@@ -16,10 +16,12 @@ package handlers
 
 import (
 	middleware "github.com/go-swagger/go-swagger/httpkit/middleware"
+	"github.com/go-swagger/go-swagger/swag"
 
 	"github.com/vmware/vic/lib/apiservers/portlayer/models"
 	"github.com/vmware/vic/lib/apiservers/portlayer/restapi/operations"
 	"github.com/vmware/vic/lib/apiservers/portlayer/restapi/operations/logging"
+	"github.com/vmware/vic/lib/config/executor"
 
 	"github.com/vmware/vic/lib/portlayer/exec"
 	portlayer "github.com/vmware/vic/lib/portlayer/logging"
@@ -47,7 +49,16 @@ func (i *LoggingHandlersImpl) JoinHandler(params logging.LoggingJoinParams) midd
 		return logging.NewLoggingJoinInternalServerError().WithPayload(err)
 	}
 
-	handleprime, err := portlayer.Join(handle)
+	var config executor.LogConfig
+	if lc := params.Config.LogConfig; lc != nil {
+		config = executor.LogConfig{
+			Driver:      swag.StringValue(lc.Driver),
+			Options:     lc.Options,
+			ForwardOnly: swag.BoolValue(lc.ForwardOnly),
+		}
+	}
+
+	handleprime, err := portlayer.Join(handle, config)
 	if err != nil {
 		return logging.NewLoggingJoinInternalServerError().WithPayload(
 			&models.Error{Message: err.Error()},
@@ -82,6 +82,41 @@ func parseScopeConfig(cfg *models.ScopeConfig) (subnet *net.IPNet, gateway net.I
 	return
 }
 
+// parseIPv6Config returns the IPv6 addressing of a dual-stack scope, or nil if IPv6 is not enabled
+// alongside the addressing of the scope
+func parseIPv6Config(cfg *models.ScopeConfig) (*network.IPv6Config, error) {
+	if cfg.Subnet6 == nil || *cfg.Subnet6 == "" {
+		if len(cfg.Ipam6) > 0 || (cfg.Gateway6 != nil && *cfg.Gateway6 != "") {
+			return nil, fmt.Errorf("IPv6 gateway and ipam cannot be specified without an IPv6 subnet")
+		}
+
+		return nil, nil
+	}
+
+	if cfg.IPV6 == nil || !*cfg.IPV6 {
+		return nil, fmt.Errorf("IPv6 must be enabled to specify an IPv6 subnet")
+	}
+
+	_, subnet, err := net.ParseCIDR(*cfg.Subnet6)
+	if err != nil {
+		return nil, err
+	}
+
+	v6 := &network.IPv6Config{
+		Subnet:  subnet,
+		Gateway: net.IPv6unspecified,
+		Pools:   cfg.Ipam6,
+	}
+
+	if cfg.Gateway6 != nil && *cfg.Gateway6 != "" {
+		if v6.Gateway = net.ParseIP(*cfg.Gateway6); v6.Gateway == nil {
+			return nil, fmt.Errorf("invalid IPv6 gateway")
+		}
+	}
+
+	return v6, nil
+}
+
 func (handler *ScopesHandlersImpl) listScopes(idName string) ([]*models.ScopeConfig, error) {
 	defer trace.End(trace.Begin(idName))
 	scs, err := handler.netCtx.Scopes(context.Background(), &idName)
@@ -114,7 +149,12 @@ func (handler *ScopesHandlersImpl) ScopesCreate(params scopes.CreateScopeParams)
 		return scopes.NewCreateScopeDefault(http.StatusServiceUnavailable).WithPayload(errorPayload(err))
 	}
 
-	s, err := handler.netCtx.NewScope(context.Background(), cfg.ScopeType, cfg.Name, subnet, gateway, dns, cfg.IPAM)
+	v6, err := parseIPv6Config(cfg)
+	if err != nil {
+		return scopes.NewCreateScopeDefault(http.StatusServiceUnavailable).WithPayload(errorPayload(err))
+	}
+
+	s, err := handler.netCtx.NewDualStackScope(context.Background(), cfg.ScopeType, cfg.Name, subnet, gateway, dns, cfg.IPAM, v6)
 	if _, ok := err.(network.DuplicateResourceError); ok {
 		return scopes.NewCreateScopeConflict()
 	}
@@ -196,7 +236,7 @@ func (handler *ScopesHandlersImpl) ScopesAddContainer(params scopes.AddContainer
 	}
 
 	err := func() error {
-		var ip *net.IP
+		var ip, ip6 *net.IP
 		if params.Config.NetworkConfig.Address != nil && *params.Config.NetworkConfig.Address != "" {
 			i := net.ParseIP(*params.Config.NetworkConfig.Address)
 			if i == nil {
@@ -206,6 +246,15 @@ func (handler *ScopesHandlersImpl) ScopesAddContainer(params scopes.AddContainer
 			ip = &i
 		}
 
+		if params.Config.NetworkConfig.Address6 != nil && *params.Config.NetworkConfig.Address6 != "" {
+			i := net.ParseIP(*params.Config.NetworkConfig.Address6)
+			if i == nil || i.To4() != nil {
+				return fmt.Errorf("invalid IPv6 address %q", *params.Config.NetworkConfig.Address6)
+			}
+
+			ip6 = &i
+		}
+
 		if params.Config.NetworkConfig.Aliases != nil {
 			log.Debugf("Links/Aliases: %#v", params.Config.NetworkConfig.Aliases)
 		}
@@ -213,6 +262,7 @@ func (handler *ScopesHandlersImpl) ScopesAddContainer(params scopes.AddContainer
 		options := &network.AddContainerOptions{
 			Scope:   params.Config.NetworkConfig.NetworkName,
 			IP:      ip,
+			IP6:     ip6,
 			Aliases: params.Config.NetworkConfig.Aliases,
 			Ports:   params.Config.NetworkConfig.Ports,
 		}
@@ -341,6 +391,18 @@ func toScopeConfig(scope *network.Scope) *models.ScopeConfig {
 		sc.IPAM = []string{subnet}
 	}
 
+	ipv6 := scope.IPv6()
+	sc.IPV6 = &ipv6
+	if subnet6 := scope.Subnet6(); subnet6 != nil {
+		s6 := subnet6.String()
+		gw6 := scope.Gateway6().String()
+		sc.Subnet6 = &s6
+		sc.Gateway6 = &gw6
+		for _, p := range scope.Pools6() {
+			sc.Ipam6 = append(sc.Ipam6, p.String())
+		}
+	}
+
 	eps := scope.Endpoints()
 	sc.Endpoints = make([]*models.EndpointConfig, len(eps))
 	for i, e := range eps {
@@ -356,6 +418,12 @@ func toEndpointConfig(e *network.Endpoint) *models.EndpointConfig {
 		addr = e.IP().String()
 	}
 
+	var addr6 *string
+	if !ip.IsUnspecifiedIP(e.IP6()) {
+		a := e.IP6().String()
+		addr6 = &a
+	}
+
 	ports := e.Ports()
 	ecports := make([]string, len(ports))
 	for i, p := range e.Ports() {
@@ -364,6 +432,7 @@ func toEndpointConfig(e *network.Endpoint) *models.EndpointConfig {
 
 	return &models.EndpointConfig{
 		Address:   addr,
+		Address6:  addr6,
 		Container: e.ID().String(),
 		ID:        e.ID().String(),
 		Name:      e.Name(),
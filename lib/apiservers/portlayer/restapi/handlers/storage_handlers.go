@@ -15,9 +15,14 @@
 package handlers
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/go-swagger/go-swagger/httpkit/middleware"
@@ -27,11 +32,14 @@ import (
 	"github.com/vmware/vic/lib/apiservers/portlayer/restapi/operations"
 	"github.com/vmware/vic/lib/apiservers/portlayer/restapi/operations/storage"
 
+	"github.com/vmware/vic/pkg/fs"
 	"github.com/vmware/vic/pkg/trace"
 	"github.com/vmware/vic/pkg/vsphere/datastore"
 
+	"github.com/vmware/vic/lib/portlayer/event/events"
 	epl "github.com/vmware/vic/lib/portlayer/exec"
 	spl "github.com/vmware/vic/lib/portlayer/storage"
+	nfsSpl "github.com/vmware/vic/lib/portlayer/storage/nfs"
 	vsphereSpl "github.com/vmware/vic/lib/portlayer/storage/vsphere"
 	"github.com/vmware/vic/lib/portlayer/util"
 
@@ -40,8 +48,9 @@ import (
 
 // StorageHandlersImpl is the receiver for all of the storage handler methods
 type StorageHandlersImpl struct {
-	imageCache  *spl.NameLookupCache
-	volumeCache *spl.VolumeLookupCache
+	imageCache     *spl.NameLookupCache
+	volumeCache    *spl.VolumeLookupCache
+	containerStore *vsphereSpl.ContainerStore
 }
 
 // Configure assigns functions to all the storage api handlers
@@ -70,6 +79,11 @@ func (h *StorageHandlersImpl) Configure(api *operations.PortLayerAPI, handlerCtx
 	// expensive metadata lookups.
 	h.imageCache = spl.NewLookupCache(ds)
 
+	h.containerStore, err = vsphereSpl.NewContainerStore(op, handlerCtx.Session)
+	if err != nil {
+		log.Panicf("Cannot instantiate the container store: %s", err)
+	}
+
 	// The same is done for volumes.  It's implemented via a cache which writes
 	// to an implementation that takes a datastore to write to.
 	vsVolumeStore, err := vsphereSpl.NewVolumeStore(op, handlerCtx.Session)
@@ -77,9 +91,26 @@ func (h *StorageHandlersImpl) Configure(api *operations.PortLayerAPI, handlerCtx
 		log.Panicf("Cannot instantiate the volume store: %s", err)
 	}
 
+	// Volume stores on NFS exports are mounted by the containers directly, and by the
+	// appliance to manage the volume directories.
+	nfsVolumeStore := nfsSpl.NewVolumeStore(op, nfsSpl.NewKernelMountServer())
+
+	dsLocations := make(map[string]*url.URL)
+	for volStoreName, u := range spl.Config.VolumeLocations {
+		if u.Scheme != fs.NFSScheme {
+			dsLocations[volStoreName] = u
+			continue
+		}
+
+		log.Infof("Adding volume store %s (%s)", volStoreName, u.String())
+		if _, err := nfsVolumeStore.AddStore(op, u, volStoreName); err != nil {
+			log.Errorf("volume addition error %s", err)
+		}
+	}
+
 	// Get the datastores for volumes.
 	// Each volume store name maps to a datastore + path, which can be referred to by the name.
-	dstores, err := datastore.GetDatastores(context.TODO(), handlerCtx.Session, spl.Config.VolumeLocations)
+	dstores, err := datastore.GetDatastores(context.TODO(), handlerCtx.Session, dsLocations)
 	if err != nil {
 		log.Panicf("Cannot find datastores: %s", err)
 	}
@@ -93,7 +124,7 @@ func (h *StorageHandlersImpl) Configure(api *operations.PortLayerAPI, handlerCtx
 		}
 	}
 
-	h.volumeCache, err = spl.NewVolumeLookupCache(op, vsVolumeStore)
+	h.volumeCache, err = spl.NewVolumeLookupCache(op, spl.NewMultiVolumeStore(vsVolumeStore, nfsVolumeStore))
 	if err != nil {
 		log.Panicf("Cannot instantiate the Volume Lookup cache: %s", err)
 	}
@@ -104,6 +135,8 @@ func (h *StorageHandlersImpl) Configure(api *operations.PortLayerAPI, handlerCtx
 	api.StorageListImagesHandler = storage.ListImagesHandlerFunc(h.ListImages)
 	api.StorageWriteImageHandler = storage.WriteImageHandlerFunc(h.WriteImage)
 	api.StorageDeleteImageHandler = storage.DeleteImageHandlerFunc(h.DeleteImage)
+	api.StorageCommitImageHandler = storage.CommitImageHandlerFunc(h.CommitImage)
+	api.StorageExportContainerHandler = storage.ExportContainerHandlerFunc(h.ExportContainer)
 
 	api.StorageVolumeStoresListHandler = storage.VolumeStoresListHandlerFunc(h.VolumeStoresList)
 	api.StorageCreateVolumeHandler = storage.CreateVolumeHandlerFunc(h.CreateVolume)
@@ -196,12 +229,44 @@ func (h *StorageHandlersImpl) DeleteImage(params storage.DeleteImageParams) midd
 		}
 	}
 
+	publishImageEvent(image.ID, events.ImageRemoved)
 	return storage.NewDeleteImageOK()
 }
 
-// GetImageTar returns an image tar file
+// GetImageTar returns a tar archive of the changes an image layer makes to its parent
 func (h *StorageHandlersImpl) GetImageTar(params storage.GetImageTarParams) middleware.Responder {
-	return middleware.NotImplemented("operation storage.GetImageTar has not yet been implemented")
+	defer trace.End(trace.Begin(params.ID))
+
+	u, err := util.ImageStoreNameToURL(params.StoreName)
+	if err != nil {
+		return storage.NewGetImageTarDefault(http.StatusInternalServerError).WithPayload(
+			&models.Error{
+				Code:    swag.Int64(http.StatusInternalServerError),
+				Message: err.Error(),
+			})
+	}
+
+	op := trace.NewOperation(context.Background(), fmt.Sprintf("GetImageTar(%s)", params.ID))
+	image, err := h.imageCache.GetImage(op, u, params.ID)
+	if err != nil {
+		log.Errorf("GetImageTar: image %s not found: %s", params.ID, err)
+		return storage.NewGetImageTarNotFound()
+	}
+
+	tar, err := h.imageCache.GetImageTar(op, image)
+	if err != nil {
+		return storage.NewGetImageTarDefault(http.StatusInternalServerError).WithPayload(
+			&models.Error{
+				Code:    swag.Int64(http.StatusInternalServerError),
+				Message: err.Error(),
+			})
+	}
+
+	// the image disks stay attached until the archive has been streamed or the client has gone away
+	return NewArchiveHandler(params.ID, func(w io.Writer) error {
+		_, err := io.Copy(w, tar)
+		return err
+	}, func() { tar.Close() })
 }
 
 // ListImages returns a list of images in a store
@@ -264,10 +329,187 @@ func (h *StorageHandlersImpl) WriteImage(params storage.WriteImageParams) middle
 				Message: err.Error(),
 			})
 	}
+	publishImageEvent(image.ID, events.ImageCreated)
+
 	i := convertImage(image)
 	return storage.NewWriteImageCreated().WithPayload(i)
 }
 
+// CommitImage writes the changes a container has made to its image to a new image layer
+func (h *StorageHandlersImpl) CommitImage(params storage.CommitImageParams) middleware.Responder {
+	defer trace.End(trace.Begin(params.ContainerID))
+
+	ferr := func(err error, code int) middleware.Responder {
+		log.Errorf("CommitImage: error %s", err.Error())
+		return storage.NewCommitImageDefault(code).WithPayload(
+			&models.Error{
+				Code:    swag.Int64(int64(code)),
+				Message: err.Error(),
+			})
+	}
+
+	c := epl.Containers.Container(params.ContainerID)
+	if c == nil {
+		return storage.NewCommitImageNotFound().WithPayload(&models.Error{
+			Code:    swag.Int64(http.StatusNotFound),
+			Message: fmt.Sprintf("container %s not found", params.ContainerID),
+		})
+	}
+
+	u, err := util.ImageStoreNameToURL(params.StoreName)
+	if err != nil {
+		return ferr(err, http.StatusInternalServerError)
+	}
+
+	op := trace.NewOperation(context.Background(), fmt.Sprintf("CommitImage(%s, %s)", params.ContainerID, params.ImageID))
+
+	// the disk of a containerVM that is powered on is locked, so the changes are read from a
+	// snapshot. As with docker commit, the container is paused while the snapshot is taken
+	// unless asked otherwise, so that the snapshot is consistent.
+	config := c.Info().Config
+	if state := c.CurrentState(); state != epl.StateStopped && state != epl.StateCreated {
+		paused := false
+		if swag.BoolValue(params.Pause) && state == epl.StateRunning {
+			if err = c.Pause(op); err != nil {
+				if _, ok := err.(epl.InvalidStateError); ok {
+					return storage.NewCommitImageConflict().WithPayload(&models.Error{
+						Code:    swag.Int64(http.StatusConflict),
+						Message: err.Error(),
+					})
+				}
+				return ferr(err, http.StatusInternalServerError)
+			}
+			paused = true
+		}
+
+		var release func()
+		config, release, err = c.Snapshot(op, "commit")
+
+		if paused {
+			if uerr := c.Unpause(op); uerr != nil {
+				log.Errorf("Unable to unpause container %s after commit snapshot: %s", params.ContainerID, uerr)
+			}
+		}
+
+		if err != nil {
+			return ferr(err, http.StatusInternalServerError)
+		}
+		defer release()
+	}
+
+	diskURI, err := vsphereSpl.ContainerDiskURI(config)
+	if err != nil {
+		return ferr(err, http.StatusInternalServerError)
+	}
+	parentURI, err := vsphereSpl.ContainerParentDiskURI(config)
+	if err != nil {
+		return ferr(err, http.StatusInternalServerError)
+	}
+
+	// the layer is staged so that its checksum is known before it is written to the store
+	f, err := ioutil.TempFile("", "commit-"+params.ImageID)
+	if err != nil {
+		return ferr(err, http.StatusInternalServerError)
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	h256 := sha256.New()
+	if err = h.containerStore.Diff(op, diskURI, parentURI, io.MultiWriter(f, h256)); err != nil {
+		return ferr(err, http.StatusInternalServerError)
+	}
+
+	size, err := f.Seek(0, os.SEEK_CUR)
+	if err != nil {
+		return ferr(err, http.StatusInternalServerError)
+	}
+	if _, err = f.Seek(0, os.SEEK_SET); err != nil {
+		return ferr(err, http.StatusInternalServerError)
+	}
+
+	parent := &spl.Image{
+		Store: u,
+		ID:    c.ExecConfig.LayerID,
+	}
+
+	var meta map[string][]byte
+	if params.Metadatakey != nil && params.Metadataval != nil {
+		meta = map[string][]byte{*params.Metadatakey: []byte(*params.Metadataval)}
+	}
+
+	diffID := fmt.Sprintf("sha256:%x", h256.Sum(nil))
+	image, err := h.imageCache.WriteImage(op, parent, params.ImageID, meta, diffID, f)
+	if err != nil {
+		return ferr(err, http.StatusInternalServerError)
+	}
+
+	publishImageEvent(image.ID, events.ImageCreated)
+
+	return storage.NewCommitImageCreated().WithPayload(&models.CommitImageResponse{
+		Image:  convertImage(image),
+		DiffID: diffID,
+		Size:   swag.Int64(size),
+	})
+}
+
+// ExportContainer streams a tar archive of the complete filesystem of a container
+func (h *StorageHandlersImpl) ExportContainer(params storage.ExportContainerParams) middleware.Responder {
+	defer trace.End(trace.Begin(params.ContainerID))
+
+	ferr := func(err error, code int) middleware.Responder {
+		log.Errorf("ExportContainer: error %s", err.Error())
+		return storage.NewExportContainerDefault(code).WithPayload(
+			&models.Error{
+				Code:    swag.Int64(int64(code)),
+				Message: err.Error(),
+			})
+	}
+
+	c := epl.Containers.Container(params.ContainerID)
+	if c == nil {
+		return storage.NewExportContainerNotFound().WithPayload(&models.Error{
+			Code:    swag.Int64(http.StatusNotFound),
+			Message: fmt.Sprintf("container %s not found", params.ContainerID),
+		})
+	}
+
+	op := trace.NewOperation(context.Background(), fmt.Sprintf("ExportContainer(%s)", params.ContainerID))
+
+	// the disk of a containerVM that is powered on is locked, so it is exported from a snapshot
+	// while the container carries on
+	config := c.Info().Config
+	release := func() {}
+	if state := c.CurrentState(); state != epl.StateStopped && state != epl.StateCreated {
+		var err error
+		if config, release, err = c.Snapshot(op, "export"); err != nil {
+			return ferr(err, http.StatusInternalServerError)
+		}
+	}
+
+	diskURI, err := vsphereSpl.ContainerDiskURI(config)
+	if err != nil {
+		release()
+		return ferr(err, http.StatusInternalServerError)
+	}
+
+	archive, err := h.containerStore.Export(op, diskURI)
+	if err != nil {
+		release()
+		return ferr(err, http.StatusInternalServerError)
+	}
+
+	// the disk stays mounted until the archive has been streamed or the client has gone away
+	return NewArchiveHandler(params.ContainerID, func(w io.Writer) error {
+		_, err := io.Copy(w, archive)
+		return err
+	}, func() {
+		archive.Close()
+		release()
+	})
+}
+
 // VolumeStoresList lists the configured volume stores and their datastore path URIs.
 func (h *StorageHandlersImpl) VolumeStoresList() middleware.Responder {
 	defer trace.End(trace.Begin("storage_handlers.VolumeStoresList"))
@@ -344,6 +586,8 @@ func (h *StorageHandlersImpl) CreateVolume(params storage.CreateVolumeParams) mi
 		})
 	}
 
+	publishVolumeEvent(volume.ID, events.VolumeCreated)
+
 	response := volumeToCreateResponse(volume, params.VolumeRequest)
 	return storage.NewCreateVolumeCreated().WithPayload(&response)
 }
@@ -397,6 +641,8 @@ func (h *StorageHandlersImpl) RemoveVolume(params storage.RemoveVolumeParams) mi
 			})
 		}
 	}
+
+	publishVolumeEvent(params.Name, events.VolumeRemoved)
 	return storage.NewRemoveVolumeOK()
 }
 
@@ -453,7 +699,12 @@ func (h *StorageHandlersImpl) VolumeJoin(params storage.VolumeJoinParams) middle
 		})
 	}
 
-	actualHandle, err = vsphereSpl.VolumeJoin(op, actualHandle, volume, params.JoinArgs.MountPath, params.JoinArgs.Flags)
+	switch volume.Device.(type) {
+	case *nfsSpl.Target:
+		actualHandle, err = nfsSpl.VolumeJoin(op, actualHandle, volume, params.JoinArgs.MountPath, params.JoinArgs.Flags)
+	default:
+		actualHandle, err = vsphereSpl.VolumeJoin(op, actualHandle, volume, params.JoinArgs.MountPath, params.JoinArgs.Flags)
+	}
 	if err != nil {
 		log.Errorf("Volumes: StorageHandler : %#v", err)
 
@@ -469,6 +720,38 @@ func (h *StorageHandlersImpl) VolumeJoin(params storage.VolumeJoinParams) middle
 
 //utility functions
 
+// publishImageEvent publishes an ImageEvent to the port layer event stream
+func publishImageEvent(id, event string) {
+	if epl.Config.EventManager == nil {
+		return
+	}
+
+	epl.Config.EventManager.Publish(&events.ImageEvent{
+		BaseEvent: &events.BaseEvent{
+			Ref:         id,
+			CreatedTime: time.Now().UTC(),
+			Event:       event,
+			Detail:      fmt.Sprintf("Image %s %s", id, event),
+		},
+	})
+}
+
+// publishVolumeEvent publishes a VolumeEvent to the port layer event stream
+func publishVolumeEvent(name, event string) {
+	if epl.Config.EventManager == nil {
+		return
+	}
+
+	epl.Config.EventManager.Publish(&events.VolumeEvent{
+		BaseEvent: &events.BaseEvent{
+			Ref:         name,
+			CreatedTime: time.Now().UTC(),
+			Event:       event,
+			Detail:      fmt.Sprintf("Volume %s %s", name, event),
+		},
+	})
+}
+
 // convert an SPL Image to a swagger-defined Image
 func convertImage(image *spl.Image) *models.Image {
 	var parent, selfLink *string
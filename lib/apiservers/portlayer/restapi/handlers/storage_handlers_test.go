@@ -189,6 +189,10 @@ func (c *MockDataStore) DeleteImage(op trace.Operation, image *spl.Image) error
 	return nil
 }
 
+func (c *MockDataStore) GetImageTar(op trace.Operation, image *spl.Image) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
 func TestCreateImageStore(t *testing.T) {
 	s := &StorageHandlersImpl{
 		imageCache: spl.NewLookupCache(&MockDataStore{}),
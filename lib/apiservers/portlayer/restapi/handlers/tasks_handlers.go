@@ -0,0 +1,216 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	middleware "github.com/go-swagger/go-swagger/httpkit/middleware"
+	"github.com/go-swagger/go-swagger/swag"
+
+	"github.com/vmware/vic/lib/apiservers/portlayer/models"
+	"github.com/vmware/vic/lib/apiservers/portlayer/restapi/operations"
+	"github.com/vmware/vic/lib/apiservers/portlayer/restapi/operations/tasks"
+	"github.com/vmware/vic/lib/config/executor"
+	"github.com/vmware/vic/lib/portlayer/exec"
+	"github.com/vmware/vic/lib/portlayer/task"
+	"github.com/vmware/vic/pkg/trace"
+)
+
+// TaskHandlersImpl is the receiver for all of the task handler methods
+type TaskHandlersImpl struct {
+}
+
+// Configure initializes the handler
+func (i *TaskHandlersImpl) Configure(api *operations.PortLayerAPI, _ *HandlerContext) {
+	api.TasksTaskJoinHandler = tasks.TaskJoinHandlerFunc(i.JoinHandler)
+	api.TasksTaskRemoveHandler = tasks.TaskRemoveHandlerFunc(i.RemoveHandler)
+	api.TasksTaskBindHandler = tasks.TaskBindHandlerFunc(i.BindHandler)
+	api.TasksTaskUnbindHandler = tasks.TaskUnbindHandlerFunc(i.UnbindHandler)
+	api.TasksTaskInspectHandler = tasks.TaskInspectHandlerFunc(i.InspectHandler)
+}
+
+// JoinHandler adds a task to the handle
+func (i *TaskHandlersImpl) JoinHandler(params tasks.TaskJoinParams) middleware.Responder {
+	defer trace.End(trace.Begin(""))
+
+	handle := exec.HandleFromInterface(params.Config.Handle)
+	if handle == nil {
+		err := &models.Error{Message: "Failed to get the Handle"}
+		return tasks.NewTaskJoinNotFound().WithPayload(err)
+	}
+
+	session := &executor.SessionConfig{
+		Common: executor.Common{
+			ID:   params.Config.ID,
+			Name: params.Config.ID,
+		},
+		Cmd: executor.Cmd{
+			Path: params.Config.Path,
+			Args: append([]string{params.Config.Path}, params.Config.Args...),
+			Env:  params.Config.Env,
+			Dir:  swag.StringValue(params.Config.WorkingDir),
+		},
+		Attach:    swag.BoolValue(params.Config.Attach),
+		OpenStdin: swag.BoolValue(params.Config.OpenStdin),
+		RunBlock:  swag.BoolValue(params.Config.Attach),
+		Tty:       swag.BoolValue(params.Config.Tty),
+		User:      swag.StringValue(params.Config.User),
+	}
+
+	// inherit the environment and working directory of the primary session if not specified
+	if primary, ok := handle.ExecConfig.Sessions[handle.ExecConfig.ID]; ok {
+		if len(session.Cmd.Env) == 0 {
+			session.Cmd.Env = primary.Cmd.Env
+		}
+		if session.Cmd.Dir == "" {
+			session.Cmd.Dir = primary.Cmd.Dir
+		}
+	}
+
+	handleprime, err := task.Join(handle, session)
+	if err != nil {
+		return tasks.NewTaskJoinInternalServerError().WithPayload(
+			&models.Error{Message: err.Error()},
+		)
+	}
+
+	res := &models.TaskJoinResponse{
+		Handle: exec.ReferenceFromHandle(handleprime),
+		ID:     session.ID,
+	}
+	return tasks.NewTaskJoinOK().WithPayload(res)
+}
+
+// RemoveHandler removes a task from the handle
+func (i *TaskHandlersImpl) RemoveHandler(params tasks.TaskRemoveParams) middleware.Responder {
+	defer trace.End(trace.Begin(""))
+
+	handle := exec.HandleFromInterface(params.Config.Handle)
+	if handle == nil {
+		err := &models.Error{Message: "Failed to get the Handle"}
+		return tasks.NewTaskRemoveNotFound().WithPayload(err)
+	}
+
+	handleprime, err := task.Remove(handle, params.Config.ID)
+	if err != nil {
+		if _, ok := err.(task.NotFoundError); ok {
+			return tasks.NewTaskRemoveNotFound().WithPayload(&models.Error{Message: err.Error()})
+		}
+
+		return tasks.NewTaskRemoveInternalServerError().WithPayload(
+			&models.Error{Message: err.Error()},
+		)
+	}
+
+	res := &models.TaskRemoveResponse{
+		Handle: exec.ReferenceFromHandle(handleprime),
+	}
+	return tasks.NewTaskRemoveOK().WithPayload(res)
+}
+
+// BindHandler activates a task
+func (i *TaskHandlersImpl) BindHandler(params tasks.TaskBindParams) middleware.Responder {
+	defer trace.End(trace.Begin(""))
+
+	handle := exec.HandleFromInterface(params.Config.Handle)
+	if handle == nil {
+		err := &models.Error{Message: "Failed to get the Handle"}
+		return tasks.NewTaskBindNotFound().WithPayload(err)
+	}
+
+	handleprime, err := task.Bind(handle, params.Config.ID)
+	if err != nil {
+		if _, ok := err.(task.NotFoundError); ok {
+			return tasks.NewTaskBindNotFound().WithPayload(&models.Error{Message: err.Error()})
+		}
+
+		return tasks.NewTaskBindInternalServerError().WithPayload(
+			&models.Error{Message: err.Error()},
+		)
+	}
+
+	res := &models.TaskBindResponse{
+		Handle: exec.ReferenceFromHandle(handleprime),
+	}
+	return tasks.NewTaskBindOK().WithPayload(res)
+}
+
+// UnbindHandler deactivates a task
+func (i *TaskHandlersImpl) UnbindHandler(params tasks.TaskUnbindParams) middleware.Responder {
+	defer trace.End(trace.Begin(""))
+
+	handle := exec.HandleFromInterface(params.Config.Handle)
+	if handle == nil {
+		err := &models.Error{Message: "Failed to get the Handle"}
+		return tasks.NewTaskUnbindNotFound().WithPayload(err)
+	}
+
+	handleprime, err := task.Unbind(handle, params.Config.ID)
+	if err != nil {
+		if _, ok := err.(task.NotFoundError); ok {
+			return tasks.NewTaskUnbindNotFound().WithPayload(&models.Error{Message: err.Error()})
+		}
+
+		return tasks.NewTaskUnbindInternalServerError().WithPayload(
+			&models.Error{Message: err.Error()},
+		)
+	}
+
+	res := &models.TaskUnbindResponse{
+		Handle: exec.ReferenceFromHandle(handleprime),
+	}
+	return tasks.NewTaskUnbindOK().WithPayload(res)
+}
+
+// InspectHandler returns the state of a task
+func (i *TaskHandlersImpl) InspectHandler(params tasks.TaskInspectParams) middleware.Responder {
+	defer trace.End(trace.Begin(""))
+
+	handle := exec.HandleFromInterface(params.Config.Handle)
+	if handle == nil {
+		err := &models.Error{Message: "Failed to get the Handle"}
+		return tasks.NewTaskInspectNotFound().WithPayload(err)
+	}
+
+	session, err := task.Inspect(handle, params.Config.ID)
+	if err != nil {
+		if _, ok := err.(task.NotFoundError); ok {
+			return tasks.NewTaskInspectNotFound().WithPayload(&models.Error{Message: err.Error()})
+		}
+
+		return tasks.NewTaskInspectInternalServerError().WithPayload(
+			&models.Error{Message: err.Error()},
+		)
+	}
+
+	var args []string
+	if len(session.Cmd.Args) > 1 {
+		args = session.Cmd.Args[1:]
+	}
+
+	res := &models.TaskInspectResponse{
+		ID:          swag.String(session.ID),
+		ContainerID: swag.String(handle.ExecConfig.ID),
+		Running:     swag.Bool(session.Started == "true" && session.StopTime == 0),
+		ExitCode:    swag.Int32(int32(session.ExitStatus)),
+		Started:     swag.String(session.Started),
+		Path:        swag.String(session.Cmd.Path),
+		Args:        args,
+		User:        swag.String(session.User),
+		Attach:      swag.Bool(session.Attach),
+		OpenStdin:   swag.Bool(session.OpenStdin),
+		Tty:         swag.Bool(session.Tty),
+	}
+	return tasks.NewTaskInspectOK().WithPayload(res)
+}
@@ -120,6 +120,10 @@ type ExecutorConfig struct {
 	// These are keyed by session ID
 	Sessions map[string]*SessionConfig `vic:"0.1" scope:"read-only" key:"sessions"`
 
+	// Execs is the set of non-persistent sessions hosted by this executor, e.g. those
+	// created via docker exec. These are keyed by session ID
+	Execs map[string]*SessionConfig `vic:"0.1" scope:"read-only" key:"execs"`
+
 	// Maps the mount name to the detail mount specification
 	Mounts map[string]MountSpec `vic:"0.1" scope:"read-only" key:"mounts"`
 
@@ -141,10 +145,37 @@ type ExecutorConfig struct {
 	// TODO: a bit docker specific
 	RepoName string `vic:"0.1" scope:"read-only" key:"repo"`
 
+	// RestartPolicy controls whether the containerVM is restarted when it stops without being asked to
+	RestartPolicy RestartPolicy `vic:"0.1" scope:"hidden" key:"restartpolicy"`
+
+	// Paused is set by the tether while the processes of the containerVM are frozen
+	Paused bool `vic:"0.1" scope:"read-write" key:"paused"`
+
 	// version
 	Version *version.Build `vic:"0.1" scope:"read-only" key:"version"`
 }
 
+// Restart policy names
+const (
+	RestartNo            = "no"
+	RestartAlways        = "always"
+	RestartOnFailure     = "on-failure"
+	RestartUnlessStopped = "unless-stopped"
+)
+
+// RestartPolicy describes when an executor is restarted after it stops
+type RestartPolicy struct {
+	// Name is one of the restart policy names, empty is equivalent to RestartNo
+	Name string `vic:"0.1" scope:"read-only" key:"name"`
+
+	// MaximumRetryCount limits the restarts made by the on-failure policy, zero is unlimited
+	MaximumRetryCount int `vic:"0.1" scope:"read-only" key:"maxretry"`
+
+	// Stopped records that the executor was last stopped on request, in which case the
+	// unless-stopped policy does not start it when the port layer starts
+	Stopped bool `vic:"0.1" scope:"read-only" key:"stopped"`
+}
+
 // Cmd is here because the encoding packages seem to have issues with the full exec.Cmd struct
 type Cmd struct {
 	// Path is the command to run
@@ -177,6 +208,9 @@ type SessionConfig struct {
 	// Allocate a tty or not
 	Tty bool `vic:"0.1" scope:"read-only" key:"tty"`
 
+	// Whether stdin is expected to be attached
+	OpenStdin bool `vic:"0.1" scope:"read-only" key:"openstdin"`
+
 	ExitStatus int `vic:"0.1" scope:"read-write" key:"status"`
 
 	Started string `vic:"0.1" scope:"read-write" key:"started"`
@@ -186,9 +220,24 @@ type SessionConfig struct {
 	// StopSignal is the signal name or number used to stop container session
 	StopSignal string `vic:"0.1" scope:"read-only" key:"stopSignal"`
 
+	// Active indicates that the session should be launched - only meaningful for execs
+	Active bool `vic:"0.1" scope:"read-only" key:"active"`
+
+	// RunBlock delays launch of the session until an attach has been performed
+	RunBlock bool `vic:"0.1" scope:"read-only" key:"runblock"`
+
 	// Diagnostics holds basic diagnostics data
 	Diagnostics Diagnostics `vic:"0.1" scope:"read-only" key:"diagnostics"`
 
+	// Healthcheck is the probe the tether runs periodically to check the session is working
+	Healthcheck HealthConfig `vic:"0.1" scope:"read-only" key:"healthcheck"`
+
+	// Health is the result of the healthcheck, maintained by the tether
+	Health HealthState `vic:"0.1" scope:"read-write" key:"health"`
+
+	// Logging selects where the tether sends the session output in addition to the datastore log
+	Logging LogConfig `vic:"0.1" scope:"read-only" key:"logging"`
+
 	// Maps the intent to the signal for this specific app
 	// Signals map[int]int
 
@@ -198,10 +247,65 @@ type SessionConfig struct {
 	// user
 	// rootfs - within the container context
 
-	// User and group for setuid programs.
+	// User and group for setuid programs, as names or ids. User may also be user:group.
 	// Need to go here since UID/GID resolution must be done on appliance
-	User  string `vic:"0.1" scope:"read-only" key:"User"`
-	Group string `vic:"0.1" scope:"read-only" key:"Group"`
+	User  string `vic:"0.1" scope:"read-only" key:"user"`
+	Group string `vic:"0.1" scope:"read-only" key:"group"`
+}
+
+// Health status values
+const (
+	HealthStarting  = "starting"
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+)
+
+// HealthConfig describes the healthcheck of a session
+type HealthConfig struct {
+	// Test is the probe, either ["CMD", args...] to run args directly or ["CMD-SHELL", command] to
+	// run command with the system shell. An empty test disables the healthcheck.
+	Test []string `vic:"0.1" scope:"read-only" key:"test"`
+
+	// Interval is the time to wait between probes
+	Interval time.Duration `vic:"0.1" scope:"read-only" key:"interval"`
+
+	// Timeout is how long a probe may run before it is considered to have failed
+	Timeout time.Duration `vic:"0.1" scope:"read-only" key:"timeout"`
+
+	// Retries is the number of consecutive failures needed to consider the session unhealthy
+	Retries int `vic:"0.1" scope:"read-only" key:"retries"`
+}
+
+// LogConfig describes the log driver that session output is forwarded with
+type LogConfig struct {
+	// Driver is the docker log driver. Output is forwarded if it is syslog, gelf or fluentd.
+	Driver string `vic:"0.1" scope:"read-only" key:"driver"`
+
+	// Options are the driver options, such as the address of the log service
+	Options map[string]string `vic:"0.1" scope:"read-only" key:"options"`
+
+	// ForwardOnly disables the datastore log, leaving the forwarded output as the only copy
+	ForwardOnly bool `vic:"0.1" scope:"read-only" key:"forwardonly"`
+}
+
+// HealthState is the healthcheck status of a session
+type HealthState struct {
+	// Status is one of the health status values
+	Status string `vic:"0.1" scope:"read-write" key:"status"`
+
+	// FailingStreak is the number of consecutive failed probes
+	FailingStreak int `vic:"0.1" scope:"read-write" key:"failingstreak"`
+
+	// Log holds the results of the most recent probes, oldest first
+	Log []HealthResult `vic:"0.1" scope:"read-write" key:"log"`
+}
+
+// HealthResult records a single run of a healthcheck probe
+type HealthResult struct {
+	Start    time.Time `vic:"0.1" scope:"read-write" key:"start"`
+	End      time.Time `vic:"0.1" scope:"read-write" key:"end"`
+	ExitCode int       `vic:"0.1" scope:"read-write" key:"exitcode"`
+	Output   string    `vic:"0.1" scope:"read-write" key:"output"`
 }
 
 type Detail struct {
@@ -38,6 +38,15 @@ type NetworkEndpoint struct {
 	// Actual IP address assigned
 	Assigned net.IPNet `vic:"0.1" scope:"read-write" key:"assigned"`
 
+	// Whether this endpoint's IPv6 address was specified by the client (true if it was)
+	Static6 bool `vic:"0.1" scope:"read-only" key:"static6"`
+
+	// IPv6 address to assign on a dual-stack network
+	IP6 *net.IPNet `vic:"0.1" scope:"read-only" key:"ip6"`
+
+	// Actual IPv6 address assigned, either IP6 or one autoconfigured from router advertisements
+	Assigned6 net.IPNet `vic:"0.1" scope:"read-write" key:"assigned6"`
+
 	// The network in which this information should be interpreted. This is embedded directly rather than
 	// as a pointer so that we can ensure the data is consistent
 	Network ContainerNetwork `vic:"0.1" scope:"read-only" key:"network"`
@@ -67,6 +76,16 @@ type ContainerNetwork struct {
 	// The IP ranges for this network
 	Pools []ip.Range `vic:"0.1" scope:"read-only" key:"pools"`
 
+	// Whether IPv6 is enabled on the network. The network may be IPv6 only, in which case
+	// the fields above hold IPv6 addresses, or dual-stack with the IPv6 details below.
+	IPv6 bool `vic:"0.1" scope:"read-only" key:"ipv6"`
+
+	// The IPv6 network scope of a dual-stack network. The IP address is the default IPv6 gateway
+	Gateway6 net.IPNet `vic:"0.1" scope:"read-write" key:"gateway6"`
+
+	// The IPv6 ranges for a dual-stack network
+	Pools6 []ip.Range `vic:"0.1" scope:"read-only" key:"pools6"`
+
 	// set of network wide links and aliases for this container on this network
 	Aliases []string `vic:"0.1" scope:"hidden" key:"aliases"`
 }
@@ -41,6 +41,14 @@ const (
 	Name = "{name}"
 )
 
+// Ways of pausing a container
+const (
+	// PauseFreeze stops the processes of the container within the containerVM
+	PauseFreeze = "freeze"
+	// PauseSuspend suspends the containerVM
+	PauseSuspend = "suspend"
+)
+
 // Can we just treat the VCH appliance as a containerVM booting off a specific bootstrap image
 // It has many of the same requirements (around networks being attached, version recorded,
 // volumes mounted, et al). Each of the components can easily be captured as a Session given they
@@ -93,14 +101,16 @@ type Container struct {
 	ContainerNameConvention string
 	// Permitted datastore URLs for container storage for this virtual container host
 	ContainerStores []url.URL `vic:"0.1" scope:"read-only" recurse:"depth=0"`
+	// How containers are paused, either PauseFreeze or PauseSuspend
+	PauseMode string `vic:"0.1" scope:"read-only" key:"pause_mode"`
 }
 
 // RegistryConfig defines the registries virtual container host can talk to
 type Registry struct {
 	// Whitelist of registries
-	RegistryWhitelist []url.URL `vic:"0.1" scope:"read-only" recurse:"depth=0"`
+	RegistryWhitelist []url.URL `vic:"0.1" scope:"read-only" key:"registry_whitelist"`
 	// Blacklist of registries
-	RegistryBlacklist []url.URL `vic:"0.1" scope:"read-only" recurse:"depth=0"`
+	RegistryBlacklist []url.URL `vic:"0.1" scope:"read-only" key:"registry_blacklist"`
 	// Insecure registries
 	InsecureRegistries []url.URL `vic:"0.1" scope:"read-only" key:"insecure_registries"`
 }
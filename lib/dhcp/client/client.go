@@ -44,6 +44,17 @@ type Client interface {
 
 	// Release releases an existing DHCP lease.
 	Release(*dhcp.Packet) error
+
+	// Request6 sends a full DHCPv6 request, resulting in the lease of an IPv6 address.
+	// On a successful lease, returns the DHCPv6 reply packet
+	Request6(ID) (*dhcp.Packet6, error)
+
+	// Renew6 renews an existing DHCPv6 lease. Returns a new reply
+	// packet on success.
+	Renew6(ID, *dhcp.Packet6) (*dhcp.Packet6, error)
+
+	// Release6 releases an existing DHCPv6 lease.
+	Release6(ID, *dhcp.Packet6) error
 }
 
 type client struct {
@@ -0,0 +1,234 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/vmware/vic/lib/dhcp"
+)
+
+const (
+	// the DHCPv6 client and server ports
+	clientPort6 = 546
+	serverPort6 = 547
+
+	// how long to wait for a response before a DHCPv6 message is sent again
+	retransmitTimeout6 = time.Second
+)
+
+// allServers6 is the All_DHCP_Relay_Agents_and_Servers address; see https://tools.ietf.org/html/rfc3315#section-5.1
+var allServers6 = net.ParseIP("ff02::1:2")
+
+// Request6 leases an IPv6 address by DHCPv6 for the interface of the ID. On success, returns
+// the reply of the server.
+func (c *client) Request6(id ID) (*dhcp.Packet6, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	conn, err := listen6(id.IfIndex)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	solicit, err := newPacket6(dhcp.Solicit6, id)
+	if err != nil {
+		return nil, err
+	}
+	solicit.Options[dhcp.OptionIANA6] = append(append([]byte{}, id.Iaid...), make([]byte, 8)...)
+
+	advertise, err := c.exchange6(conn, id, solicit, dhcp.Advertise6)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := newPacket6(dhcp.Request6, id)
+	if err != nil {
+		return nil, err
+	}
+	request.Options[dhcp.OptionServerID6] = advertise.ServerID()
+	request.Options[dhcp.OptionIANA6] = advertise.Options[dhcp.OptionIANA6]
+
+	reply, err := c.exchange6(conn, id, request, dhcp.Reply6)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debugf("DHCPv6 lease of %s for %s", reply.Address(), reply.LeaseTime())
+	return reply, nil
+}
+
+// Renew6 renews an existing DHCPv6 lease. Returns the new reply of the server on success.
+func (c *client) Renew6(id ID, reply *dhcp.Packet6) (*dhcp.Packet6, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	log.Debugf("renewing IP %s", reply.Address())
+
+	conn, err := listen6(id.IfIndex)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	renew, err := newPacket6(dhcp.Renew6, id)
+	if err != nil {
+		return nil, err
+	}
+	renew.Options[dhcp.OptionServerID6] = reply.ServerID()
+	renew.Options[dhcp.OptionIANA6] = reply.Options[dhcp.OptionIANA6]
+
+	return c.exchange6(conn, id, renew, dhcp.Reply6)
+}
+
+// Release6 releases an existing DHCPv6 lease.
+func (c *client) Release6(id ID, reply *dhcp.Packet6) error {
+	c.Lock()
+	defer c.Unlock()
+
+	log.Debugf("releasing IP %s", reply.Address())
+
+	conn, err := listen6(id.IfIndex)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	release, err := newPacket6(dhcp.Release6, id)
+	if err != nil {
+		return err
+	}
+	release.Options[dhcp.OptionServerID6] = reply.ServerID()
+	release.Options[dhcp.OptionIANA6] = reply.Options[dhcp.OptionIANA6]
+
+	_, err = c.exchange6(conn, id, release, dhcp.Reply6)
+	return err
+}
+
+// listen6 returns a socket on the DHCPv6 client port of the link local address of the interface
+func listen6(ifindex int) (*net.UDPConn, error) {
+	iface, err := net.InterfaceByIndex(ifindex)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range addrs {
+		if n, ok := a.(*net.IPNet); ok && n.IP.To4() == nil && n.IP.IsLinkLocalUnicast() {
+			return net.ListenUDP("udp6", &net.UDPAddr{IP: n.IP, Port: clientPort6, Zone: iface.Name})
+		}
+	}
+
+	return nil, fmt.Errorf("no link local address on interface %s", iface.Name)
+}
+
+// newPacket6 returns a message of the type from the client of the ID, with a new transaction ID
+func newPacket6(t uint8, id ID) (*dhcp.Packet6, error) {
+	duid, err := id.Duid.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &dhcp.Packet6{
+		Type: t,
+		Options: dhcp.Options6{
+			dhcp.OptionClientID6:    duid,
+			dhcp.OptionElapsedTime6: {0, 0},
+		},
+	}
+
+	if _, err = rand.Read(p.TransactionID[:]); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// exchange6 sends the message to the DHCPv6 servers on the link, and returns the first response
+// of the expected type to it, sending the message again until the client timeout if none arrives
+func (c *client) exchange6(conn *net.UDPConn, id ID, p *dhcp.Packet6, expected uint8) (*dhcp.Packet6, error) {
+	servers := &net.UDPAddr{IP: allServers6, Port: serverPort6, Zone: conn.LocalAddr().(*net.UDPAddr).Zone}
+
+	start := time.Now()
+	deadline := start.Add(c.timeout)
+	buf := make([]byte, 1500)
+
+	for time.Now().Before(deadline) {
+		// the elapsed time is in hundredths of a second; see https://tools.ietf.org/html/rfc3315#section-22.9
+		elapsed := make([]byte, 2)
+		binary.BigEndian.PutUint16(elapsed, uint16(time.Since(start)/(10*time.Millisecond)))
+		p.Options[dhcp.OptionElapsedTime6] = elapsed
+
+		b, err := p.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = conn.WriteToUDP(b, servers); err != nil {
+			return nil, err
+		}
+
+		wait := time.Now().Add(retransmitTimeout6)
+		if wait.After(deadline) {
+			wait = deadline
+		}
+		if err = conn.SetReadDeadline(wait); err != nil {
+			return nil, err
+		}
+
+		for {
+			n, _, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+					break
+				}
+				return nil, err
+			}
+
+			response, err := dhcp.NewPacket6(buf[:n])
+			if err != nil {
+				log.Debugf("ignoring DHCPv6 message: %s", err)
+				continue
+			}
+
+			if response.Type != expected || response.TransactionID != p.TransactionID {
+				continue
+			}
+
+			if code, message := response.StatusCode(); code != dhcp.StatusSuccess6 {
+				return nil, fmt.Errorf("DHCPv6 server returned status %d: %s", code, message)
+			}
+
+			if p.Type != dhcp.Release6 && response.Address() == nil {
+				return nil, fmt.Errorf("DHCPv6 server did not offer an address")
+			}
+
+			return response, nil
+		}
+	}
+
+	return nil, fmt.Errorf("timed out waiting for a DHCPv6 response on interface %d", id.IfIndex)
+}
@@ -0,0 +1,224 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dhcp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+)
+
+// DHCPv6 message types; see https://tools.ietf.org/html/rfc3315#section-5.3
+const (
+	Solicit6   uint8 = 1
+	Advertise6 uint8 = 2
+	Request6   uint8 = 3
+	Renew6     uint8 = 5
+	Reply6     uint8 = 7
+	Release6   uint8 = 8
+)
+
+// DHCPv6 options; see https://tools.ietf.org/html/rfc3315#section-24.3
+const (
+	OptionClientID6    uint16 = 1
+	OptionServerID6    uint16 = 2
+	OptionIANA6        uint16 = 3
+	OptionIAAddr6      uint16 = 5
+	OptionElapsedTime6 uint16 = 8
+	OptionStatusCode6  uint16 = 13
+)
+
+// StatusSuccess6 is the status code of a successful exchange; see https://tools.ietf.org/html/rfc3315#section-24.4
+const StatusSuccess6 uint16 = 0
+
+// infinity6 is the lifetime of an address that does not expire
+const infinity6 uint32 = 0xffffffff
+
+// ianaLen6 is the length of the fixed fields of an IA_NA option: the IAID, T1 and T2
+const ianaLen6 = 12
+
+// Options6 are the options of a DHCPv6 message, by option code. Only a single instance of each
+// option is kept, which is all a client leasing a single address needs.
+type Options6 map[uint16][]byte
+
+// Packet6 is a representation of a DHCPv6 message
+type Packet6 struct {
+	Type          uint8
+	TransactionID [3]byte
+	Options       Options6
+}
+
+// NewPacket6 parses a DHCPv6 message
+func NewPacket6(p []byte) (*Packet6, error) {
+	if len(p) < 4 {
+		return nil, fmt.Errorf("DHCPv6 message too short: %d bytes", len(p))
+	}
+
+	options, err := parseOptions6(p[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	packet := &Packet6{Type: p[0], Options: options}
+	copy(packet.TransactionID[:], p[1:4])
+	return packet, nil
+}
+
+func parseOptions6(b []byte) (Options6, error) {
+	options := make(Options6)
+	for len(b) > 0 {
+		if len(b) < 4 {
+			return nil, fmt.Errorf("truncated DHCPv6 option")
+		}
+
+		code := binary.BigEndian.Uint16(b)
+		length := int(binary.BigEndian.Uint16(b[2:]))
+		if len(b) < 4+length {
+			return nil, fmt.Errorf("truncated DHCPv6 option %d", code)
+		}
+
+		if _, ok := options[code]; !ok {
+			options[code] = b[4 : 4+length]
+		}
+		b = b[4+length:]
+	}
+
+	return options, nil
+}
+
+// MarshalBinary implements the BinaryMarshaler interface
+func (p *Packet6) MarshalBinary() ([]byte, error) {
+	b := append([]byte{p.Type}, p.TransactionID[:]...)
+
+	// options are written in order so the message is the same each time it is sent
+	codes := make([]int, 0, len(p.Options))
+	for code := range p.Options {
+		codes = append(codes, int(code))
+	}
+	sort.Ints(codes)
+
+	for _, code := range codes {
+		value := p.Options[uint16(code)]
+		if len(value) > 0xffff {
+			return nil, fmt.Errorf("DHCPv6 option %d too long: %d bytes", code, len(value))
+		}
+
+		b = append(b, byte(code>>8), byte(code), byte(len(value)>>8), byte(len(value)))
+		b = append(b, value...)
+	}
+
+	return b, nil
+}
+
+// ServerID returns the DUID of the server that sent the message
+func (p *Packet6) ServerID() []byte {
+	return p.Options[OptionServerID6]
+}
+
+// StatusCode returns the status of the message, or of its IA_NA if that is not successful
+func (p *Packet6) StatusCode() (uint16, string) {
+	code, message := status6(p.Options)
+	if code != StatusSuccess6 {
+		return code, message
+	}
+
+	if options := p.iaOptions(); options != nil {
+		return status6(options)
+	}
+
+	return code, message
+}
+
+// status6 returns the status carried by options, which is success if there is none
+func status6(options Options6) (uint16, string) {
+	b := options[OptionStatusCode6]
+	if len(b) < 2 {
+		return StatusSuccess6, ""
+	}
+
+	return binary.BigEndian.Uint16(b), string(b[2:])
+}
+
+// iaOptions returns the options nested in the IA_NA of the message
+func (p *Packet6) iaOptions() Options6 {
+	b := p.Options[OptionIANA6]
+	if len(b) < ianaLen6 {
+		return nil
+	}
+
+	options, err := parseOptions6(b[ianaLen6:])
+	if err != nil {
+		return nil
+	}
+
+	return options
+}
+
+// iaAddr returns the IA address option of the IA_NA of the message, which is the address and its
+// preferred and valid lifetimes
+func (p *Packet6) iaAddr() []byte {
+	b := p.iaOptions()[OptionIAAddr6]
+	if len(b) < net.IPv6len+8 {
+		return nil
+	}
+
+	return b
+}
+
+// Address returns the address leased to the client
+func (p *Packet6) Address() net.IP {
+	b := p.iaAddr()
+	if b == nil {
+		return nil
+	}
+
+	return net.IP(b[:net.IPv6len])
+}
+
+// LeaseTime returns the valid lifetime of the leased address, or zero if it does not expire
+func (p *Packet6) LeaseTime() time.Duration {
+	b := p.iaAddr()
+	if b == nil {
+		return 0
+	}
+
+	valid := binary.BigEndian.Uint32(b[net.IPv6len+4:])
+	if valid == infinity6 {
+		return 0
+	}
+
+	return time.Duration(valid) * time.Second
+}
+
+// RenewTime returns when the lease should be renewed, the T1 of the IA_NA or else half the lease
+// time, or zero if it need not be renewed
+func (p *Packet6) RenewTime() time.Duration {
+	b := p.Options[OptionIANA6]
+	if len(b) < ianaLen6 {
+		return 0
+	}
+
+	t1 := binary.BigEndian.Uint32(b[4:])
+	switch t1 {
+	case 0:
+		return p.LeaseTime() / 2
+	case infinity6:
+		return 0
+	}
+
+	return time.Duration(t1) * time.Second
+}
@@ -27,6 +27,7 @@ import (
 	log "github.com/Sirupsen/logrus"
 
 	"github.com/vmware/vic/lib/portlayer/network"
+	"github.com/vmware/vic/pkg/ip"
 	"github.com/vmware/vic/pkg/trace"
 
 	mdns "github.com/miekg/dns"
@@ -333,7 +334,7 @@ func (s *Server) HandleVIC(w mdns.ResponseWriter, r *mdns.Msg) (bool, error) {
 	}
 
 	log.Debugf("RemoteAddr: %s", clientIP)
-	ip := net.ParseIP(clientIP)
+	addr := net.ParseIP(clientIP)
 
 	var name string
 	var domain string
@@ -346,9 +347,9 @@ func (s *Server) HandleVIC(w mdns.ResponseWriter, r *mdns.Msg) (bool, error) {
 	}
 
 	// get the requesting container's endpoint
-	e := ctx.ContainerByAddr(ip)
+	e := ctx.ContainerByAddr(addr)
 	if e == nil {
-		return false, fmt.Errorf("Could not find requesting container with ip %s", ip)
+		return false, fmt.Errorf("Could not find requesting container with ip %s", addr)
 	}
 	scope := e.Scope()
 
@@ -369,22 +370,13 @@ func (s *Server) HandleVIC(w mdns.ResponseWriter, r *mdns.Msg) (bool, error) {
 	}
 
 	e = c.Endpoint(scope)
-	if e.IP().IsUnspecified() {
+	addrs := []net.IP{e.IP(), e.IP6()}
+	if ip.IsUnspecifiedIP(addrs[0]) && ip.IsUnspecifiedIP(addrs[1]) {
 		return false, fmt.Errorf("No ip for container %q", name)
 	}
 
-	// FIXME: Add AAAA when we support it
-	answer := []mdns.RR{
-		&mdns.A{
-			Hdr: mdns.RR_Header{
-				Name:   question.Name,
-				Rrtype: mdns.TypeA,
-				Class:  mdns.ClassINET,
-				Ttl:    uint32(DefaultTTL.Seconds()),
-			},
-			A: e.IP(),
-		},
-	}
+	// a container without an address of the requested family gets an empty answer
+	answer := addressRecords(question, addrs)
 
 	// Start crafting reply msg
 	m := &mdns.Msg{
@@ -442,6 +434,33 @@ func (s *Server) HandleVIC(w mdns.ResponseWriter, r *mdns.Msg) (bool, error) {
 	return true, nil
 }
 
+// addressRecords returns the A or AAAA records, as asked for by the question, for the addresses
+func addressRecords(question mdns.Question, addrs []net.IP) []mdns.RR {
+	var answer []mdns.RR
+
+	hdr := mdns.RR_Header{
+		Name:   question.Name,
+		Rrtype: question.Qtype,
+		Class:  mdns.ClassINET,
+		Ttl:    uint32(DefaultTTL.Seconds()),
+	}
+
+	for _, a := range addrs {
+		if ip.IsUnspecifiedIP(a) {
+			continue
+		}
+
+		switch {
+		case question.Qtype == mdns.TypeA && !ip.IsIPv6(a):
+			answer = append(answer, &mdns.A{Hdr: hdr, A: a})
+		case question.Qtype == mdns.TypeAAAA && ip.IsIPv6(a):
+			answer = append(answer, &mdns.AAAA{Hdr: hdr, AAAA: a})
+		}
+	}
+
+	return answer
+}
+
 // ServeDNS implements the handler interface
 func (s *Server) ServeDNS(w mdns.ResponseWriter, r *mdns.Msg) {
 	defer trace.End(trace.Begin(r.String()))
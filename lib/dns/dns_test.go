@@ -168,3 +168,31 @@ func TestVIC(t *testing.T) {
 	server.Stop()
 	server.Wait()
 }
+
+func TestAddressRecords(t *testing.T) {
+	addrs := []net.IP{net.ParseIP("172.16.0.2"), net.ParseIP("fd00::2")}
+
+	r := addressRecords(mdns.Question{Name: "foo.", Qtype: mdns.TypeA, Qclass: mdns.ClassINET}, addrs)
+	if len(r) != 1 || !r[0].(*mdns.A).A.Equal(addrs[0]) {
+		t.Fatalf("A => %v, want %s", r, addrs[0])
+	}
+
+	r = addressRecords(mdns.Question{Name: "foo.", Qtype: mdns.TypeAAAA, Qclass: mdns.ClassINET}, addrs)
+	if len(r) != 1 || !r[0].(*mdns.AAAA).AAAA.Equal(addrs[1]) {
+		t.Fatalf("AAAA => %v, want %s", r, addrs[1])
+	}
+	if r[0].Header().Rrtype != mdns.TypeAAAA || r[0].Header().Name != "foo." {
+		t.Fatalf("AAAA => header %+v", r[0].Header())
+	}
+
+	// no records of the other family
+	if r = addressRecords(mdns.Question{Name: "foo.", Qtype: mdns.TypeAAAA}, []net.IP{addrs[0], nil}); len(r) != 0 {
+		t.Fatalf("AAAA => %v, want none", r)
+	}
+	if r = addressRecords(mdns.Question{Name: "foo.", Qtype: mdns.TypeA}, []net.IP{net.IPv4zero, addrs[1]}); len(r) != 0 {
+		t.Fatalf("A => %v, want none", r)
+	}
+	if r = addressRecords(mdns.Question{Name: "foo.", Qtype: mdns.TypeTXT}, addrs); len(r) != 0 {
+		t.Fatalf("TXT => %v, want none", r)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"net/url"
 	"os"
 	"path"
@@ -29,11 +30,19 @@ import (
 	log "github.com/Sirupsen/logrus"
 
 	ddigest "github.com/docker/distribution/digest"
+	dmanifest "github.com/docker/distribution/manifest"
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema1"
+	"github.com/docker/distribution/manifest/schema2"
+	docker "github.com/docker/docker/image"
+	imagev1 "github.com/docker/docker/image/v1"
 	dlayer "github.com/docker/docker/layer"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/progress"
+	"github.com/docker/engine-api/types/container"
 	"github.com/docker/libtrust"
 
+	"github.com/vmware/vic/lib/apiservers/portlayer/models"
 	urlfetcher "github.com/vmware/vic/pkg/fetcher"
 	"github.com/vmware/vic/pkg/trace"
 )
@@ -61,6 +70,9 @@ type Manifest struct {
 	FSLayers []FSLayer `json:"fsLayers"`
 	History  []History `json:"history"`
 	// ignoring signatures
+
+	// Config is the image configuration blob of a schema 2 manifest
+	Config []byte `json:"-"`
 }
 
 // LearnRegistryURL returns the registry URL after making sure that it responds to queries
@@ -161,7 +173,7 @@ func FetchToken(ctx context.Context, options Options, url *url.URL, progressOutp
 		InsecureSkipVerify: options.InsecureSkipVerify,
 	})
 
-	token, err := fetcher.FetchAuthToken(url)
+	token, err := fetcher.FetchAuthToken(ctx, url)
 	if err != nil {
 		err := fmt.Errorf("FetchToken (%s) failed: %s", url, err)
 		log.Error(err)
@@ -200,7 +212,7 @@ func FetchImageBlob(ctx context.Context, options Options, image *ImageWithMeta,
 	ctx, cancel := context.WithTimeout(ctx, options.Timeout)
 	defer cancel()
 
-	imageFileName, err := fetcher.Fetch(ctx, url, true, progressOutput, image.String())
+	imageFileName, err := fetcher.Fetch(ctx, url, nil, true, progressOutput, image.String())
 	if err != nil {
 		return diffID, err
 	}
@@ -305,15 +317,124 @@ func FetchImageBlob(ctx context.Context, options Options, image *ImageWithMeta,
 	return diffID, nil
 }
 
-// FetchImageManifest fetches the image manifest file
+// manifestMediaTypes are the manifest formats requested from the registry, in order of preference
+var manifestMediaTypes = []string{
+	manifestlist.MediaTypeManifestList,
+	schema2.MediaTypeManifest,
+	schema1.MediaTypeSignedManifest,
+	schema1.MediaTypeManifest,
+}
+
+// FetchImageManifest fetches the image manifest file. Schema 2 manifests are converted to
+// the schema 1 layout with the image configuration attached, and manifest lists are
+// resolved to the linux/amd64 image.
 func FetchImageManifest(ctx context.Context, options Options, progressOutput progress.Output) (*Manifest, error) {
 	defer trace.End(trace.Begin(options.Image + "/" + options.Tag))
 
+	content, err := fetchManifest(ctx, options, options.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	versioned := dmanifest.Versioned{}
+	if err = json.Unmarshal(content, &versioned); err != nil {
+		return nil, err
+	}
+
+	var manifest *Manifest
+	var digest string
+
+	switch {
+	case versioned.SchemaVersion == 1:
+		manifest = &Manifest{}
+		if err = json.Unmarshal(content, manifest); err != nil {
+			return nil, err
+		}
+
+		if manifest.Name != options.Image {
+			return nil, fmt.Errorf("name doesn't match what was requested, expected: %s, downloaded: %s", options.Image, manifest.Name)
+		}
+
+		// manifests fetched by digest are verified against the digest instead
+		if _, derr := ddigest.ParseDigest(options.Tag); derr != nil && manifest.Tag != options.Tag {
+			return nil, fmt.Errorf("tag doesn't match what was requested, expected: %s, downloaded: %s", options.Tag, manifest.Tag)
+		}
+
+		if digest, err = getManifestDigest(content); err != nil {
+			return nil, err
+		}
+
+	case versioned.MediaType == manifestlist.MediaTypeManifestList:
+		// the image is known by the digest of the list
+		digest = string(ddigest.FromBytes(content))
+
+		list := manifestlist.ManifestList{}
+		if err = json.Unmarshal(content, &list); err != nil {
+			return nil, err
+		}
+
+		var platform string
+		for _, m := range list.Manifests {
+			if m.Platform.OS == "linux" && m.Platform.Architecture == "amd64" {
+				platform = string(m.Digest)
+				break
+			}
+		}
+		if platform == "" {
+			return nil, fmt.Errorf("no linux/amd64 image found in manifest list of %s:%s", options.Image, options.Tag)
+		}
+
+		if content, err = fetchManifest(ctx, options, platform); err != nil {
+			return nil, err
+		}
+		if d := string(ddigest.FromBytes(content)); d != platform {
+			return nil, fmt.Errorf("manifest digest mismatch, expected: %s, downloaded: %s", platform, d)
+		}
+
+		if manifest, err = fromSchema2(ctx, options, content); err != nil {
+			return nil, err
+		}
+
+	case versioned.MediaType == schema2.MediaTypeManifest:
+		digest = string(ddigest.FromBytes(content))
+
+		if manifest, err = fromSchema2(ctx, options, content); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported manifest format %q (schema version %d)", versioned.MediaType, versioned.SchemaVersion)
+	}
+
+	if _, derr := ddigest.ParseDigest(options.Tag); derr == nil && digest != options.Tag {
+		return nil, fmt.Errorf("manifest digest mismatch, expected: %s, downloaded: %s", options.Tag, digest)
+	}
+
+	manifest.Digest = digest
+
+	// Ensure the parent directory exists
+	destination := DestinationDirectory(options)
+	err = os.MkdirAll(destination, 0755) /* #nosec */
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the manifest alongside the layers
+	err = ioutil.WriteFile(path.Join(destination, "manifest.json"), content, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// fetchManifest fetches the manifest with the given tag or digest in any of the formats imagec understands
+func fetchManifest(ctx context.Context, options Options, reference string) ([]byte, error) {
 	url, err := url.Parse(options.Registry)
 	if err != nil {
 		return nil, err
 	}
-	url.Path = path.Join(url.Path, options.Image, "manifests", options.Tag)
+	url.Path = path.Join(url.Path, options.Image, "manifests", reference)
 
 	log.Debugf("URL: %s", url)
 
@@ -325,59 +446,151 @@ func FetchImageManifest(ctx context.Context, options Options, progressOutput pro
 		InsecureSkipVerify: options.InsecureSkipVerify,
 	})
 
-	manifestFileName, err := fetcher.Fetch(ctx, url, true, progressOutput)
+	reqHdrs := &http.Header{"Accept": manifestMediaTypes}
+	content, err := fetcher.Fetch(ctx, url, reqHdrs, false, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	// Cleanup function for the error case
-	defer func() {
-		if err != nil {
-			os.Remove(manifestFileName)
-		}
-	}()
+	return []byte(content), nil
+}
 
-	// Read the entire file into []byte for json.Unmarshal
-	content, err := ioutil.ReadFile(manifestFileName)
+// FetchImageConfig fetches the image configuration blob of a schema 2 manifest
+func FetchImageConfig(ctx context.Context, options Options, digest string) ([]byte, error) {
+	defer trace.End(trace.Begin(options.Image + "/" + digest))
+
+	url, err := url.Parse(options.Registry)
 	if err != nil {
 		return nil, err
 	}
+	url.Path = path.Join(url.Path, options.Image, "blobs", digest)
 
-	manifest := &Manifest{}
+	fetcher := urlfetcher.NewURLFetcher(urlfetcher.Options{
+		Timeout:            options.Timeout,
+		Username:           options.Username,
+		Password:           options.Password,
+		Token:              options.Token,
+		InsecureSkipVerify: options.InsecureSkipVerify,
+	})
 
-	err = json.Unmarshal(content, manifest)
+	content, err := fetcher.Fetch(ctx, url, nil, false, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	if manifest.Name != options.Image {
-		return nil, fmt.Errorf("name doesn't match what was requested, expected: %s, downloaded: %s", options.Image, manifest.Name)
+	if d := string(ddigest.FromBytes([]byte(content))); d != digest {
+		return nil, fmt.Errorf("Failed to validate image config checksum. Expected %s got %s", digest, d)
 	}
 
-	if manifest.Tag != options.Tag {
-		return nil, fmt.Errorf("tag doesn't match what was requested, expected: %s, downloaded: %s", options.Tag, manifest.Tag)
+	return []byte(content), nil
+}
+
+// v1Layers returns the layers described by an image configuration, oldest first, with their
+// v1 metadata. The configuration has no v1 IDs, so each layer is identified by its chain ID,
+// which makes the IDs stable across pulls and shared between images built on the same layers.
+func v1Layers(img *docker.Image) ([]*ImageWithMeta, error) {
+	if img.RootFS == nil {
+		return nil, fmt.Errorf("image config has no rootfs")
 	}
 
-	digest, err := getManifestDigest(content)
-	if err != nil {
+	// the history entries that created layers, oldest first
+	var history []docker.History
+	for _, h := range img.History {
+		if !h.EmptyLayer {
+			history = append(history, h)
+		}
+	}
+
+	diffIDs := img.RootFS.DiffIDs
+	layers := make([]*ImageWithMeta, len(diffIDs))
+
+	var parent string
+	for i := range diffIDs {
+		id := ddigest.Digest(dlayer.CreateChainID(diffIDs[:i+1])).Hex()
+
+		var v1 []byte
+		var err error
+		if i == len(diffIDs)-1 {
+			v1, err = imagev1.MakeV1ConfigFromConfig(img, id, parent, false)
+		} else {
+			h := docker.History{}
+			if i < len(history) {
+				h = history[i]
+			}
+			v1, err = json.Marshal(docker.V1Image{
+				ID:              id,
+				Parent:          parent,
+				Created:         h.Created,
+				Author:          h.Author,
+				Comment:         h.Comment,
+				ContainerConfig: container.Config{Cmd: []string{h.CreatedBy}},
+			})
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		layerParent := "scratch"
+		if parent != "" {
+			layerParent = parent
+		}
+
+		layers[i] = &ImageWithMeta{
+			Image: &models.Image{
+				ID:     id,
+				Parent: &layerParent,
+			},
+			DiffID: string(diffIDs[i]),
+			Meta:   string(v1),
+		}
+
+		parent = id
+	}
+
+	return layers, nil
+}
+
+// fromSchema2 converts a schema 2 manifest to the schema 1 layout the layers are pulled with
+func fromSchema2(ctx context.Context, options Options, content []byte) (*Manifest, error) {
+	m := schema2.Manifest{}
+	if err := json.Unmarshal(content, &m); err != nil {
 		return nil, err
 	}
 
-	manifest.Digest = digest
+	config, err := FetchImageConfig(ctx, options, string(m.Config.Digest))
+	if err != nil {
+		return nil, err
+	}
 
-	// Ensure the parent directory exists
-	destination := DestinationDirectory(options)
-	err = os.MkdirAll(destination, 0755) /* #nosec */
+	img, err := docker.NewFromJSON(config)
 	if err != nil {
 		return nil, err
 	}
 
-	// Move(rename) the temporary file to its final destination
-	err = os.Rename(string(manifestFileName), path.Join(destination, "manifest.json"))
+	layers, err := v1Layers(img)
 	if err != nil {
 		return nil, err
 	}
 
+	n := len(m.Layers)
+	if len(layers) != n {
+		return nil, fmt.Errorf("image config of %s:%s does not match its %d layers", options.Image, options.Tag, n)
+	}
+
+	manifest := &Manifest{
+		Name:     options.Image,
+		Tag:      options.Tag,
+		FSLayers: make([]FSLayer, n),
+		History:  make([]History, n),
+		Config:   config,
+	}
+
+	// schema 1 lists the layers newest first
+	for i, layer := range layers {
+		manifest.FSLayers[n-1-i] = FSLayer{BlobSum: string(m.Layers[i].Digest)}
+		manifest.History[n-1-i] = History{V1Compatibility: layer.Meta}
+	}
+
 	return manifest, nil
 }
 
@@ -23,6 +23,7 @@ import (
 	"github.com/vmware/vic/lib/apiservers/engine/backends/cache"
 	"github.com/vmware/vic/pkg/trace"
 
+	ddigest "github.com/docker/distribution/digest"
 	"github.com/docker/docker/distribution/xfer"
 	"github.com/docker/docker/pkg/progress"
 	"github.com/docker/docker/pkg/streamformatter"
@@ -199,10 +200,15 @@ func (ldm *LayerDownloader) DownloadLayers(ctx context.Context, ic *ImageC) erro
 
 	progress.Message(progressOutput, "", "Digest: "+ic.ImageManifest.Digest)
 
+	name := ic.Image + ":" + ic.Tag
+	if _, err := ddigest.ParseDigest(ic.Tag); err == nil {
+		name = ic.Image + "@" + ic.Tag
+	}
+
 	if layerCount > 0 {
-		progress.Message(progressOutput, "", "Status: Downloaded newer image for "+ic.Image+":"+ic.Tag)
+		progress.Message(progressOutput, "", "Status: Downloaded newer image for "+name)
 	} else {
-		progress.Message(progressOutput, "", "Status: Image is up to date for "+ic.Image+":"+ic.Tag)
+		progress.Message(progressOutput, "", "Status: Image is up to date for "+name)
 	}
 
 	return nil
@@ -29,6 +29,7 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 
+	ddigest "github.com/docker/distribution/digest"
 	docker "github.com/docker/docker/image"
 	dockerLayer "github.com/docker/docker/layer"
 	"github.com/docker/docker/pkg/ioutils"
@@ -155,6 +156,10 @@ func (ic *ImageC) ParseReference() error {
 		if tagged, ok := ref.(reference.NamedTagged); ok {
 			ic.Tag = tagged.Tag()
 		}
+		// manifests are fetched by digest the same way as by tag
+		if canonical, ok := ref.(reference.Canonical); ok {
+			ic.Tag = canonical.Digest().String()
+		}
 	}
 
 	ic.Registry = DefaultDockerURL
@@ -343,6 +348,33 @@ func (ic *ImageC) CreateImageConfig(images []*ImageWithMeta) (metadata.ImageConf
 	}
 
 	manifest := ic.ImageManifest
+
+	var imageConfig metadata.ImageConfig
+	var err error
+	if manifest.Config != nil {
+		imageConfig, err = ImageConfigFromBlob(manifest.Config, images)
+	} else {
+		imageConfig, err = ImageConfigFromLayers(images)
+	}
+	if err != nil {
+		return metadata.ImageConfig{}, err
+	}
+
+	imageConfig.Digests = []string{manifest.Digest}
+	if _, err := ddigest.ParseDigest(ic.Tag); err != nil {
+		imageConfig.Tags = []string{ic.Tag}
+	}
+	imageConfig.Name = manifest.Name
+	imageConfig.Reference = ic.Reference
+
+	return imageConfig, nil
+}
+
+// ImageConfigFromLayers calculates the image ID and metadata of the image composed of the
+// supplied layers, leaf first. Naming the image is left to the caller.
+func ImageConfigFromLayers(images []*ImageWithMeta) (metadata.ImageConfig, error) {
+	imageLayer := images[0]
+
 	image := docker.V1Image{}
 	rootFS := docker.NewRootFS()
 	history := make([]docker.History, 0, len(images))
@@ -400,19 +432,87 @@ func (ic *ImageC) CreateImageConfig(images []*ImageWithMeta) (metadata.ImageConf
 	imageConfig := metadata.ImageConfig{
 		V1Image: result.V1Image,
 		ImageID: sum,
-		// TODO: this will change when issue 1186 is
-		// implemented -- only populate the digests when pulled by digest
-		Digests:   []string{manifest.Digest},
-		Tags:      []string{ic.Tag},
-		Name:      manifest.Name,
-		DiffIDs:   diffIDs,
-		History:   history,
-		Reference: ic.Reference,
+		DiffIDs: diffIDs,
+		History: history,
+	}
+
+	if imageConfig.Healthcheck, err = healthcheckFromJSON([]byte(imageLayer.Meta)); err != nil {
+		return metadata.ImageConfig{}, err
 	}
 
 	return imageConfig, nil
 }
 
+// ImageConfigFromBlob builds the image metadata from the configuration blob of a schema 2
+// manifest. The image ID is the digest of the blob, so it matches the ID the registry and
+// docker report for the image.
+func ImageConfigFromBlob(config []byte, images []*ImageWithMeta) (metadata.ImageConfig, error) {
+	image, err := docker.NewFromJSON(config)
+	if err != nil {
+		return metadata.ImageConfig{}, fmt.Errorf("Failed to unmarshall image config: %s", err)
+	}
+
+	if image.RootFS == nil || len(image.RootFS.DiffIDs) != len(images) {
+		return metadata.ImageConfig{}, fmt.Errorf("Image config does not match the %d downloaded layers", len(images))
+	}
+
+	diffIDs := make(map[string]string)
+	var size int64
+
+	// the rootfs lists the layers oldest first
+	for i, diffID := range image.RootFS.DiffIDs {
+		layer := images[len(images)-1-i]
+		if string(diffID) != layer.DiffID {
+			return metadata.ImageConfig{}, fmt.Errorf("Layer %s has diffID %s, image config expects %s", layer.ID, layer.DiffID, diffID)
+		}
+		diffIDs[layer.DiffID] = layer.ID
+		size += layer.Size
+	}
+
+	sum := fmt.Sprintf("%x", sha256.Sum256(config))
+	log.Infof("Image ID: sha256:%s", sum)
+
+	v1 := image.V1Image
+	v1.ID = images[0].ID
+	v1.Parent = ""
+	if len(images) > 1 {
+		v1.Parent = images[1].ID
+	}
+	v1.Size = size
+
+	healthcheck, err := healthcheckFromJSON(config)
+	if err != nil {
+		return metadata.ImageConfig{}, err
+	}
+
+	return metadata.ImageConfig{
+		V1Image:     v1,
+		ImageID:     sum,
+		DiffIDs:     diffIDs,
+		History:     image.History,
+		Healthcheck: healthcheck,
+	}, nil
+}
+
+// healthcheckFromJSON returns the healthcheck in the container config of an image config or
+// layer metadata, if any
+func healthcheckFromJSON(config []byte) (*metadata.HealthConfig, error) {
+	var image struct {
+		Config *struct {
+			Healthcheck *metadata.HealthConfig
+		} `json:"config"`
+	}
+
+	if err := json.Unmarshal(config, &image); err != nil {
+		return nil, fmt.Errorf("Failed to unmarshall image healthcheck: %s", err)
+	}
+	if image.Config == nil {
+		return nil, nil
+	}
+
+	return image.Config.Healthcheck, nil
+}
+
 // PullImage pulls an image from docker hub
 func (ic *ImageC) PullImage() error {
 
@@ -18,7 +18,9 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -28,10 +30,21 @@ import (
 	"path"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/docker/distribution"
+	ddigest "github.com/docker/distribution/digest"
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+	docker "github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/streamformatter"
+	"github.com/docker/docker/reference"
+	"github.com/docker/engine-api/types/container"
+	"github.com/docker/engine-api/types/registry"
+	"github.com/stretchr/testify/assert"
 
 	"github.com/vmware/vic/lib/apiservers/portlayer/models"
+	"github.com/vmware/vic/lib/metadata"
 	urlfetcher "github.com/vmware/vic/pkg/fetcher"
 )
 
@@ -268,6 +281,130 @@ func TestFetchImageManifest(t *testing.T) {
 	}
 }
 
+func TestFetchImageManifestSchema2(t *testing.T) {
+
+	options := Options{
+		Outstream: os.Stdout,
+	}
+
+	ic := NewImageC(options, streamformatter.NewJSONStreamFormatter())
+
+	config := []byte(`{"architecture":"amd64","os":"linux","config":{"Cmd":["sh"]},` +
+		`"rootfs":{"type":"layers","diff_ids":["` + DigestSHA256EmptyData + `","` + DigestSHA256LayerContent + `"]},` +
+		`"history":[{"created_by":"/bin/sh -c #(nop) ADD file:a in /"},{"created_by":"/bin/sh -c #(nop) CMD [\"sh\"]","empty_layer":true},{"created_by":"/bin/sh -c touch b"}]}`)
+	configDigest := fmt.Sprintf("sha256:%x", sha256.Sum256(config))
+
+	m := schema2.Manifest{
+		Versioned: schema2.SchemaVersion,
+		Config:    distribution.Descriptor{MediaType: schema2.MediaTypeConfig, Digest: ddigest.Digest(configDigest)},
+		Layers: []distribution.Descriptor{
+			{MediaType: schema2.MediaTypeLayer, Digest: "sha256:0001"},
+			{MediaType: schema2.MediaTypeLayer, Digest: "sha256:0002"},
+		},
+	}
+	manifest, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestDigest := fmt.Sprintf("sha256:%x", sha256.Sum256(manifest))
+
+	list, err := json.Marshal(manifestlist.ManifestList{
+		Versioned: manifestlist.SchemaVersion,
+		Manifests: []manifestlist.ManifestDescriptor{
+			{
+				Descriptor: distribution.Descriptor{Digest: "sha256:0003"},
+				Platform:   manifestlist.PlatformSpec{Architecture: "arm", OS: "linux"},
+			},
+			{
+				Descriptor: distribution.Descriptor{Digest: ddigest.Digest(manifestDigest)},
+				Platform:   manifestlist.PlatformSpec{Architecture: "amd64", OS: "linux"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case strings.HasSuffix(r.URL.Path, "/manifests/"+Tag):
+				if !strings.Contains(strings.Join(r.Header["Accept"], ","), manifestlist.MediaTypeManifestList) {
+					t.Errorf("Manifest list not accepted: %s", r.Header["Accept"])
+				}
+				w.Header().Set("Content-Type", manifestlist.MediaTypeManifestList)
+				w.Write(list)
+			case strings.HasSuffix(r.URL.Path, "/manifests/"+manifestDigest):
+				w.Header().Set("Content-Type", schema2.MediaTypeManifest)
+				w.Write(manifest)
+			case strings.HasSuffix(r.URL.Path, "/blobs/"+configDigest):
+				w.Write(config)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+	defer s.Close()
+
+	ic.Options.Registry = s.URL
+	ic.Options.Image = Image
+	ic.Options.Tag = Tag
+	ic.Options.Timeout = DefaultHTTPTimeout
+	ic.Options.Token = &urlfetcher.Token{Token: OAuthToken}
+
+	dir, err := ioutil.TempDir("", "imagec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ic.Options.Destination = dir
+
+	ic.ImageManifest, err = FetchImageManifest(context.TODO(), ic.Options, ic.progressOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the image is identified by the digest of the list
+	assert.Equal(t, fmt.Sprintf("sha256:%x", sha256.Sum256(list)), ic.ImageManifest.Digest)
+	assert.Equal(t, config, ic.ImageManifest.Config)
+
+	// layers are converted to schema 1 order, newest first
+	assert.Equal(t, "sha256:0002", ic.ImageManifest.FSLayers[0].BlobSum)
+	assert.Equal(t, "sha256:0001", ic.ImageManifest.FSLayers[1].BlobSum)
+
+	layers, err := ic.LayersToDownload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, layers, 2) {
+		return
+	}
+
+	base := ddigest.Digest(DigestSHA256EmptyData).Hex()
+	assert.Equal(t, base, layers[1].ID)
+	assert.Equal(t, base, *layers[0].Parent)
+	assert.Contains(t, layers[1].Meta, "ADD file:a in /")
+
+	layers[0].DiffID = DigestSHA256LayerContent
+	layers[1].DiffID = DigestSHA256EmptyData
+
+	imageConfig, err := ImageConfigFromBlob(config, layers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, configDigest, "sha256:"+imageConfig.ImageID)
+	assert.Equal(t, layers[0].ID, imageConfig.ID)
+	assert.Equal(t, base, imageConfig.Parent)
+	assert.Equal(t, []string{"sh"}, []string(imageConfig.Config.Cmd))
+	assert.Len(t, imageConfig.History, 3)
+
+	// layers that don't match the config are rejected
+	layers[0].DiffID = DigestSHA256EmptyData
+	if _, err = ImageConfigFromBlob(config, layers); err == nil {
+		t.Errorf("Mismatched diffIDs were accepted")
+	}
+}
+
 func TestFetchImageBlob(t *testing.T) {
 
 	options := Options{
@@ -550,3 +687,251 @@ func TestFetchScenarios(t *testing.T) {
 		t.Errorf(err.Error())
 	}
 }
+
+func TestUploadBlob(t *testing.T) {
+	content := strings.Repeat(LayerContent, 1024)
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(content)))
+
+	var uploaded bytes.Buffer
+	var completed string
+
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer "+OAuthToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			switch r.Method {
+			case "POST":
+				// a relative location must be resolved against the registry
+				w.Header().Set("Location", "/v2/"+Image+"/blobs/uploads/1")
+				w.WriteHeader(http.StatusAccepted)
+			case "PATCH":
+				if r.Header.Get("Content-Range") != fmt.Sprintf("%d-%d", uploaded.Len(), uploaded.Len()+int(r.ContentLength)-1) {
+					w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+					return
+				}
+				io.Copy(&uploaded, r.Body)
+				w.Header().Set("Location", r.URL.String())
+				w.WriteHeader(http.StatusAccepted)
+			case "PUT":
+				completed = r.URL.Query().Get("digest")
+				w.WriteHeader(http.StatusCreated)
+			}
+		}))
+	defer s.Close()
+
+	options := Options{
+		Registry: s.URL + "/v2/",
+		Image:    Image,
+		Timeout:  DefaultHTTPTimeout,
+		Token:    &urlfetcher.Token{Token: OAuthToken},
+	}
+
+	err := UploadBlob(context.TODO(), options, strings.NewReader(content), int64(len(content)), digest, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if uploaded.String() != content {
+		t.Errorf("uploaded %d bytes that differ from the %d byte blob", uploaded.Len(), len(content))
+	}
+	if completed != digest {
+		t.Errorf("upload completed with digest %q, expected %q", completed, digest)
+	}
+}
+
+func TestSaveImages(t *testing.T) {
+
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte(LayerContent))
+		}))
+	defer s.Close()
+
+	base, leaf := "scratch", LayerID
+	parent := "09a5baea69e9c781d64df5366c36492d53d507048035abd68632264dc23a1edb"
+	LayerCache().Add(&ImageWithMeta{Image: &models.Image{ID: parent, Parent: &base}, Meta: "{}"})
+	LayerCache().Add(&ImageWithMeta{Image: &models.Image{ID: leaf, Parent: &parent}, Meta: LayerHistory})
+	defer LayerCache().Remove(parent)
+	defer LayerCache().Remove(leaf)
+
+	imageConfig := &metadata.ImageConfig{ImageID: "abc"}
+	imageConfig.ID = leaf
+
+	ref, err := reference.ParseNamed("busybox:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the same image twice only writes its layers once
+	images := []ArchivedImage{
+		{Image: imageConfig, Refs: []reference.NamedTagged{ref.(reference.NamedTagged)}},
+		{Image: imageConfig},
+	}
+
+	var buf bytes.Buffer
+	if err = SaveImages(s.URL[7:], Storename, images, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	files := make(map[string]string)
+	order := make(map[string]int)
+	tr := tar.NewReader(&buf)
+	for i := 0; ; i++ {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := files[hdr.Name]; ok {
+			t.Errorf("%s written more than once", hdr.Name)
+		}
+		content, _ := ioutil.ReadAll(tr)
+		files[hdr.Name] = string(content)
+		order[hdr.Name] = i
+	}
+
+	// the archive can be loaded as a stream
+	assert.True(t, order["manifest.json"] < order[parent+"/layer.tar"], "manifest.json written after the layers")
+	assert.True(t, order[parent+"/layer.tar"] < order[leaf+"/layer.tar"], "layer written before its parent")
+
+	assert.Equal(t, LayerContent, files[leaf+"/layer.tar"])
+	assert.Equal(t, LayerHistory, files[leaf+"/json"])
+	assert.Equal(t, "1.0", files[parent+"/VERSION"])
+	assert.Equal(t, `{"busybox":{"latest":"`+leaf+`"}}`, files["repositories"])
+
+	var manifest []manifestItem
+	if err = json.Unmarshal([]byte(files["manifest.json"]), &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, manifest, 2) {
+		return
+	}
+	assert.Equal(t, []string{"busybox:latest"}, manifest[0].RepoTags)
+	assert.Equal(t, []string{parent + "/layer.tar", leaf + "/layer.tar"}, manifest[0].Layers)
+
+	// the config lists the diffIDs of the exported layers
+	config, err := docker.NewFromJSON([]byte(files[manifest[0].Config]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(LayerContent))), string(config.RootFS.DiffIDs[1]))
+}
+
+func TestImageHealthcheck(t *testing.T) {
+	config := []byte(`{"config":{"Cmd":["sh"],"Healthcheck":{"Test":["CMD-SHELL","true"],"Interval":5000000000,"Retries":2}}}`)
+
+	healthcheck, err := healthcheckFromJSON(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &metadata.HealthConfig{
+		Test:     []string{"CMD-SHELL", "true"},
+		Interval: 5 * time.Second,
+		Retries:  2,
+	}
+	assert.Equal(t, expected, healthcheck)
+
+	healthcheck, err = healthcheckFromJSON([]byte(`{"config":{"Cmd":["sh"]}}`))
+	assert.NoError(t, err)
+	assert.Nil(t, healthcheck)
+
+	// the healthcheck survives the round trip through the image config
+	imageConfig := &metadata.ImageConfig{Healthcheck: expected}
+	imageConfig.Config = &container.Config{Cmd: []string{"sh"}}
+
+	raw, err := imageConfigJSON(imageConfig, docker.NewRootFS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	healthcheck, err = healthcheckFromJSON(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, healthcheck)
+
+	image, err := docker.NewFromJSON(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"sh"}, []string(image.Config.Cmd))
+}
+
+func TestSearchRegistryV1(t *testing.T) {
+	s := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/search" || r.URL.Query().Get("q") != "photon" {
+				http.NotFound(w, r)
+				return
+			}
+
+			results := registry.SearchResults{
+				Query: "photon",
+				Results: []registry.SearchResult{
+					{Name: "library/photon", IsOfficial: true, StarCount: 10},
+					{Name: "someone/photon"},
+				},
+			}
+			json.NewEncoder(w).Encode(&results)
+		}))
+	defer s.Close()
+
+	options := Options{
+		Registry:          s.URL[7:],
+		Timeout:           10 * time.Second,
+		InsecureAllowHTTP: true,
+	}
+
+	results, err := SearchRegistry(context.Background(), options, "photon", DefaultSearchLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, results, 2)
+	assert.Equal(t, "library/photon", results[0].Name)
+	assert.True(t, results[0].IsOfficial)
+
+	results, err = SearchRegistry(context.Background(), options, "photon", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, results, 1)
+}
+
+func TestSearchRegistryCatalog(t *testing.T) {
+	var s *httptest.Server
+	s = httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/token":
+				json.NewEncoder(w).Encode(&urlfetcher.Token{Token: OAuthToken})
+
+			case "/v2/_catalog":
+				if r.Header.Get("Authorization") != "Bearer "+OAuthToken {
+					w.Header().Set("www-authenticate",
+						fmt.Sprintf("Bearer realm=\"%s/token\",service=\"registry\",scope=\"registry:catalog:*\"", s.URL))
+					http.Error(w, "You shall not pass", http.StatusUnauthorized)
+					return
+				}
+				w.Write([]byte(`{"repositories":["library/busybox","library/photon","test/photon-dev"]}`))
+
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+	defer s.Close()
+
+	options := Options{
+		Registry:          s.URL[7:],
+		Timeout:           10 * time.Second,
+		InsecureAllowHTTP: true,
+	}
+
+	results, err := SearchRegistry(context.Background(), options, "photon", DefaultSearchLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []registry.SearchResult{{Name: "library/photon"}, {Name: "test/photon-dev"}}, results)
+}
@@ -0,0 +1,116 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imagec
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	docker "github.com/docker/docker/image"
+	dockerLayer "github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/stringid"
+
+	"github.com/vmware/vic/lib/apiservers/portlayer/models"
+	"github.com/vmware/vic/lib/metadata"
+	"github.com/vmware/vic/pkg/trace"
+)
+
+// ImportImage writes the filesystem tarball read from r to the image store as a single layer
+// image on top of scratch. The configuration of the image is taken from image. The image is
+// added to the layer cache but naming and caching the image is left to the caller.
+func ImportImage(host, storename string, r io.Reader, image docker.V1Image) (*metadata.ImageConfig, error) {
+	defer trace.End(trace.Begin(storename))
+
+	decompressed, err := archive.DecompressStream(r)
+	if err != nil {
+		return nil, err
+	}
+	defer decompressed.Close()
+
+	// the layer is staged so that its diffID is known before it is written to the store
+	f, err := ioutil.TempFile("", "imagec-import-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, h), decompressed)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read image: %s", err)
+	}
+	if _, err = f.Seek(0, 0); err != nil {
+		return nil, err
+	}
+
+	diffID := dockerLayer.DiffID(fmt.Sprintf("sha256:%x", h.Sum(nil)))
+
+	image.ID = stringid.GenerateRandomID()
+	image.Parent = ""
+
+	meta, err := json.Marshal(image)
+	if err != nil {
+		return nil, err
+	}
+
+	parent := "scratch"
+	layer := &ImageWithMeta{
+		Image: &models.Image{
+			ID:     image.ID,
+			Parent: &parent,
+			Store:  storename,
+		},
+		DiffID: string(diffID),
+		Layer:  FSLayer{BlobSum: string(diffID)},
+		Meta:   string(meta),
+		Size:   size,
+	}
+
+	if err = WriteImage(host, layer, f); err != nil {
+		return nil, fmt.Errorf("Failed to write to image store: %s", err)
+	}
+	LayerCache().Commit(layer)
+
+	rootFS := docker.NewRootFS()
+	rootFS.Append(diffID)
+
+	image.ID = ""
+	config, err := (&docker.Image{
+		V1Image: image,
+		RootFS:  rootFS,
+		History: []docker.History{
+			{
+				Created: image.Created,
+				Comment: image.Comment,
+			},
+		},
+	}).MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	imageConfig, err := ImageConfigFromBlob(config, []*ImageWithMeta{layer})
+	if err != nil {
+		return nil, err
+	}
+
+	return &imageConfig, nil
+}
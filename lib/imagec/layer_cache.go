@@ -134,6 +134,30 @@ func (lc *LCache) Get(id string) (*ImageWithMeta, error) {
 	return layer, nil
 }
 
+// Chain returns the cached layer with the given id followed by each of its ancestors, up to
+// but excluding scratch
+func (lc *LCache) Chain(id string) ([]*ImageWithMeta, error) {
+	defer trace.End(trace.Begin(id))
+	lc.m.RLock()
+	defer lc.m.RUnlock()
+
+	var layers []*ImageWithMeta
+	for id != "" && id != "scratch" {
+		layer, ok := lc.layers[id]
+		if !ok {
+			return nil, LayerNotFoundError{}
+		}
+		layers = append(layers, layer)
+
+		id = ""
+		if layer.Parent != nil {
+			id = *layer.Parent
+		}
+	}
+
+	return layers, nil
+}
+
 // Save will persist the image cache to the portlayer k/v store
 func (lc *LCache) Save() error {
 	defer trace.End(trace.Begin(""))
@@ -0,0 +1,172 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imagec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/docker/engine-api/types/registry"
+
+	urlfetcher "github.com/vmware/vic/pkg/fetcher"
+	"github.com/vmware/vic/pkg/trace"
+)
+
+const (
+	// DefaultIndexURL is the host of the Docker Hub search index
+	DefaultIndexURL = "index.docker.io"
+
+	// DefaultSearchLimit is the number of results returned by a search, as for docker
+	DefaultSearchLimit = 25
+
+	// catalogPageSize is the number of repositories requested from a v2 catalog. Only the
+	// first page of the catalog is searched.
+	catalogPageSize = 1000
+)
+
+// SearchRegistry searches the registry in options.Registry, a hostname with an optional port,
+// for repositories matching term. The v1 search endpoint is used where the registry provides
+// it, otherwise the repository names in the v2 catalog are matched against term. The search
+// ends early if ctx is cancelled.
+func SearchRegistry(ctx context.Context, options Options, term string, limit int) ([]registry.SearchResult, error) {
+	defer trace.End(trace.Begin(options.Registry + "/" + term))
+
+	scheme := searchScheme(ctx, options)
+
+	results, err := searchV1(ctx, options, scheme, term, limit)
+	if err == nil || ctx.Err() != nil {
+		return results, err
+	}
+	log.Debugf("v1 search of %s failed, searching its catalog: %s", options.Registry, err)
+
+	return searchCatalog(ctx, options, scheme, term, limit)
+}
+
+// searchScheme returns the scheme to search the registry with. https is used unless the
+// registry is insecure and does not answer over https.
+func searchScheme(ctx context.Context, options Options) string {
+	if !options.InsecureAllowHTTP {
+		return "https"
+	}
+
+	fetcher := urlfetcher.NewURLFetcher(urlfetcher.Options{
+		Timeout:            options.Timeout,
+		InsecureSkipVerify: true,
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+
+	// any http response shows the registry is served over https
+	_, err := fetcher.Exists(ctx, &url.URL{Scheme: "https", Host: options.Registry, Path: "/v2/"})
+	if err == nil || fetcher.IsStatusUnauthorized() {
+		return "https"
+	}
+
+	log.Debugf("Falling back to http scheme for %s", options.Registry)
+	return "http"
+}
+
+// searchV1 queries the v1 search endpoint of the registry
+func searchV1(ctx context.Context, options Options, scheme, term string, limit int) ([]registry.SearchResult, error) {
+	u := &url.URL{Scheme: scheme, Host: options.Registry, Path: "/v1/search"}
+	q := u.Query()
+	q.Set("q", term)
+	q.Set("n", strconv.Itoa(limit))
+	u.RawQuery = q.Encode()
+
+	data, err := fetchSearch(ctx, options, u)
+	if err != nil {
+		return nil, err
+	}
+
+	var results registry.SearchResults
+	if err = json.Unmarshal([]byte(data), &results); err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal search results from %s: %s", options.Registry, err)
+	}
+
+	if len(results.Results) > limit {
+		results.Results = results.Results[:limit]
+	}
+	return results.Results, nil
+}
+
+// searchCatalog matches the repository names in the v2 catalog of the registry against term
+func searchCatalog(ctx context.Context, options Options, scheme, term string, limit int) ([]registry.SearchResult, error) {
+	u := &url.URL{Scheme: scheme, Host: options.Registry, Path: "/v2/_catalog"}
+	q := u.Query()
+	q.Set("n", strconv.Itoa(catalogPageSize))
+	u.RawQuery = q.Encode()
+
+	data, err := fetchSearch(ctx, options, u)
+	if err != nil {
+		return nil, err
+	}
+
+	var catalog struct {
+		Repositories []string `json:"repositories"`
+	}
+	if err = json.Unmarshal([]byte(data), &catalog); err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal catalog from %s: %s", options.Registry, err)
+	}
+
+	var results []registry.SearchResult
+	for _, repo := range catalog.Repositories {
+		if len(results) == limit {
+			break
+		}
+		if strings.Contains(repo, term) {
+			results = append(results, registry.SearchResult{Name: repo})
+		}
+	}
+
+	return results, nil
+}
+
+// fetchSearch fetches u with basic auth, fetching an OAuth token if the registry asks for one
+func fetchSearch(ctx context.Context, options Options, u *url.URL) (string, error) {
+	fetcher := urlfetcher.NewURLFetcher(urlfetcher.Options{
+		Timeout:            options.Timeout,
+		Username:           options.Username,
+		Password:           options.Password,
+		InsecureSkipVerify: options.InsecureSkipVerify,
+	})
+
+	data, err := fetcher.Fetch(ctx, u, nil, false, nil)
+	if err == nil || !fetcher.IsStatusUnauthorized() || fetcher.AuthURL() == nil {
+		return data, err
+	}
+
+	token, err := FetchToken(ctx, options, fetcher.AuthURL(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	fetcher = urlfetcher.NewURLFetcher(urlfetcher.Options{
+		Timeout:            options.Timeout,
+		Username:           options.Username,
+		Password:           options.Password,
+		Token:              token,
+		InsecureSkipVerify: options.InsecureSkipVerify,
+	})
+
+	return fetcher.Fetch(ctx, u, nil, false, nil)
+}
@@ -115,3 +115,26 @@ func WriteImage(host string, image *ImageWithMeta, data io.ReadCloser) error {
 	return nil
 
 }
+
+// ReadImageTar writes a tar archive of the image layer from the given image store to w
+func ReadImageTar(host, storename, id string, w io.Writer) error {
+	defer trace.End(trace.Begin(id))
+
+	transport := httptransport.New(host, "/", []string{"http"})
+	client := apiclient.New(transport, nil)
+
+	transport.Consumers["application/octet-stream"] = httpkit.ByteStreamConsumer()
+
+	_, err := client.Storage.GetImageTar(
+		storage.NewGetImageTarParamsWithContext(ctx).
+			WithStoreName(storename).
+			WithID(id),
+		w,
+	)
+	if err != nil {
+		log.Debugf("Reading image %s failed: %s", id, err)
+		return err
+	}
+
+	return nil
+}
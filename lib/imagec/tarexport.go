@@ -0,0 +1,638 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imagec
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	docker "github.com/docker/docker/image"
+	dockerLayer "github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/pkg/progress"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/reference"
+
+	"github.com/vmware/vic/lib/apiservers/engine/backends/cache"
+	"github.com/vmware/vic/lib/metadata"
+	"github.com/vmware/vic/pkg/trace"
+)
+
+const (
+	manifestFileName     = "manifest.json"
+	repositoriesFileName = "repositories"
+	layerFileName        = "layer.tar"
+	layerConfigFileName  = "json"
+	layerVersionFileName = "VERSION"
+	layerVersion         = "1.0"
+)
+
+// manifestItem describes an image in the manifest.json of a docker save archive
+type manifestItem struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// ArchivedImage is an image in a docker save archive along with the references it is saved under
+type ArchivedImage struct {
+	Image *metadata.ImageConfig
+	Refs  []reference.NamedTagged
+}
+
+// SaveImages writes the images to w in the docker save format. The layers are exported from
+// the image store rather than the original blobs, so the image configurations are rewritten
+// with the diffIDs of the exported layers.
+//
+// manifest.json and the image configs are written ahead of the layers, and each layer after its
+// parent, so that LoadImages can write the layers to an image store as the archive is read.
+func SaveImages(host, storename string, images []ArchivedImage, w io.Writer) error {
+	defer trace.End(trace.Begin(storename))
+
+	tw := tar.NewWriter(w)
+
+	// layers and configs shared between images are only written once
+	exported := make(map[string]*exportedLayer)
+	var layerOrder []*exportedLayer
+	configs := make(map[string][]byte)
+	var configOrder []string
+
+	var manifest []manifestItem
+	repositories := make(map[string]map[string]string)
+
+	for _, image := range images {
+		layers, err := LayerCache().Chain(image.Image.ID)
+		if err != nil || len(layers) == 0 {
+			return fmt.Errorf("Unable to find the layers of image %s", image.Image.ImageID)
+		}
+
+		item := manifestItem{}
+		rootFS := docker.NewRootFS()
+
+		// the archive lists the layers oldest first
+		for i := len(layers) - 1; i >= 0; i-- {
+			layer := layers[i]
+
+			e, ok := exported[layer.ID]
+			if !ok {
+				if e, err = sizeLayer(host, storename, layer); err != nil {
+					return err
+				}
+				exported[layer.ID] = e
+				layerOrder = append(layerOrder, e)
+			}
+
+			rootFS.DiffIDs = append(rootFS.DiffIDs, e.diffID)
+			item.Layers = append(item.Layers, path.Join(layer.ID, layerFileName))
+		}
+
+		config, err := imageConfigJSON(image.Image, rootFS)
+		if err != nil {
+			return fmt.Errorf("Failed to marshal image config: %s", err)
+		}
+
+		item.Config = fmt.Sprintf("%x.json", sha256.Sum256(config))
+		if _, ok := configs[item.Config]; !ok {
+			configs[item.Config] = config
+			configOrder = append(configOrder, item.Config)
+		}
+
+		for _, ref := range image.Refs {
+			item.RepoTags = append(item.RepoTags, ref.String())
+
+			if repositories[ref.Name()] == nil {
+				repositories[ref.Name()] = make(map[string]string)
+			}
+			repositories[ref.Name()][ref.Tag()] = layers[0].ID
+		}
+
+		manifest = append(manifest, item)
+	}
+
+	content, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	if err = writeTarFile(tw, manifestFileName, content); err != nil {
+		return err
+	}
+
+	// repositories is read by docker versions that predate manifest.json
+	if len(repositories) > 0 {
+		if content, err = json.Marshal(repositories); err != nil {
+			return err
+		}
+		if err = writeTarFile(tw, repositoriesFileName, content); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range configOrder {
+		if err = writeTarFile(tw, name, configs[name]); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range layerOrder {
+		if err = saveLayer(host, storename, tw, e); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+// exportedLayer is a layer of the image store along with the size and diffID of its export
+type exportedLayer struct {
+	layer  *ImageWithMeta
+	size   int64
+	diffID dockerLayer.DiffID
+}
+
+// sizeLayer exports the supplied layer to find the size and diffID of the layer tar. Both are
+// needed before the layer is written, the size for the tar header and the diffID for the image
+// config, so the layer is exported once to measure it and again into the archive.
+func sizeLayer(host, storename string, layer *ImageWithMeta) (*exportedLayer, error) {
+	defer trace.End(trace.Begin(layer.ID))
+
+	h := sha256.New()
+	counter := ioutils.NewWriteCounter(h)
+	if err := ReadImageTar(host, storename, layer.ID, counter); err != nil {
+		return nil, fmt.Errorf("Failed to export layer %s: %s", layer.ID, err)
+	}
+
+	return &exportedLayer{
+		layer:  layer,
+		size:   counter.Count,
+		diffID: dockerLayer.DiffID(fmt.Sprintf("sha256:%x", h.Sum(nil))),
+	}, nil
+}
+
+// saveLayer writes the layer directory for the supplied layer, streaming the layer tar from
+// the image store into the archive
+func saveLayer(host, storename string, tw *tar.Writer, e *exportedLayer) error {
+	defer trace.End(trace.Begin(e.layer.ID))
+
+	id := e.layer.ID
+	err := tw.WriteHeader(&tar.Header{
+		Name:     id + "/",
+		Mode:     0755,
+		ModTime:  time.Now().UTC(),
+		Typeflag: tar.TypeDir,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = writeTarFile(tw, path.Join(id, layerVersionFileName), []byte(layerVersion)); err != nil {
+		return err
+	}
+	if err = writeTarFile(tw, path.Join(id, layerConfigFileName), []byte(e.layer.Meta)); err != nil {
+		return err
+	}
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:     path.Join(id, layerFileName),
+		Mode:     0644,
+		Size:     e.size,
+		ModTime:  time.Now().UTC(),
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the tar writer rejects an export that is longer than the header, and one that is
+	// shorter or differs from the measured export is caught by the size and digest checks
+	h := sha256.New()
+	counter := ioutils.NewWriteCounter(io.MultiWriter(tw, h))
+	if err = ReadImageTar(host, storename, id, counter); err != nil {
+		return fmt.Errorf("Failed to export layer %s: %s", id, err)
+	}
+	if counter.Count != e.size || dockerLayer.DiffID(fmt.Sprintf("sha256:%x", h.Sum(nil))) != e.diffID {
+		return fmt.Errorf("Export of layer %s changed between reads", id)
+	}
+
+	return nil
+}
+
+func writeTarFile(tw *tar.Writer, name string, content []byte) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(content)),
+		ModTime:  time.Now().UTC(),
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tw.Write(content)
+	return err
+}
+
+// LoadImages writes the images in a docker save archive to the image store and adds them to
+// the image and repository caches. Layers already in the image store are not written again.
+//
+// The archive is read as a stream. A layer is written to the image store as its entry is read
+// once manifest.json and the image config that place it have been read and its parent is in
+// the image store, which is always the case for archives written by SaveImages. docker writes
+// manifest.json after the layers, so layers that are read before they can be placed are spooled
+// to a temporary file until they can be written.
+func LoadImages(host, storename string, r io.Reader, progressOutput progress.Output) ([]ArchivedImage, error) {
+	defer trace.End(trace.Begin(storename))
+
+	l := &loader{
+		host:           host,
+		storename:      storename,
+		progressOutput: progressOutput,
+		files:          make(map[string][]byte),
+		links:          make(map[string]string),
+		placed:         make(map[string][]*ImageWithMeta),
+		spooled:        make(map[string]string),
+	}
+	defer l.cleanup()
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read archive: %s", err)
+		}
+
+		name := path.Clean(hdr.Name)
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+		case tar.TypeSymlink, tar.TypeLink:
+			// docker links the layer tars of layers that it has already written
+			if path.Base(name) == layerFileName {
+				target := hdr.Linkname
+				if hdr.Typeflag == tar.TypeSymlink {
+					target = path.Join(path.Dir(name), target)
+				}
+				l.links[name] = path.Clean(target)
+			}
+			continue
+		default:
+			continue
+		}
+
+		if path.Base(name) == layerFileName {
+			if err = l.layer(name, hdr.Size, tr); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read %s from archive: %s", name, err)
+		}
+		if err = l.file(name, content); err != nil {
+			return nil, err
+		}
+	}
+
+	if l.manifest == nil {
+		return nil, fmt.Errorf("Archive has no %s, archives from docker versions before 1.10 are not supported", manifestFileName)
+	}
+
+	// write any layers that were spooled until the end of the archive
+	if err := l.writeSpooled(); err != nil {
+		return nil, err
+	}
+
+	var loaded []ArchivedImage
+	for _, item := range l.manifest {
+		image, err := l.image(item)
+		if err != nil {
+			return nil, err
+		}
+		loaded = append(loaded, image)
+	}
+
+	if err := cache.ImageCache().Save(); err != nil {
+		return nil, fmt.Errorf("Unable to save image cache: %s", err)
+	}
+
+	return loaded, nil
+}
+
+// loader holds the state of a docker save archive as it is read by LoadImages
+type loader struct {
+	host           string
+	storename      string
+	progressOutput progress.Output
+
+	manifest []manifestItem
+
+	// files holds the image configs read from the archive, by path
+	files map[string][]byte
+	// links maps the paths of linked layer tars to the path of their target
+	links map[string]string
+	// placed maps the path of a layer tar to the layers it holds
+	placed map[string][]*ImageWithMeta
+	// spooled maps the path of a layer tar to the temporary file it is spooled to
+	spooled map[string]string
+}
+
+// file records a file read from the archive, placing the layers of the images it completes
+func (l *loader) file(name string, content []byte) error {
+	if name == manifestFileName {
+		if err := json.Unmarshal(content, &l.manifest); err != nil {
+			return fmt.Errorf("Failed to unmarshal %s: %s", manifestFileName, err)
+		}
+	} else if path.Dir(name) == "." && path.Ext(name) == ".json" {
+		l.files[name] = content
+	} else {
+		return nil
+	}
+
+	for _, item := range l.manifest {
+		config, ok := l.files[path.Clean(item.Config)]
+		if !ok || l.isPlaced(item) {
+			continue
+		}
+		if err := l.place(item, config); err != nil {
+			return err
+		}
+	}
+
+	return l.writeSpooled()
+}
+
+// place records the layers that each layer tar of the supplied image holds
+func (l *loader) place(item manifestItem, config []byte) error {
+	img, err := docker.NewFromJSON(config)
+	if err != nil {
+		return fmt.Errorf("Failed to unmarshal image config: %s", err)
+	}
+
+	layers, err := v1Layers(img)
+	if err != nil {
+		return err
+	}
+	if len(layers) != len(item.Layers) {
+		return fmt.Errorf("Invalid manifest, image config has %d layers, manifest lists %d", len(layers), len(item.Layers))
+	}
+
+	for i, layer := range layers {
+		layer.Store = l.storename
+		name := l.resolve(item.Layers[i])
+		if !holds(l.placed[name], layer.ID) {
+			l.placed[name] = append(l.placed[name], layer)
+		}
+	}
+
+	return nil
+}
+
+func holds(layers []*ImageWithMeta, id string) bool {
+	for _, layer := range layers {
+		if layer.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *loader) isPlaced(item manifestItem) bool {
+	if len(item.Layers) == 0 {
+		return true
+	}
+	leaf := l.resolve(item.Layers[len(item.Layers)-1])
+	return len(l.placed[leaf]) > 0
+}
+
+// resolve follows the links between layer tars in the archive
+func (l *loader) resolve(name string) string {
+	name = path.Clean(name)
+	for i := 0; i < 10; i++ {
+		target, ok := l.links[name]
+		if !ok {
+			break
+		}
+		name = target
+	}
+	return name
+}
+
+// pending returns the layers held by the layer tar at name that are not yet in the image store
+func (l *loader) pending(name string) []*ImageWithMeta {
+	var pending []*ImageWithMeta
+	for _, layer := range l.placed[name] {
+		if cached, err := LayerCache().Get(layer.ID); err != nil || cached.Downloading {
+			pending = append(pending, layer)
+		}
+	}
+	return pending
+}
+
+// ready returns true if the parent of the layer is in the image store
+func ready(layer *ImageWithMeta) bool {
+	if *layer.Parent == "scratch" {
+		return true
+	}
+	parent, err := LayerCache().Get(*layer.Parent)
+	return err == nil && !parent.Downloading
+}
+
+// layer handles a layer tar entry of the archive. The layer is written to the image store as
+// it is read if it can be placed, and spooled otherwise.
+func (l *loader) layer(name string, size int64, r io.Reader) error {
+	if _, ok := l.placed[name]; ok {
+		pending := l.pending(name)
+		if len(pending) == 0 {
+			return nil
+		}
+		if len(pending) == 1 && ready(pending[0]) {
+			if err := l.write(pending[0], r, size); err != nil {
+				return err
+			}
+			return l.writeSpooled()
+		}
+	}
+
+	f, err := ioutil.TempFile("", "imagec-load-")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	l.spooled[name] = f.Name()
+	if _, err = io.Copy(f, r); err != nil {
+		return fmt.Errorf("Failed to read %s from archive: %s", name, err)
+	}
+
+	return nil
+}
+
+// writeSpooled writes the spooled layers that can now be placed, repeating until no more can
+// be written as each layer written may be the parent of another
+func (l *loader) writeSpooled() error {
+	for progressed := true; progressed; {
+		progressed = false
+
+		for name, spool := range l.spooled {
+			for _, layer := range l.pending(name) {
+				if !ready(layer) {
+					continue
+				}
+
+				f, err := os.Open(spool)
+				if err != nil {
+					return err
+				}
+				fi, err := f.Stat()
+				if err == nil {
+					err = l.write(layer, f, fi.Size())
+				}
+				f.Close()
+				if err != nil {
+					return err
+				}
+
+				progressed = true
+			}
+
+			if len(l.placed[name]) > 0 && len(l.pending(name)) == 0 {
+				os.Remove(spool)
+				delete(l.spooled, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// write writes the layer tar read from r to the image store
+func (l *loader) write(layer *ImageWithMeta, r io.Reader, size int64) error {
+	defer trace.End(trace.Begin(layer.ID))
+
+	decompressed, err := archive.DecompressStream(r)
+	if err != nil {
+		return err
+	}
+
+	in := progress.NewProgressReader(decompressed, l.progressOutput, size, stringid.TruncateID(layer.DiffID), "Loading layer")
+	defer in.Close()
+
+	// the image store verifies the uncompressed layer against its diffID
+	layer.Layer.BlobSum = layer.DiffID
+	layer.Size = size
+
+	if err = WriteImage(l.host, layer, in); err != nil {
+		return fmt.Errorf("Failed to write layer %s to image store: %s", layer.ID, err)
+	}
+
+	LayerCache().Commit(layer)
+	log.Debugf("Loaded layer %s (%s)", layer.ID, layer.DiffID)
+	return nil
+}
+
+func (l *loader) cleanup() {
+	for _, spool := range l.spooled {
+		os.Remove(spool)
+	}
+}
+
+// image caches the image described by item, all of whose layers are in the image store
+func (l *loader) image(item manifestItem) (ArchivedImage, error) {
+	defer trace.End(trace.Begin(item.Config))
+
+	config, ok := l.files[path.Clean(item.Config)]
+	if !ok {
+		return ArchivedImage{}, fmt.Errorf("Archive has no image config %s", item.Config)
+	}
+
+	for _, name := range item.Layers {
+		name = l.resolve(name)
+		if len(l.pending(name)) == 0 {
+			continue
+		}
+		if _, ok := l.spooled[name]; ok {
+			return ArchivedImage{}, fmt.Errorf("Unable to load layer %s, its parent is not in the archive", name)
+		}
+		return ArchivedImage{}, fmt.Errorf("Archive has no layer %s", name)
+	}
+
+	img, err := docker.NewFromJSON(config)
+	if err != nil {
+		return ArchivedImage{}, fmt.Errorf("Failed to unmarshal image config: %s", err)
+	}
+	placed, err := v1Layers(img)
+	if err != nil {
+		return ArchivedImage{}, err
+	}
+
+	// the image config is built leaf first
+	layers := make([]*ImageWithMeta, len(placed))
+	for i, layer := range placed {
+		cached, err := LayerCache().Get(layer.ID)
+		if err != nil {
+			return ArchivedImage{}, err
+		}
+		layers[len(placed)-1-i] = cached
+	}
+
+	imageConfig, err := ImageConfigFromBlob(config, layers)
+	if err != nil {
+		return ArchivedImage{}, err
+	}
+
+	loaded := ArchivedImage{Image: &imageConfig}
+	for _, repoTag := range item.RepoTags {
+		named, err := reference.ParseNamed(repoTag)
+		if err != nil {
+			return ArchivedImage{}, err
+		}
+		ref, ok := named.(reference.NamedTagged)
+		if !ok {
+			return ArchivedImage{}, fmt.Errorf("Invalid tag %q", repoTag)
+		}
+
+		tagged := imageConfig
+		tagged.Name = ref.Name()
+		tagged.Tags = []string{ref.Tag()}
+		tagged.Reference = ref.String()
+
+		if err = cache.RepositoryCache().AddReference(ref, imageConfig.ImageID, true, layers[0].ID, true); err != nil {
+			return ArchivedImage{}, fmt.Errorf("Unable to add image reference %s: %s", ref, err)
+		}
+		cache.ImageCache().Add(&tagged)
+
+		loaded.Refs = append(loaded.Refs, ref)
+	}
+
+	if len(loaded.Refs) == 0 {
+		cache.ImageCache().Add(&imageConfig)
+	}
+
+	return loaded, nil
+}
@@ -0,0 +1,449 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imagec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+
+	"golang.org/x/net/context"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/docker/distribution"
+	ddigest "github.com/docker/distribution/digest"
+	"github.com/docker/distribution/manifest/schema2"
+	docker "github.com/docker/docker/image"
+	dockerLayer "github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/progress"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/reference"
+
+	"github.com/vmware/vic/lib/apiservers/engine/backends/cache"
+	"github.com/vmware/vic/lib/metadata"
+	urlfetcher "github.com/vmware/vic/pkg/fetcher"
+	"github.com/vmware/vic/pkg/trace"
+	"github.com/vmware/vic/pkg/vsphere/sys"
+)
+
+// uploadChunkSize is the size of the chunks blobs are uploaded to the registry in
+const uploadChunkSize = 10 * 1024 * 1024
+
+// LearnUploadAuthURL returns the URL of the OAuth endpoint that grants push access to the
+// repository. Registries only issue the push scope in the challenge to an upload request.
+func LearnUploadAuthURL(options Options) (*url.URL, error) {
+	defer trace.End(trace.Begin(options.Image))
+
+	url, err := url.Parse(options.Registry)
+	if err != nil {
+		return nil, err
+	}
+	url.Path = path.Join(url.Path, options.Image, "blobs", "uploads") + "/"
+
+	log.Debugf("URL: %s", url)
+
+	fetcher := urlfetcher.NewURLFetcher(urlfetcher.Options{
+		Timeout:            options.Timeout,
+		Username:           options.Username,
+		Password:           options.Password,
+		InsecureSkipVerify: options.InsecureSkipVerify,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), options.Timeout)
+	defer cancel()
+
+	// Registries without token authentication accept the upload straight away. The
+	// abandoned upload is purged by the registry.
+	_, err = fetcher.Post(ctx, url, nil, nil)
+	if err == nil {
+		log.Debugf("%s does not support OAuth", url)
+		return nil, nil
+	}
+
+	if fetcher.IsStatusUnauthorized() && fetcher.AuthURL() != nil {
+		return fetcher.AuthURL(), nil
+	}
+
+	return nil, fmt.Errorf("%s returned an unexpected response: %s", url, err)
+}
+
+// BlobExists reports whether the registry already holds the blob with the given digest
+func BlobExists(ctx context.Context, options Options, digest string) (bool, error) {
+	defer trace.End(trace.Begin(options.Image + "/" + digest))
+
+	url, err := url.Parse(options.Registry)
+	if err != nil {
+		return false, err
+	}
+	url.Path = path.Join(url.Path, options.Image, "blobs", digest)
+
+	fetcher := urlfetcher.NewURLFetcher(urlfetcher.Options{
+		Timeout:            options.Timeout,
+		Username:           options.Username,
+		Password:           options.Password,
+		Token:              options.Token,
+		InsecureSkipVerify: options.InsecureSkipVerify,
+	})
+
+	return fetcher.Exists(ctx, url)
+}
+
+// UploadBlob uploads size bytes of blob to the registry in chunks, completing the upload
+// with the digest the registry verifies the content against
+func UploadBlob(ctx context.Context, options Options, blob io.Reader, size int64, digest string, progressOutput progress.Output, id string) error {
+	defer trace.End(trace.Begin(options.Image + "/" + digest))
+
+	registry, err := url.Parse(options.Registry)
+	if err != nil {
+		return err
+	}
+
+	uploads := *registry
+	uploads.Path = path.Join(registry.Path, options.Image, "blobs", "uploads") + "/"
+
+	fetcher := urlfetcher.NewURLFetcher(urlfetcher.Options{
+		Timeout:            options.Timeout,
+		Username:           options.Username,
+		Password:           options.Password,
+		Token:              options.Token,
+		InsecureSkipVerify: options.InsecureSkipVerify,
+	})
+
+	hdrs, err := fetcher.Post(ctx, &uploads, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	if progressOutput != nil {
+		blob = progress.NewProgressReader(ioutil.NopCloser(blob), progressOutput, size, id, "Pushing")
+	}
+
+	var offset int64
+	for {
+		location, err := uploadLocation(registry, hdrs)
+		if err != nil {
+			return err
+		}
+
+		if offset == size {
+			// an empty PUT completes the upload
+			q := location.Query()
+			q.Set("digest", digest)
+			location.RawQuery = q.Encode()
+
+			_, err = fetcher.Put(ctx, location, nil, http.Header{
+				"Content-Length": {"0"},
+			})
+			return err
+		}
+
+		n := size - offset
+		if n > uploadChunkSize {
+			n = uploadChunkSize
+		}
+
+		hdrs, err = fetcher.Patch(ctx, location, io.LimitReader(blob, n), http.Header{
+			"Content-Type":   {"application/octet-stream"},
+			"Content-Length": {strconv.FormatInt(n, 10)},
+			"Content-Range":  {fmt.Sprintf("%d-%d", offset, offset+n-1)},
+		})
+		if err != nil {
+			return err
+		}
+		offset += n
+	}
+}
+
+// uploadLocation returns the URL an upload continues at, which registries may give relative
+// to themselves
+func uploadLocation(registry *url.URL, hdrs http.Header) (*url.URL, error) {
+	location := hdrs.Get("Location")
+	if location == "" {
+		return nil, fmt.Errorf("registry did not return an upload location")
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+
+	return registry.ResolveReference(u), nil
+}
+
+// PushImageManifest puts the manifest to the registry under the tag and returns its digest
+func PushImageManifest(ctx context.Context, options Options, mediaType string, payload []byte) (string, error) {
+	defer trace.End(trace.Begin(options.Image + "/" + options.Tag))
+
+	url, err := url.Parse(options.Registry)
+	if err != nil {
+		return "", err
+	}
+	url.Path = path.Join(url.Path, options.Image, "manifests", options.Tag)
+
+	log.Debugf("URL: %s", url)
+
+	fetcher := urlfetcher.NewURLFetcher(urlfetcher.Options{
+		Timeout:            options.Timeout,
+		Username:           options.Username,
+		Password:           options.Password,
+		Token:              options.Token,
+		InsecureSkipVerify: options.InsecureSkipVerify,
+	})
+
+	hdrs, err := fetcher.Put(ctx, url, bytes.NewReader(payload), http.Header{
+		"Content-Type":   {mediaType},
+		"Content-Length": {strconv.Itoa(len(payload))},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if digest := hdrs.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+	return string(ddigest.FromBytes(payload)), nil
+}
+
+// PushImage pushes the image named by the reference from the image store to its registry
+// as a schema 2 manifest
+func (ic *ImageC) PushImage() error {
+
+	// ctx
+	ctx, cancel := context.WithTimeout(ctx, ic.Options.Timeout)
+	defer cancel()
+
+	// Parse the -reference parameter
+	if err := ic.ParseReference(); err != nil {
+		log.Errorf(err.Error())
+		return err
+	}
+
+	imageConfig, err := cache.ImageCache().Get(ic.Reference)
+	if err != nil {
+		return fmt.Errorf("An image does not exist locally with the tag: %s", ic.Reference)
+	}
+
+	layers, err := LayerCache().Chain(imageConfig.ID)
+	if err != nil {
+		return fmt.Errorf("Unable to find the layers of %s: %s", ic.Reference, err)
+	}
+
+	host, err := sys.UUID()
+	if err != nil {
+		log.Errorf("Failed to return host name: %s", err)
+		return err
+	}
+	ic.Storename = host
+
+	// Calculate (and overwrite) the registry URL and make sure that it responds to requests
+	ic.Registry, err = LearnRegistryURL(ic.Options)
+	if err != nil {
+		log.Errorf("Error while pushing image: %s", err)
+		return err
+	}
+
+	url, err := LearnUploadAuthURL(ic.Options)
+	if err != nil {
+		return fmt.Errorf("Failed to obtain OAuth endpoint: %s", err)
+	}
+
+	// Get the OAuth token - if only we have a URL
+	if url != nil {
+		token, err := FetchToken(ctx, ic.Options, url, ic.progressOutput)
+		if err != nil {
+			log.Errorf("Failed to fetch OAuth token: %s", err)
+			return err
+		}
+		ic.Token = token
+	}
+
+	named, err := reference.ParseNamed(ic.Reference)
+	if err != nil {
+		return err
+	}
+	progress.Messagef(ic.progressOutput, "", "The push refers to a repository [%s]", named.FullName())
+
+	// the layers are read back out of the image store, so their diffIDs are those of the
+	// archives the store produces rather than those recorded when they were pulled
+	m := schema2.Manifest{
+		Versioned: schema2.SchemaVersion,
+	}
+	rootFS := docker.NewRootFS()
+	for i := len(layers) - 1; i >= 0; i-- {
+		descriptor, diffID, err := ic.pushLayer(ctx, layers[i])
+		if err != nil {
+			return fmt.Errorf("Failed to push layer %s: %s", layers[i].ID, err)
+		}
+		m.Layers = append(m.Layers, descriptor)
+		rootFS.DiffIDs = append(rootFS.DiffIDs, dockerLayer.DiffID(diffID))
+	}
+
+	config, err := imageConfigJSON(imageConfig, rootFS)
+	if err != nil {
+		return err
+	}
+
+	m.Config = distribution.Descriptor{
+		MediaType: schema2.MediaTypeConfig,
+		Size:      int64(len(config)),
+		Digest:    ddigest.FromBytes(config),
+	}
+	exists, err := BlobExists(ctx, ic.Options, string(m.Config.Digest))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err = UploadBlob(ctx, ic.Options, bytes.NewReader(config), m.Config.Size, string(m.Config.Digest), nil, ""); err != nil {
+			return fmt.Errorf("Failed to push image config: %s", err)
+		}
+	}
+
+	manifest, err := schema2.FromStruct(m)
+	if err != nil {
+		return err
+	}
+	mediaType, payload, err := manifest.Payload()
+	if err != nil {
+		return err
+	}
+
+	digest, err := PushImageManifest(ctx, ic.Options, mediaType, payload)
+	if err != nil {
+		return fmt.Errorf("Failed to push image manifest: %s", err)
+	}
+
+	progress.Messagef(ic.progressOutput, "", "%s: digest: %s size: %d", ic.Tag, digest, len(payload))
+	return nil
+}
+
+// pushLayer uploads a layer from the image store as a gzipped tar, skipping the upload if
+// the registry already holds it. It returns the descriptor and diffID of the layer.
+func (ic *ImageC) pushLayer(ctx context.Context, layer *ImageWithMeta) (distribution.Descriptor, string, error) {
+	defer trace.End(trace.Begin(layer.ID))
+
+	id := stringid.TruncateID(layer.ID)
+	progress.Update(ic.progressOutput, id, "Preparing")
+
+	// the blob is staged so that its digest is known before it is uploaded
+	f, err := ioutil.TempFile("", "push-"+layer.ID)
+	if err != nil {
+		return distribution.Descriptor{}, "", err
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	blobSum := sha256.New()
+	diffIDSum := sha256.New()
+
+	gz := gzip.NewWriter(io.MultiWriter(f, blobSum))
+	if err = ReadImageTar(ic.Host, ic.Storename, layer.ID, io.MultiWriter(gz, diffIDSum)); err != nil {
+		return distribution.Descriptor{}, "", err
+	}
+	if err = gz.Close(); err != nil {
+		return distribution.Descriptor{}, "", err
+	}
+
+	size, err := f.Seek(0, os.SEEK_CUR)
+	if err != nil {
+		return distribution.Descriptor{}, "", err
+	}
+	if _, err = f.Seek(0, os.SEEK_SET); err != nil {
+		return distribution.Descriptor{}, "", err
+	}
+
+	descriptor := distribution.Descriptor{
+		MediaType: schema2.MediaTypeLayer,
+		Size:      size,
+		Digest:    ddigest.Digest(fmt.Sprintf("sha256:%x", blobSum.Sum(nil))),
+	}
+	diffID := fmt.Sprintf("sha256:%x", diffIDSum.Sum(nil))
+
+	exists, err := BlobExists(ctx, ic.Options, string(descriptor.Digest))
+	if err != nil {
+		return distribution.Descriptor{}, "", err
+	}
+	if exists {
+		progress.Update(ic.progressOutput, id, "Layer already exists")
+		return descriptor, diffID, nil
+	}
+
+	if err = UploadBlob(ctx, ic.Options, f, size, string(descriptor.Digest), ic.progressOutput, id); err != nil {
+		return distribution.Descriptor{}, "", err
+	}
+
+	progress.Update(ic.progressOutput, id, "Pushed")
+	return descriptor, diffID, nil
+}
+
+// imageConfigJSON returns the image configuration blob of the image with the given root filesystem
+func imageConfigJSON(imageConfig *metadata.ImageConfig, rootFS *docker.RootFS) ([]byte, error) {
+	image := docker.Image{
+		V1Image: docker.V1Image{
+			Comment:         imageConfig.Comment,
+			Created:         imageConfig.Created,
+			Container:       imageConfig.Container,
+			ContainerConfig: imageConfig.ContainerConfig,
+			DockerVersion:   imageConfig.DockerVersion,
+			Author:          imageConfig.Author,
+			Config:          imageConfig.Config,
+			Architecture:    imageConfig.Architecture,
+			OS:              imageConfig.OS,
+		},
+		RootFS:  rootFS,
+		History: imageConfig.History,
+	}
+
+	config, err := image.MarshalJSON()
+	if err != nil || imageConfig.Healthcheck == nil {
+		return config, err
+	}
+
+	// the healthcheck belongs in the container config, which the vendored docker types lack
+	var fields map[string]*json.RawMessage
+	if err = json.Unmarshal(config, &fields); err != nil {
+		return nil, err
+	}
+
+	var containerConfig map[string]interface{}
+	if fields["config"] != nil {
+		if err = json.Unmarshal(*fields["config"], &containerConfig); err != nil {
+			return nil, err
+		}
+	}
+	if containerConfig == nil {
+		containerConfig = make(map[string]interface{})
+	}
+	containerConfig["Healthcheck"] = imageConfig.Healthcheck
+
+	raw, err := json.Marshal(containerConfig)
+	if err != nil {
+		return nil, err
+	}
+	fields["config"] = (*json.RawMessage)(&raw)
+
+	return json.Marshal(fields)
+}
@@ -63,6 +63,10 @@ type Data struct {
 	BridgeIPRange *net.IPNet
 
 	InsecureRegistries []url.URL
+	RegistryWhitelist  []url.URL
+	RegistryBlacklist  []url.URL
+
+	PauseMode string
 
 	NumCPUs  int
 	MemoryMB int
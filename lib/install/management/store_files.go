@@ -30,6 +30,7 @@ import (
 	"github.com/vmware/vic/lib/portlayer/storage/vsphere"
 	"github.com/vmware/vic/lib/portlayer/store"
 	"github.com/vmware/vic/pkg/errors"
+	"github.com/vmware/vic/pkg/fs"
 	"github.com/vmware/vic/pkg/trace"
 	"github.com/vmware/vic/pkg/vsphere/datastore"
 	"github.com/vmware/vic/pkg/vsphere/tasks"
@@ -269,6 +270,11 @@ func (d *Dispatcher) lsFolder(ds *object.Datastore, dsPath string) (*types.HostD
 func (d *Dispatcher) createVolumeStores(conf *config.VirtualContainerHostConfigSpec) error {
 	defer trace.End(trace.Begin(""))
 	for _, url := range conf.VolumeLocations {
+		// the directories of nfs volume stores are created by the appliance
+		if url.Scheme == fs.NFSScheme {
+			continue
+		}
+
 		ds, err := d.session.Finder.Datastore(d.ctx, url.Host)
 		if err != nil {
 			return errors.Errorf("Could not retrieve datastore with host %q due to error %s", url.Host, err)
@@ -308,6 +314,11 @@ func (d *Dispatcher) deleteVolumeStoreIfForced(conf *config.VirtualContainerHost
 
 	log.Infoln("Removing volume stores")
 	for label, url := range conf.VolumeLocations {
+		if url.Scheme == fs.NFSScheme {
+			log.Warnf("Volume store %q on NFS export %s is not removed. Delete the volumes on the export if you do not wish to keep them.", label, url.String())
+			continue
+		}
+
 		// FIXME: url is being encoded by the portlayer incorrectly, so we have to convert url.Path to the right url.URL object
 		dsURL, err := datastore.ToURL(url.Path)
 		if err != nil {
@@ -26,6 +26,7 @@ import (
 	"github.com/vmware/vic/lib/config"
 	"github.com/vmware/vic/lib/install/data"
 	"github.com/vmware/vic/pkg/errors"
+	"github.com/vmware/vic/pkg/fs"
 	"github.com/vmware/vic/pkg/trace"
 	"github.com/vmware/vic/pkg/vsphere/datastore"
 )
@@ -58,6 +59,15 @@ func (v *Validator) storage(ctx context.Context, input *data.Data, conf *config.
 
 	// TODO: add volume locations
 	for label, volDSpath := range input.VolumeLocations {
+		if strings.HasPrefix(volDSpath, fs.NFSScheme+"://") {
+			nfsURL, err := v.nfsVolumeStore(volDSpath, label)
+			v.NoteIssue(err)
+			if nfsURL != nil {
+				conf.VolumeLocations[label] = nfsURL
+			}
+			continue
+		}
+
 		dsURL, _, err := v.DatastoreHelper(ctx, volDSpath, label, "--volume-store")
 		v.NoteIssue(err)
 		if dsURL != nil {
@@ -66,6 +76,28 @@ func (v *Validator) storage(ctx context.Context, input *data.Data, conf *config.
 	}
 }
 
+// nfsVolumeStore checks the form of an NFS export, nfs://host/path, used as a volume store. The
+// export is mounted by the appliance and containerVMs rather than vic-machine, so it is not
+// checked that the export is reachable.
+func (v *Validator) nfsVolumeStore(export string, label string) (*url.URL, error) {
+	defer trace.End(trace.Begin(export))
+
+	nfsURL, err := url.Parse(export)
+	if err != nil {
+		return nil, errors.Errorf("error parsing nfs volume store %q: %s", export, err)
+	}
+
+	if nfsURL.Host == "" || nfsURL.Path == "" {
+		return nil, errors.Errorf("nfs volume store %q must be in format nfs://host/path:label", label)
+	}
+
+	if nfsURL.User != nil {
+		return nil, errors.Errorf("nfs volume store %q cannot include credentials", label)
+	}
+
+	return nfsURL, nil
+}
+
 func (v *Validator) DatastoreHelper(ctx context.Context, path string, label string, flag string) (*url.URL, *object.Datastore, error) {
 	defer trace.End(trace.Begin(path))
 
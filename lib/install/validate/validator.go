@@ -32,6 +32,7 @@ import (
 	"github.com/vmware/vic/lib/config"
 	"github.com/vmware/vic/lib/install/data"
 	"github.com/vmware/vic/pkg/errors"
+	"github.com/vmware/vic/pkg/fs"
 	"github.com/vmware/vic/pkg/trace"
 	"github.com/vmware/vic/pkg/version"
 	"github.com/vmware/vic/pkg/vsphere/session"
@@ -448,6 +449,9 @@ func (v *Validator) getAllDatastores(ctx context.Context, conf *config.VirtualCo
 		datastoreSet = v.getDatastore(ctx, &u, datastoreSet)
 	}
 	for _, u := range conf.VolumeLocations {
+		if u.Scheme == fs.NFSScheme {
+			continue
+		}
 		datastoreSet = v.getDatastore(ctx, u, datastoreSet)
 	}
 	return datastoreSet
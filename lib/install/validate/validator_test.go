@@ -291,6 +291,21 @@ func testStorage(v *Validator, input *data.Data, conf *config.VirtualContainerHo
 			map[string]string{"volume1": "ds://LocalDS_0/volumes/volume1",
 				"volume2": "ds://LocalDS_0/volumes/volume2"}},
 
+		{"LocalDS_0",
+			map[string]string{"volume1": "LocalDS_0/volumes/volume1",
+				"volume2": "nfs://nfs.example.com/exports/vch?vers=4"},
+			false,
+			"ds://LocalDS_0/test001",
+			map[string]string{"volume1": "ds://LocalDS_0/volumes/volume1",
+				"volume2": "nfs://nfs.example.com/exports/vch?vers=4"}},
+
+		{"LocalDS_0",
+			map[string]string{"volume1": "LocalDS_0/volumes/volume1",
+				"volume2": "nfs://nfs.example.com"},
+			true,
+			"ds://LocalDS_0/test001",
+			nil},
+
 		{"ds://😗",
 			map[string]string{"volume1": "😗/volumes/volume1",
 				"volume2": "ds://😗/volumes/volume2"},
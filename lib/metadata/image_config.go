@@ -15,6 +15,8 @@
 package metadata
 
 import (
+	"time"
+
 	docker "github.com/docker/docker/image"
 )
 
@@ -30,4 +32,18 @@ type ImageConfig struct {
 	DiffIDs   map[string]string `json:"diff_ids,omitempty"`
 	History   []docker.History  `json:"history,omitempty"`
 	Reference string            `json:"registry"`
+
+	// Healthcheck is kept apart from the container config as the vendored docker types predate it
+	Healthcheck *HealthConfig `json:"healthcheck,omitempty"`
+}
+
+// HealthConfig is the HEALTHCHECK of an image, in the form docker keeps it in the container config
+type HealthConfig struct {
+	// Test is the probe, either ["NONE"], ["CMD", args...] or ["CMD-SHELL", command]
+	Test []string `json:",omitempty"`
+
+	// Zero values mean the defaults
+	Interval time.Duration `json:",omitempty"`
+	Timeout  time.Duration `json:",omitempty"`
+	Retries  int           `json:",omitempty"`
 }
@@ -153,3 +153,20 @@ func Unbind(h interface{}) (interface{}, error) {
 	}
 	return toggle(handle, false)
 }
+
+// containerForExec returns the ID of the container hosting the exec session id, or
+// the empty string if there is no such session
+func containerForExec(id string) string {
+	if exec.Containers == nil {
+		return ""
+	}
+
+	for _, c := range exec.Containers.Containers(nil) {
+		info := c.Info()
+		if _, ok := info.ExecConfig.Execs[id]; ok {
+			return info.ExecConfig.ID
+		}
+	}
+
+	return ""
+}
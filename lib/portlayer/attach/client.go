@@ -15,8 +15,10 @@
 package attach
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 
 	log "github.com/Sirupsen/logrus"
 	"golang.org/x/crypto/ssh"
@@ -26,7 +28,8 @@ import (
 )
 
 const (
-	attachChannelType = "attach"
+	attachChannelType  = "attach"
+	archiveChannelType = "archive"
 )
 
 type SessionInteraction interface {
@@ -71,6 +74,128 @@ func SSHls(client *ssh.Client) ([]string, error) {
 	return ids.IDs, nil
 }
 
+// SSHStat returns the details of the filesystem resource at path. A nil response is returned
+// if nothing exists at that path.
+func SSHStat(client *ssh.Client, path string) (*msgs.StatResponseMsg, error) {
+	defer trace.End(trace.Begin(path))
+
+	msg := msgs.StatMsg{Path: path}
+	ok, reply, err := client.SendRequest(msgs.StatReq, true, msg.Marshal())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("failed to stat %s: %s", path, string(reply))
+	}
+
+	stat := &msgs.StatResponseMsg{}
+	if err = stat.Unmarshal(reply); err != nil {
+		log.Debugf("raw stat response: %+v", reply)
+		return nil, fmt.Errorf("failed to unmarshal stat from remote: %s", err)
+	}
+
+	if !stat.Exists {
+		return nil, nil
+	}
+	return stat, nil
+}
+
+// SSHTop returns a ps style listing of the processes running in the container as column
+// titles and one row of values per process. args are the ps arguments, separated by spaces.
+func SSHTop(client *ssh.Client, args string) ([]string, [][]string, error) {
+	defer trace.End(trace.Begin(args))
+
+	msg := msgs.TopMsg{Args: args}
+	ok, reply, err := client.SendRequest(msgs.TopReq, true, msg.Marshal())
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, fmt.Errorf("failed to list processes: %s", string(reply))
+	}
+
+	top := &msgs.TopResponseMsg{}
+	if err = top.Unmarshal(reply); err != nil {
+		log.Debugf("raw top response: %+v", reply)
+		return nil, nil, fmt.Errorf("failed to unmarshal top from remote: %s", err)
+	}
+
+	return top.Titles, top.Processes(), nil
+}
+
+// SSHArchive returns a stream containing a tar archive of the filesystem resource at path
+func SSHArchive(client *ssh.Client, path string) (io.ReadCloser, error) {
+	defer trace.End(trace.Begin(path))
+
+	msg := msgs.ArchiveMsg{Op: msgs.ArchiveExport, Path: path}
+	channel, requests, err := client.OpenChannel(archiveChannelType, msg.Marshal())
+	if err != nil {
+		return nil, err
+	}
+	go ssh.DiscardRequests(requests)
+
+	return &archiveReader{channel: channel}, nil
+}
+
+// SSHExtract writes the tar archive read from r into the directory at path, returning once
+// the remote has finished unpacking it
+func SSHExtract(client *ssh.Client, path string, noOverwriteDirNonDir bool, r io.Reader) error {
+	defer trace.End(trace.Begin(path))
+
+	msg := msgs.ArchiveMsg{Op: msgs.ArchiveImport, Path: path, NoOverwriteDirNonDir: noOverwriteDirNonDir}
+	channel, requests, err := client.OpenChannel(archiveChannelType, msg.Marshal())
+	if err != nil {
+		return err
+	}
+	go ssh.DiscardRequests(requests)
+	defer channel.Close()
+
+	if _, err = io.Copy(channel, r); err != nil {
+		// the remote may have given up on the archive, in which case it will have said why
+		if rerr := remoteError(channel); rerr != nil {
+			return rerr
+		}
+		return err
+	}
+	channel.CloseWrite()
+
+	// the remote closes the channel once extraction is complete, reporting failure via stderr
+	if _, err = io.Copy(ioutil.Discard, channel); err != nil {
+		return err
+	}
+	return remoteError(channel)
+}
+
+// archiveReader surfaces failures reported by the remote once the archive stream is exhausted
+type archiveReader struct {
+	channel ssh.Channel
+}
+
+func (a *archiveReader) Read(p []byte) (int, error) {
+	n, err := a.channel.Read(p)
+	if err == io.EOF {
+		if rerr := remoteError(a.channel); rerr != nil {
+			return n, rerr
+		}
+	}
+	return n, err
+}
+
+func (a *archiveReader) Close() error {
+	return a.channel.Close()
+}
+
+func remoteError(channel ssh.Channel) error {
+	detail, err := ioutil.ReadAll(channel.Stderr())
+	if err != nil {
+		return err
+	}
+	if len(detail) > 0 {
+		return errors.New(string(detail))
+	}
+	return nil
+}
+
 // SSHAttach returns a stream connection to the requested session
 // The ssh client is assumed to be connected to the Executor hosting the session
 func SSHAttach(client *ssh.Client, id string) (SessionInteraction, error) {
@@ -37,10 +37,16 @@ import (
 type Connection struct {
 	spty SessionInteraction
 
+	// the ssh client hosting the session - this is shared by all sessions in a container
+	client *ssh.Client
+
 	// the container's ID
 	id string
 }
 
+// how long to wait between attempts to attach to an exec session that the tether has not yet prepared
+const execAttachRetryInterval = 200 * time.Millisecond
+
 type Connector struct {
 	mutex       sync.RWMutex
 	cond        *sync.Cond
@@ -87,6 +93,9 @@ func (c *Connector) Get(ctx context.Context, id string, timeout time.Duration) (
 	c.mutex.RUnlock()
 	if conn != nil {
 		return conn.spty, nil
+	} else if cid := containerForExec(id); cid != "" {
+		// exec sessions are launched after the connection to the container is established
+		return c.attachExec(ctx, cid, id)
 	} else if timeout == 0 {
 		return nil, fmt.Errorf("no such connection")
 	}
@@ -127,6 +136,65 @@ func (c *Connector) Get(ctx context.Context, id string, timeout time.Duration) (
 	}
 }
 
+// Client returns the ssh client connected to the container with the specified ID, waiting
+// for the specified timeout for the connection to be established if necessary
+func (c *Connector) Client(ctx context.Context, id string, timeout time.Duration) (*ssh.Client, error) {
+	defer trace.End(trace.Begin(id))
+
+	if _, err := c.Get(ctx, id, timeout); err != nil {
+		return nil, err
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	conn := c.connections[id]
+	if conn == nil || conn.client == nil {
+		return nil, fmt.Errorf("attach connector: no client connection for %s", id)
+	}
+	return conn.client, nil
+}
+
+// attachExec opens a channel for the exec session sid over the existing connection to
+// container cid. The session may not yet be known to the tether so this retries until the
+// context expires.
+func (c *Connector) attachExec(ctx context.Context, cid, sid string) (SessionInteraction, error) {
+	defer trace.End(trace.Begin(sid))
+
+	c.mutex.RLock()
+	conn := c.connections[cid]
+	c.mutex.RUnlock()
+	if conn == nil || conn.client == nil {
+		return nil, fmt.Errorf("attach connector: no connection to container %s for exec %s", cid, sid)
+	}
+
+	for {
+		si, err := SSHAttach(conn.client, sid)
+		if err == nil {
+			log.Infof("Established connection with exec session %s in container VM %s", sid, cid)
+
+			c.mutex.Lock()
+			c.connections[sid] = &Connection{
+				spty:   si,
+				client: conn.client,
+				id:     sid,
+			}
+			c.cond.Broadcast()
+			c.mutex.Unlock()
+
+			return si, nil
+		}
+
+		log.Debugf("attach connector: exec session %s not yet available: %s", sid, err)
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("attach connector: unable to attach to exec session %s: %s", sid, err)
+		case <-time.After(execAttachRetryInterval):
+		}
+	}
+}
+
 func (c *Connector) Remove(id string) error {
 	defer trace.End(trace.Begin(id))
 
@@ -222,8 +290,9 @@ func (c *Connector) processIncoming(conn net.Conn) {
 
 		c.mutex.Lock()
 		connection := &Connection{
-			spty: si,
-			id:   id,
+			spty:   si,
+			client: client,
+			id:     id,
 		}
 
 		c.connections[connection.id] = connection
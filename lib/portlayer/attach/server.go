@@ -19,6 +19,7 @@ import (
 	"net"
 	"time"
 
+	"golang.org/x/crypto/ssh"
 	"golang.org/x/net/context"
 
 	log "github.com/Sirupsen/logrus"
@@ -98,3 +99,11 @@ func (n *Server) Remove(id string) error {
 
 	return n.connServer.Remove(id)
 }
+
+// Client returns the ssh client connected to the given container. If the container cannot
+// be found, this call will wait for the given timeout.
+func (n *Server) Client(ctx context.Context, id string, timeout time.Duration) (*ssh.Client, error) {
+	defer trace.End(trace.Begin(id))
+
+	return n.connServer.Client(ctx, id, timeout)
+}
@@ -26,6 +26,11 @@ const (
 	ContainerStarted      = "Started"
 	ContainerStopped      = "Stopped"
 	ContainerRegistered   = "Registered"
+	ContainerHealthy      = "Healthy"
+	ContainerUnhealthy    = "Unhealthy"
+	ContainerPaused       = "Paused"
+	ContainerUnpaused     = "Unpaused"
+	ContainerRenamed      = "Renamed"
 )
 
 type ContainerEvent struct {
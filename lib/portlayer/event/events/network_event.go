@@ -0,0 +1,39 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+const (
+	NetworkCreated      = "Created"
+	NetworkRemoved      = "Removed"
+	NetworkConnected    = "Connected"
+	NetworkDisconnected = "Disconnected"
+)
+
+type NetworkEvent struct {
+	*BaseEvent
+
+	// Name and ScopeType describe the network, which is referenced by ID
+	Name      string
+	ScopeType string
+	// Container is set for connect and disconnect events
+	Container string
+}
+
+func (ne *NetworkEvent) Topic() string {
+	if ne.Type == "" {
+		ne.Type = NewEventType(ne)
+	}
+	return ne.Type.Topic()
+}
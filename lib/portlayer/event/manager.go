@@ -139,9 +139,13 @@ func (mgr *Manager) Publish(e events.Event) {
 	// TODO: this will not block, but might still want to consider
 	// a timeout for the callback
 	go func() {
-		// subscribers for this event
+		// copy the subscribers for this event, as they may subscribe or unsubscribe while
+		// the callbacks are made
 		mgr.subs.mu.RLock()
-		subs := mgr.subs.subscribers[e.Topic()]
+		subs := make(map[string]func(events.Event), len(mgr.subs.subscribers[e.Topic()]))
+		for sub, f := range mgr.subs.subscribers[e.Topic()] {
+			subs[sub] = f
+		}
 		mgr.subs.mu.RUnlock()
 
 		log.Debugf("Found %d subscribers to %s: %s", len(subs), e.Topic(), e.Message())
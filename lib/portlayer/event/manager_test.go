@@ -15,6 +15,8 @@
 package event
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/vmware/vic/lib/portlayer/event/collector/vsphere"
@@ -74,6 +76,28 @@ func TestSubscribe(t *testing.T) {
 
 }
 
+func TestPublishWhileSubscribing(t *testing.T) {
+	mgr := NewEventManager()
+	topic := events.NewEventType(vsphere.VMEvent{}).Topic()
+
+	var wg sync.WaitGroup
+	received := make(chan struct{}, 1000)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			sub := fmt.Sprintf("tester%d", i)
+			mgr.Subscribe(topic, sub, func(e events.Event) { received <- struct{}{} })
+			mgr.Publish(newVMEvent())
+			mgr.Unsubscribe(topic, sub)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 0, mgr.Subscribed())
+}
+
 func TestRegisterCollector(t *testing.T) {
 	mgr := NewEventManager()
 	// register nil
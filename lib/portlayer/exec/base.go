@@ -78,6 +78,15 @@ func newBase(vm *vm.VirtualMachine, c *types.VirtualMachineConfigInfo, r *types.
 	return base
 }
 
+// VMReference returns the reference of the containerVM, or an empty reference if the VM has
+// not been created yet
+func (c *containerBase) VMReference() types.ManagedObjectReference {
+	if c.vm == nil {
+		return types.ManagedObjectReference{}
+	}
+	return c.vm.Reference()
+}
+
 // unlocked refresh of container state
 func (c *containerBase) refresh(ctx context.Context) error {
 	defer trace.End(trace.Begin(c.ExecConfig.ID))
@@ -36,6 +36,7 @@ func Commit(ctx context.Context, sess *session.Session, h *Handle, waitTime *int
 
 	c := Containers.Container(h.ExecConfig.ID)
 	creation := h.vm == nil
+	renamed := c != nil && h.renamed
 	if creation {
 		if h.Spec == nil {
 			return fmt.Errorf("a spec must be provided for create operations")
@@ -94,6 +95,16 @@ func Commit(ctx context.Context, sess *session.Session, h *Handle, waitTime *int
 		h.Spec = nil
 	}
 
+	// a requested power operation supersedes any pending restart
+	if c != nil {
+		switch h.TargetState() {
+		case StateStopped:
+			c.cancelRestart(true)
+		case StateRunning:
+			c.cancelRestart(false)
+		}
+	}
+
 	// if we're stopping the VM, do so before the reconfigure to preserve the extraconfig
 	refresh := true
 	if h.TargetState() == StateStopped {
@@ -151,8 +162,8 @@ func Commit(ctx context.Context, sess *session.Session, h *Handle, waitTime *int
 
 				s.ChangeVersion = h.Config.ChangeVersion
 
-				// nilify ExtraConfig if vm is running
-				if h.Runtime.PowerState == types.VirtualMachinePowerStatePoweredOn {
+				// nilify ExtraConfig if vm is running, unless the changes are destined for the guest
+				if h.Runtime.PowerState == types.VirtualMachinePowerStatePoweredOn && !h.reload {
 					log.Errorf("Nilifying ExtraConfig as we are running")
 					s.ExtraConfig = nil
 				}
@@ -178,9 +189,30 @@ func Commit(ctx context.Context, sess *session.Session, h *Handle, waitTime *int
 
 				break
 			}
+
+			// tell the tether to pick up the new configuration
+			if h.reload && h.Runtime.PowerState == types.VirtualMachinePowerStatePoweredOn {
+				if err := c.startGuestProgram(ctx, "reload", ""); err != nil {
+					log.Errorf("Unable to trigger configuration reload for %s: %s", h.ExecConfig.ID, err)
+					return err
+				}
+			}
 		}
 	}
 
+	// the name is updated in the cache ahead of the refresh so that subscribers to the event
+	// see the new name
+	if renamed {
+		c.m.Lock()
+		// the cached config is shared with readers, so is replaced rather than modified
+		config := *c.ExecConfig
+		config.Name = h.ExecConfig.Name
+		c.ExecConfig = &config
+		c.m.Unlock()
+
+		publishContainerEvent(h.ExecConfig.ID, time.Now().UTC(), events.ContainerRenamed)
+	}
+
 	// best effort update of container cache using committed state - this will not reflect the power on below, however
 	// this is primarily for updating ExtraConfig state.
 	if !creation {
@@ -25,7 +25,9 @@ import (
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/soap"
 	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/vic/lib/config"
 	"github.com/vmware/vic/pkg/errors"
+	"github.com/vmware/vic/pkg/log/jsonlog"
 	"github.com/vmware/vic/pkg/trace"
 	"github.com/vmware/vic/pkg/uid"
 	"github.com/vmware/vic/pkg/vsphere/session"
@@ -51,6 +53,7 @@ const (
 	StateCreating
 	StateRemoving
 	StateRemoved
+	StatePaused
 
 	propertyCollectorTimeout = 3 * time.Minute
 	containerLogName         = "output.log"
@@ -74,12 +77,28 @@ func (s State) String() string {
 		return "Stopping"
 	case StateStopped:
 		return "Stopped"
+	case StateSuspending:
+		return "Suspending"
+	case StateSuspended:
+		return "Suspended"
+	case StatePaused:
+		return "Paused"
 	case StateUnknown:
 		return "Unknown"
 	}
 	return ""
 }
 
+// Running returns whether the container has been started and not since stopped. A paused
+// container is still running, and its disks are held by the containerVM.
+func (s State) Running() bool {
+	switch s {
+	case StateRunning, StateSuspending, StateSuspended, StatePaused:
+		return true
+	}
+	return false
+}
+
 // NotFoundError is returned when a types.ManagedObjectNotFound is returned from a vmomi call
 type NotFoundError struct {
 	err error
@@ -98,6 +117,15 @@ func (r RemovePowerError) Error() string {
 	return r.err.Error()
 }
 
+// InvalidStateError is returned when an operation is not permitted in the current state of the container
+type InvalidStateError struct {
+	err error
+}
+
+func (r InvalidStateError) Error() string {
+	return r.err.Error()
+}
+
 // ConcurrentAccessError is returned when concurrent calls tries to modify same object
 type ConcurrentAccessError struct {
 	err error
@@ -134,6 +162,11 @@ type Container struct {
 	logFollowers []io.Closer
 
 	newStateEvents map[State]chan struct{}
+
+	// restart policy supervision, see restart.go
+	restartTimer  *time.Timer
+	restartDelay  time.Duration
+	stopRequested bool
 }
 
 // newContainer constructs a Container suitable for adding to the cache
@@ -155,6 +188,9 @@ func newContainer(base *containerBase) *Container {
 		switch base.Runtime.PowerState {
 		case types.VirtualMachinePowerStatePoweredOn:
 			c.state = StateRunning
+			if base.ExecConfig.Paused {
+				c.state = StatePaused
+			}
 		case types.VirtualMachinePowerStatePoweredOff:
 			// check if any of the sessions was started
 			for _, s := range base.ExecConfig.Sessions {
@@ -164,6 +200,10 @@ func newContainer(base *containerBase) *Container {
 				}
 			}
 		case types.VirtualMachinePowerStateSuspended:
+			if Config.PauseMode == config.PauseSuspend {
+				c.state = StatePaused
+				break
+			}
 			c.state = StateSuspended
 			log.Warnf("container VM %s: invalid power state %s", base.vm.Reference(), base.Runtime.PowerState)
 		}
@@ -360,6 +400,9 @@ func (c *Container) Signal(ctx context.Context, num int64) error {
 		return fmt.Errorf("vm not set")
 	}
 
+	// as with docker, a container that is signalled on request is not restarted when it exits
+	c.cancelRestart(true)
+
 	return c.startGuestProgram(ctx, "kill", fmt.Sprintf("%d", num))
 }
 
@@ -373,7 +416,9 @@ func (c *Container) onStop() {
 	}
 }
 
-func (c *Container) LogReader(ctx context.Context, tail int, follow bool) (io.ReadCloser, error) {
+// LogReader returns a reader of the log of the container. If tail is not negative the reader
+// starts at the last tail entries of the log that are selected by options.
+func (c *Container) LogReader(ctx context.Context, tail int, follow bool, options jsonlog.ReaderOptions) (io.ReadCloser, error) {
 	defer trace.End(trace.Begin(c.ExecConfig.ID))
 	c.m.Lock()
 	defer c.m.Unlock()
@@ -397,13 +442,13 @@ func (c *Container) LogReader(ctx context.Context, tail int, follow bool) (io.Re
 	}
 
 	if tail >= 0 {
-		err = file.Tail(tail)
+		err = jsonlog.Tail(file, tail, options)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	if follow && c.state == StateRunning {
+	if follow && c.state.Running() {
 		follower := file.Follow(time.Second)
 
 		c.logFollowers = append(c.logFollowers, follower)
@@ -425,10 +470,12 @@ func (c *Container) Remove(ctx context.Context, sess *session.Session) error {
 	}
 
 	// check state first
-	if c.state == StateRunning {
+	if c.state.Running() {
 		return RemovePowerError{fmt.Errorf("Container is powered on")}
 	}
 
+	c.cancelRestartLocked(true)
+
 	// get existing state and set to removing
 	// if there's a failure we'll revert to existing
 	existingState := c.updateState(StateRemoving)
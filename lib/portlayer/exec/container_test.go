@@ -40,6 +40,18 @@ func TestStateStringer(t *testing.T) {
 	assert.Equal(t, "Starting", c.state.String())
 	c.state = StateCreated
 	assert.Equal(t, "Created", c.state.String())
+	c.state = StatePaused
+	assert.Equal(t, "Paused", c.state.String())
+}
+
+func TestStateRunning(t *testing.T) {
+	for _, s := range []State{StateRunning, StateSuspending, StateSuspended, StatePaused} {
+		assert.True(t, s.Running(), s.String())
+	}
+
+	for _, s := range []State{StateUnknown, StateCreating, StateCreated, StateStarting, StateStopping, StateStopped, StateRemoving, StateRemoved} {
+		assert.False(t, s.Running(), s.String())
+	}
 }
 
 func NewContainer(id uid.UID) *Handle {
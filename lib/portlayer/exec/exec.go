@@ -120,6 +120,10 @@ func Init(ctx context.Context, sess *session.Session, source extraconfig.DataSou
 		if err = Containers.sync(ctx, sess); err != nil {
 			return
 		}
+
+		startContainers()
+
+		go newHealthMonitor().run(context.Background())
 	})
 	return initializer.err
 }
@@ -159,6 +163,11 @@ func eventCallback(ie events.Event) {
 					}
 					// regardless of update success failure publish the container event
 					publishContainerEvent(container.ExecConfig.ID, ie.Created(), ie.String())
+
+					// the exit status is only known once the container has been refreshed
+					if err == nil && newState == StateStopped {
+						container.scheduleRestart()
+					}
 				}()
 			case StateRemoved:
 				log.Debugf("Container(%s) %s via event activity", container.ExecConfig.ID, newState.String())
@@ -240,8 +249,8 @@ func eventedState(e string, current State) State {
 			return StateStopped
 		}
 	case events.ContainerSuspended:
-		// are we in the process of suspending
-		if current != StateSuspending {
+		// are we in the process of suspending, or paused by suspending
+		if current != StateSuspending && current != StatePaused {
 			return StateSuspended
 		}
 	case events.ContainerRemoved:
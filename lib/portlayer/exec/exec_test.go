@@ -15,13 +15,16 @@
 package exec
 
 import (
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/vmware/vic/lib/config/executor"
 	"github.com/vmware/vic/lib/portlayer/event"
 	"github.com/vmware/vic/lib/portlayer/event/events"
+	"github.com/vmware/vic/pkg/vsphere/extraconfig"
 )
 
 var containerEvents []events.Event
@@ -45,6 +48,7 @@ func TestEventedState(t *testing.T) {
 	assert.EqualValues(t, StateSuspending, eventedState(event, StateSuspending))
 	assert.EqualValues(t, StateSuspended, eventedState(event, StateSuspended))
 	assert.EqualValues(t, StateSuspended, eventedState(event, StateRunning))
+	assert.EqualValues(t, StatePaused, eventedState(event, StatePaused))
 
 	// removed event
 	event = events.ContainerRemoved
@@ -81,3 +85,40 @@ func TestPublishContainerEvent(t *testing.T) {
 func containerCallback(ee events.Event) {
 	containerEvents = append(containerEvents, ee)
 }
+
+func TestRemovedExecKeys(t *testing.T) {
+	session := func(id string) *executor.SessionConfig {
+		return &executor.SessionConfig{
+			Common: executor.Common{ID: id},
+			Cmd:    executor.Cmd{Path: "/bin/ls"},
+		}
+	}
+
+	h := TestHandle("container")
+	h.ExecConfig.Execs = map[string]*executor.SessionConfig{
+		"kept":    session("kept"),
+		"removed": session("removed"),
+	}
+	h.RemoveExec("removed")
+	h.RemoveExec("missing")
+
+	cfg := make(map[string]string)
+	extraconfig.Encode(extraconfig.MapSink(cfg), h.ExecConfig)
+
+	keys := removedExecKeys(cfg, h.removedExecs)
+	assert.NotEmpty(t, keys)
+	for _, k := range keys {
+		assert.Contains(t, k, "removed", "only keys of the removed session should be cleared")
+	}
+
+	// once the last session is gone the list of sessions is cleared as well
+	h.RemoveExec("kept")
+	cfg = make(map[string]string)
+	extraconfig.Encode(extraconfig.MapSink(cfg), h.ExecConfig)
+
+	var list bool
+	for _, k := range removedExecKeys(cfg, h.removedExecs) {
+		list = list || strings.HasSuffix(k, "execs")
+	}
+	assert.True(t, list, "expected the list of sessions to be cleared")
+}
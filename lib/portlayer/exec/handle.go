@@ -68,6 +68,19 @@ type Handle struct {
 	// desired state
 	targetState State
 
+	// should the guest be told to reload its configuration after commit
+	reload bool
+
+	// is the commit a restart made under the restart policy of the container, rather than
+	// on request
+	resurrection bool
+
+	// has the container been renamed through this handle
+	renamed bool
+
+	// the exec sessions removed from the container, whose configuration is cleared on commit
+	removedExecs map[string]*executor.SessionConfig
+
 	// allow for passing outside of the process
 	key string
 }
@@ -120,6 +133,62 @@ func (h *Handle) SetTargetState(s State) {
 	h.targetState = s
 }
 
+// Reload marks the handle so that, if the container is running, the changes to the
+// executor config are pushed to the guest and the tether is told to reload them on commit
+func (h *Handle) Reload() {
+	h.reload = true
+}
+
+// Rename changes the name of the container and the display name of its VM on commit
+func (h *Handle) Rename(newName string) {
+	h.ExecConfig.Name = newName
+	h.renamed = true
+	h.Spec.Spec().Name = spec.DisplayName(newName, h.ExecConfig.ID)
+
+	// the guest resolves its own name, so must be told of the change
+	h.Reload()
+}
+
+// Renamed returns whether the container has been renamed through this handle
+func (h *Handle) Renamed() bool {
+	return h.renamed
+}
+
+// RemoveExec removes the exec session from the container and clears its configuration from
+// the VM on commit
+func (h *Handle) RemoveExec(id string) {
+	session, ok := h.ExecConfig.Execs[id]
+	if !ok {
+		return
+	}
+
+	if h.removedExecs == nil {
+		h.removedExecs = make(map[string]*executor.SessionConfig)
+	}
+	h.removedExecs[id] = session
+	delete(h.ExecConfig.Execs, id)
+}
+
+// removedExecKeys returns the keys of the removed exec sessions that are absent from the
+// encoded config cfg, including the list of sessions if none remain
+func removedExecKeys(cfg map[string]string, execs map[string]*executor.SessionConfig) []string {
+	if len(execs) == 0 {
+		return nil
+	}
+
+	removed := make(map[string]string)
+	extraconfig.Encode(extraconfig.MapSink(removed), &executor.ExecutorConfig{Execs: execs})
+
+	var keys []string
+	for k := range removed {
+		if _, ok := cfg[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
 // GetHandle finds and returns the handle that is referred by key
 func GetHandle(key string) *Handle {
 	handlesLock.Lock()
@@ -173,12 +242,24 @@ func (h *Handle) Commit(ctx context.Context, sess *session.Session, waitTime *in
 	// Set timestamps based on target state
 	switch h.TargetState() {
 	case StateRunning:
+		// a start on request clears the history of the restart policy
+		if !h.resurrection {
+			h.ExecConfig.Diagnostics.ResurrectionCount = 0
+		}
+		h.ExecConfig.RestartPolicy.Stopped = false
+
 		for _, sc := range h.ExecConfig.Sessions {
 			sc.StartTime = time.Now().UTC().Unix()
 			sc.Started = ""
 			sc.ExitStatus = 0
 		}
+		// exec sessions do not survive a restart of the container
+		for _, sc := range h.ExecConfig.Execs {
+			sc.Active = false
+		}
 	case StateStopped:
+		h.ExecConfig.RestartPolicy.Stopped = true
+
 		for _, sc := range h.ExecConfig.Sessions {
 			sc.StopTime = time.Now().UTC().Unix()
 		}
@@ -188,6 +269,11 @@ func (h *Handle) Commit(ctx context.Context, sess *session.Session, waitTime *in
 	s := h.Spec.Spec()
 	s.ExtraConfig = append(s.ExtraConfig, vmomi.OptionValueFromMap(cfg)...)
 
+	// an empty value deletes the key
+	for _, k := range removedExecKeys(cfg, h.removedExecs) {
+		s.ExtraConfig = append(s.ExtraConfig, &types.OptionValue{Key: k, Value: ""})
+	}
+
 	if err := Commit(ctx, sess, h, waitTime); err != nil {
 		return err
 	}
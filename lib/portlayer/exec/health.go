@@ -0,0 +1,154 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"golang.org/x/net/context"
+
+	"github.com/vmware/vic/lib/config/executor"
+	"github.com/vmware/vic/lib/portlayer/event/events"
+)
+
+const (
+	// healthPollInterval is how often the health monitor looks for containers due a refresh
+	healthPollInterval = 5 * time.Second
+
+	// defaultHealthInterval matches the probe interval the tether uses when none is configured
+	defaultHealthInterval = 30 * time.Second
+)
+
+// healthMonitor follows the health of running containers with a healthcheck. The tether runs
+// the probes and records the results in guestinfo, which raises no vSphere event, so the
+// monitor refreshes each container at its probe interval and publishes health status changes.
+type healthMonitor struct {
+	// next is when each container is next due a refresh
+	next map[string]time.Time
+	// status is the last health status seen for each container
+	status map[string]string
+}
+
+func newHealthMonitor() *healthMonitor {
+	return &healthMonitor{
+		next:   make(map[string]time.Time),
+		status: make(map[string]string),
+	}
+}
+
+// hasHealthcheck returns true if the session has a healthcheck for the tether to run
+func hasHealthcheck(session *executor.SessionConfig) bool {
+	test := session.Healthcheck.Test
+	return len(test) > 0 && test[0] != "NONE"
+}
+
+// run polls the running containers until the context is cancelled
+func (m *healthMonitor) run(ctx context.Context) {
+	ticker := time.NewTicker(healthPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.poll(ctx, now)
+		}
+	}
+}
+
+// poll refreshes the running containers that are due and publishes an event for each change
+// in health status
+func (m *healthMonitor) poll(ctx context.Context, now time.Time) {
+	running := StateRunning
+	seen := make(map[string]bool)
+
+	for _, c := range Containers.Containers(&running) {
+		id := c.ExecConfig.ID
+		session := c.primarySession()
+		if session == nil || !hasHealthcheck(session) {
+			continue
+		}
+
+		seen[id] = true
+		if now.Before(m.next[id]) {
+			continue
+		}
+
+		interval := session.Healthcheck.Interval
+		if interval <= 0 {
+			interval = defaultHealthInterval
+		}
+		m.next[id] = now.Add(interval)
+
+		rctx, cancel := context.WithTimeout(ctx, propertyCollectorTimeout)
+		err := c.Refresh(rctx)
+		cancel()
+		if err != nil {
+			log.Errorf("Unable to refresh health of container %s: %s", id, err)
+			continue
+		}
+
+		if session = c.primarySession(); session == nil {
+			continue
+		}
+		if event := m.update(id, session.Health.Status); event != "" {
+			log.Infof("Container(%s) health status is %s", id, session.Health.Status)
+			publishContainerEvent(id, now, event)
+		}
+	}
+
+	// forget containers that have stopped or gone, so a restart is reported afresh
+	for id := range m.next {
+		if !seen[id] {
+			delete(m.next, id)
+			delete(m.status, id)
+		}
+	}
+}
+
+// primarySession returns a copy of the primary session of the container, taken under lock
+// as the sessions are replaced whenever the container is refreshed
+func (c *Container) primarySession() *executor.SessionConfig {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	session := c.ExecConfig.Sessions[c.ExecConfig.ID]
+	if session == nil {
+		return nil
+	}
+
+	copied := *session
+	return &copied
+}
+
+// update records the health status of the container and returns the event to publish, if the
+// status has changed to healthy or unhealthy
+func (m *healthMonitor) update(id, status string) string {
+	if m.status[id] == status {
+		return ""
+	}
+	m.status[id] = status
+
+	switch status {
+	case executor.HealthHealthy:
+		return events.ContainerHealthy
+	case executor.HealthUnhealthy:
+		return events.ContainerUnhealthy
+	}
+
+	return ""
+}
@@ -0,0 +1,52 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware/vic/lib/config/executor"
+	"github.com/vmware/vic/lib/portlayer/event/events"
+)
+
+func TestHasHealthcheck(t *testing.T) {
+	session := &executor.SessionConfig{}
+	assert.False(t, hasHealthcheck(session))
+
+	session.Healthcheck.Test = []string{"NONE"}
+	assert.False(t, hasHealthcheck(session))
+
+	session.Healthcheck.Test = []string{"CMD-SHELL", "true"}
+	assert.True(t, hasHealthcheck(session))
+}
+
+func TestHealthMonitorUpdate(t *testing.T) {
+	m := newHealthMonitor()
+
+	// starting is not reported
+	assert.Equal(t, "", m.update("c1", executor.HealthStarting))
+
+	assert.Equal(t, events.ContainerHealthy, m.update("c1", executor.HealthHealthy))
+	assert.Equal(t, "", m.update("c1", executor.HealthHealthy))
+
+	assert.Equal(t, events.ContainerUnhealthy, m.update("c1", executor.HealthUnhealthy))
+	assert.Equal(t, "", m.update("c1", executor.HealthUnhealthy))
+
+	// each container is followed separately
+	assert.Equal(t, events.ContainerHealthy, m.update("c2", executor.HealthHealthy))
+	assert.Equal(t, events.ContainerHealthy, m.update("c1", executor.HealthHealthy))
+}
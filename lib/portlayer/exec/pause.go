@@ -0,0 +1,108 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/vic/lib/config"
+	"github.com/vmware/vic/lib/portlayer/event/events"
+	"github.com/vmware/vic/pkg/trace"
+	"github.com/vmware/vic/pkg/vsphere/tasks"
+)
+
+// Pause pauses a running container. Depending on the pause mode of the VCH either the tether
+// freezes the container processes, or the containerVM is suspended.
+func (c *Container) Pause(ctx context.Context) error {
+	defer trace.End(trace.Begin(c.ExecConfig.ID))
+
+	if c.vm == nil {
+		return fmt.Errorf("vm not set")
+	}
+
+	// the transitional state keeps the suspend event from being taken as out-of-band
+	c.m.Lock()
+	if c.state != StateRunning {
+		c.m.Unlock()
+		return InvalidStateError{fmt.Errorf("Container %s is not running", c.ExecConfig.ID)}
+	}
+	c.updateState(StateSuspending)
+	c.m.Unlock()
+
+	var err error
+	if Config.PauseMode == config.PauseSuspend {
+		_, err = c.vm.WaitForResult(ctx, func(ctx context.Context) (tasks.Task, error) {
+			return c.vm.Suspend(ctx)
+		})
+	} else {
+		err = c.startGuestProgram(ctx, "pause", "")
+	}
+
+	if err != nil {
+		log.Errorf("Unable to pause container %s: %s", c.ExecConfig.ID, err)
+		c.SetState(StateRunning)
+		return err
+	}
+
+	c.SetState(StatePaused)
+	publishContainerEvent(c.ExecConfig.ID, time.Now().UTC(), events.ContainerPaused)
+	return nil
+}
+
+// Unpause resumes a paused container. A suspended containerVM is powered on whatever the pause
+// mode of the VCH, as it may have been suspended out-of-band.
+func (c *Container) Unpause(ctx context.Context) error {
+	defer trace.End(trace.Begin(c.ExecConfig.ID))
+
+	if c.vm == nil {
+		return fmt.Errorf("vm not set")
+	}
+
+	// the transitional state keeps the power on event from being reported as a start
+	c.m.Lock()
+	previous := c.state
+	if previous != StatePaused && previous != StateSuspended {
+		c.m.Unlock()
+		return InvalidStateError{fmt.Errorf("Container %s is not paused", c.ExecConfig.ID)}
+	}
+	c.updateState(StateStarting)
+	c.m.Unlock()
+
+	power, err := c.vm.PowerState(ctx)
+	if err == nil {
+		if power == types.VirtualMachinePowerStateSuspended {
+			_, err = c.vm.WaitForResult(ctx, func(ctx context.Context) (tasks.Task, error) {
+				return c.vm.PowerOn(ctx)
+			})
+		} else {
+			err = c.startGuestProgram(ctx, "unpause", "")
+		}
+	}
+
+	if err != nil {
+		log.Errorf("Unable to unpause container %s: %s", c.ExecConfig.ID, err)
+		c.SetState(previous)
+		return err
+	}
+
+	c.SetState(StateRunning)
+	publishContainerEvent(c.ExecConfig.ID, time.Now().UTC(), events.ContainerUnpaused)
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"fmt"
+
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/vic/lib/config/executor"
+	"github.com/vmware/vic/pkg/trace"
+)
+
+// Resources describes a change to the compute resources of a containerVM. Zero or nil fields
+// are left unchanged.
+type Resources struct {
+	// NumCPUs and MemoryMB are the virtual hardware of the containerVM
+	NumCPUs  int32
+	MemoryMB int64
+
+	// CPU (MHz) and Memory (MB) are the allocations of the containerVM from the host
+	CPU    *types.ResourceAllocationInfo
+	Memory *types.ResourceAllocationInfo
+}
+
+// SetResources changes the compute resources of the containerVM when the handle is committed.
+// The allocations can be changed at any time, but the virtual hardware of a running containerVM
+// can only grow, and only if hot add is enabled for it.
+func (h *Handle) SetResources(res Resources) error {
+	defer trace.End(trace.Begin(h.ExecConfig.ID))
+
+	running := h.Runtime != nil && h.Runtime.PowerState == types.VirtualMachinePowerStatePoweredOn
+	if running && h.Config != nil {
+		hw := h.Config.Hardware
+
+		if res.NumCPUs != 0 && res.NumCPUs != hw.NumCPU {
+			if res.NumCPUs < hw.NumCPU {
+				return InvalidStateError{fmt.Errorf("Cannot reduce the CPUs of running container %s from %d to %d", h.ExecConfig.ID, hw.NumCPU, res.NumCPUs)}
+			}
+			if !enabled(h.Config.CpuHotAddEnabled) {
+				return InvalidStateError{fmt.Errorf("CPU hot add is not enabled for container %s, stop it to change its CPUs", h.ExecConfig.ID)}
+			}
+		}
+
+		if res.MemoryMB != 0 && res.MemoryMB != int64(hw.MemoryMB) {
+			if res.MemoryMB < int64(hw.MemoryMB) {
+				return InvalidStateError{fmt.Errorf("Cannot reduce the memory of running container %s from %dMB to %dMB", h.ExecConfig.ID, hw.MemoryMB, res.MemoryMB)}
+			}
+			if !enabled(h.Config.MemoryHotAddEnabled) {
+				return InvalidStateError{fmt.Errorf("Memory hot add is not enabled for container %s, stop it to change its memory", h.ExecConfig.ID)}
+			}
+		}
+	}
+
+	s := h.Spec.Spec()
+	if res.NumCPUs != 0 {
+		s.NumCPUs = res.NumCPUs
+	}
+	if res.MemoryMB != 0 {
+		s.MemoryMB = res.MemoryMB
+	}
+	if res.CPU != nil {
+		s.CpuAllocation = res.CPU
+	}
+	if res.Memory != nil {
+		s.MemoryAllocation = res.Memory
+	}
+
+	return nil
+}
+
+// SetRestartPolicy changes the restart policy of the container when the handle is committed
+func (h *Handle) SetRestartPolicy(policy executor.RestartPolicy) {
+	defer trace.End(trace.Begin(h.ExecConfig.ID))
+
+	h.ExecConfig.RestartPolicy = policy
+
+	// the policy is kept in extraconfig, which is only written to a running containerVM on reload
+	h.Reload()
+}
+
+func enabled(b *bool) bool {
+	return b != nil && *b
+}
@@ -0,0 +1,62 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/vic/lib/config/executor"
+)
+
+func TestSetResources(t *testing.T) {
+	hotAdd := true
+	h := TestHandle("resources")
+	h.Runtime = &types.VirtualMachineRuntimeInfo{PowerState: types.VirtualMachinePowerStatePoweredOn}
+	h.Config = &types.VirtualMachineConfigInfo{
+		Hardware: types.VirtualHardware{NumCPU: 2, MemoryMB: 2048},
+	}
+
+	cpu := &types.ResourceAllocationInfo{Limit: 1000}
+
+	// allocations can change while running
+	assert.NoError(t, h.SetResources(Resources{CPU: cpu}))
+	assert.Equal(t, cpu, h.Spec.Spec().CpuAllocation)
+
+	// hardware cannot shrink, or grow without hot add, while running
+	_, ok := h.SetResources(Resources{MemoryMB: 1024}).(InvalidStateError)
+	assert.True(t, ok)
+	_, ok = h.SetResources(Resources{NumCPUs: 4}).(InvalidStateError)
+	assert.True(t, ok)
+
+	h.Config.CpuHotAddEnabled = &hotAdd
+	assert.NoError(t, h.SetResources(Resources{NumCPUs: 4}))
+	assert.Equal(t, int32(4), h.Spec.Spec().NumCPUs)
+
+	// anything goes once stopped
+	h.Runtime.PowerState = types.VirtualMachinePowerStatePoweredOff
+	assert.NoError(t, h.SetResources(Resources{MemoryMB: 1024}))
+	assert.Equal(t, int64(1024), h.Spec.Spec().MemoryMB)
+}
+
+func TestSetRestartPolicy(t *testing.T) {
+	h := TestHandle("restart")
+
+	h.SetRestartPolicy(executor.RestartPolicy{Name: executor.RestartAlways})
+	assert.Equal(t, executor.RestartAlways, h.ExecConfig.RestartPolicy.Name)
+	assert.True(t, h.reload)
+}
@@ -0,0 +1,202 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"golang.org/x/net/context"
+
+	"github.com/vmware/vic/lib/config/executor"
+	"github.com/vmware/vic/pkg/trace"
+)
+
+const (
+	// restartBackoffMin is the delay before the first restart of a container, it doubles on
+	// each subsequent restart up to restartBackoffMax
+	restartBackoffMin = 100 * time.Millisecond
+	restartBackoffMax = time.Minute
+
+	// a container that stays up for restartResetInterval has started successfully and the
+	// backoff starts over
+	restartResetInterval = 10 * time.Second
+)
+
+// exitFailed returns true if the session did not exit cleanly. The tether records the stop time
+// when the process exits, so a session without one was halted by a guest crash or a power off
+// from outside of the VCH.
+func exitFailed(session *executor.SessionConfig) bool {
+	return session.ExitStatus != 0 || session.Started != "true" || session.StopTime < session.StartTime
+}
+
+// shouldRestart returns true if the restart policy calls for a container whose primary session
+// exited as recorded in session to be restarted, given how many times it has been restarted
+func shouldRestart(policy executor.RestartPolicy, session *executor.SessionConfig, count int) bool {
+	switch policy.Name {
+	case executor.RestartAlways:
+		return true
+	case executor.RestartUnlessStopped:
+		return !policy.Stopped
+	case executor.RestartOnFailure:
+		if policy.MaximumRetryCount > 0 && count >= policy.MaximumRetryCount {
+			return false
+		}
+		return exitFailed(session)
+	}
+
+	return false
+}
+
+// scheduleRestart arranges for the container to be started again, after the backoff delay,
+// if it stopped by itself and its restart policy asks for it
+func (c *Container) scheduleRestart() {
+	defer trace.End(trace.Begin(c.ExecConfig.ID))
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.state != StateStopped || c.restartTimer != nil {
+		return
+	}
+
+	if c.stopRequested {
+		// a container stopped by a signal rather than a stop operation has not recorded
+		// the request, which must survive a restart of the port layer
+		if c.ExecConfig.RestartPolicy.Name == executor.RestartUnlessStopped && !c.ExecConfig.RestartPolicy.Stopped {
+			go c.recordStopped()
+		}
+		return
+	}
+
+	session := c.ExecConfig.Sessions[c.ExecConfig.ID]
+	if session == nil || !shouldRestart(c.ExecConfig.RestartPolicy, session, c.ExecConfig.Diagnostics.ResurrectionCount) {
+		return
+	}
+
+	if c.restartDelay == 0 || time.Since(time.Unix(session.StartTime, 0)) >= restartResetInterval {
+		c.restartDelay = restartBackoffMin
+	} else if c.restartDelay *= 2; c.restartDelay > restartBackoffMax {
+		c.restartDelay = restartBackoffMax
+	}
+
+	log.Infof("Container(%s) exited with status %d, restarting in %s per %q restart policy",
+		c.ExecConfig.ID, session.ExitStatus, c.restartDelay, c.ExecConfig.RestartPolicy.Name)
+
+	c.restartTimer = time.AfterFunc(c.restartDelay, c.restart)
+}
+
+// restart starts the container and records the restart in its diagnostics
+func (c *Container) restart() {
+	defer trace.End(trace.Begin(c.ExecConfig.ID))
+
+	c.m.Lock()
+	c.restartTimer = nil
+	cancelled := c.stopRequested || c.state != StateStopped
+	c.m.Unlock()
+
+	if cancelled {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), propertyCollectorTimeout)
+	defer cancel()
+
+	h := c.NewHandle(ctx)
+	if h == nil {
+		log.Errorf("Unable to restart container %s: failed to get handle", c.ExecConfig.ID)
+		return
+	}
+
+	h.ExecConfig.Diagnostics.ResurrectionCount++
+	h.resurrection = true
+	h.SetTargetState(StateRunning)
+
+	// the session is only needed when creating a containerVM
+	if err := h.Commit(ctx, nil, nil); err != nil {
+		log.Errorf("Unable to restart container %s: %s", c.ExecConfig.ID, err)
+		h.Close()
+	}
+}
+
+// recordStopped persists that the container was stopped on request
+func (c *Container) recordStopped() {
+	defer trace.End(trace.Begin(c.ExecConfig.ID))
+
+	ctx, cancel := context.WithTimeout(context.Background(), propertyCollectorTimeout)
+	defer cancel()
+
+	h := c.NewHandle(ctx)
+	if h == nil {
+		log.Errorf("Unable to record stop of container %s: failed to get handle", c.ExecConfig.ID)
+		return
+	}
+
+	h.ExecConfig.RestartPolicy.Stopped = true
+
+	if err := h.Commit(ctx, nil, nil); err != nil {
+		log.Errorf("Unable to record stop of container %s: %s", c.ExecConfig.ID, err)
+		h.Close()
+	}
+}
+
+// startsWithPortLayer returns true if the restart policy calls for a stopped container to be
+// started when the port layer starts, as docker does for its containers when the daemon starts
+func startsWithPortLayer(policy executor.RestartPolicy) bool {
+	switch policy.Name {
+	case executor.RestartAlways:
+		return true
+	case executor.RestartUnlessStopped:
+		return !policy.Stopped
+	}
+
+	return false
+}
+
+// startContainers starts the stopped containers in the cache whose restart policy calls for it
+func startContainers() {
+	defer trace.End(trace.Begin(""))
+
+	stopped := StateStopped
+	for _, c := range Containers.Containers(&stopped) {
+		c.m.Lock()
+		start := startsWithPortLayer(c.ExecConfig.RestartPolicy)
+		c.m.Unlock()
+
+		if !start {
+			continue
+		}
+
+		log.Infof("Starting container %s per %q restart policy", c.ExecConfig.ID, c.ExecConfig.RestartPolicy.Name)
+		go c.restart()
+	}
+}
+
+// cancelRestart stops any pending restart of the container. stopRequested records whether the
+// container is being stopped on request, which suppresses restarts until it is next started.
+func (c *Container) cancelRestart(stopRequested bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.cancelRestartLocked(stopRequested)
+}
+
+func (c *Container) cancelRestartLocked(stopRequested bool) {
+	if c.restartTimer != nil {
+		c.restartTimer.Stop()
+		c.restartTimer = nil
+	}
+	c.stopRequested = stopRequested
+}
@@ -0,0 +1,117 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware/vic/lib/config/executor"
+)
+
+func TestShouldRestart(t *testing.T) {
+	now := time.Now().Unix()
+
+	clean := &executor.SessionConfig{Started: "true", ExitStatus: 0}
+	clean.StartTime, clean.StopTime = now-20, now
+
+	failed := &executor.SessionConfig{Started: "true", ExitStatus: 1}
+	failed.StartTime, failed.StopTime = now-20, now
+
+	// no stop time was recorded, so the guest went down with the process running
+	crashed := &executor.SessionConfig{Started: "true", ExitStatus: 0}
+	crashed.StartTime, crashed.StopTime = now, now-20
+
+	unlaunched := &executor.SessionConfig{Started: "exec: not found"}
+
+	no := executor.RestartPolicy{Name: executor.RestartNo}
+	for _, s := range []*executor.SessionConfig{clean, failed, crashed, unlaunched} {
+		assert.False(t, shouldRestart(no, s, 0))
+		assert.False(t, shouldRestart(executor.RestartPolicy{}, s, 0))
+	}
+
+	for _, name := range []string{executor.RestartAlways, executor.RestartUnlessStopped} {
+		policy := executor.RestartPolicy{Name: name}
+		for _, s := range []*executor.SessionConfig{clean, failed, crashed} {
+			assert.True(t, shouldRestart(policy, s, 100))
+		}
+	}
+
+	// unless-stopped does not restart a container last stopped on request
+	stopped := executor.RestartPolicy{Name: executor.RestartUnlessStopped, Stopped: true}
+	assert.False(t, shouldRestart(stopped, failed, 0))
+
+	onFailure := executor.RestartPolicy{Name: executor.RestartOnFailure}
+	assert.False(t, shouldRestart(onFailure, clean, 0))
+	assert.True(t, shouldRestart(onFailure, failed, 100))
+	assert.True(t, shouldRestart(onFailure, crashed, 0))
+	assert.True(t, shouldRestart(onFailure, unlaunched, 0))
+
+	onFailure.MaximumRetryCount = 3
+	assert.True(t, shouldRestart(onFailure, failed, 2))
+	assert.False(t, shouldRestart(onFailure, failed, 3))
+}
+
+func TestScheduleRestartBackoff(t *testing.T) {
+	c := newContainer(&containerBase{ExecConfig: &executor.ExecutorConfig{}})
+	c.ExecConfig.ID = "restart"
+	c.ExecConfig.RestartPolicy.Name = executor.RestartAlways
+	c.ExecConfig.Sessions = map[string]*executor.SessionConfig{
+		"restart": {Started: "true"},
+	}
+	c.ExecConfig.Sessions["restart"].StartTime = time.Now().Unix()
+
+	// not restarted until stopped
+	c.scheduleRestart()
+	assert.Nil(t, c.restartTimer)
+
+	c.state = StateStopped
+
+	delays := []time.Duration{restartBackoffMin, 2 * restartBackoffMin, 4 * restartBackoffMin}
+	for _, delay := range delays {
+		c.scheduleRestart()
+		assert.NotNil(t, c.restartTimer)
+		assert.Equal(t, delay, c.restartDelay)
+
+		c.cancelRestart(false)
+		assert.Nil(t, c.restartTimer)
+	}
+
+	c.restartDelay = restartBackoffMax
+	c.scheduleRestart()
+	assert.Equal(t, restartBackoffMax, c.restartDelay)
+
+	// a long running container starts the backoff over
+	c.cancelRestart(false)
+	c.ExecConfig.Sessions["restart"].StartTime = time.Now().Add(-restartResetInterval).Unix()
+	c.scheduleRestart()
+	assert.Equal(t, restartBackoffMin, c.restartDelay)
+
+	// a stop request suppresses restarts
+	c.cancelRestart(true)
+	c.scheduleRestart()
+	assert.Nil(t, c.restartTimer)
+}
+
+func TestStartsWithPortLayer(t *testing.T) {
+	assert.True(t, startsWithPortLayer(executor.RestartPolicy{Name: executor.RestartAlways}))
+	assert.True(t, startsWithPortLayer(executor.RestartPolicy{Name: executor.RestartAlways, Stopped: true}))
+	assert.True(t, startsWithPortLayer(executor.RestartPolicy{Name: executor.RestartUnlessStopped}))
+	assert.False(t, startsWithPortLayer(executor.RestartPolicy{Name: executor.RestartUnlessStopped, Stopped: true}))
+	assert.False(t, startsWithPortLayer(executor.RestartPolicy{Name: executor.RestartOnFailure}))
+	assert.False(t, startsWithPortLayer(executor.RestartPolicy{}))
+}
@@ -0,0 +1,71 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/vic/pkg/trace"
+	"github.com/vmware/vic/pkg/vsphere/tasks"
+)
+
+// Snapshot takes a snapshot of the containerVM without its memory. The containerVM carries on
+// writing to new delta disks, leaving the disks of the snapshot unchanged so that they can be
+// read by the appliance while the container runs.
+//
+// returns the configuration of the containerVM captured by the snapshot, which refers to the
+// snapshot disks, and a function that removes the snapshot once they are no longer needed
+func (c *Container) Snapshot(ctx context.Context, name string) (*types.VirtualMachineConfigInfo, func(), error) {
+	defer trace.End(trace.Begin(c.ExecConfig.ID))
+
+	if c.vm == nil {
+		return nil, nil, fmt.Errorf("vm not set")
+	}
+
+	info, err := c.vm.WaitForResult(ctx, func(ctx context.Context) (tasks.Task, error) {
+		return c.vm.CreateSnapshot(ctx, name, fmt.Sprintf("%s of container %s", name, c.ExecConfig.ID), false, false)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ref, ok := info.Result.(types.ManagedObjectReference)
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected snapshot result for container %s: %#v", c.ExecConfig.ID, info.Result)
+	}
+
+	// the snapshot is consolidated back into the disks of the containerVM when removed
+	remove := func() {
+		_, err := c.vm.WaitForResult(context.Background(), func(ctx context.Context) (tasks.Task, error) {
+			return c.vm.RemoveSnapshot(ctx, ref, false, true)
+		})
+		if err != nil {
+			log.Errorf("Unable to remove %s snapshot of container %s: %s", name, c.ExecConfig.ID, err)
+		}
+	}
+
+	var snapshot mo.VirtualMachineSnapshot
+	if err = c.vm.Properties(ctx, ref, []string{"config"}, &snapshot); err != nil {
+		remove()
+		return nil, nil, err
+	}
+
+	return &snapshot.Config, remove, nil
+}
@@ -18,12 +18,15 @@ import (
 	"fmt"
 
 	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/vic/lib/config/executor"
 	"github.com/vmware/vic/lib/portlayer/exec"
 	"github.com/vmware/vic/pkg/trace"
 )
 
-// Join adds two file backed serial port and configures them
-func Join(h interface{}) (interface{}, error) {
+// Join adds the file backed serial ports for the debug and output logs and configures them.
+// The log driver in config is recorded on the primary session for the tether to forward output
+// with, and the output log is omitted if the output is only forwarded.
+func Join(h interface{}, config executor.LogConfig) (interface{}, error) {
 	defer trace.End(trace.Begin(""))
 
 	handle, ok := h.(*exec.Handle)
@@ -31,10 +34,21 @@ func Join(h interface{}) (interface{}, error) {
 		return nil, fmt.Errorf("Type assertion failed for %#+v", handle)
 	}
 
+	session, ok := handle.ExecConfig.Sessions[handle.ExecConfig.ID]
+	if !ok {
+		return nil, fmt.Errorf("No primary session for %s", handle.ExecConfig.ID)
+	}
+	session.Logging = config
+
 	VMPathName := handle.Spec.VMPathName()
 	VMName := handle.Spec.Spec().Name
 
-	for _, logFile := range []string{"tether.debug", "output.log"} {
+	logFiles := []string{"tether.debug"}
+	if !config.ForwardOnly {
+		logFiles = append(logFiles, "output.log")
+	}
+
+	for _, logFile := range logFiles {
 		filename := fmt.Sprintf("%s/%s/%s", VMPathName, VMName, logFile)
 
 		// Debug and log serial ports - backed by datastore file
@@ -0,0 +1,407 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics samples the resource usage of containerVMs from the vSphere quickStats and
+// the realtime counters of the PerformanceManager.
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"golang.org/x/net/context"
+
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/vmware/vic/pkg/trace"
+	"github.com/vmware/vic/pkg/vsphere/session"
+)
+
+const (
+	// realtimeInterval is the sampling interval, in seconds, of the vSphere realtime counters
+	realtimeInterval = 20
+
+	netRx        = "net.received.average"
+	netTx        = "net.transmitted.average"
+	netRxPackets = "net.packetsRx.summation"
+	netTxPackets = "net.packetsTx.summation"
+	diskRead     = "virtualDisk.read.average"
+	diskWrite    = "virtualDisk.write.average"
+	diskReadOps  = "virtualDisk.numberReadAveraged.average"
+	diskWriteOps = "virtualDisk.numberWriteAveraged.average"
+)
+
+// sampleInterval is the period between the samples delivered to subscribers, matching the rate
+// at which vSphere refreshes the realtime counters and quickStats
+var sampleInterval = realtimeInterval * time.Second
+
+var counterNames = []string{netRx, netTx, netRxPackets, netTxPackets, diskRead, diskWrite, diskReadOps, diskWriteOps}
+
+// Sample is the resource usage of a containerVM at a point in time. Network and storage
+// figures are per second rates averaged over the most recent realtime interval.
+type Sample struct {
+	Read time.Time
+
+	CPUCount int32
+	// CPUUsage and CPULimit are in MHz
+	CPUUsage int64
+	CPULimit int64
+
+	// MemoryUsage and MemoryLimit are in bytes
+	MemoryUsage int64
+	MemoryLimit int64
+
+	NetworkRxBytes   int64
+	NetworkTxBytes   int64
+	NetworkRxPackets int64
+	NetworkTxPackets int64
+
+	StorageReadBytes  int64
+	StorageWriteBytes int64
+	StorageReadOps    int64
+	StorageWriteOps   int64
+}
+
+// Collector samples containerVM resource usage
+type Collector struct {
+	session *session.Session
+
+	// sample is the function used to poll on behalf of subscribers
+	sample func(ctx context.Context, ref types.ManagedObjectReference) (*Sample, error)
+
+	m        sync.Mutex
+	counters map[string]int32
+
+	streamsM sync.Mutex
+	streams  map[string]*stream
+}
+
+// NewCollector returns a Collector that queries vSphere via the supplied session
+func NewCollector(s *session.Session) *Collector {
+	c := &Collector{session: s}
+	c.sample = c.Sample
+	return c
+}
+
+// stream is the poller shared by all subscribers to a single VM
+type stream struct {
+	ref    types.ManagedObjectReference
+	cancel context.CancelFunc
+
+	// the following are guarded by Collector.streamsM
+	subscribers map[chan *Sample]struct{}
+	latest      *Sample
+	err         error
+}
+
+// Subscription delivers the samples of a VM to a single consumer
+type Subscription struct {
+	// C receives a sample each interval and is closed if sampling fails
+	C <-chan *Sample
+
+	collector *Collector
+	stream    *stream
+	ch        chan *Sample
+}
+
+// Subscribe returns a Subscription to the samples of the VM identified by ref. All subscribers
+// to a VM share a single poller, which samples once per realtime interval and stops when the
+// last subscription is closed. A consumer that is slow to read only sees the latest sample.
+func (c *Collector) Subscribe(ref types.ManagedObjectReference) *Subscription {
+	defer trace.End(trace.Begin(ref.Value))
+
+	c.streamsM.Lock()
+	defer c.streamsM.Unlock()
+
+	if c.streams == nil {
+		c.streams = make(map[string]*stream)
+	}
+
+	s, ok := c.streams[ref.Value]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		s = &stream{
+			ref:         ref,
+			cancel:      cancel,
+			subscribers: make(map[chan *Sample]struct{}),
+		}
+		c.streams[ref.Value] = s
+
+		go c.poll(ctx, s)
+	}
+
+	ch := make(chan *Sample, 1)
+	s.subscribers[ch] = struct{}{}
+	if s.latest != nil {
+		ch <- s.latest
+	}
+
+	return &Subscription{
+		C:         ch,
+		collector: c,
+		stream:    s,
+		ch:        ch,
+	}
+}
+
+// Err returns the reason the subscription channel was closed
+func (s *Subscription) Err() error {
+	s.collector.streamsM.Lock()
+	defer s.collector.streamsM.Unlock()
+
+	return s.stream.err
+}
+
+// Close ends the subscription, stopping the poller if there are no other subscribers
+func (s *Subscription) Close() {
+	c := s.collector
+
+	c.streamsM.Lock()
+	defer c.streamsM.Unlock()
+
+	if _, ok := s.stream.subscribers[s.ch]; !ok {
+		// the stream has already failed
+		return
+	}
+
+	delete(s.stream.subscribers, s.ch)
+	if len(s.stream.subscribers) == 0 {
+		s.stream.cancel()
+		c.remove(s.stream)
+	}
+}
+
+// poll samples the stream VM each interval until cancelled or sampling fails
+func (c *Collector) poll(ctx context.Context, s *stream) {
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+
+	for {
+		sample, err := c.sample(ctx, s.ref)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			log.Errorf("Unable to sample stats for %s: %s", s.ref.Value, err)
+			c.fail(s, err)
+			return
+		}
+
+		c.publish(s, sample)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// publish hands the sample to each subscriber, replacing any sample that has yet to be read
+func (c *Collector) publish(s *stream, sample *Sample) {
+	c.streamsM.Lock()
+	defer c.streamsM.Unlock()
+
+	s.latest = sample
+	for ch := range s.subscribers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- sample
+	}
+}
+
+// fail closes the channels of all subscribers to the stream
+func (c *Collector) fail(s *stream, err error) {
+	c.streamsM.Lock()
+	defer c.streamsM.Unlock()
+
+	s.err = err
+	for ch := range s.subscribers {
+		close(ch)
+		delete(s.subscribers, ch)
+	}
+	c.remove(s)
+}
+
+// remove drops the stream so that the next subscriber starts a new poller. Must be called
+// with streamsM held.
+func (c *Collector) remove(s *stream) {
+	if c.streams[s.ref.Value] == s {
+		delete(c.streams, s.ref.Value)
+	}
+}
+
+// Sample returns the current resource usage of the VM identified by ref
+func (c *Collector) Sample(ctx context.Context, ref types.ManagedObjectReference) (*Sample, error) {
+	defer trace.End(trace.Begin(ref.Value))
+
+	var mvm mo.VirtualMachine
+	pc := property.DefaultCollector(c.session.Vim25())
+	if err := pc.RetrieveOne(ctx, ref, []string{"summary"}, &mvm); err != nil {
+		return nil, err
+	}
+
+	summary := mvm.Summary
+	sample := &Sample{
+		Read:        time.Now().UTC(),
+		CPUCount:    summary.Config.NumCpu,
+		CPUUsage:    int64(summary.QuickStats.OverallCpuUsage),
+		CPULimit:    int64(summary.Runtime.MaxCpuUsage),
+		MemoryUsage: int64(summary.QuickStats.GuestMemoryUsage) * 1024 * 1024,
+		MemoryLimit: int64(summary.Config.MemorySizeMB) * 1024 * 1024,
+	}
+
+	// realtime counters are only collected for powered on VMs
+	if summary.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn {
+		return sample, nil
+	}
+
+	values, err := c.query(ctx, ref)
+	if err != nil {
+		// the quickStats are still of use without the performance counters
+		log.Warnf("Unable to query performance counters for %s: %s", ref.Value, err)
+		return sample, nil
+	}
+
+	// rates are reported in KBps and summations are totals over the interval
+	sample.NetworkRxBytes = values[netRx] * 1024
+	sample.NetworkTxBytes = values[netTx] * 1024
+	sample.NetworkRxPackets = values[netRxPackets] / realtimeInterval
+	sample.NetworkTxPackets = values[netTxPackets] / realtimeInterval
+	sample.StorageReadBytes = values[diskRead] * 1024
+	sample.StorageWriteBytes = values[diskWrite] * 1024
+	sample.StorageReadOps = values[diskReadOps]
+	sample.StorageWriteOps = values[diskWriteOps]
+
+	return sample, nil
+}
+
+// query returns the latest realtime value of each of the counters, keyed by counter name
+func (c *Collector) query(ctx context.Context, ref types.ManagedObjectReference) (map[string]int64, error) {
+	counters, err := c.counterIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[int32]string, len(counters))
+	spec := types.PerfQuerySpec{
+		Entity:     ref,
+		MaxSample:  1,
+		IntervalId: realtimeInterval,
+	}
+	for _, name := range counterNames {
+		id, ok := counters[name]
+		if !ok {
+			continue
+		}
+		names[id] = name
+		spec.MetricId = append(spec.MetricId, types.PerfMetricId{CounterId: id, Instance: "*"})
+	}
+
+	req := types.QueryPerf{
+		This:      *c.session.ServiceContent.PerfManager,
+		QuerySpec: []types.PerfQuerySpec{spec},
+	}
+	res, err := methods.QueryPerf(ctx, c.session.Vim25(), &req)
+	if err != nil {
+		return nil, err
+	}
+
+	var series []types.PerfMetricIntSeries
+	for _, base := range res.Returnval {
+		metric, ok := base.(*types.PerfEntityMetric)
+		if !ok {
+			continue
+		}
+		for _, value := range metric.Value {
+			if s, ok := value.(*types.PerfMetricIntSeries); ok {
+				series = append(series, *s)
+			}
+		}
+	}
+
+	return aggregate(series, names), nil
+}
+
+// aggregate reduces the series to a single value per counter. The aggregate instance is used
+// where vSphere provides one, otherwise the values of the individual instances are summed.
+func aggregate(series []types.PerfMetricIntSeries, names map[int32]string) map[string]int64 {
+	totals := make(map[string]int64)
+	aggregates := make(map[string]int64)
+
+	for _, s := range series {
+		name, ok := names[s.Id.CounterId]
+		if !ok || len(s.Value) == 0 {
+			continue
+		}
+
+		// the most recent sample is last
+		value := s.Value[len(s.Value)-1]
+		if value < 0 {
+			continue
+		}
+
+		if s.Id.Instance == "" {
+			aggregates[name] = value
+			continue
+		}
+		totals[name] += value
+	}
+
+	for name, value := range aggregates {
+		totals[name] = value
+	}
+
+	return totals
+}
+
+// counterIDs returns the IDs of the performance counters, keyed by group.name.rollup
+func (c *Collector) counterIDs(ctx context.Context) (map[string]int32, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.counters != nil {
+		return c.counters, nil
+	}
+
+	if c.session.ServiceContent.PerfManager == nil {
+		return nil, fmt.Errorf("performance manager is not available")
+	}
+
+	var pm mo.PerformanceManager
+	pc := property.DefaultCollector(c.session.Vim25())
+	if err := pc.RetrieveOne(ctx, *c.session.ServiceContent.PerfManager, []string{"perfCounter"}, &pm); err != nil {
+		return nil, err
+	}
+
+	counters := make(map[string]int32, len(pm.PerfCounter))
+	for _, info := range pm.PerfCounter {
+		name := fmt.Sprintf("%s.%s.%s",
+			info.GroupInfo.GetElementDescription().Key,
+			info.NameInfo.GetElementDescription().Key,
+			info.RollupType)
+		counters[name] = info.Key
+	}
+
+	c.counters = counters
+	return counters, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func series(counter int32, instance string, values ...int64) types.PerfMetricIntSeries {
+	return types.PerfMetricIntSeries{
+		PerfMetricSeries: types.PerfMetricSeries{
+			Id: types.PerfMetricId{CounterId: counter, Instance: instance},
+		},
+		Value: values,
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	names := map[int32]string{
+		1: netRx,
+		2: diskRead,
+	}
+
+	values := aggregate([]types.PerfMetricIntSeries{
+		// the aggregate instance is preferred over the individual NICs
+		series(1, "4000", 10),
+		series(1, "", 30),
+		series(1, "4001", 20),
+		// disks have no aggregate instance
+		series(2, "scsi0:0", 5, 7),
+		series(2, "scsi0:1", 3),
+		// unavailable values are reported as -1
+		series(2, "scsi0:2", -1),
+		// counters that were not requested are ignored
+		series(3, "", 100),
+	}, names)
+
+	assert.Equal(t, int64(30), values[netRx])
+	assert.Equal(t, int64(10), values[diskRead])
+	assert.Len(t, values, 2)
+}
+
+func TestSubscribeSharesPoller(t *testing.T) {
+	interval := sampleInterval
+	sampleInterval = 10 * time.Millisecond
+	defer func() { sampleInterval = interval }()
+
+	var calls int32
+	c := &Collector{
+		sample: func(ctx context.Context, ref types.ManagedObjectReference) (*Sample, error) {
+			return &Sample{CPUUsage: int64(atomic.AddInt32(&calls, 1))}, nil
+		},
+	}
+	ref := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"}
+
+	first := c.Subscribe(ref)
+	second := c.Subscribe(ref)
+	assert.Len(t, c.streams, 1)
+
+	// both subscribers see the same samples
+	for i := 0; i < 3; i++ {
+		a := <-first.C
+		b := <-second.C
+		assert.True(t, a == b || a.CPUUsage < b.CPUUsage, "second subscriber saw a stale sample")
+	}
+
+	first.Close()
+	assert.Len(t, c.streams, 1)
+
+	second.Close()
+	assert.Len(t, c.streams, 0)
+
+	// the poller stops once the last subscriber has gone
+	time.Sleep(5 * sampleInterval)
+	stopped := atomic.LoadInt32(&calls)
+	time.Sleep(5 * sampleInterval)
+	assert.Equal(t, stopped, atomic.LoadInt32(&calls))
+}
+
+func TestSubscribeFailure(t *testing.T) {
+	c := &Collector{
+		sample: func(ctx context.Context, ref types.ManagedObjectReference) (*Sample, error) {
+			return nil, fmt.Errorf("no such vm")
+		},
+	}
+	ref := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"}
+
+	sub := c.Subscribe(ref)
+	_, ok := <-sub.C
+	assert.False(t, ok)
+	assert.EqualError(t, sub.Err(), "no such vm")
+	assert.Len(t, c.streams, 0)
+
+	// closing after the failure is harmless
+	sub.Close()
+}
@@ -117,6 +117,7 @@ func (c *Container) Refresh(ctx context.Context) error {
 		}
 
 		e.ip = ne.Assigned.IP
+		e.ip6 = ne.Assigned6.IP
 		if err := s.Refresh(h); err != nil {
 			return err
 		}
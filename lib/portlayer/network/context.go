@@ -21,6 +21,7 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/go-connections/nat"
@@ -29,6 +30,8 @@ import (
 	"github.com/vmware/govmomi/vim25/types"
 	"github.com/vmware/vic/lib/config/executor"
 	"github.com/vmware/vic/lib/portlayer/constants"
+	"github.com/vmware/vic/lib/portlayer/event"
+	"github.com/vmware/vic/lib/portlayer/event/events"
 	"github.com/vmware/vic/lib/portlayer/exec"
 	"github.com/vmware/vic/lib/spec"
 	"github.com/vmware/vic/pkg/ip"
@@ -61,11 +64,15 @@ type Context struct {
 	defaultScope *Scope
 
 	kv kvstore.KeyValueStore
+
+	// em receives the network events for the context, if set
+	em event.EventManager
 }
 
 type AddContainerOptions struct {
 	Scope   string
 	IP      *net.IP
+	IP6     *net.IP
 	Aliases []string
 	Ports   []string
 }
@@ -112,7 +119,7 @@ func NewContext(config *Configuration, kv kvstore.KeyValueStore) (*Context, erro
 		return nil, fmt.Errorf("default bridge network %s not present in config", ctx.config.BridgeNetwork)
 	}
 
-	s, err := ctx.newScope(n.Type, n.Name, nil, nil, nil, nil)
+	s, err := ctx.newScope(n.Type, n.Name, nil, nil, nil, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -131,7 +138,7 @@ func NewContext(config *Configuration, kv kvstore.KeyValueStore) (*Context, erro
 		}
 
 		subnet := net.IPNet{IP: n.Gateway.IP.Mask(n.Gateway.Mask), Mask: n.Gateway.Mask}
-		s, err := ctx.newScope(n.Type, nn, &subnet, n.Gateway.IP, n.Nameservers, pools)
+		s, err := ctx.newScope(n.Type, nn, &subnet, n.Gateway.IP, n.Nameservers, pools, ipv6Config(n))
 		if err != nil {
 			return nil, err
 		}
@@ -178,6 +185,25 @@ func NewContext(config *Configuration, kv kvstore.KeyValueStore) (*Context, erro
 	return ctx, nil
 }
 
+// ipv6Config returns the IPv6 addressing of a container network that has IPv6 enabled
+func ipv6Config(n *executor.ContainerNetwork) *IPv6Config {
+	if !n.IPv6 {
+		return nil
+	}
+
+	cfg := &IPv6Config{}
+	if !ip.IsUnspecifiedSubnet(&n.Gateway6) {
+		cfg.Subnet = &net.IPNet{IP: n.Gateway6.IP.Mask(n.Gateway6.Mask), Mask: n.Gateway6.Mask}
+		cfg.Gateway = n.Gateway6.IP
+	}
+
+	for _, p := range n.Pools6 {
+		cfg.Pools = append(cfg.Pools, p.String())
+	}
+
+	return cfg
+}
+
 func reserveGateway(gateway net.IP, subnet *net.IPNet, spaces []*AddressSpace) (net.IP, error) {
 	defer trace.End(trace.Begin(""))
 	if ip.IsUnspecifiedSubnet(subnet) {
@@ -192,7 +218,7 @@ func reserveGateway(gateway net.IP, subnet *net.IPNet, spaces []*AddressSpace) (
 
 		// optionally reserve it in one of the pools
 		for _, p := range spaces {
-			if err := p.ReserveIP4(gateway); err == nil {
+			if err := p.ReserveIP(gateway); err == nil {
 				break
 			}
 		}
@@ -203,7 +229,7 @@ func reserveGateway(gateway net.IP, subnet *net.IPNet, spaces []*AddressSpace) (
 	// gateway is not specified, pick one from the available pools
 	if len(spaces) > 0 {
 		var err error
-		if gateway, err = spaces[0].ReserveNextIP4(); err != nil {
+		if gateway, err = spaces[0].ReserveNextIP(); err != nil {
 			return nil, err
 		}
 
@@ -241,24 +267,24 @@ func (c *Context) addScope(s *Scope) error {
 		for _, p := range spaces {
 			// release DNS IPs
 			for _, d := range s.dns {
-				p.ReleaseIP4(d)
+				p.ReleaseIP(d)
 			}
 
 			// release gateway
 			if !ip.IsUnspecifiedIP(gateway) {
-				p.ReleaseIP4(gateway)
+				p.ReleaseIP(gateway)
 			}
 
 			// release all-ones and all-zeros addresses
 			if !ip.IsUnspecifiedIP(allzeros) {
-				p.ReleaseIP4(allzeros)
+				p.ReleaseIP(allzeros)
 			}
 			if !ip.IsUnspecifiedIP(allones) {
-				p.ReleaseIP4(allones)
+				p.ReleaseIP(allones)
 			}
 		}
 
-		c.defaultBridgePool.ReleaseIP4Range(space)
+		c.defaultBridgePool.ReleaseIPRange(space)
 	}()
 
 	// subnet may not be specified, e.g. for "external" networks
@@ -281,8 +307,8 @@ func (c *Context) addScope(s *Scope) error {
 		allones = ip.AllOnesAddr(subnet)
 		allzeros = ip.AllZerosAddr(subnet)
 		for _, p := range spaces {
-			p.ReserveIP4(allones)
-			p.ReserveIP4(allzeros)
+			p.ReserveIP(allones)
+			p.ReserveIP(allzeros)
 
 			// reserve DNS IPs
 			for _, d := range s.dns {
@@ -290,7 +316,7 @@ func (c *Context) addScope(s *Scope) error {
 					continue // gateway will be reserved later
 				}
 
-				p.ReserveIP4(d)
+				p.ReserveIP(d)
 			}
 		}
 
@@ -303,23 +329,86 @@ func (c *Context) addScope(s *Scope) error {
 		s.subnet = subnet
 	}
 
+	if err = c.reserveIPv6(s); err != nil {
+		return err
+	}
+
 	c.scopes[s.name] = s
 
 	return nil
 }
 
-func (c *Context) newScopeCommon(id uid.UID, name, scopeType string, subnet *net.IPNet, gateway net.IP, dns []net.IP, pools []string, network object.NetworkReference) (*Scope, error) {
-	defer trace.End(trace.Begin(""))
+// reserveIPv6 reserves the IPv6 subnet, pools and gateway of a dual-stack scope
+func (c *Context) reserveIPv6(s *Scope) error {
+	if ip.IsUnspecifiedSubnet(s.subnet6) {
+		if len(s.spaces6) > 0 {
+			return fmt.Errorf("ipam cannot be specified without an IPv6 subnet")
+		}
 
-	newScope := newScope(id, name, scopeType, subnet, gateway, dns, network)
-	newScope.spaces = make([]*AddressSpace, len(pools))
+		return nil
+	}
+
+	if !ip.IsIPv6(s.subnet6.IP) {
+		return fmt.Errorf("subnet %s is not an IPv6 subnet", s.subnet6)
+	}
+
+	if err := c.checkNetOverlap(s.subnet6); err != nil {
+		return err
+	}
+
+	subnet := &net.IPNet{IP: lowestIP(s.subnet6), Mask: s.subnet6.Mask}
+	spaces, err := reservePools(NewAddressSpaceFromNetwork(subnet), s.spaces6)
+	if err != nil {
+		return err
+	}
+
+	// the subnet-router anycast address is not handed out
+	for _, p := range spaces {
+		p.ReserveIP(subnet.IP)
+	}
+
+	gateway, err := reserveGateway(s.gateway6, subnet, spaces)
+	if err != nil {
+		return err
+	}
+
+	s.subnet6 = subnet
+	s.gateway6 = gateway
+	s.spaces6 = spaces
+	return nil
+}
+
+// poolSpacesFromStrings returns the address spaces for the pools specified for a scope
+func poolSpacesFromStrings(name string, pools []string) ([]*AddressSpace, error) {
+	spaces := make([]*AddressSpace, len(pools))
 	for i, p := range pools {
 		r := ip.ParseRange(p)
 		if r == nil {
 			return nil, fmt.Errorf("invalid pool %s specified for scope %s", p, name)
 		}
 
-		newScope.spaces[i] = NewAddressSpaceFromRange(r.FirstIP, r.LastIP)
+		spaces[i] = NewAddressSpaceFromRange(r.FirstIP, r.LastIP)
+	}
+
+	return spaces, nil
+}
+
+func (c *Context) newScopeCommon(id uid.UID, name, scopeType string, subnet *net.IPNet, gateway net.IP, dns []net.IP, pools []string, v6 *IPv6Config, network object.NetworkReference) (*Scope, error) {
+	defer trace.End(trace.Begin(""))
+
+	var err error
+	newScope := newScope(id, name, scopeType, subnet, gateway, dns, network)
+	if newScope.spaces, err = poolSpacesFromStrings(name, pools); err != nil {
+		return nil, err
+	}
+
+	if v6 != nil {
+		newScope.ipv6 = true
+		newScope.subnet6 = v6.Subnet
+		newScope.gateway6 = v6.Gateway
+		if newScope.spaces6, err = poolSpacesFromStrings(name, v6.Pools); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := c.addScope(newScope); err != nil {
@@ -329,7 +418,7 @@ func (c *Context) newScopeCommon(id uid.UID, name, scopeType string, subnet *net
 	return newScope, nil
 }
 
-func (c *Context) newBridgeScope(id uid.UID, name string, subnet *net.IPNet, gateway net.IP, dns []net.IP, pools []string) (newScope *Scope, err error) {
+func (c *Context) newBridgeScope(id uid.UID, name string, subnet *net.IPNet, gateway net.IP, dns []net.IP, pools []string, v6 *IPv6Config) (newScope *Scope, err error) {
 	defer trace.End(trace.Begin(""))
 	bnPG, ok := c.config.PortGroups[c.config.BridgeNetwork]
 	if !ok || bnPG == nil {
@@ -339,28 +428,35 @@ func (c *Context) newBridgeScope(id uid.UID, name string, subnet *net.IPNet, gat
 	if ip.IsUnspecifiedSubnet(subnet) {
 		// get the next available subnet from the default bridge pool
 		var err error
-		subnet, err = c.defaultBridgePool.NextIP4Net(c.defaultBridgeMask)
+		subnet, err = c.defaultBridgePool.NextIPNet(c.defaultBridgeMask)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	s, err := c.newScopeCommon(id, name, constants.BridgeScopeType, subnet, gateway, dns, pools, bnPG)
+	s, err := c.newScopeCommon(id, name, constants.BridgeScopeType, subnet, gateway, dns, pools, v6, bnPG)
 	if err != nil {
 		return nil, err
 	}
 
-	// add the gateway address to the bridge interface
-	if err = c.config.BridgeLink.AddrAdd(net.IPNet{IP: s.Gateway(), Mask: s.Subnet().Mask}); err != nil {
-		if errno, ok := err.(syscall.Errno); !ok || errno != syscall.EEXIST {
-			log.Warnf("failed to add gateway address %s to bridge interface: %s", s.Gateway(), err)
+	// add the gateway addresses to the bridge interface
+	gateways := []net.IPNet{{IP: s.Gateway(), Mask: s.Subnet().Mask}}
+	if s.Subnet6() != nil {
+		gateways = append(gateways, net.IPNet{IP: s.Gateway6(), Mask: s.Subnet6().Mask})
+	}
+
+	for _, gw := range gateways {
+		if err = c.config.BridgeLink.AddrAdd(gw); err != nil {
+			if errno, ok := err.(syscall.Errno); !ok || errno != syscall.EEXIST {
+				log.Warnf("failed to add gateway address %s to bridge interface: %s", gw.IP, err)
+			}
 		}
 	}
 
 	return s, nil
 }
 
-func (c *Context) newExternalScope(id uid.UID, name string, subnet *net.IPNet, gateway net.IP, dns []net.IP, pools []string) (*Scope, error) {
+func (c *Context) newExternalScope(id uid.UID, name string, subnet *net.IPNet, gateway net.IP, dns []net.IP, pools []string, v6 *IPv6Config) (*Scope, error) {
 	defer trace.End(trace.Begin(""))
 	// ipam cannot be specified without gateway and subnet
 	if len(pools) > 0 {
@@ -372,7 +468,7 @@ func (c *Context) newExternalScope(id uid.UID, name string, subnet *net.IPNet, g
 	if !ip.IsUnspecifiedSubnet(subnet) {
 		// cannot overlap with the default bridge pool
 		if c.defaultBridgePool.Network.Contains(subnet.IP) ||
-			c.defaultBridgePool.Network.Contains(highestIP4(subnet)) {
+			c.defaultBridgePool.Network.Contains(highestIP(subnet)) {
 			return nil, fmt.Errorf("external network cannot overlap with default bridge network")
 		}
 	}
@@ -382,7 +478,7 @@ func (c *Context) newExternalScope(id uid.UID, name string, subnet *net.IPNet, g
 		return nil, fmt.Errorf("no network info for external scope %s", name)
 	}
 
-	return c.newScopeCommon(id, name, constants.ExternalScopeType, subnet, gateway, dns, pools, pg)
+	return c.newScopeCommon(id, name, constants.ExternalScopeType, subnet, gateway, dns, pools, v6, pg)
 }
 
 func (c *Context) reserveSubnet(subnet *net.IPNet) (*AddressSpace, bool, error) {
@@ -393,7 +489,7 @@ func (c *Context) reserveSubnet(subnet *net.IPNet) (*AddressSpace, bool, error)
 	}
 
 	// reserve from the default pool first
-	space, err := c.defaultBridgePool.ReserveIP4Net(subnet)
+	space, err := c.defaultBridgePool.ReserveIPNet(subnet)
 	if err == nil {
 		return space, true, nil
 	}
@@ -404,10 +500,16 @@ func (c *Context) reserveSubnet(subnet *net.IPNet) (*AddressSpace, bool, error)
 
 func (c *Context) checkNetOverlap(subnet *net.IPNet) error {
 	// check if the requested subnet is available
-	highestIP := highestIP4(subnet)
+	highestIP := highestIP(subnet)
 	for _, scope := range c.scopes {
-		if scope.subnet.Contains(subnet.IP) || scope.subnet.Contains(highestIP) {
-			return fmt.Errorf("subnet %s overlaps with scope %s subnet %s", subnet, scope.Name(), scope.Subnet())
+		for _, sn := range []*net.IPNet{scope.subnet, scope.subnet6} {
+			if sn == nil {
+				continue
+			}
+
+			if sn.Contains(subnet.IP) || sn.Contains(highestIP) {
+				return fmt.Errorf("subnet %s overlaps with scope %s subnet %s", subnet, scope.Name(), sn)
+			}
 		}
 	}
 
@@ -432,7 +534,7 @@ func reservePools(space *AddressSpace, pools []*AddressSpace) ([]*AddressSpace,
 			if s == nil {
 				continue
 			}
-			space.ReleaseIP4Range(s)
+			space.ReleaseIPRange(s)
 
 		}
 	}()
@@ -440,7 +542,7 @@ func reservePools(space *AddressSpace, pools []*AddressSpace) ([]*AddressSpace,
 	for i, p := range pools {
 		var ss *AddressSpace
 		if p.Network != nil {
-			ss, err = space.ReserveIP4Net(p.Network)
+			ss, err = space.ReserveIPNet(p.Network)
 			if err != nil {
 				return nil, err
 			}
@@ -449,7 +551,7 @@ func reservePools(space *AddressSpace, pools []*AddressSpace) ([]*AddressSpace,
 			continue
 		}
 
-		ss, err = space.ReserveIP4Range(p.Pool.FirstIP, p.Pool.LastIP)
+		ss, err = space.ReserveIPRange(p.Pool.FirstIP, p.Pool.LastIP)
 		if err != nil {
 			return nil, err
 		}
@@ -469,12 +571,18 @@ func scopeKey(sn string) string {
 }
 
 func (c *Context) NewScope(ctx context.Context, scopeType, name string, subnet *net.IPNet, gateway net.IP, dns []net.IP, pools []string) (*Scope, error) {
+	return c.NewDualStackScope(ctx, scopeType, name, subnet, gateway, dns, pools, nil)
+}
+
+// NewDualStackScope creates a scope that also has the IPv6 addressing in v6, if it is not nil.
+// The subnet, gateway and pools may themselves be IPv6, for a scope that has only IPv6 addressing.
+func (c *Context) NewDualStackScope(ctx context.Context, scopeType, name string, subnet *net.IPNet, gateway net.IP, dns []net.IP, pools []string, v6 *IPv6Config) (*Scope, error) {
 	defer trace.End(trace.Begin(""))
 
 	c.Lock()
 	defer c.Unlock()
 
-	s, err := c.newScope(scopeType, name, subnet, gateway, dns, pools)
+	s, err := c.newScope(scopeType, name, subnet, gateway, dns, pools, v6)
 	if err != nil {
 		return nil, err
 	}
@@ -497,10 +605,11 @@ func (c *Context) NewScope(ctx context.Context, scopeType, name string, subnet *
 		}
 	}
 
+	c.publishEvent(s, events.NetworkCreated, nil)
 	return s, nil
 }
 
-func (c *Context) newScope(scopeType, name string, subnet *net.IPNet, gateway net.IP, dns []net.IP, pools []string) (*Scope, error) {
+func (c *Context) newScope(scopeType, name string, subnet *net.IPNet, gateway net.IP, dns []net.IP, pools []string, v6 *IPv6Config) (*Scope, error) {
 	// sanity checks
 	if name == "" {
 		return nil, fmt.Errorf("scope name must not be empty")
@@ -518,10 +627,10 @@ func (c *Context) newScope(scopeType, name string, subnet *net.IPNet, gateway ne
 	var err error
 	switch scopeType {
 	case constants.BridgeScopeType:
-		s, err = c.newBridgeScope(uid.New(), name, subnet, gateway, dns, pools)
+		s, err = c.newBridgeScope(uid.New(), name, subnet, gateway, dns, pools, v6)
 
 	case constants.ExternalScopeType:
-		s, err = c.newExternalScope(uid.New(), name, subnet, gateway, dns, pools)
+		s, err = c.newExternalScope(uid.New(), name, subnet, gateway, dns, pools, v6)
 
 	default:
 		return nil, fmt.Errorf("scope type not supported")
@@ -618,20 +727,24 @@ func (c *Context) BindContainer(h *exec.Handle) ([]*Endpoint, error) {
 
 func (c *Context) bindContainer(h *exec.Handle) ([]*Endpoint, error) {
 	con, err := c.container(h)
-	if con != nil {
-		return con.Endpoints(), nil // already bound
-	}
-
-	if _, ok := err.(ResourceNotFoundError); !ok {
-		return nil, err
+	if err != nil {
+		if _, ok := err.(ResourceNotFoundError); !ok {
+			return nil, err
+		}
 	}
 
-	con = &Container{
-		id:   uid.Parse(h.ExecConfig.ID),
-		name: h.ExecConfig.Name,
+	// a container that is already bound only has endpoints to add for the networks
+	// it has been added to since, as when a running container is connected to a network
+	bound := con != nil
+	if !bound {
+		con = &Container{
+			id:   uid.Parse(h.ExecConfig.ID),
+			name: h.ExecConfig.Name,
+		}
 	}
 
-	defaultMarked := false
+	// the default network of a bound container does not change
+	defaultMarked := bound
 	aliases := make(map[string]*Container)
 	var endpoints []*Endpoint
 	for _, ne := range h.ExecConfig.Networks {
@@ -641,6 +754,10 @@ func (c *Context) bindContainer(h *exec.Handle) ([]*Endpoint, error) {
 			return nil, &ResourceNotFoundError{}
 		}
 
+		if bound && con.Endpoint(s) != nil {
+			continue
+		}
+
 		defer func() {
 			if err == nil {
 				return
@@ -664,6 +781,12 @@ func (c *Context) bindContainer(h *exec.Handle) ([]*Endpoint, error) {
 
 		e := newEndpoint(con, s, eip, nil)
 		e.static = ne.Static
+		e.static6 = ne.Static6
+		if ne.Static6 && ne.IP6 != nil {
+			e.ip6 = ne.IP6.IP
+		} else if !ip.IsUnspecifiedIP(ne.Assigned6.IP) {
+			e.ip6 = ne.Assigned6.IP
+		}
 		if err = s.AddContainer(con, e); err != nil {
 			return nil, err
 		}
@@ -690,6 +813,13 @@ func (c *Context) bindContainer(h *exec.Handle) ([]*Endpoint, error) {
 			}
 		}
 		ne.Network.Gateway = net.IPNet{IP: e.Gateway(), Mask: e.Subnet().Mask}
+		if subnet6 := s.Subnet6(); subnet6 != nil {
+			if !ip.IsUnspecifiedIP(e.IP6()) {
+				ne.IP6 = &net.IPNet{IP: e.IP6(), Mask: subnet6.Mask}
+			}
+			ne.Network.Gateway6 = net.IPNet{IP: e.Gateway6(), Mask: subnet6.Mask}
+		}
+		ne.Network.IPv6 = s.IPv6()
 		ne.Network.Nameservers = make([]net.IP, len(s.dns))
 		copy(ne.Network.Nameservers, s.dns)
 
@@ -778,6 +908,19 @@ func (c *Context) bindContainer(h *exec.Handle) ([]*Endpoint, error) {
 		c.containers[k] = v
 	}
 
+	for _, e := range endpoints {
+		c.publishEvent(e.Scope(), events.NetworkConnected, con)
+	}
+
+	if bound {
+		// the new endpoints are configured in the guest on reload
+		if len(endpoints) > 0 {
+			h.Reload()
+		}
+
+		return con.Endpoints(), nil
+	}
+
 	return endpoints, nil
 }
 
@@ -819,37 +962,12 @@ func (c *Context) UnbindContainer(h *exec.Handle) ([]*Endpoint, error) {
 			return nil, &ResourceNotFoundError{}
 		}
 
-		// save the endpoint info
-		e := con.Endpoint(s).copy()
-
-		if err = s.RemoveContainer(con); err != nil {
+		e, as, err := c.removeEndpoint(con, s, ne)
+		if err != nil {
 			return nil, err
 		}
 
-		// clear out assigned ip
-		ne.Assigned.IP = net.IPv4zero
-
-		// aliases to remove
-		// name for dns lookup
-		aliases = append(aliases, fmt.Sprintf("%s:%s", s.Name(), con.name))
-		aliases = append(aliases, fmt.Sprintf("%s:%s", s.Name(), con.id.Truncate()))
-		for _, as := range e.aliases {
-			for _, a := range as {
-				aliases = append(aliases, a.scopedName())
-			}
-		}
-
-		// aliases from other containers
-		for _, e := range s.Endpoints() {
-			if e.Container() == con {
-				continue
-			}
-
-			for _, a := range e.getAliases(con.name) {
-				aliases = append(aliases, a.scopedName())
-			}
-		}
-
+		aliases = append(aliases, as...)
 		endpoints = append(endpoints, e)
 	}
 
@@ -865,9 +983,124 @@ func (c *Context) UnbindContainer(h *exec.Handle) ([]*Endpoint, error) {
 	// name
 	delete(c.containers, con.Name())
 
+	for _, e := range endpoints {
+		c.publishEvent(e.Scope(), events.NetworkDisconnected, con)
+	}
+
 	return endpoints, nil
 }
 
+// removeEndpoint removes a bound container from the scope, releasing its address, and returns
+// the removed endpoint along with the aliases that no longer resolve to the container
+func (c *Context) removeEndpoint(con *Container, s *Scope, ne *executor.NetworkEndpoint) (*Endpoint, []string, error) {
+	// save the endpoint info
+	e := con.Endpoint(s).copy()
+
+	if err := s.RemoveContainer(con); err != nil {
+		return nil, nil, err
+	}
+
+	// clear out assigned ip
+	ne.Assigned.IP = net.IPv4zero
+	ne.Assigned6 = net.IPNet{}
+
+	// aliases to remove
+	// name for dns lookup
+	var aliases []string
+	aliases = append(aliases, fmt.Sprintf("%s:%s", s.Name(), con.name))
+	aliases = append(aliases, fmt.Sprintf("%s:%s", s.Name(), con.id.Truncate()))
+	for _, as := range e.aliases {
+		for _, a := range as {
+			aliases = append(aliases, a.scopedName())
+		}
+	}
+
+	// aliases from other containers
+	for _, e := range s.Endpoints() {
+		if e.Container() == con {
+			continue
+		}
+
+		for _, a := range e.getAliases(con.name) {
+			aliases = append(aliases, a.scopedName())
+		}
+	}
+
+	return e, aliases, nil
+}
+
+// RenameContainer changes the name of a bound container, along with the name and aliases it
+// is resolved by. Containers that are not bound pick up the new name from their config when
+// they are.
+func (c *Context) RenameContainer(id uid.UID, name string) error {
+	defer trace.End(trace.Begin(id.String()))
+	c.Lock()
+	defer c.Unlock()
+
+	con, ok := c.containers[id.String()]
+	if !ok {
+		return nil // not bound
+	}
+
+	old := con.Name()
+	if old == name {
+		return nil
+	}
+
+	// the keys that contain the container name, from old to new
+	keys := map[string]string{old: name}
+	for _, e := range con.Endpoints() {
+		s := e.Scope()
+		keys[fmt.Sprintf("%s:%s", s.Name(), old)] = fmt.Sprintf("%s:%s", s.Name(), name)
+
+		// aliases for other containers are scoped to this container
+		for who, as := range e.aliases {
+			if who == old {
+				continue
+			}
+
+			for _, a := range as {
+				keys[fmt.Sprintf("%s:%s:%s", s.Name(), old, a.Name)] = fmt.Sprintf("%s:%s:%s", s.Name(), name, a.Name)
+			}
+		}
+	}
+
+	for from, to := range keys {
+		if other, ok := c.containers[to]; ok && other != c.containers[from] {
+			return fmt.Errorf("name %s for container %s conflicts with container %s", to, id, other.ID())
+		}
+	}
+
+	for from, to := range keys {
+		if v, ok := c.containers[from]; ok {
+			delete(c.containers, from)
+			c.containers[to] = v
+		}
+	}
+
+	// aliases, of this container and of others, that refer to the container by name
+	for _, e := range con.Endpoints() {
+		for _, other := range e.Scope().Endpoints() {
+			as, ok := other.aliases[old]
+			if !ok {
+				continue
+			}
+
+			for i := range as {
+				as[i].Container = name
+			}
+			delete(other.aliases, old)
+			other.aliases[name] = as
+		}
+	}
+
+	con.Lock()
+	con.name = name
+	con.Unlock()
+
+	return nil
+}
+
 var addEthernetCard = func(h *exec.Handle, s *Scope) (types.BaseVirtualDevice, error) {
 	var devices object.VirtualDeviceList
 	var d types.BaseVirtualDevice
@@ -973,6 +1206,16 @@ func (c *Context) AddContainer(h *exec.Handle, options *AddContainerOptions) err
 		}
 	}
 
+	eip, eip6 := options.IP, options.IP6
+	if eip6 != nil && !ip.IsUnspecifiedIP(*eip6) && s.Subnet6() == nil {
+		// the IPv6 address of a scope that has only IPv6 addressing is its primary address
+		if !s.IPv6() || ip.IsUnspecifiedSubnet(s.Subnet()) || (eip != nil && !ip.IsUnspecifiedIP(*eip)) {
+			return fmt.Errorf("cannot assign IPv6 address %s: scope %s has no IPv6 subnet", *eip6, s.Name())
+		}
+
+		eip, eip6 = eip6, nil
+	}
+
 	// figure out if we need to add a new NIC
 	// if there is already a NIC connected to a
 	// bridge network and we are adding the container
@@ -1031,16 +1274,28 @@ func (c *Context) AddContainer(h *exec.Handle, options *AddContainerOptions) err
 	for i, p := range pools {
 		ne.Network.Pools[i] = *p
 	}
+	for _, p := range s.Pools6() {
+		ne.Network.Pools6 = append(ne.Network.Pools6, *p)
+	}
 
 	ne.Static = false
-	if options.IP != nil && !ip.IsUnspecifiedIP(*options.IP) {
+	if eip != nil && !ip.IsUnspecifiedIP(*eip) {
 		ne.Static = true
 		ne.IP = &net.IPNet{
-			IP:   *options.IP,
+			IP:   *eip,
 			Mask: s.Subnet().Mask,
 		}
 	}
 
+	ne.Static6 = false
+	if eip6 != nil && !ip.IsUnspecifiedIP(*eip6) {
+		ne.Static6 = true
+		ne.IP6 = &net.IPNet{
+			IP:   *eip6,
+			Mask: s.Subnet6().Mask,
+		}
+	}
+
 	h.ExecConfig.Networks[s.Name()] = ne
 	return nil
 }
@@ -1054,10 +1309,6 @@ func (c *Context) RemoveContainer(h *exec.Handle, scope string) error {
 		return fmt.Errorf("handle is required")
 	}
 
-	if con, _ := c.container(h); con != nil {
-		return fmt.Errorf("container is bound")
-	}
-
 	var err error
 	s, err := c.resolveScope(scope)
 	if err != nil {
@@ -1070,6 +1321,14 @@ func (c *Context) RemoveContainer(h *exec.Handle, scope string) error {
 		return fmt.Errorf("container %s not part of network %s", h.ExecConfig.ID, s.Name())
 	}
 
+	con, _ := c.container(h)
+	bound := con != nil && con.Endpoint(s) != nil
+
+	// the guest config cannot express that all of its endpoints have been removed
+	if bound && len(h.ExecConfig.Networks) == 1 {
+		return fmt.Errorf("container %s is bound and cannot be removed from its only network %s", h.ExecConfig.ID, s.Name())
+	}
+
 	// figure out if any other networks are using the NIC
 	removeNIC := true
 	for _, ne2 := range h.ExecConfig.Networks {
@@ -1083,23 +1342,25 @@ func (c *Context) RemoveContainer(h *exec.Handle, scope string) error {
 	}
 
 	if removeNIC {
-		var devices object.VirtualDeviceList
-		backing, err := s.network.EthernetCardBackingInfo(context.Background())
-		if err != nil {
+		if err = removeEthernetCard(h, atoiOrZero(ne.ID)); err != nil {
 			return err
 		}
+	}
 
-		d, err := devices.CreateEthernetCard("vmxnet3", backing)
+	// a bound container is disconnected from the scope straight away, and the guest
+	// removes the endpoint on reload
+	if bound {
+		_, aliases, err := c.removeEndpoint(con, s, ne)
 		if err != nil {
 			return err
 		}
 
-		devices = append(devices, d)
-		spec, err := devices.ConfigSpec(types.VirtualDeviceConfigSpecOperationRemove)
-		if err != nil {
-			return err
+		for _, a := range aliases {
+			delete(c.containers, a)
 		}
-		h.Spec.DeviceChange = append(h.Spec.DeviceChange, spec...)
+
+		c.publishEvent(s, events.NetworkDisconnected, con)
+		h.Reload()
 	}
 
 	delete(h.ExecConfig.Networks, s.Name())
@@ -1107,6 +1368,34 @@ func (c *Context) RemoveContainer(h *exec.Handle, scope string) error {
 	return nil
 }
 
+// removeEthernetCard removes the NIC in the pci slot from the containerVM, or from the spec if
+// it has yet to be added to the containerVM
+func removeEthernetCard(h *exec.Handle, slot int32) error {
+	for i, dc := range h.Spec.DeviceChange {
+		ds := dc.GetVirtualDeviceConfigSpec()
+		if ds.Operation != types.VirtualDeviceConfigSpecOperationAdd || spec.VirtualDeviceSlotNumber(ds.Device) != slot {
+			continue
+		}
+
+		if _, ok := ds.Device.(types.BaseVirtualEthernetCard); ok {
+			h.Spec.DeviceChange = append(h.Spec.DeviceChange[:i], h.Spec.DeviceChange[i+1:]...)
+			return nil
+		}
+	}
+
+	if h.Config != nil {
+		devices := object.VirtualDeviceList(h.Config.Hardware.Device)
+		for _, d := range devices.SelectByType((*types.VirtualEthernetCard)(nil)) {
+			if spec.VirtualDeviceSlotNumber(d) == slot {
+				h.Spec.RemoveVirtualDevice(d)
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("no NIC found in pci slot %d", slot)
+}
+
 func (c *Context) Container(key string) *Container {
 	c.Lock()
 	defer c.Unlock()
@@ -1162,9 +1451,35 @@ func (c *Context) DeleteScope(ctx context.Context, name string) error {
 	}
 
 	c.deleteScope(s)
+	c.publishEvent(s, events.NetworkRemoved, nil)
 	return nil
 }
 
+// publishEvent publishes a NetworkEvent for the scope. con is the container
+// connected or disconnected, and is nil for scope events.
+func (c *Context) publishEvent(s *Scope, event string, con *Container) {
+	if c.em == nil {
+		return
+	}
+
+	ne := &events.NetworkEvent{
+		BaseEvent: &events.BaseEvent{
+			Ref:         s.ID().String(),
+			CreatedTime: time.Now().UTC(),
+			Event:       event,
+			Detail:      fmt.Sprintf("Network %s %s", s.Name(), event),
+		},
+		Name:      s.Name(),
+		ScopeType: s.Type(),
+	}
+	if con != nil {
+		ne.Container = con.ID().String()
+		ne.Detail = fmt.Sprintf("Container %s %s network %s", con.ID(), event, s.Name())
+	}
+
+	c.em.Publish(ne)
+}
+
 func (c *Context) deleteScope(s *Scope) {
 	if s.Type() == constants.BridgeScopeType {
 		// remove gateway ip from bridge interface
@@ -1198,6 +1198,209 @@ func TestAliases(t *testing.T) {
 	assert.Nil(t, ctx.Container(fmt.Sprintf("%s:c3:c2", scope.Name())))
 }
 
+func TestRenameContainer(t *testing.T) {
+	ctx, err := NewContext(testConfig(), nil)
+	assert.NoError(t, err)
+
+	scope := ctx.DefaultScope()
+
+	// c1 has an alias for itself and one for c2, and c2 has one for c1
+	c1 := newContainer("c1")
+	c2 := newContainer("c2")
+	assert.NoError(t, ctx.AddContainer(c1, &AddContainerOptions{Scope: scope.Name(), Aliases: []string{":self", "c2:other"}}))
+	assert.NoError(t, ctx.AddContainer(c2, &AddContainerOptions{Scope: scope.Name(), Aliases: []string{"c1:first"}}))
+
+	_, err = ctx.BindContainer(c1)
+	assert.NoError(t, err)
+	_, err = ctx.BindContainer(c2)
+	assert.NoError(t, err)
+
+	con1 := ctx.Container(c1.ExecConfig.ID)
+	con2 := ctx.Container(c2.ExecConfig.ID)
+
+	assert.NoError(t, ctx.RenameContainer(uid.Parse(c1.ExecConfig.ID), "renamed"))
+	assert.Equal(t, "renamed", con1.Name())
+
+	assert.Nil(t, ctx.Container("c1"))
+	assert.Nil(t, ctx.Container(fmt.Sprintf("%s:c1", scope.Name())))
+	assert.Nil(t, ctx.Container(fmt.Sprintf("%s:c1:other", scope.Name())))
+
+	assert.Equal(t, con1, ctx.Container("renamed"))
+	assert.Equal(t, con1, ctx.Container(fmt.Sprintf("%s:renamed", scope.Name())))
+	assert.Equal(t, con1, ctx.Container(fmt.Sprintf("%s:self", scope.Name())))
+	assert.Equal(t, con2, ctx.Container(fmt.Sprintf("%s:renamed:other", scope.Name())))
+	assert.Equal(t, con1, ctx.Container(fmt.Sprintf("%s:c2:first", scope.Name())))
+
+	// the aliases still resolve to the container when looked up by its new name
+	for _, e := range scope.Endpoints() {
+		for _, a := range e.getAliases("renamed") {
+			assert.Equal(t, con1, ctx.Container(a.scopedName()))
+		}
+	}
+
+	// the name of another container cannot be taken
+	assert.Error(t, ctx.RenameContainer(uid.Parse(c1.ExecConfig.ID), "c2"))
+
+	// unbound containers are ignored
+	assert.NoError(t, ctx.RenameContainer(uid.New(), "unbound"))
+}
+
+func TestConnectDisconnectBoundContainer(t *testing.T) {
+	ctx, err := NewContext(testConfig(), nil)
+	assert.NoError(t, err)
+
+	scope, err := ctx.NewScope(context.TODO(), constants.BridgeScopeType, "scope", nil, nil, nil, nil)
+	assert.NoError(t, err)
+
+	h := newContainer("foo")
+	assert.NoError(t, ctx.AddContainer(h, &AddContainerOptions{Scope: ctx.DefaultScope().Name()}))
+	eps, err := ctx.BindContainer(h)
+	assert.NoError(t, err)
+	assert.Len(t, eps, 1)
+
+	con := ctx.Container(h.ExecConfig.ID)
+	if !assert.NotNil(t, con) {
+		return
+	}
+
+	// connecting the bound container binds just the new network
+	assert.NoError(t, ctx.AddContainer(h, &AddContainerOptions{Scope: scope.Name(), Aliases: []string{":bar"}}))
+	eps, err = ctx.BindContainer(h)
+	assert.NoError(t, err)
+	assert.Len(t, eps, 2)
+
+	e := con.Endpoint(scope)
+	if !assert.NotNil(t, e) {
+		return
+	}
+	assert.True(t, h.ExecConfig.Networks[scope.Name()].IP.IP.Equal(e.IP()))
+	assert.Equal(t, con, ctx.Container(fmt.Sprintf("%s:foo", scope.Name())))
+	assert.Equal(t, con, ctx.Container(fmt.Sprintf("%s:bar", scope.Name())))
+	assert.Equal(t, con, ctx.ContainerByAddr(e.IP()).Container())
+
+	// disconnecting it releases the address and the names in that network only
+	addr := e.IP()
+	assert.NoError(t, ctx.RemoveContainer(h, scope.Name()))
+	assert.Nil(t, con.Endpoint(scope))
+	assert.Nil(t, scope.Container(con.ID()))
+	assert.Nil(t, ctx.ContainerByAddr(addr))
+	assert.Nil(t, ctx.Container(fmt.Sprintf("%s:foo", scope.Name())))
+	assert.Nil(t, ctx.Container(fmt.Sprintf("%s:bar", scope.Name())))
+	assert.Equal(t, con, ctx.Container("foo"))
+	assert.Equal(t, con, ctx.Container(fmt.Sprintf("%s:foo", ctx.DefaultScope().Name())))
+
+	_, ok := h.ExecConfig.Networks[scope.Name()]
+	assert.False(t, ok)
+
+	// the last network of a bound container cannot be removed
+	assert.Error(t, ctx.RemoveContainer(h, ctx.DefaultScope().Name()))
+}
+
+func TestDualStackScope(t *testing.T) {
+	ctx, err := NewContext(testConfig(), nil)
+	assert.NoError(t, err)
+
+	_, subnet6, _ := net.ParseCIDR("fd00:1::/64")
+	scope, err := ctx.NewDualStackScope(context.TODO(), constants.BridgeScopeType, "dual", nil, nil, nil, nil, &IPv6Config{Subnet: subnet6})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, scope.IPv6())
+	assert.True(t, scope.Gateway6().Equal(net.ParseIP("fd00:1::1")))
+	assert.False(t, ip.IsUnspecifiedIP(scope.Gateway()))
+
+	// the IPv6 subnet cannot be used by another scope
+	_, err = ctx.NewDualStackScope(context.TODO(), constants.BridgeScopeType, "other", nil, nil, nil, nil, &IPv6Config{Subnet: subnet6})
+	assert.Error(t, err)
+
+	// an IPv6 address cannot be requested on a scope without IPv6
+	h := newContainer("foo")
+	static6 := net.ParseIP("fd00:1::100")
+	assert.Error(t, ctx.AddContainer(h, &AddContainerOptions{Scope: ctx.DefaultScope().Name(), IP6: &static6}))
+
+	assert.NoError(t, ctx.AddContainer(h, &AddContainerOptions{Scope: scope.Name(), IP6: &static6}))
+	h2 := newContainer("bar")
+	assert.NoError(t, ctx.AddContainer(h2, &AddContainerOptions{Scope: scope.Name()}))
+
+	for _, h := range []*exec.Handle{h, h2} {
+		_, err = ctx.BindContainer(h)
+		assert.NoError(t, err)
+	}
+
+	con := ctx.Container(h.ExecConfig.ID)
+	con2 := ctx.Container(h2.ExecConfig.ID)
+	if !assert.NotNil(t, con) || !assert.NotNil(t, con2) {
+		return
+	}
+
+	e, e2 := con.Endpoint(scope), con2.Endpoint(scope)
+	assert.True(t, e.IP6().Equal(static6))
+	assert.True(t, e2.IP6().Equal(net.ParseIP("fd00:1::2")))
+	assert.True(t, ip.IsIPv6(e2.IP6()))
+	assert.False(t, ip.IsIPv6(e2.IP()))
+
+	ne := h2.ExecConfig.Networks[scope.Name()]
+	assert.True(t, ne.Network.IPv6)
+	assert.True(t, ne.IP6.IP.Equal(e2.IP6()))
+	assert.True(t, ne.Network.Gateway6.IP.Equal(scope.Gateway6()))
+	assert.Equal(t, subnet6.Mask, ne.Network.Gateway6.Mask)
+
+	assert.Equal(t, con, ctx.ContainerByAddr(static6).Container())
+	assert.Equal(t, con2, ctx.ContainerByAddr(e2.IP6()).Container())
+
+	// unbinding releases the dynamic address, but not the static one
+	_, err = ctx.UnbindContainer(h2)
+	assert.NoError(t, err)
+	assert.Nil(t, ctx.ContainerByAddr(net.ParseIP("fd00:1::2")))
+	_, err = ctx.UnbindContainer(h)
+	assert.NoError(t, err)
+	assert.True(t, e.IP6().Equal(static6))
+
+	// the IPv6 addressing survives a round trip through the kv store
+	d, err := scope.MarshalJSON()
+	assert.NoError(t, err)
+	other := newScope(uid.NilUID, "", "", nil, nil, nil, nil)
+	assert.NoError(t, other.UnmarshalJSON(d))
+	assert.True(t, other.IPv6())
+	assert.Equal(t, scope.Subnet6().String(), other.Subnet6().String())
+	assert.True(t, scope.Gateway6().Equal(other.Gateway6()))
+	assert.Equal(t, scope.Pools6(), other.Pools6())
+}
+
+func TestIPv6OnlyScope(t *testing.T) {
+	ctx, err := NewContext(testConfig(), nil)
+	assert.NoError(t, err)
+
+	_, subnet, _ := net.ParseCIDR("fd00:2::/112")
+	scope, err := ctx.NewScope(context.TODO(), constants.BridgeScopeType, "v6", subnet, nil, nil, []string{"fd00:2::100-fd00:2::1ff"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, scope.IPv6())
+	assert.Nil(t, scope.Subnet6())
+	assert.True(t, scope.Gateway().Equal(net.ParseIP("fd00:2::100")))
+
+	// an IPv6 address is the primary address of the endpoint
+	h := newContainer("foo")
+	static := net.ParseIP("fd00:2::1ff")
+	assert.NoError(t, ctx.AddContainer(h, &AddContainerOptions{Scope: scope.Name(), IP6: &static}))
+	assert.True(t, h.ExecConfig.Networks[scope.Name()].Static)
+	assert.True(t, h.ExecConfig.Networks[scope.Name()].IP.IP.Equal(static))
+
+	h2 := newContainer("bar")
+	assert.NoError(t, ctx.AddContainer(h2, &AddContainerOptions{Scope: scope.Name()}))
+	for _, h := range []*exec.Handle{h, h2} {
+		_, err = ctx.BindContainer(h)
+		assert.NoError(t, err)
+	}
+
+	e := ctx.Container(h2.ExecConfig.ID).Endpoint(scope)
+	assert.True(t, e.IP().Equal(net.ParseIP("fd00:2::101")))
+	assert.Equal(t, subnet.Mask, h2.ExecConfig.Networks[scope.Name()].IP.Mask)
+	assert.True(t, h2.ExecConfig.Networks[scope.Name()].Network.IPv6)
+	assert.Equal(t, ctx.Container(h.ExecConfig.ID), ctx.ContainerByAddr(static).Container())
+}
+
 func TestLoadScopesFromKV(t *testing.T) {
 	// sample kv store data
 	var tests = []struct {
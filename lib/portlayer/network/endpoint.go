@@ -36,6 +36,8 @@ type Endpoint struct {
 	scope     *Scope
 	ip        net.IP
 	static    bool
+	ip6       net.IP // the IPv6 address on a dual-stack scope
+	static6   bool
 	ports     map[Port]interface{} // exposed ports
 	aliases   map[string][]alias
 }
@@ -96,6 +98,11 @@ func (e *Endpoint) IP() net.IP {
 	return e.ip
 }
 
+// IP6 returns the IPv6 address of an endpoint on a dual-stack scope
+func (e *Endpoint) IP6() net.IP {
+	return e.ip6
+}
+
 func (e *Endpoint) Scope() *Scope {
 	return e.scope
 }
@@ -120,6 +127,11 @@ func (e *Endpoint) Gateway() net.IP {
 	return e.Scope().Gateway()
 }
 
+// Gateway6 returns the IPv6 gateway of a dual-stack scope
+func (e *Endpoint) Gateway6() net.IP {
+	return e.Scope().Gateway6()
+}
+
 func (e *Endpoint) Ports() []Port {
 	ports := make([]Port, len(e.ports))
 	i := 0
@@ -21,7 +21,7 @@
 // are available as valid addresses. This behavior can be
 // accomplished, however, by just reserving those two addresses
 // first thing after requesting a CIDR address space, by using
-// the ReserveIP4() call.
+// the ReserveIP() call.
 
 package network
 
@@ -42,26 +42,32 @@ type AddressSpace struct {
 	availableRanges []*ip.Range
 }
 
-// compareIPv4 compares two IPv4 addresses.
+// compareIP compares two IP addresses of the same family.
 // Returns -1 if ip1 < ip2, 0 if they are equal,
 // and 1 if ip1 > ip2
-func compareIP4(ip1 net.IP, ip2 net.IP) int {
+func compareIP(ip1 net.IP, ip2 net.IP) int {
 	ip1 = ip1.To16()
 	ip2 = ip2.To16()
 	return bytes.Compare(ip1, ip2)
 }
 
-func incrementIP4(ip net.IP) net.IP {
-	if !isIP4(ip) {
+// firstOctet returns the index of the first octet of the address
+// proper, which is 12 for an IPv4 address in 16 byte form
+func firstOctet(ip net.IP) int {
+	if len(ip) == net.IPv6len && isIP4(ip) {
+		return net.IPv6len - net.IPv4len
+	}
+
+	return 0
+}
+
+func incrementIP(ip net.IP) net.IP {
+	if len(ip) != net.IPv4len && len(ip) != net.IPv6len {
 		return nil
 	}
 
 	newIP := copyIP(ip)
-	s := 0
-	if len(ip) == net.IPv6len {
-		s = 12
-	}
-	for i := len(newIP) - 1; i >= s; i-- {
+	for i := len(newIP) - 1; i >= firstOctet(ip); i-- {
 		newIP[i]++
 		if newIP[i] > 0 {
 			break
@@ -71,17 +77,13 @@ func incrementIP4(ip net.IP) net.IP {
 	return newIP
 }
 
-func decrementIP4(ip net.IP) net.IP {
-	if !isIP4(ip) {
+func decrementIP(ip net.IP) net.IP {
+	if len(ip) != net.IPv4len && len(ip) != net.IPv6len {
 		return nil
 	}
 
 	newIP := copyIP(ip)
-	s := 0
-	if len(ip) == net.IPv6len {
-		s = 12
-	}
-	for i := len(newIP) - 1; i >= s; i-- {
+	for i := len(newIP) - 1; i >= firstOctet(ip); i-- {
 		newIP[i]--
 		if newIP[i] != 0xff {
 			break
@@ -101,39 +103,30 @@ func isIP4(ip net.IP) bool {
 	return ip.To4() != nil
 }
 
-// lowestIP4 returns the lowest possible IP address
+// lowestIP returns the lowest possible IP address
 // in an IP network. For example:
 //
-//     lowestIP4(net.IPNet{}IP: net.ParseIP("172.16.0.0"), Mask: net.CIDRMask(16, 32)}) -> 172.16.0.0
+//     lowestIP(net.IPNet{}IP: net.ParseIP("172.16.0.0"), Mask: net.CIDRMask(16, 32)}) -> 172.16.0.0
 //
-func lowestIP4(ipRange *net.IPNet) net.IP {
+func lowestIP(ipRange *net.IPNet) net.IP {
 	return ipRange.IP.Mask(ipRange.Mask).To16()
 }
 
-// highestIP4 returns the highest possible IP address
+// highestIP returns the highest possible IP address
 // in an IP network. For example:
 //
-//     highestIP4(net.IPNet{}IP: net.ParseIP("172.16.0.0"), Mask: net.CIDRMask(16, 32)}) -> 172.16.255.255
+//     highestIP(net.IPNet{}IP: net.ParseIP("172.16.0.0"), Mask: net.CIDRMask(16, 32)}) -> 172.16.255.255
+//     highestIP(net.IPNet{}IP: net.ParseIP("fd00::"), Mask: net.CIDRMask(112, 128)}) -> fd00::ffff
 //
-func highestIP4(ipRange *net.IPNet) net.IP {
-	if !isIP4(ipRange.IP) {
-		return nil
-	}
-
-	newIP := net.IPv4(0, 0, 0, 0)
-	ipRange.IP = ipRange.IP.To4()
-	for i := 0; i < len(ipRange.Mask); i++ {
-		newIP[i+12] = ipRange.IP[i] | ^ipRange.Mask[i]
-	}
-
-	return newIP
+func highestIP(ipRange *net.IPNet) net.IP {
+	return ip.AllOnesAddr(ipRange)
 }
 
 // NewAddressSpaceFromNetwork creates a new AddressSpace from a network specification.
 func NewAddressSpaceFromNetwork(ipRange *net.IPNet) *AddressSpace {
 	s := &AddressSpace{
 		Network: ipRange,
-		Pool:    &ip.Range{FirstIP: lowestIP4(ipRange), LastIP: highestIP4(ipRange)},
+		Pool:    &ip.Range{FirstIP: lowestIP(ipRange), LastIP: highestIP(ipRange)},
 	}
 	s.availableRanges = []*ip.Range{s.Pool}
 
@@ -142,7 +135,7 @@ func NewAddressSpaceFromNetwork(ipRange *net.IPNet) *AddressSpace {
 
 // NewAddressSpaceFromRange creates a new AddressSpace from a range of IP addresses.
 func NewAddressSpaceFromRange(firstIP net.IP, lastIP net.IP) *AddressSpace {
-	if compareIP4(firstIP, lastIP) > 0 {
+	if compareIP(firstIP, lastIP) > 0 {
 		return nil
 	}
 
@@ -151,22 +144,35 @@ func NewAddressSpaceFromRange(firstIP net.IP, lastIP net.IP) *AddressSpace {
 		availableRanges: []*ip.Range{{FirstIP: firstIP, LastIP: lastIP}}}
 }
 
-func (s *AddressSpace) NextIP4Net(mask net.IPMask) (*net.IPNet, error) {
-	ones, _ := mask.Size()
+// NextIPNet returns the next available network of the given mask in the
+// address space, without reserving it.
+func (s *AddressSpace) NextIPNet(mask net.IPMask) (*net.IPNet, error) {
+	ones, bits := mask.Size()
+	if bits == 0 {
+		return nil, fmt.Errorf("invalid mask %s", mask)
+	}
+
+	// the octets before off are not part of the address for IPv4
+	off := net.IPv6len - bits/8
 	for _, r := range s.availableRanges {
 		network := r.FirstIP.Mask(mask).To16()
+		if network == nil {
+			// the range is of a different address family
+			continue
+		}
+
 		var firstIP net.IP
 		// check if the start of the current range
 		// is lower than the network boundary
-		if compareIP4(network, r.FirstIP) >= 0 {
+		if compareIP(network, r.FirstIP) >= 0 {
 			// found the start of the range
 			firstIP = network
 		} else {
 			// network address is lower than the first
 			// ip in the range; try the next network
 			// in the mask
-			for i := len(network) - 1; i >= 12; i-- {
-				partialByteIndex := ones/8 + 12
+			for i := len(network) - 1; i >= off; i-- {
+				partialByteIndex := ones/8 + off
 				var inc byte
 				if i == partialByteIndex {
 					// this octet may only be occupied
@@ -205,8 +211,8 @@ func (s *AddressSpace) NextIP4Net(mask net.IPMask) (*net.IPNet, error) {
 			// we found the first IP for the requested range,
 			// now check if the available range can accommodate
 			// the highest address given the first IP and the mask
-			lastIP := highestIP4(&net.IPNet{IP: firstIP, Mask: mask})
-			if compareIP4(lastIP, r.LastIP) <= 0 {
+			lastIP := highestIP(&net.IPNet{IP: firstIP, Mask: mask})
+			if compareIP(lastIP, r.LastIP) <= 0 {
 				return &net.IPNet{IP: firstIP, Mask: mask}, nil
 			}
 		}
@@ -215,43 +221,43 @@ func (s *AddressSpace) NextIP4Net(mask net.IPMask) (*net.IPNet, error) {
 	return nil, fmt.Errorf("could not find IP range for mask %s", mask)
 }
 
-// ReserveNextIP4Net reserves a new sub address space within the given address
+// ReserveNextIPNet reserves a new sub address space within the given address
 // space, given a bitmask specifying the "width" of the requested space.
-func (s *AddressSpace) ReserveNextIP4Net(mask net.IPMask) (*AddressSpace, error) {
-	n, err := s.NextIP4Net(mask)
+func (s *AddressSpace) ReserveNextIPNet(mask net.IPMask) (*AddressSpace, error) {
+	n, err := s.NextIPNet(mask)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.ReserveIP4Net(n)
+	return s.ReserveIPNet(n)
 }
 
 func splitRange(parentRange *ip.Range, firstIP net.IP, lastIP net.IP) (before, reserved, after *ip.Range) {
 	if !firstIP.Equal(parentRange.FirstIP) {
-		before = ip.NewRange(parentRange.FirstIP, decrementIP4(firstIP))
+		before = ip.NewRange(parentRange.FirstIP, decrementIP(firstIP))
 	}
 	if !lastIP.Equal(parentRange.LastIP) {
-		after = ip.NewRange(incrementIP4(lastIP), parentRange.LastIP)
+		after = ip.NewRange(incrementIP(lastIP), parentRange.LastIP)
 	}
 
 	reserved = ip.NewRange(firstIP, lastIP)
 	return
 }
 
-// ReserveIP4Net reserves a new sub address space given an IP and mask.
+// ReserveIPNet reserves a new sub address space given an IP and mask.
 // Mask is required.
-// If IP is nil or "0.0.0.0", same as calling ReserveNextIP4Net
-// with the mask.
-func (s *AddressSpace) ReserveIP4Net(ipNet *net.IPNet) (*AddressSpace, error) {
+// If IP is nil or unspecified, e.g. "0.0.0.0" or "::", same as calling
+// ReserveNextIPNet with the mask.
+func (s *AddressSpace) ReserveIPNet(ipNet *net.IPNet) (*AddressSpace, error) {
 	if ipNet.Mask == nil {
 		return nil, fmt.Errorf("network mask not specified")
 	}
 
-	if ipNet.IP == nil || ipNet.IP.Equal(net.ParseIP("0.0.0.0")) {
-		return s.ReserveNextIP4Net(ipNet.Mask)
+	if ip.IsUnspecifiedIP(ipNet.IP) {
+		return s.ReserveNextIPNet(ipNet.Mask)
 	}
 
-	sub, err := s.ReserveIP4Range(lowestIP4(ipNet), highestIP4(ipNet))
+	sub, err := s.ReserveIPRange(lowestIP(ipNet), highestIP(ipNet))
 	if err != nil {
 		return nil, err
 	}
@@ -272,11 +278,11 @@ func (s *AddressSpace) reserveSubRange(firstIP net.IP, lastIP net.IP, index int)
 	}
 }
 
-// ReserveIP4Range reserves a sub address space given a first and last IP.
-func (s *AddressSpace) ReserveIP4Range(firstIP net.IP, lastIP net.IP) (*AddressSpace, error) {
+// ReserveIPRange reserves a sub address space given a first and last IP.
+func (s *AddressSpace) ReserveIPRange(firstIP net.IP, lastIP net.IP) (*AddressSpace, error) {
 	for i, r := range s.availableRanges {
-		if compareIP4(firstIP, r.FirstIP) < 0 ||
-			compareIP4(lastIP, r.LastIP) > 0 {
+		if compareIP(firstIP, r.FirstIP) < 0 ||
+			compareIP(lastIP, r.LastIP) > 0 {
 			continue
 		}
 
@@ -307,9 +313,14 @@ func insertAddressRanges(r []*ip.Range, index int, ranges ...*ip.Range) []*ip.Ra
 	return r
 }
 
-// ReserveNextIP4 reserves the next available IPv4 address.
-func (s *AddressSpace) ReserveNextIP4() (net.IP, error) {
-	space, err := s.ReserveIP4Net(&net.IPNet{Mask: net.CIDRMask(32, 32)})
+// ReserveNextIP reserves the next available address.
+func (s *AddressSpace) ReserveNextIP() (net.IP, error) {
+	bits := 8 * net.IPv4len
+	if s.Pool != nil && !isIP4(s.Pool.FirstIP) {
+		bits = 8 * net.IPv6len
+	}
+
+	space, err := s.ReserveIPNet(&net.IPNet{Mask: net.CIDRMask(bits, bits)})
 	if err != nil {
 		return nil, err
 	}
@@ -317,15 +328,15 @@ func (s *AddressSpace) ReserveNextIP4() (net.IP, error) {
 	return space.availableRanges[0].FirstIP, nil
 }
 
-// ReserveIP4 reserves the given IPv4 address.
-func (s *AddressSpace) ReserveIP4(ip net.IP) error {
-	_, err := s.ReserveIP4Range(ip, ip)
+// ReserveIP reserves the given address.
+func (s *AddressSpace) ReserveIP(ip net.IP) error {
+	_, err := s.ReserveIPRange(ip, ip)
 	return err
 }
 
-// ReleaseIP4Range releases a sub address space into the parent address space.
+// ReleaseIPRange releases a sub address space into the parent address space.
 // Sub address space has to have only a single available range.
-func (s *AddressSpace) ReleaseIP4Range(space *AddressSpace) error {
+func (s *AddressSpace) ReleaseIPRange(space *AddressSpace) error {
 	// nothing to release
 	if space == nil || len(space.availableRanges) == 0 {
 		return nil
@@ -342,25 +353,25 @@ func (s *AddressSpace) ReleaseIP4Range(space *AddressSpace) error {
 
 	firstIP := space.availableRanges[0].FirstIP
 	lastIP := space.availableRanges[0].LastIP
-	if compareIP4(firstIP, lastIP) > 0 {
+	if compareIP(firstIP, lastIP) > 0 {
 		return fmt.Errorf("address space first ip %s is greater than last ip %s", firstIP, lastIP)
 	}
 
 	i := 0
 	for ; i < len(s.availableRanges); i++ {
-		if compareIP4(lastIP, s.availableRanges[i].FirstIP) < 0 {
+		if compareIP(lastIP, s.availableRanges[i].FirstIP) < 0 {
 			if i == 0 {
 				break
 			}
 
-			if i > 0 && compareIP4(firstIP, s.availableRanges[i-1].LastIP) > 0 {
+			if i > 0 && compareIP(firstIP, s.availableRanges[i-1].LastIP) > 0 {
 				break
 			}
 		}
 	}
 
 	if i > 0 && i == len(s.availableRanges) {
-		if compareIP4(firstIP, s.availableRanges[i-1].LastIP) <= 0 {
+		if compareIP(firstIP, s.availableRanges[i-1].LastIP) <= 0 {
 			return fmt.Errorf("Could not release IP range")
 		}
 	}
@@ -370,18 +381,18 @@ func (s *AddressSpace) ReleaseIP4Range(space *AddressSpace) error {
 	return nil
 }
 
-// ReleaseIP4 releases the given IPv4 address.
-func (s *AddressSpace) ReleaseIP4(ip net.IP) error {
+// ReleaseIP releases the given address.
+func (s *AddressSpace) ReleaseIP(ip net.IP) error {
 	tmp := NewAddressSpaceFromRange(ip, ip)
 	tmp.Parent = s
-	return s.ReleaseIP4Range(tmp)
+	return s.ReleaseIPRange(tmp)
 }
 
 func (s *AddressSpace) Defragment() error {
 	for i := 1; i < len(s.availableRanges); {
 		first := s.availableRanges[i-1]
 		second := s.availableRanges[i]
-		if incrementIP4(first.LastIP).Equal(second.FirstIP) {
+		if incrementIP(first.LastIP).Equal(second.FirstIP) {
 			first.LastIP = second.LastIP
 			s.availableRanges = append(s.availableRanges[:i], s.availableRanges[i+1:]...)
 		} else {
@@ -399,8 +410,8 @@ func (s *AddressSpace) Equal(other *AddressSpace) bool {
 	}
 
 	for i := 0; i < len(s.availableRanges); i++ {
-		if compareIP4(s.availableRanges[i].FirstIP, other.availableRanges[i].FirstIP) != 0 ||
-			compareIP4(s.availableRanges[i].LastIP, other.availableRanges[i].LastIP) != 0 {
+		if compareIP(s.availableRanges[i].FirstIP, other.availableRanges[i].FirstIP) != 0 ||
+			compareIP(s.availableRanges[i].LastIP, other.availableRanges[i].LastIP) != 0 {
 			return false
 		}
 	}
@@ -19,12 +19,13 @@ import (
 	"testing"
 )
 
-func TestIncrementIP4(t *testing.T) {
+func TestIncrementIP(t *testing.T) {
 	var tests = []struct {
 		in  net.IP
 		out net.IP
 	}{
-		{net.IPv6loopback, nil},
+		{net.IPv6loopback, net.ParseIP("::2")},
+		{net.ParseIP("fd00::ffff"), net.ParseIP("fd00::1:0")},
 		{net.ParseIP("10.10.10.255"), net.ParseIP("10.10.11.0")},
 		{net.ParseIP("10.10.255.255"), net.ParseIP("10.11.0.0")},
 		{net.ParseIP("10.255.255.255"), net.ParseIP("11.0.0.0")},
@@ -32,19 +33,20 @@ func TestIncrementIP4(t *testing.T) {
 	}
 
 	for _, te := range tests {
-		ip := incrementIP4(te.in)
+		ip := incrementIP(te.in)
 		if !te.out.Equal(ip) {
 			t.Errorf("got: %s, expected: %s", ip, te.out)
 		}
 	}
 }
 
-func TestDecrementIP4(t *testing.T) {
+func TestDecrementIP(t *testing.T) {
 	var tests = []struct {
 		in  net.IP
 		out net.IP
 	}{
-		{net.IPv6loopback, nil},
+		{net.IPv6loopback, net.IPv6unspecified},
+		{net.ParseIP("fd00::1:0"), net.ParseIP("fd00::ffff")},
 		{net.ParseIP("10.10.10.0"), net.ParseIP("10.10.9.255")},
 		{net.ParseIP("10.10.0.0"), net.ParseIP("10.9.255.255")},
 		{net.ParseIP("10.0.0.0"), net.ParseIP("9.255.255.255")},
@@ -52,14 +54,14 @@ func TestDecrementIP4(t *testing.T) {
 	}
 
 	for _, te := range tests {
-		ip := decrementIP4(te.in)
+		ip := decrementIP(te.in)
 		if !te.out.Equal(ip) {
 			t.Errorf("got: %s, expected: %s", ip, te.out)
 		}
 	}
 }
 
-func TestCompareIP4(t *testing.T) {
+func TestCompareIP(t *testing.T) {
 	ips := []net.IP{
 		net.ParseIP("10.10.10.10"),
 		net.ParseIP("10.10.10.9"),
@@ -68,13 +70,13 @@ func TestCompareIP4(t *testing.T) {
 		net.ParseIP("9.9.9.9")}
 
 	for i := 0; i < len(ips)-1; i++ {
-		if res := compareIP4(ips[i+1], ips[i]); res != -1 {
+		if res := compareIP(ips[i+1], ips[i]); res != -1 {
 			t.Fatalf("comparing %s %s got: %v, expected: -1", ips[i+1], ips[i], res)
 		}
-		if res := compareIP4(ips[i], ips[i+1]); res != 1 {
+		if res := compareIP(ips[i], ips[i+1]); res != 1 {
 			t.Fatalf("comparing %s %s got: %v, expected: 1", ips[i], ips[i+1], res)
 		}
-		if res := compareIP4(ips[i], ips[i]); res != 0 {
+		if res := compareIP(ips[i], ips[i]); res != 0 {
 			t.Fatalf("comparing %s %s got: %v expected: 0", ips[i], ips[i], res)
 		}
 	}
@@ -91,89 +93,90 @@ func TestIsIP4(t *testing.T) {
 	}
 }
 
-func TestLowestIP4(t *testing.T) {
+func TestLowestIP(t *testing.T) {
 	r := &net.IPNet{IP: net.ParseIP("10.10.10.10").To4(), Mask: net.CIDRMask(24, 32)}
 	ip := net.ParseIP("10.10.10.0")
-	if res := lowestIP4(r); !res.Equal(ip) {
+	if res := lowestIP(r); !res.Equal(ip) {
 		t.Errorf("range %s got: %s expected %s", r, res, ip)
 	}
 }
 
-func TestHighestIP4(t *testing.T) {
+func TestHighestIP(t *testing.T) {
 	var tests = []struct {
 		in  *net.IPNet
 		out net.IP
 	}{
 		{&net.IPNet{IP: net.IPv6loopback}, nil},
 		{&net.IPNet{IP: net.ParseIP("10.10.10.10").To4(), Mask: net.CIDRMask(24, 32)}, net.ParseIP("10.10.10.255")},
+		{&net.IPNet{IP: net.ParseIP("fd00::10"), Mask: net.CIDRMask(120, 128)}, net.ParseIP("fd00::ff")},
 	}
 
 	for _, te := range tests {
-		if res := highestIP4(te.in); !res.Equal(te.out) {
+		if res := highestIP(te.in); !res.Equal(te.out) {
 			t.Errorf("range %s got: %s expected %s", te.in, res, te.out)
 		}
 	}
 }
 
-func TestReserveIP4(t *testing.T) {
+func TestReserveIP(t *testing.T) {
 	space := NewAddressSpaceFromRange(net.ParseIP("10.10.10.10"),
 		net.ParseIP("10.10.10.11"))
 
-	ip, err := space.ReserveNextIP4()
+	ip, err := space.ReserveNextIP()
 	expected := net.ParseIP("10.10.10.10")
 	if err != nil || !ip.Equal(expected) {
 		t.Errorf("got: %s, %s expected: %s, nil", ip, err, expected)
 	}
 
-	ip, err = space.ReserveNextIP4()
+	ip, err = space.ReserveNextIP()
 	expected = net.ParseIP("10.10.10.11")
 	if err != nil || !ip.Equal(expected) {
 		t.Errorf("got: %s, %s expected: %s, nil", ip, err, expected)
 	}
 
-	ip, err = space.ReserveNextIP4()
+	ip, err = space.ReserveNextIP()
 	if err == nil {
 		t.Errorf("got: %s, %s expected: nil, error", ip, err)
 	}
 }
 
-func TestReleaseIP4(t *testing.T) {
+func TestReleaseIP(t *testing.T) {
 	space := NewAddressSpaceFromRange(net.ParseIP("10.10.10.10"),
 		net.ParseIP("10.10.10.11"))
 
-	ip, err := space.ReserveNextIP4()
+	ip, err := space.ReserveNextIP()
 	expected := net.ParseIP("10.10.10.10")
 	if err != nil || !ip.Equal(expected) {
 		t.Errorf("got: %s, %s expected: %s, nil", ip, err, expected)
 	}
 
-	ip, err = space.ReserveNextIP4()
+	ip, err = space.ReserveNextIP()
 	expected = net.ParseIP("10.10.10.11")
 	if err != nil || !ip.Equal(expected) {
 		t.Errorf("got: %s, %s expected: %s, nil", ip, err, expected)
 	}
 
-	ip, err = space.ReserveNextIP4()
+	ip, err = space.ReserveNextIP()
 	if err == nil {
 		t.Errorf("got: %s, %s expected: nil, error", ip, err)
 	}
 
-	err = space.ReleaseIP4(net.ParseIP("10.10.10.10"))
+	err = space.ReleaseIP(net.ParseIP("10.10.10.10"))
 	if err != nil {
 		t.Errorf("got: %s expected: nil", err)
 	}
 
-	err = space.ReleaseIP4(net.ParseIP("10.10.10.10"))
+	err = space.ReleaseIP(net.ParseIP("10.10.10.10"))
 	if err == nil {
 		t.Errorf("got: nil expected: error")
 	}
 
-	err = space.ReleaseIP4(net.ParseIP("10.10.10.11"))
+	err = space.ReleaseIP(net.ParseIP("10.10.10.11"))
 	if err != nil {
 		t.Errorf("got: %s expected: nil", err)
 	}
 
-	ip, err = space.ReserveNextIP4()
+	ip, err = space.ReserveNextIP()
 	expected = net.ParseIP("10.10.10.10")
 	if err != nil || !ip.Equal(expected) {
 		t.Errorf("got: %s, %s expected: %s, nil", ip, err, expected)
@@ -181,24 +184,24 @@ func TestReleaseIP4(t *testing.T) {
 
 }
 
-func TestReserveNextIP4Net(t *testing.T) {
+func TestReserveNextIPNet(t *testing.T) {
 	_, net1, _ := net.ParseCIDR("172.16.0.0/12")
 	space := NewAddressSpaceFromNetwork(net1)
 	firstIP := net.IPv4(172, 16, 0, 0)
 	lastIP := net.IPv4(172, 16, 255, 255)
 	totalSubspaces := 0
-	subspace, err := space.ReserveNextIP4Net(net.CIDRMask(16, 32))
+	subspace, err := space.ReserveNextIPNet(net.CIDRMask(16, 32))
 	for err == nil {
 		totalSubspaces++
-		if compareIP4(firstIP, subspace.availableRanges[0].FirstIP) != 0 {
+		if compareIP(firstIP, subspace.availableRanges[0].FirstIP) != 0 {
 			t.Errorf("got: %s, expected: %s", subspace.availableRanges[0].FirstIP, firstIP)
 		}
-		if compareIP4(lastIP, subspace.availableRanges[0].LastIP) != 0 {
+		if compareIP(lastIP, subspace.availableRanges[0].LastIP) != 0 {
 			t.Errorf("got: %s, expected: %s", subspace.availableRanges[0].LastIP, lastIP)
 		}
 		firstIP = net.IPv4(172, firstIP[13]+1, 0, 0)
 		lastIP = net.IPv4(172, lastIP[13]+1, 255, 255)
-		subspace, err = space.ReserveNextIP4Net(net.CIDRMask(16, 32))
+		subspace, err = space.ReserveNextIPNet(net.CIDRMask(16, 32))
 	}
 
 	if totalSubspaces != 16 {
@@ -207,80 +210,132 @@ func TestReserveNextIP4Net(t *testing.T) {
 
 	space = NewAddressSpaceFromNetwork(net1)
 	// peal off one ip from the range
-	ip, err := space.ReserveNextIP4()
+	ip, err := space.ReserveNextIP()
 	if !ip.Equal(net.ParseIP("172.16.0.0")) {
 		t.Errorf("got: %s, expected: 172.16.0.0", ip)
 	}
-	subSpace, err := space.ReserveNextIP4Net(net.CIDRMask(16, 32))
-	ip, err = subSpace.ReserveNextIP4()
-	if compareIP4(ip, net.ParseIP("172.17.0.0")) != 0 {
+	subSpace, err := space.ReserveNextIPNet(net.CIDRMask(16, 32))
+	ip, err = subSpace.ReserveNextIP()
+	if compareIP(ip, net.ParseIP("172.17.0.0")) != 0 {
 		t.Errorf("got: %s, expected: %s", ip, net.ParseIP("172.17.0.0"))
 	}
 
-	subSpace, err = space.ReserveNextIP4Net(net.CIDRMask(15, 32))
-	ip, err = subSpace.ReserveNextIP4()
-	if compareIP4(ip, net.ParseIP("172.18.0.0")) != 0 {
+	subSpace, err = space.ReserveNextIPNet(net.CIDRMask(15, 32))
+	ip, err = subSpace.ReserveNextIP()
+	if compareIP(ip, net.ParseIP("172.18.0.0")) != 0 {
 		t.Errorf("got: %s, expected: %s", ip, net.ParseIP("172.18.0.0"))
 	}
 }
 
-func TestReserveIP4Net(t *testing.T) {
+func TestReserveNextIP6Net(t *testing.T) {
+	_, net1, _ := net.ParseCIDR("fd00:1::/48")
+	space := NewAddressSpaceFromNetwork(net1)
+
+	// subnets are handed out in order
+	for i := 0; i < 3; i++ {
+		subSpace, err := space.ReserveNextIPNet(net.CIDRMask(64, 128))
+		if err != nil {
+			t.Fatalf("got: %s, expected: nil", err)
+		}
+
+		expected := net.ParseIP("fd00:1::")
+		expected[7] = byte(i)
+		if compareIP(subSpace.availableRanges[0].FirstIP, expected) != 0 {
+			t.Errorf("got: %s, expected: %s", subSpace.availableRanges[0].FirstIP, expected)
+		}
+
+		ip, err := subSpace.ReserveNextIP()
+		if err != nil || compareIP(ip, expected) != 0 {
+			t.Errorf("got: %s, expected: %s", ip, expected)
+		}
+		ip, err = subSpace.ReserveNextIP()
+		if expected = incrementIP(expected); err != nil || compareIP(ip, expected) != 0 {
+			t.Errorf("got: %s, expected: %s", ip, expected)
+		}
+	}
+
+	// an IPv4 mask does not fit in an IPv6 space
+	if _, err := space.ReserveNextIPNet(net.CIDRMask(24, 32)); err == nil {
+		t.Errorf("got: nil, expected: error")
+	}
+
+	// "::" is unspecified
+	if _, err := space.ReserveIPNet(&net.IPNet{IP: net.IPv6unspecified, Mask: net.CIDRMask(64, 128)}); err != nil {
+		t.Errorf("got: %s, expected: nil", err)
+	}
+
+	ip := net.ParseIP("fd00:1:0:10::1")
+	if err := space.ReserveIP(ip); err != nil {
+		t.Errorf("got: %s, expected: nil", err)
+	}
+	if err := space.ReserveIP(ip); err == nil {
+		t.Errorf("got: nil, expected: error")
+	}
+	if err := space.ReleaseIP(ip); err != nil {
+		t.Errorf("got: %s, expected: nil", err)
+	}
+	if err := space.ReserveIP(ip); err != nil {
+		t.Errorf("got: %s, expected: nil", err)
+	}
+}
+
+func TestReserveIPNet(t *testing.T) {
 	ipNet := &net.IPNet{IP: net.ParseIP("172.16.0.0"), Mask: net.CIDRMask(12, 32)}
 	space := NewAddressSpaceFromNetwork(ipNet)
 	// no mask
-	_, err := space.ReserveIP4Net(&net.IPNet{IP: net.ParseIP("10.10.10.10")})
+	_, err := space.ReserveIPNet(&net.IPNet{IP: net.ParseIP("10.10.10.10")})
 	if err == nil {
 		t.Errorf("got: nil, expected: error")
 	}
 
 	// IP == nil, Mask != nil
-	_, err = space.ReserveIP4Net(&net.IPNet{Mask: net.CIDRMask(12, 32)})
+	_, err = space.ReserveIPNet(&net.IPNet{Mask: net.CIDRMask(12, 32)})
 	if err != nil {
 		t.Errorf("got: %s, expected: nil", err)
 	}
-	_, err = space.ReserveNextIP4()
+	_, err = space.ReserveNextIP()
 	if err == nil {
 		t.Errorf("got: nil, expected: error")
 	}
 	space = NewAddressSpaceFromNetwork(ipNet)
 
 	// ip == "0.0.0.0", Mask != nil
-	_, err = space.ReserveIP4Net(&net.IPNet{IP: net.ParseIP("0.0.0.0"), Mask: net.CIDRMask(12, 32)})
+	_, err = space.ReserveIPNet(&net.IPNet{IP: net.ParseIP("0.0.0.0"), Mask: net.CIDRMask(12, 32)})
 	if err != nil {
 		t.Errorf("got: %s, expected: nil", err)
 	}
-	_, err = space.ReserveNextIP4()
+	_, err = space.ReserveNextIP()
 	if err == nil {
 		t.Errorf("got: nil, expected: error")
 	}
 	space = NewAddressSpaceFromNetwork(ipNet)
 
 	// reserve the full space
-	_, err = space.ReserveIP4Net(ipNet)
+	_, err = space.ReserveIPNet(ipNet)
 	if err != nil {
 		t.Errorf("got: %s, expected: nil", err)
 	}
 	// no more ips left
-	_, err = space.ReserveNextIP4()
+	_, err = space.ReserveNextIP()
 	if err == nil {
 		t.Errorf("got: nil, expected: error")
 	}
 }
 
-func TestReserveIP4Range(t *testing.T) {
+func TestReserveIPRange(t *testing.T) {
 	s := NewAddressSpaceFromNetwork(&net.IPNet{IP: net.IPv4(10, 10, 10, 0), Mask: net.CIDRMask(24, 32)})
-	s.ReserveNextIP4()
+	s.ReserveNextIP()
 	// try to reserve an unavailable range
-	_, err := s.ReserveIP4Range(net.IPv4(10, 10, 10, 0), net.IPv4(10, 10, 10, 255))
+	_, err := s.ReserveIPRange(net.IPv4(10, 10, 10, 0), net.IPv4(10, 10, 10, 255))
 	if err == nil {
 		t.Errorf("got: nil, expected: error")
 	}
 }
 
-func TestReleaseIP4Range(t *testing.T) {
+func TestReleaseIPRange(t *testing.T) {
 	_, net1, _ := net.ParseCIDR("172.16.0.0/12")
 	space := NewAddressSpaceFromNetwork(net1)
-	err := space.ReleaseIP4Range(nil)
+	err := space.ReleaseIPRange(nil)
 	if err != nil {
 		t.Errorf("got: %s, expected: nil", err)
 	}
@@ -288,62 +343,62 @@ func TestReleaseIP4Range(t *testing.T) {
 	// reserve the full range
 	subSpaces := make([]*AddressSpace, 16)
 	totalReserved := 0
-	subSpaces[0], err = space.ReserveNextIP4Net(net.CIDRMask(16, 32))
+	subSpaces[0], err = space.ReserveNextIPNet(net.CIDRMask(16, 32))
 	if err != nil {
 		t.Errorf("got: %s, expected: nil", err)
 	}
 	totalReserved++
 	for i := 1; i < len(subSpaces) && err == nil; i++ {
 		totalReserved++
-		subSpaces[i], err = space.ReserveNextIP4Net(net.CIDRMask(16, 32))
+		subSpaces[i], err = space.ReserveNextIPNet(net.CIDRMask(16, 32))
 	}
 	if totalReserved != 16 {
 		t.Errorf("got: %d, expected: 16", totalReserved)
 	}
 
 	// release a range at the beginning
-	err = space.ReleaseIP4Range(subSpaces[0])
+	err = space.ReleaseIPRange(subSpaces[0])
 	if err != nil {
 		t.Errorf("got: %s, expected: nil", err)
 	}
 
 	// try to release an already released range
-	err = space.ReleaseIP4Range(subSpaces[0])
+	err = space.ReleaseIPRange(subSpaces[0])
 	if err == nil {
 		t.Errorf("got: nil, expected: error")
 	}
 
 	// release a range in the middle
-	err = space.ReleaseIP4Range(subSpaces[5])
+	err = space.ReleaseIPRange(subSpaces[5])
 	if err != nil {
 		t.Errorf("got: %s, expected: nil", err)
 	}
 
 	// release a range at the end
-	err = space.ReleaseIP4Range(subSpaces[len(subSpaces)-1])
+	err = space.ReleaseIPRange(subSpaces[len(subSpaces)-1])
 	if err != nil {
 		t.Errorf("got: %s, expected: nil", err)
 	}
 
 	// try to reserve a released range
-	subspace, err := space.ReserveNextIP4Net(net.CIDRMask(16, 32))
+	subspace, err := space.ReserveNextIPNet(net.CIDRMask(16, 32))
 	if err != nil || !subSpaces[0].Equal(subspace) {
 		t.Fail()
 	}
 
 	space = NewAddressSpaceFromNetwork(net1)
 	// get a sub space
-	subSpace, err := space.ReserveNextIP4Net(net.CIDRMask(16, 32))
+	subSpace, err := space.ReserveNextIPNet(net.CIDRMask(16, 32))
 	if err != nil {
 		t.Errorf("got: %s, expected: nil", err)
 	}
 	// fragment the sub space
-	err = subSpace.ReserveIP4(net.ParseIP("172.16.0.2"))
+	err = subSpace.ReserveIP(net.ParseIP("172.16.0.2"))
 	if err != nil {
 		t.Errorf("got: %s, expected: nil", err)
 	}
 	// try to release it; should fail
-	err = space.ReleaseIP4Range(subSpace)
+	err = space.ReleaseIPRange(subSpace)
 	if err == nil {
 		t.Errorf("got: nil, expected: error")
 	}
@@ -352,17 +407,17 @@ func TestReleaseIP4Range(t *testing.T) {
 func TestDefragment(t *testing.T) {
 	_, net1, _ := net.ParseCIDR("172.16.0.0/24")
 	space := NewAddressSpaceFromNetwork(net1)
-	ip, _ := space.ReserveNextIP4()
-	if compareIP4(ip, net.ParseIP("172.16.0.0")) != 0 {
+	ip, _ := space.ReserveNextIP()
+	if compareIP(ip, net.ParseIP("172.16.0.0")) != 0 {
 		t.Errorf("got: %s, expected: %s", ip, net.ParseIP("172.16.0.0"))
 	}
 
-	err := space.ReserveIP4(net.ParseIP("172.16.0.24"))
+	err := space.ReserveIP(net.ParseIP("172.16.0.24"))
 	if err != nil {
 		t.Errorf("got: %s, expected: nil", err)
 	}
 
-	space.ReleaseIP4(ip)
+	space.ReleaseIP(ip)
 	if len(space.availableRanges) != 2 {
 		t.Errorf("got: %d, expected: 2", len(space.availableRanges))
 	}
@@ -372,7 +427,7 @@ func TestDefragment(t *testing.T) {
 		t.Errorf("got: %d, expected: 2", len(space.availableRanges))
 	}
 
-	space.ReleaseIP4(net.ParseIP("172.16.0.24"))
+	space.ReleaseIP(net.ParseIP("172.16.0.24"))
 	if len(space.availableRanges) != 1 {
 		t.Errorf("got: %d, expected: 1", len(space.availableRanges))
 	}
@@ -195,18 +195,21 @@ func engageContext(ctx context.Context, netctx *Context, em event.EventManager)
 			}
 
 			log.Debugf("adding scope %s", n)
-			if _, err = netctx.newScope(ne.Network.Type, n, &ne.Network.Gateway, ne.Network.Gateway.IP, ne.Network.Nameservers, pools); err != nil {
+			if _, err = netctx.newScope(ne.Network.Type, n, &ne.Network.Gateway, ne.Network.Gateway.IP, ne.Network.Nameservers, pools, ipv6Config(&ne.Network)); err != nil {
 				return err
 			}
 		}
 
-		if c.CurrentState() == exec.StateRunning {
+		if c.CurrentState().Running() {
 			if _, err = netctx.bindContainer(h); err != nil {
 				return err
 			}
 		}
 	}
 
+	// only publish network events for changes made after the existing
+	// containers have been bound
+	netctx.em = em
 	return nil
 }
 
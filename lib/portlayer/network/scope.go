@@ -41,6 +41,21 @@ type Scope struct {
 	spaces     []*AddressSpace
 	builtin    bool
 	network    object.NetworkReference
+
+	// the IPv6 addressing of a dual-stack scope; an IPv6 only scope
+	// uses subnet, gateway and spaces instead
+	ipv6     bool
+	subnet6  *net.IPNet
+	gateway6 net.IP
+	spaces6  []*AddressSpace
+}
+
+// IPv6Config is the IPv6 addressing of a dual-stack scope. A scope that has
+// one but no subnet relies on the network to configure IPv6 addresses.
+type IPv6Config struct {
+	Subnet  *net.IPNet
+	Gateway net.IP
+	Pools   []string
 }
 
 func newScope(id uid.UID, name string, scopeType string, subnet *net.IPNet, gateway net.IP, dns []net.IP, network object.NetworkReference) *Scope {
@@ -95,10 +110,22 @@ func (s *Scope) Pools() []*ip.Range {
 	return s.pools()
 }
 
+// Pools6 returns the IPv6 pools of a dual-stack scope
+func (s *Scope) Pools6() []*ip.Range {
+	s.RLock()
+	defer s.RUnlock()
+
+	return spacePools(s.spaces6)
+}
+
 func (s *Scope) pools() []*ip.Range {
-	pools := make([]*ip.Range, len(s.spaces))
-	for i := range s.spaces {
-		sp := s.spaces[i]
+	return spacePools(s.spaces)
+}
+
+func spacePools(spaces []*AddressSpace) []*ip.Range {
+	pools := make([]*ip.Range, len(spaces))
+	for i := range spaces {
+		sp := spaces[i]
 		if sp.Network != nil {
 			r := ip.ParseRange(sp.Network.String())
 			if r == nil {
@@ -119,23 +146,47 @@ func (s *Scope) reserveEndpointIP(e *Endpoint) error {
 		return nil
 	}
 
-	// reserve an ip address
+	eip, err := reserveIP(s.spaces, e.ip)
+	if err != nil {
+		return err
+	}
+	e.ip = eip
+
+	if len(s.spaces6) == 0 {
+		return nil
+	}
+
+	eip, err = reserveIP(s.spaces6, e.ip6)
+	if err != nil {
+		releaseIP(s.spaces, e.ip)
+		if !e.static {
+			e.ip = net.IPv4(0, 0, 0, 0)
+		}
+		return err
+	}
+	e.ip6 = eip
+
+	return nil
+}
+
+// reserveIP reserves the address in one of the spaces, or the next
+// available address if it is unspecified
+func reserveIP(spaces []*AddressSpace, addr net.IP) (net.IP, error) {
 	var err error
-	for _, p := range s.spaces {
-		if !ip.IsUnspecifiedIP(e.ip) {
-			if err = p.ReserveIP4(e.ip); err == nil {
-				return nil
+	for _, p := range spaces {
+		if !ip.IsUnspecifiedIP(addr) {
+			if err = p.ReserveIP(addr); err == nil {
+				return addr, nil
 			}
 		} else {
 			var eip net.IP
-			if eip, err = p.ReserveNextIP4(); err == nil {
-				e.ip = eip
-				return nil
+			if eip, err = p.ReserveNextIP(); err == nil {
+				return eip, nil
 			}
 		}
 	}
 
-	return err
+	return addr, err
 }
 
 func (s *Scope) releaseEndpointIP(e *Endpoint) error {
@@ -143,11 +194,29 @@ func (s *Scope) releaseEndpointIP(e *Endpoint) error {
 		return nil
 	}
 
-	for _, p := range s.spaces {
-		if err := p.ReleaseIP4(e.ip); err == nil {
-			if !e.static {
-				e.ip = net.IPv4(0, 0, 0, 0)
-			}
+	if len(s.spaces6) > 0 {
+		if err := releaseIP(s.spaces6, e.ip6); err != nil {
+			return err
+		}
+
+		if !e.static6 {
+			e.ip6 = nil
+		}
+	}
+
+	if err := releaseIP(s.spaces, e.ip); err != nil {
+		return err
+	}
+
+	if !e.static {
+		e.ip = net.IPv4(0, 0, 0, 0)
+	}
+	return nil
+}
+
+func releaseIP(spaces []*AddressSpace, addr net.IP) error {
+	for _, p := range spaces {
+		if err := p.ReleaseIP(addr); err == nil {
 			return nil
 		}
 	}
@@ -236,7 +305,7 @@ func (s *Scope) ContainerByAddr(addr net.IP) *Endpoint {
 	}
 
 	for _, e := range s.endpoints {
-		if addr.Equal(e.IP()) {
+		if addr.Equal(e.IP()) || addr.Equal(e.IP6()) {
 			return e
 		}
 	}
@@ -267,6 +336,31 @@ func (s *Scope) Gateway() net.IP {
 	return s.gateway
 }
 
+// IPv6 returns true if IPv6 is enabled on the scope, either as its only
+// address family or alongside IPv4
+func (s *Scope) IPv6() bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.ipv6 || (s.subnet != nil && ip.IsIPv6(s.subnet.IP))
+}
+
+// Subnet6 returns the IPv6 subnet of a dual-stack scope
+func (s *Scope) Subnet6() *net.IPNet {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.subnet6
+}
+
+// Gateway6 returns the IPv6 gateway of a dual-stack scope
+func (s *Scope) Gateway6() net.IP {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.gateway6
+}
+
 func (s *Scope) DNS() []net.IP {
 	s.RLock()
 	defer s.RUnlock()
@@ -308,6 +402,11 @@ type scopeJSON struct {
 	DNS     []net.IP
 	Builtin bool
 	Pools   []*ip.Range
+
+	IPv6     bool
+	Subnet6  *net.IPNet
+	Gateway6 net.IP
+	Pools6   []*ip.Range
 }
 
 func (s *Scope) MarshalJSON() ([]byte, error) {
@@ -323,6 +422,11 @@ func (s *Scope) MarshalJSON() ([]byte, error) {
 		DNS:     s.dns,
 		Builtin: s.builtin,
 		Pools:   s.pools(),
+
+		IPv6:     s.ipv6,
+		Subnet6:  s.subnet6,
+		Gateway6: s.gateway6,
+		Pools6:   spacePools(s.spaces6),
 	})
 }
 
@@ -345,14 +449,16 @@ func (s *Scope) UnmarshalJSON(data []byte) error {
 	ns.gateway = sj.Gateway
 	ns.dns = sj.DNS
 	ns.builtin = sj.Builtin
-	ns.spaces = make([]*AddressSpace, len(sj.Pools))
-	for i := range sj.Pools {
-		sp := NewAddressSpaceFromRange(sj.Pools[i].FirstIP, sj.Pools[i].LastIP)
-		if sp == nil {
-			return fmt.Errorf("invalid pool %s in scope %s", sj.Pools[i].String(), sj.Name)
-		}
+	ns.ipv6 = sj.IPv6
+	ns.subnet6 = sj.Subnet6
+	ns.gateway6 = sj.Gateway6
 
-		ns.spaces[i] = sp
+	var err error
+	if ns.spaces, err = poolSpaces(sj.Pools); err != nil {
+		return fmt.Errorf("%s in scope %s", err, sj.Name)
+	}
+	if ns.spaces6, err = poolSpaces(sj.Pools6); err != nil {
+		return fmt.Errorf("%s in scope %s", err, sj.Name)
 	}
 
 	s.swap(&ns)
@@ -360,6 +466,20 @@ func (s *Scope) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+func poolSpaces(pools []*ip.Range) ([]*AddressSpace, error) {
+	spaces := make([]*AddressSpace, len(pools))
+	for i := range pools {
+		sp := NewAddressSpaceFromRange(pools[i].FirstIP, pools[i].LastIP)
+		if sp == nil {
+			return nil, fmt.Errorf("invalid pool %s", pools[i].String())
+		}
+
+		spaces[i] = sp
+	}
+
+	return spaces, nil
+}
+
 func (s *Scope) swap(other *Scope) {
 	s.id, other.id = other.id, s.id
 	s.name, other.name = other.name, s.name
@@ -372,4 +492,8 @@ func (s *Scope) swap(other *Scope) {
 	s.endpoints, other.endpoints = other.endpoints, s.endpoints
 	s.containers, other.containers = other.containers, s.containers
 	s.network, other.network = other.network, s.network
+	s.ipv6, other.ipv6 = other.ipv6, s.ipv6
+	s.subnet6, other.subnet6 = other.subnet6, s.subnet6
+	s.gateway6, other.gateway6 = other.gateway6, s.gateway6
+	s.spaces6, other.spaces6 = other.spaces6, s.spaces6
 }
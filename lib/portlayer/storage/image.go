@@ -64,6 +64,10 @@ type ImageStorer interface {
 	// images in the image store if no param is passed.
 	ListImages(op trace.Operation, store *url.URL, IDs []string) ([]*Image, error)
 
+	// GetImageTar returns a tar archive of the changes the image layer makes
+	// to its parent.  The archive must be closed by the caller.
+	GetImageTar(op trace.Operation, image *Image) (io.ReadCloser, error)
+
 	// DeleteImage deletes an image from the image store.  If the image is in
 	// use either by way of inheritance or because it's attached to a
 	// container, this will return an error.
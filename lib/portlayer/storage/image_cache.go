@@ -325,6 +325,20 @@ func (c *NameLookupCache) ListImages(op trace.Operation, store *url.URL, IDs []s
 	return imageList, nil
 }
 
+// GetImageTar returns a tar archive of the image layer from the datastore
+func (c *NameLookupCache) GetImageTar(op trace.Operation, image *Image) (io.ReadCloser, error) {
+	infof("GetImageTar: exporting %s", image.Self())
+
+	// Check the image exists.  This will rehydrate the cache if necessary.
+	img, err := c.GetImage(op, image.Store, image.ID)
+	if err != nil {
+		errorf("GetImageTar: %s", err)
+		return nil, err
+	}
+
+	return c.DataStore.GetImageTar(op, img)
+}
+
 // DeleteImage deletes an image from the image store.  If it is in use or is being inheritted from, then this will return an error.
 func (c *NameLookupCache) DeleteImage(op trace.Operation, image *Image) error {
 	infof("DeleteImage: deleting %s", image.Self())
@@ -119,6 +119,10 @@ func (c *MockDataStore) DeleteImage(op trace.Operation, image *Image) error {
 	return nil
 }
 
+func (c *MockDataStore) GetImageTar(op trace.Operation, image *Image) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
 func TestListImages(t *testing.T) {
 	s := NewLookupCache(NewMockDataStore())
 
@@ -0,0 +1,69 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nfs
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+
+	"github.com/vmware/vic/pkg/fs"
+	"github.com/vmware/vic/pkg/trace"
+)
+
+// MountServer mounts the export of a volume store in the appliance so the volume store can
+// create and remove the directories of volumes
+type MountServer interface {
+	// Mount mounts the export and returns the path it is mounted at
+	Mount(op trace.Operation, export *url.URL) (string, error)
+
+	// Unmount unmounts an export mounted at path
+	Unmount(op trace.Operation, path string) error
+}
+
+// KernelMountServer mounts exports with the kernel NFS client on temporary directories
+type KernelMountServer struct {
+	nfs *fs.NFS
+}
+
+func NewKernelMountServer() *KernelMountServer {
+	return &KernelMountServer{
+		nfs: fs.NewNFS(),
+	}
+}
+
+func (k *KernelMountServer) Mount(op trace.Operation, export *url.URL) (string, error) {
+	dir, err := ioutil.TempDir("", "nfs-volumes-")
+	if err != nil {
+		return "", err
+	}
+
+	if err = k.nfs.Mount(export, dir, nil); err != nil {
+		op.Errorf("Unable to mount %s: %s", export.String(), err)
+		os.Remove(dir)
+		return "", err
+	}
+
+	return dir, nil
+}
+
+func (k *KernelMountServer) Unmount(op trace.Operation, path string) error {
+	if err := k.nfs.Unmount(path); err != nil {
+		op.Errorf("Unable to unmount %s: %s", path, err)
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,306 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nfs implements volume stores on NFS exports. Each volume is a directory of the export
+// that containerVMs mount directly, so unlike a VMDK a volume can be used by several running
+// containers at once.
+package nfs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/vmware/vic/lib/portlayer/storage"
+	"github.com/vmware/vic/lib/portlayer/util"
+	"github.com/vmware/vic/pkg/fs"
+	"github.com/vmware/vic/pkg/trace"
+)
+
+const (
+	// VolumesDir is the directory of the export the volumes are created in
+	VolumesDir = "volumes"
+
+	// metadataDir holds the metadata of each volume, outside of the volume so that it is not
+	// visible to containers
+	metadataDir = "volumes_metadata"
+)
+
+// Target is the backing of a volume on an NFS export, the directory of the volume
+type Target struct {
+	// Source is the nfs URL of the volume directory, with the mount options of the export
+	Source url.URL
+}
+
+// MountPath returns an error as volumes are mounted by the containerVM, not the appliance
+func (t *Target) MountPath() (string, error) {
+	return "", fmt.Errorf("nfs volume %s is not mounted in the appliance", t.Source.String())
+}
+
+// DiskPath returns the nfs URL of the volume
+func (t *Target) DiskPath() string {
+	return t.Source.String()
+}
+
+// VolumeStore caches the exports of the NFS volume stores
+type VolumeStore struct {
+	// maps the volume store url to the export
+	exports     map[url.URL]*url.URL
+	exportsLock sync.RWMutex
+
+	// mounts the exports to operate on them
+	mounter MountServer
+}
+
+func NewVolumeStore(op trace.Operation, mounter MountServer) *VolumeStore {
+	return &VolumeStore{
+		exports: make(map[url.URL]*url.URL),
+		mounter: mounter,
+	}
+}
+
+// AddStore adds the NFS export, nfs://host/path, as the named volume store. The export is
+// mounted to create the volume directories, which checks that it is usable.
+//
+// returns the URL used to refer to the volume store
+func (v *VolumeStore) AddStore(op trace.Operation, export *url.URL, storeName string) (*url.URL, error) {
+	if export.Scheme != fs.NFSScheme {
+		return nil, fmt.Errorf("volume store %s is not an nfs export: %s", storeName, export.String())
+	}
+
+	u, err := util.VolumeStoreNameToURL(storeName)
+	if err != nil {
+		return nil, err
+	}
+
+	v.exportsLock.Lock()
+	defer v.exportsLock.Unlock()
+
+	if _, ok := v.exports[*u]; ok {
+		return nil, fmt.Errorf("volumestore (%s) already added", u.String())
+	}
+
+	err = v.withExport(op, export, func(root string) error {
+		for _, dir := range []string{VolumesDir, metadataDir} {
+			if err := os.Mkdir(filepath.Join(root, dir), 0755); err != nil && !os.IsExist(err) {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	v.exports[*u] = export
+	return u, nil
+}
+
+func (v *VolumeStore) VolumeStoresList(op trace.Operation) (map[string]url.URL, error) {
+	m := make(map[string]url.URL)
+
+	v.exportsLock.RLock()
+	defer v.exportsLock.RUnlock()
+
+	for u, export := range v.exports {
+		// from the storage url, get the store name
+		storeName, err := util.VolumeStoreName(&u)
+		if err != nil {
+			return nil, err
+		}
+
+		m[storeName] = *export
+	}
+
+	return m, nil
+}
+
+func (v *VolumeStore) getExport(store *url.URL) (*url.URL, error) {
+	v.exportsLock.RLock()
+	defer v.exportsLock.RUnlock()
+
+	export, ok := v.exports[*store]
+	if !ok {
+		return nil, storage.VolumeStoreNotFoundError{Msg: fmt.Sprintf("volume store (%s) not found", store.String())}
+	}
+
+	return export, nil
+}
+
+// withExport mounts the export for the duration of fn, which is passed the mount point
+func (v *VolumeStore) withExport(op trace.Operation, export *url.URL, fn func(root string) error) error {
+	root, err := v.mounter.Mount(op, export)
+	if err != nil {
+		return fmt.Errorf("unable to mount nfs volume store %s: %s", export.String(), err)
+	}
+	defer v.mounter.Unmount(op, root)
+
+	return fn(root)
+}
+
+// target returns the backing of the volume with the given ID on the export
+func target(export *url.URL, ID string) *Target {
+	source := *export
+	source.Path = path.Join(export.Path, VolumesDir, ID)
+
+	return &Target{Source: source}
+}
+
+func (v *VolumeStore) VolumeCreate(op trace.Operation, ID string, store *url.URL, capacityKB uint64, info map[string][]byte) (*storage.Volume, error) {
+	export, err := v.getExport(store)
+	if err != nil {
+		return nil, err
+	}
+
+	if capacityKB != 0 {
+		op.Debugf("Capacity of %dKB is not enforced for nfs volume %s", capacityKB, ID)
+	}
+
+	vol, err := storage.NewVolume(store, ID, info, target(export, ID))
+	if err != nil {
+		return nil, err
+	}
+
+	err = v.withExport(op, export, func(root string) error {
+		if err := os.Mkdir(filepath.Join(root, VolumesDir, ID), 0755); err != nil {
+			return err
+		}
+
+		return writeMetadata(op, filepath.Join(root, metadataDir, ID), info)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("volumestore: %s (%s)", ID, vol.SelfLink)
+	return vol, nil
+}
+
+func (v *VolumeStore) VolumeDestroy(op trace.Operation, vol *storage.Volume) error {
+	if err := storage.VolumeInUse(vol.ID); err != nil {
+		log.Errorf("VolumeStore: delete error: %s", err.Error())
+		return err
+	}
+
+	export, err := v.getExport(vol.Store)
+	if err != nil {
+		return err
+	}
+
+	return v.withExport(op, export, func(root string) error {
+		log.Infof("VolumeStore: Deleting %s from %s", vol.ID, export.String())
+
+		for _, dir := range []string{VolumesDir, metadataDir} {
+			if err := os.RemoveAll(filepath.Join(root, dir, vol.ID)); err != nil {
+				log.Errorf("VolumeStore: delete error: %s", err.Error())
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (v *VolumeStore) VolumesList(op trace.Operation) ([]*storage.Volume, error) {
+	volumes := []*storage.Volume{}
+
+	v.exportsLock.RLock()
+	defer v.exportsLock.RUnlock()
+
+	for volStore, export := range v.exports {
+		store := volStore
+
+		err := v.withExport(op, export, func(root string) error {
+			files, err := ioutil.ReadDir(filepath.Join(root, VolumesDir))
+			if err != nil {
+				return fmt.Errorf("error listing vols: %s", err)
+			}
+
+			for _, f := range files {
+				if !f.IsDir() {
+					continue
+				}
+
+				ID := f.Name()
+				meta, err := getMetadata(op, filepath.Join(root, metadataDir, ID))
+				if err != nil {
+					return err
+				}
+
+				vol, err := storage.NewVolume(&store, ID, meta, target(export, ID))
+				if err != nil {
+					return err
+				}
+
+				volumes = append(volumes, vol)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return volumes, nil
+}
+
+// writeMetadata writes each metadata blob to a file with the corresponding name in dir
+func writeMetadata(op trace.Operation, dir string, meta map[string][]byte) error {
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return err
+	}
+
+	for name, value := range meta {
+		pth := filepath.Join(dir, name)
+		op.Debugf("Writing metadata %s", pth)
+		if err := ioutil.WriteFile(pth, value, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getMetadata reads the metadata blobs in dir
+func getMetadata(op trace.Operation, dir string) (map[string][]byte, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			op.Infof("No meta found for %s", dir)
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	meta := make(map[string][]byte)
+	for _, f := range files {
+		buf, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		meta[f.Name()] = buf
+	}
+
+	return meta, nil
+}
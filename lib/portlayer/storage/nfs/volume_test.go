@@ -0,0 +1,160 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nfs
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/vic/lib/portlayer/exec"
+	"github.com/vmware/vic/lib/portlayer/storage"
+	"github.com/vmware/vic/pkg/trace"
+)
+
+// dirMountServer "mounts" exports from a local directory, using the export path as the path
+// of a subdirectory
+type dirMountServer struct {
+	root   string
+	mounts int
+}
+
+func (d *dirMountServer) Mount(op trace.Operation, export *url.URL) (string, error) {
+	dir := filepath.Join(d.root, export.Path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	d.mounts++
+	return dir, nil
+}
+
+func (d *dirMountServer) Unmount(op trace.Operation, path string) error {
+	d.mounts--
+	return nil
+}
+
+func testVolumeStore(t *testing.T, mounter MountServer, export *url.URL) {
+	op := trace.NewOperation(context.Background(), "test")
+	exec.NewContainerCache()
+
+	vs := NewVolumeStore(op, mounter)
+	store, err := vs.AddStore(op, export, "shared")
+	require.NoError(t, err)
+
+	_, err = vs.AddStore(op, export, "shared")
+	assert.Error(t, err, "a store can only be added once")
+
+	stores, err := vs.VolumeStoresList(op)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]url.URL{"shared": *export}, stores)
+
+	cache, err := storage.NewVolumeLookupCache(op, vs)
+	require.NoError(t, err)
+
+	volumes := make(map[string]*storage.Volume)
+	for i := 0; i < 3; i++ {
+		ID := fmt.Sprintf("testvolume-%d", i)
+
+		// add some metadata if i is even
+		var info map[string][]byte
+		if i%2 == 0 {
+			info = map[string][]byte{ID: []byte(ID)}
+		}
+
+		vol, err := cache.VolumeCreate(op, ID, store, 10240, info)
+		require.NoError(t, err)
+
+		source := *export
+		source.Path = path.Join(export.Path, VolumesDir, ID)
+		assert.Equal(t, source.String(), vol.Device.DiskPath())
+
+		volumes[ID] = vol
+	}
+
+	_, err = vs.VolumeCreate(op, "testvolume-0", store, 0, nil)
+	assert.True(t, os.IsExist(err), "volume creation should fail with an existing volume: %s", err)
+
+	// simulate a restart with a new store over the same export
+	second := NewVolumeStore(op, mounter)
+	_, err = second.AddStore(op, export, "shared")
+	require.NoError(t, err)
+
+	vols, err := second.VolumesList(op)
+	require.NoError(t, err)
+	require.Len(t, vols, len(volumes))
+	for _, vol := range vols {
+		assert.Equal(t, volumes[vol.ID], vol)
+	}
+
+	// a volume can be joined to several containers
+	for _, id := range []string{"c1", "c2"} {
+		h, err := VolumeJoin(op, exec.TestHandle(id), volumes["testvolume-1"], "/data", map[string]string{"Mode": "rw"})
+		require.NoError(t, err)
+
+		mount := h.ExecConfig.Mounts["testvolume-1"]
+		assert.Equal(t, volumes["testvolume-1"].Device.DiskPath(), mount.Source.String())
+		assert.Equal(t, "/data", mount.Path)
+		assert.Empty(t, h.Spec.DeviceChange, "no device is added for nfs volumes")
+	}
+
+	for ID := range volumes {
+		require.NoError(t, cache.VolumeDestroy(op, ID))
+	}
+
+	vols, err = vs.VolumesList(op)
+	require.NoError(t, err)
+	assert.Empty(t, vols)
+}
+
+func TestVolumeStore(t *testing.T) {
+	root, err := ioutil.TempDir("", "nfs-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	mounter := &dirMountServer{root: root}
+	testVolumeStore(t, mounter, &url.URL{Scheme: "nfs", Host: "server", Path: "/exports/vch"})
+
+	assert.Equal(t, 0, mounter.mounts, "exports should be unmounted after each operation")
+
+	// the metadata is kept out of the volumes
+	_, err = os.Stat(filepath.Join(root, "exports/vch", metadataDir))
+	assert.NoError(t, err)
+}
+
+// TestVolumeStoreServer runs against the NFS export in NFS_TEST_URL, such as the export of an
+// NFS server run in a container:
+//
+//   docker run -d --privileged -p 2049:2049 -v /tmp/exports:/exports -e SHARED_DIRECTORY=/exports itsthenetwork/nfs-server-alpine
+//   sudo NFS_TEST_URL="nfs://127.0.0.1/?vers=4" go test ./lib/portlayer/storage/nfs
+func TestVolumeStoreServer(t *testing.T) {
+	export := os.Getenv("NFS_TEST_URL")
+	if export == "" {
+		t.Skip("NFS_TEST_URL is not set")
+	}
+
+	u, err := url.Parse(export)
+	require.NoError(t, err)
+
+	testVolumeStore(t, NewKernelMountServer(), u)
+}
@@ -22,6 +22,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/vmware/vic/lib/portlayer/exec"
 	"github.com/vmware/vic/lib/portlayer/util"
 	"github.com/vmware/vic/pkg/trace"
 )
@@ -139,3 +140,26 @@ func (v *Volume) Parse(u *url.URL) error {
 
 	return nil
 }
+
+// VolumeInUse returns an ErrVolumeInUse if the volume is mounted by a container
+func VolumeInUse(ID string) error {
+	conts := exec.Containers.Containers(nil)
+	if len(conts) == 0 {
+		return nil
+	}
+
+	for _, cont := range conts {
+
+		if cont.ExecConfig.Mounts == nil {
+			continue
+		}
+
+		if _, mounted := cont.ExecConfig.Mounts[ID]; mounted {
+			return &ErrVolumeInUse{
+				Msg: fmt.Sprintf("volume %s in use by %s", ID, cont.ExecConfig.ID),
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/vmware/vic/lib/portlayer/util"
+	"github.com/vmware/vic/pkg/trace"
+)
+
+// MultiVolumeStore presents the volume stores of several VolumeStorer implementations, such as
+// datastore and NFS backed stores, as a single VolumeStorer. Each operation is passed to the
+// implementation that provides the volume store it refers to.
+type MultiVolumeStore struct {
+	storers []VolumeStorer
+}
+
+func NewMultiVolumeStore(storers ...VolumeStorer) *MultiVolumeStore {
+	return &MultiVolumeStore{
+		storers: storers,
+	}
+}
+
+// storer returns the implementation providing the volume store
+func (m *MultiVolumeStore) storer(op trace.Operation, store *url.URL) (VolumeStorer, error) {
+	storeName, err := util.VolumeStoreName(store)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vs := range m.storers {
+		stores, err := vs.VolumeStoresList(op)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := stores[storeName]; ok {
+			return vs, nil
+		}
+	}
+
+	return nil, VolumeStoreNotFoundError{Msg: fmt.Sprintf("volume store (%s) not found", store.String())}
+}
+
+func (m *MultiVolumeStore) VolumeCreate(op trace.Operation, ID string, store *url.URL, capacityKB uint64, info map[string][]byte) (*Volume, error) {
+	vs, err := m.storer(op, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return vs.VolumeCreate(op, ID, store, capacityKB, info)
+}
+
+func (m *MultiVolumeStore) VolumeDestroy(op trace.Operation, vol *Volume) error {
+	vs, err := m.storer(op, vol.Store)
+	if err != nil {
+		return err
+	}
+
+	return vs.VolumeDestroy(op, vol)
+}
+
+func (m *MultiVolumeStore) VolumesList(op trace.Operation) ([]*Volume, error) {
+	var volumes []*Volume
+
+	for _, vs := range m.storers {
+		vols, err := vs.VolumesList(op)
+		if err != nil {
+			return nil, err
+		}
+
+		volumes = append(volumes, vols...)
+	}
+
+	return volumes, nil
+}
+
+func (m *MultiVolumeStore) VolumeStoresList(op trace.Operation) (map[string]url.URL, error) {
+	stores := make(map[string]url.URL)
+
+	for _, vs := range m.storers {
+		s, err := vs.VolumeStoresList(op)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, u := range s {
+			if _, ok := stores[name]; ok {
+				return nil, fmt.Errorf("volume store %s is configured more than once", name)
+			}
+			stores[name] = u
+		}
+	}
+
+	return stores, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"net/url"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/vic/lib/portlayer/util"
+	"github.com/vmware/vic/pkg/trace"
+)
+
+// namedMockVolumeStore is a MockVolumeStore that provides the named volume stores
+type namedMockVolumeStore struct {
+	*MockVolumeStore
+
+	stores map[string]url.URL
+}
+
+func (m *namedMockVolumeStore) VolumeStoresList(op trace.Operation) (map[string]url.URL, error) {
+	return m.stores, nil
+}
+
+func TestMultiVolumeStore(t *testing.T) {
+	op := trace.NewOperation(context.Background(), "test")
+
+	ds := &namedMockVolumeStore{
+		MockVolumeStore: NewMockVolumeStore(),
+		stores:          map[string]url.URL{"default": {Scheme: "ds", Host: "datastore1", Path: "/volumes"}},
+	}
+	nfs := &namedMockVolumeStore{
+		MockVolumeStore: NewMockVolumeStore(),
+		stores:          map[string]url.URL{"shared": {Scheme: "nfs", Host: "server", Path: "/exports"}},
+	}
+
+	m := NewMultiVolumeStore(ds, nfs)
+
+	stores, err := m.VolumeStoresList(op)
+	require.NoError(t, err)
+	assert.Len(t, stores, 2)
+
+	for id, store := range map[string]string{"local": "default", "rwx": "shared"} {
+		storeURL, err := util.VolumeStoreNameToURL(store)
+		require.NoError(t, err)
+
+		_, err = m.VolumeCreate(op, id, storeURL, 0, nil)
+		require.NoError(t, err)
+	}
+	assert.Contains(t, ds.db, "local")
+	assert.Contains(t, nfs.db, "rwx")
+
+	unknown, err := util.VolumeStoreNameToURL("unknown")
+	require.NoError(t, err)
+	_, err = m.VolumeCreate(op, "lost", unknown, 0, nil)
+	assert.IsType(t, VolumeStoreNotFoundError{}, err)
+
+	vols, err := m.VolumesList(op)
+	require.NoError(t, err)
+	assert.Len(t, vols, 2)
+
+	for _, vol := range vols {
+		require.NoError(t, m.VolumeDestroy(op, vol))
+	}
+	assert.Empty(t, ds.db)
+	assert.Empty(t, nfs.db)
+
+	// a store name must identify a single store
+	nfs.stores["default"] = url.URL{Scheme: "nfs", Host: "server", Path: "/default"}
+	_, err = m.VolumeStoresList(op)
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vsphere
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/ioutils"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/vic/pkg/trace"
+	"github.com/vmware/vic/pkg/vsphere/disk"
+	"github.com/vmware/vic/pkg/vsphere/session"
+)
+
+// ContainerStore provides the appliance with access to the filesystems of containerVMs
+// that are not running.
+type ContainerStore struct {
+	dm *disk.Manager
+}
+
+func NewContainerStore(op trace.Operation, s *session.Session) (*ContainerStore, error) {
+	dm, err := disk.NewDiskManager(op, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContainerStore{dm: dm}, nil
+}
+
+// ContainerDiskURI returns the datastore URI of the disk backing the container's root filesystem
+func ContainerDiskURI(config *types.VirtualMachineConfigInfo) (string, error) {
+	backing, err := containerDisk(config)
+	if err != nil {
+		return "", err
+	}
+
+	return backing.FileName, nil
+}
+
+// ContainerParentDiskURI returns the datastore URI of the image disk the container's root
+// filesystem was created from
+func ContainerParentDiskURI(config *types.VirtualMachineConfigInfo) (string, error) {
+	backing, err := containerDisk(config)
+	if err != nil {
+		return "", err
+	}
+
+	if backing.Parent == nil {
+		return "", fmt.Errorf("disk for container %s has no parent", config.Name)
+	}
+
+	return backing.Parent.FileName, nil
+}
+
+func containerDisk(config *types.VirtualMachineConfigInfo) (*types.VirtualDiskFlatVer2BackingInfo, error) {
+	if config == nil {
+		return nil, fmt.Errorf("container has no configuration")
+	}
+
+	disks := object.VirtualDeviceList(config.Hardware.Device).SelectByType((*types.VirtualDisk)(nil))
+	for _, d := range disks {
+		if backing, ok := d.GetVirtualDevice().Backing.(*types.VirtualDiskFlatVer2BackingInfo); ok {
+			return backing, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no disk found for container %s", config.Name)
+}
+
+// Mount attaches the disk at diskURI to the appliance and mounts it in a temporary
+// directory. The returned function unmounts and detaches the disk and must be called
+// before the container is started again.
+func (c *ContainerStore) Mount(op trace.Operation, diskURI string) (string, func(), error) {
+	return mountDisk(op, c.dm, diskURI, os.O_RDWR)
+}
+
+// Diff writes a tar archive of the changes made on the container disk at diskURI relative
+// to the image disk at parentURI to w. Deleted files are recorded as whiteouts and the
+// files tether manages in the container are left out.
+func (c *ContainerStore) Diff(op trace.Operation, diskURI, parentURI string, w io.Writer) error {
+	return diffDisks(op, c.dm, diskURI, parentURI, w, excludedFromDiff)
+}
+
+// Export returns a tar archive of the complete filesystem on the container disk at diskURI.
+// The disk stays mounted until the archive is closed.
+func (c *ContainerStore) Export(op trace.Operation, diskURI string) (io.ReadCloser, error) {
+	dir, unmount, err := mountDisk(op, c.dm, diskURI, os.O_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+
+	tar, err := archive.TarWithOptions(dir, &archive.TarOptions{ExcludePatterns: exportExclusions})
+	if err != nil {
+		unmount()
+		return nil, err
+	}
+
+	return ioutils.NewReadCloserWrapper(tar, func() error {
+		defer unmount()
+		return tar.Close()
+	}), nil
+}
+
+// exportExclusions are the paths on a container disk, relative to its root, that are not
+// part of the container filesystem
+var exportExclusions = []string{
+	".tether",
+	"lost+found",
+}
+
+// diffExclusions are the paths in a container filesystem that are generated by tether
+// rather than the container process
+var diffExclusions = []string{
+	"/.tether",
+	"/etc/hostname",
+	"/etc/hosts",
+	"/etc/resolv.conf",
+}
+
+func excludedFromDiff(path string) bool {
+	for _, excluded := range diffExclusions {
+		if path == excluded || strings.HasPrefix(path, excluded+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vsphere
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/archive"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExcludedFromDiff(t *testing.T) {
+	assert.True(t, excludedFromDiff("/.tether"))
+	assert.True(t, excludedFromDiff("/.tether/lib/modules"))
+	assert.True(t, excludedFromDiff("/etc/hosts"))
+	assert.True(t, excludedFromDiff("/etc/resolv.conf"))
+
+	assert.False(t, excludedFromDiff("/.tetherd"))
+	assert.False(t, excludedFromDiff("/etc/hosts.allow"))
+	assert.False(t, excludedFromDiff("/etc"))
+	assert.False(t, excludedFromDiff("/usr/bin/app"))
+}
+
+func TestChanges(t *testing.T) {
+	now := time.Now()
+	dir := fileState{mode: os.ModeDir | 0755, mtime: now}
+	file := fileState{mode: 0644, size: 10, mtime: now}
+
+	parent := map[string]fileState{
+		"/etc":           dir,
+		"/etc/passwd":    file,
+		"/usr":           dir,
+		"/usr/bin":       dir,
+		"/usr/bin/tool":  file,
+		"/var":           dir,
+		"/var/lib":       dir,
+		"/var/lib/state": file,
+	}
+
+	grown := file
+	grown.size = 20
+
+	// a touched directory is not a change by itself
+	touched := dir
+	touched.mtime = now.Add(time.Minute)
+
+	child := map[string]fileState{
+		"/etc":          touched,
+		"/etc/passwd":   grown,
+		"/usr":          touched,
+		"/usr/bin":      dir,
+		"/usr/bin/tool": file,
+		"/var":          dir,
+		"/opt":          dir,
+		"/opt/app":      file,
+	}
+
+	expected := []archive.Change{
+		{Path: "/etc", Kind: archive.ChangeModify},
+		{Path: "/etc/passwd", Kind: archive.ChangeModify},
+		{Path: "/opt", Kind: archive.ChangeAdd},
+		{Path: "/opt/app", Kind: archive.ChangeAdd},
+		{Path: "/var", Kind: archive.ChangeModify},
+		{Path: "/var/lib", Kind: archive.ChangeDelete},
+	}
+	assert.Equal(t, expected, changes(parent, child))
+}
@@ -0,0 +1,240 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vsphere
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/archive"
+
+	"github.com/vmware/vic/pkg/trace"
+	"github.com/vmware/vic/pkg/vsphere/disk"
+)
+
+// diffDisks writes a tar archive of the changes on the disk at diskURI relative to the disk
+// at parentURI to w. Changes to paths for which exclude returns true are left out.
+//
+// The disk at diskURI is a child of the disk at parentURI, so the two are not attached at
+// the same time. The state of the files on the parent is recorded and the parent detached
+// before the child is attached.
+func diffDisks(op trace.Operation, dm *disk.Manager, diskURI, parentURI string, w io.Writer, exclude func(path string) bool) error {
+	defer trace.End(trace.Begin(diskURI))
+
+	parentDir, unmountParent, err := mountDisk(op, dm, parentURI, os.O_RDONLY)
+	if err != nil {
+		return err
+	}
+	parent, err := fileStates(parentDir)
+	unmountParent()
+	if err != nil {
+		return err
+	}
+
+	dir, unmount, err := mountDisk(op, dm, diskURI, os.O_RDONLY)
+	if err != nil {
+		return err
+	}
+	defer unmount()
+
+	child, err := fileStates(dir)
+	if err != nil {
+		return err
+	}
+
+	var included []archive.Change
+	for _, change := range changes(parent, child) {
+		if exclude == nil || !exclude(change.Path) {
+			included = append(included, change)
+		}
+	}
+
+	tar, err := archive.ExportChanges(dir, included, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer tar.Close()
+
+	_, err = io.Copy(w, tar)
+	return err
+}
+
+// fileState is the part of the state of a file that is compared to find the changes between
+// two filesystems, following archive.ChangesDirs
+type fileState struct {
+	mode  os.FileMode
+	uid   uint32
+	gid   uint32
+	rdev  uint64
+	size  int64
+	mtime time.Time
+}
+
+// fileStates returns the state of every file in the filesystem rooted at root, keyed by
+// its absolute path within the filesystem
+func fileStates(root string) (map[string]fileState, error) {
+	states := make(map[string]fileState)
+
+	err := filepath.Walk(root, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+
+		state := fileState{
+			mode:  fi.Mode(),
+			size:  fi.Size(),
+			mtime: fi.ModTime(),
+		}
+		if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+			state.uid = st.Uid
+			state.gid = st.Gid
+			state.rdev = uint64(st.Rdev)
+		}
+
+		states[path.Join("/", filepath.ToSlash(rel))] = state
+		return nil
+	})
+
+	return states, err
+}
+
+// changes returns the changes that turn the parent filesystem into the child, sorted by path.
+// As with archive.ChangesDirs, a deleted directory is reported without its contents and a
+// directory containing changes is reported as modified so that its attributes are kept.
+func changes(parent, child map[string]fileState) []archive.Change {
+	changed := make(map[string]archive.ChangeType)
+
+	for p, cs := range child {
+		ps, ok := parent[p]
+		switch {
+		case !ok:
+			changed[p] = archive.ChangeAdd
+		case ps.changed(cs):
+			changed[p] = archive.ChangeModify
+		}
+	}
+
+	for p := range parent {
+		if _, ok := child[p]; ok {
+			continue
+		}
+		if _, ok := parent[path.Dir(p)]; ok {
+			if _, ok := child[path.Dir(p)]; !ok {
+				// the deletion of the directory covers its contents
+				continue
+			}
+		}
+		changed[p] = archive.ChangeDelete
+	}
+
+	for p := range changed {
+		for dir := path.Dir(p); dir != "/"; dir = path.Dir(dir) {
+			if _, ok := changed[dir]; ok {
+				break
+			}
+			changed[dir] = archive.ChangeModify
+		}
+	}
+
+	result := make([]archive.Change, 0, len(changed))
+	for p, kind := range changed {
+		result = append(result, archive.Change{Path: p, Kind: kind})
+	}
+	sort.Sort(changesByPath(result))
+
+	return result
+}
+
+type changesByPath []archive.Change
+
+func (c changesByPath) Len() int           { return len(c) }
+func (c changesByPath) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c changesByPath) Less(i, j int) bool { return c[i].Path < c[j].Path }
+
+// changed returns true if a file with the state s differs from one with the state o. The size
+// and modification time of directories are not a good measure of change and are ignored.
+func (s fileState) changed(o fileState) bool {
+	if s.mode != o.mode || s.uid != o.uid || s.gid != o.gid || s.rdev != o.rdev {
+		return true
+	}
+	if s.mode.IsDir() {
+		return false
+	}
+	return s.size != o.size || !sameFsTime(s.mtime, o.mtime)
+}
+
+// sameFsTime compares modification times the way archive.ChangesDirs does, as some
+// filesystems only record whole seconds
+func sameFsTime(a, b time.Time) bool {
+	return a.Equal(b) ||
+		(a.Unix() == b.Unix() && (a.Nanosecond() == 0 || b.Nanosecond() == 0))
+}
+
+// mountDisk attaches the disk at diskURI to the appliance with the given flags and mounts it
+// in a temporary directory. The returned function unmounts and detaches the disk.
+func mountDisk(op trace.Operation, dm *disk.Manager, diskURI string, flags int) (string, func(), error) {
+	defer trace.End(trace.Begin(diskURI))
+
+	vmdisk, err := dm.CreateAndAttach(op, diskURI, "", 0, flags)
+	if err != nil {
+		return "", nil, err
+	}
+
+	cleanup := func() {
+		if vmdisk.Mounted() {
+			if err := vmdisk.Unmount(); err != nil {
+				log.Errorf("Failed to unmount %s: %s", diskURI, err)
+			}
+		}
+
+		if err := dm.Detach(op, vmdisk); err != nil {
+			log.Errorf("Failed to detach %s: %s", diskURI, err)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "mnt-disk")
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	if err = vmdisk.Mount(dir, nil); err != nil {
+		cleanup()
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+
+	unmount := func() {
+		cleanup()
+		os.RemoveAll(dir)
+	}
+
+	return dir, unmount, nil
+}
@@ -25,6 +25,7 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/ioutils"
 
 	"github.com/vmware/govmomi/vim25/types"
 	"github.com/vmware/vic/lib/portlayer/exec"
@@ -490,6 +491,44 @@ func (v *ImageStore) ListImages(op trace.Operation, store *url.URL, IDs []string
 	return images, nil
 }
 
+// GetImageTar exports the changes the image disk makes to its parent disk as a tar archive.
+// The archive is streamed while the disks are mounted, so it must be closed to release them.
+func (v *ImageStore) GetImageTar(op trace.Operation, image *portlayer.Image) (io.ReadCloser, error) {
+	defer trace.End(trace.Begin(image.ID))
+
+	if image.ID == portlayer.Scratch.ID {
+		return nil, fmt.Errorf("the %s image has no content", image.ID)
+	}
+
+	storeName, err := util.ImageStoreName(image.Store)
+	if err != nil {
+		return nil, err
+	}
+
+	parentID := v.parents.Get(image.ID)
+	if parentID == "" {
+		return nil, fmt.Errorf("parent of image %s not found", image.ID)
+	}
+
+	diskDsURI := v.imageDiskDSPath(storeName, image.ID)
+	parentDiskDsURI := v.imageDiskDSPath(storeName, parentID)
+
+	r, w := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.CloseWithError(diffDisks(op, v.dm, diskDsURI, parentDiskDsURI, w, nil))
+	}()
+
+	// closing the archive before it has been read in full fails the writes of the diff, which
+	// then releases the disks
+	return ioutils.NewReadCloserWrapper(r, func() error {
+		err := r.Close()
+		<-done
+		return err
+	}), nil
+}
+
 // DeleteImage deletes an image from the image store.  If the image is in
 // use either by way of inheritance or because it's attached to a
 // container, this will return an error.
@@ -24,7 +24,6 @@ import (
 	log "github.com/Sirupsen/logrus"
 
 	"github.com/vmware/govmomi/vim25/types"
-	"github.com/vmware/vic/lib/portlayer/exec"
 	"github.com/vmware/vic/lib/portlayer/storage"
 	"github.com/vmware/vic/lib/portlayer/util"
 	"github.com/vmware/vic/pkg/trace"
@@ -204,7 +203,7 @@ func (v *VolumeStore) VolumeCreate(op trace.Operation, ID string, store *url.URL
 }
 
 func (v *VolumeStore) VolumeDestroy(op trace.Operation, vol *storage.Volume) error {
-	if err := volumeInUse(vol.ID); err != nil {
+	if err := storage.VolumeInUse(vol.ID); err != nil {
 		log.Errorf("VolumeStore: delete error: %s", err.Error())
 		return err
 	}
@@ -282,25 +281,3 @@ func (v *VolumeStore) VolumesList(op trace.Operation) ([]*storage.Volume, error)
 
 	return volumes, nil
 }
-
-func volumeInUse(ID string) error {
-	conts := exec.Containers.Containers(nil)
-	if len(conts) == 0 {
-		return nil
-	}
-
-	for _, cont := range conts {
-
-		if cont.ExecConfig.Mounts == nil {
-			continue
-		}
-
-		if _, mounted := cont.ExecConfig.Mounts[ID]; mounted {
-			return &storage.ErrVolumeInUse{
-				Msg: fmt.Sprintf("volume %s in use by %s", ID, cont.ExecConfig.ID),
-			}
-		}
-	}
-
-	return nil
-}
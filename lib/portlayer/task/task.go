@@ -0,0 +1,142 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vmware/vic/lib/config/executor"
+	"github.com/vmware/vic/lib/portlayer/exec"
+	"github.com/vmware/vic/pkg/trace"
+)
+
+// NotFoundError is returned when the requested task is not present in the handle
+type NotFoundError struct {
+	ID string
+}
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf("task %s not found", e.ID)
+}
+
+// Join adds the task described by session to the handle. The task is not launched until
+// it has been bound and the handle committed.
+func Join(h interface{}, session *executor.SessionConfig) (interface{}, error) {
+	defer trace.End(trace.Begin(session.ID))
+
+	handle, ok := h.(*exec.Handle)
+	if !ok {
+		return nil, fmt.Errorf("Type assertion failed for %#+v", handle)
+	}
+
+	if _, ok := handle.ExecConfig.Sessions[session.ID]; ok {
+		return nil, fmt.Errorf("task %s conflicts with an existing session", session.ID)
+	}
+
+	if handle.ExecConfig.Execs == nil {
+		handle.ExecConfig.Execs = make(map[string]*executor.SessionConfig)
+	}
+
+	if _, ok := handle.ExecConfig.Execs[session.ID]; ok {
+		return nil, fmt.Errorf("task %s already exists", session.ID)
+	}
+
+	session.Active = false
+	session.CreateTime = time.Now().UTC().Unix()
+	handle.ExecConfig.Execs[session.ID] = session
+
+	handle.Reload()
+	return handle, nil
+}
+
+// Remove removes the task from the handle. A task that is still running cannot be removed.
+func Remove(h interface{}, id string) (interface{}, error) {
+	defer trace.End(trace.Begin(id))
+
+	handle, ok := h.(*exec.Handle)
+	if !ok {
+		return nil, fmt.Errorf("Type assertion failed for %#+v", handle)
+	}
+
+	session, ok := handle.ExecConfig.Execs[id]
+	if !ok {
+		return nil, NotFoundError{ID: id}
+	}
+
+	if session.Started == "true" && session.StopTime == 0 {
+		return nil, fmt.Errorf("task %s is still running", id)
+	}
+
+	handle.RemoveExec(id)
+
+	handle.Reload()
+	return handle, nil
+}
+
+// Bind activates the task so that it is launched by the tether when the handle is committed
+func Bind(h interface{}, id string) (interface{}, error) {
+	defer trace.End(trace.Begin(id))
+
+	return toggle(h, id, true)
+}
+
+// Unbind deactivates the task, preventing launch if it has not already occurred
+func Unbind(h interface{}, id string) (interface{}, error) {
+	defer trace.End(trace.Begin(id))
+
+	return toggle(h, id, false)
+}
+
+// Inspect returns the current configuration and status of the task
+func Inspect(h interface{}, id string) (*executor.SessionConfig, error) {
+	defer trace.End(trace.Begin(id))
+
+	handle, ok := h.(*exec.Handle)
+	if !ok {
+		return nil, fmt.Errorf("Type assertion failed for %#+v", handle)
+	}
+
+	session, ok := handle.ExecConfig.Execs[id]
+	if !ok {
+		return nil, NotFoundError{ID: id}
+	}
+
+	return session, nil
+}
+
+func toggle(h interface{}, id string, active bool) (interface{}, error) {
+	handle, ok := h.(*exec.Handle)
+	if !ok {
+		return nil, fmt.Errorf("Type assertion failed for %#+v", handle)
+	}
+
+	session, ok := handle.ExecConfig.Execs[id]
+	if !ok {
+		return nil, NotFoundError{ID: id}
+	}
+
+	if active && session.Started != "" {
+		return nil, fmt.Errorf("task %s has already been launched", id)
+	}
+
+	session.Active = active
+	if active {
+		session.StartTime = time.Now().UTC().Unix()
+	}
+
+	handle.Reload()
+	return handle, nil
+}
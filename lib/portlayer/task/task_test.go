@@ -0,0 +1,116 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package task
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware/vic/lib/config/executor"
+	"github.com/vmware/vic/lib/portlayer/exec"
+)
+
+func testHandle(id string) *exec.Handle {
+	h := exec.TestHandle(id)
+	h.ExecConfig.Sessions = map[string]*executor.SessionConfig{
+		id: {Common: executor.Common{ID: id}},
+	}
+	return h
+}
+
+func TestJoinBindInspect(t *testing.T) {
+	h := testHandle("container")
+
+	session := &executor.SessionConfig{
+		Common: executor.Common{ID: "exec"},
+		Cmd:    executor.Cmd{Path: "/bin/ls"},
+	}
+
+	_, err := Join(h, session)
+	assert.NoError(t, err)
+
+	ec, err := Inspect(h, "exec")
+	assert.NoError(t, err)
+	assert.False(t, ec.Active, "joined task should not be active")
+
+	_, err = Bind(h, "exec")
+	assert.NoError(t, err)
+
+	ec, err = Inspect(h, "exec")
+	assert.NoError(t, err)
+	assert.True(t, ec.Active, "bound task should be active")
+	assert.NotZero(t, ec.StartTime)
+
+	_, err = Unbind(h, "exec")
+	assert.NoError(t, err)
+	assert.False(t, h.ExecConfig.Execs["exec"].Active)
+}
+
+func TestJoinConflicts(t *testing.T) {
+	h := testHandle("container")
+
+	// may not collide with the primary session
+	_, err := Join(h, &executor.SessionConfig{Common: executor.Common{ID: "container"}})
+	assert.Error(t, err)
+
+	_, err = Join(h, &executor.SessionConfig{Common: executor.Common{ID: "exec"}})
+	assert.NoError(t, err)
+
+	_, err = Join(h, &executor.SessionConfig{Common: executor.Common{ID: "exec"}})
+	assert.Error(t, err)
+}
+
+func TestBindUnknownAndLaunched(t *testing.T) {
+	h := testHandle("container")
+
+	_, err := Bind(h, "missing")
+	_, ok := err.(NotFoundError)
+	assert.True(t, ok, "expected NotFoundError, got %#v", err)
+
+	_, err = Inspect(h, "missing")
+	_, ok = err.(NotFoundError)
+	assert.True(t, ok, "expected NotFoundError, got %#v", err)
+
+	_, err = Join(h, &executor.SessionConfig{Common: executor.Common{ID: "exec"}})
+	assert.NoError(t, err)
+
+	h.ExecConfig.Execs["exec"].Started = "true"
+	_, err = Bind(h, "exec")
+	assert.Error(t, err, "should not be able to bind a launched task")
+}
+
+func TestRemove(t *testing.T) {
+	h := testHandle("container")
+
+	_, err := Remove(h, "missing")
+	_, ok := err.(NotFoundError)
+	assert.True(t, ok, "expected NotFoundError, got %#v", err)
+
+	_, err = Join(h, &executor.SessionConfig{Common: executor.Common{ID: "exec"}})
+	assert.NoError(t, err)
+
+	h.ExecConfig.Execs["exec"].Started = "true"
+	_, err = Remove(h, "exec")
+	assert.Error(t, err, "should not be able to remove a running task")
+
+	h.ExecConfig.Execs["exec"].StopTime = 1
+	_, err = Remove(h, "exec")
+	assert.NoError(t, err)
+
+	_, err = Inspect(h, "exec")
+	_, ok = err.(NotFoundError)
+	assert.True(t, ok, "expected NotFoundError, got %#v", err)
+}
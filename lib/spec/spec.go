@@ -96,14 +96,7 @@ func NewVirtualMachineConfigSpec(ctx context.Context, session *session.Session,
 	log.Debugf("Adding metadata to the configspec: %+v", config.Metadata)
 	// TEMPORARY
 
-	// set VM name to prettyname-ID, to make it readable a little bit
-	// if prettyname-ID is longer than max vm name length, truncate pretty name, instead of UUID, to make it unique
-	nameMaxLen := maxVMNameLength - len(config.ID)
-	prettyName := config.Name
-	if len(prettyName) > nameMaxLen-1 {
-		prettyName = prettyName[:nameMaxLen-1]
-	}
-	fullName := fmt.Sprintf("%s-%s", prettyName, config.ID)
+	fullName := DisplayName(config.Name, config.ID)
 	config.VMFullName = fullName
 
 	s := &types.VirtualMachineConfigSpec{
@@ -208,6 +201,18 @@ func (s *VirtualMachineConfigSpec) RemoveAndDestroyVirtualDevice(device types.Ba
 	return s
 }
 
+// DisplayName returns the VM name for the container, prettyname-ID, to make it readable a little bit.
+// If prettyname-ID is longer than max vm name length, the pretty name is truncated, instead of the
+// ID, to keep it unique.
+func DisplayName(name, id string) string {
+	nameMaxLen := maxVMNameLength - len(id)
+	if len(name) > nameMaxLen-1 {
+		name = name[:nameMaxLen-1]
+	}
+
+	return fmt.Sprintf("%s-%s", name, id)
+}
+
 // Name returns the name of the VM
 func (s *VirtualMachineConfigSpec) Name() string {
 	defer trace.End(trace.Begin(s.config.Name))
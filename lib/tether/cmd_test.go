@@ -327,3 +327,75 @@ func TestMissingRelativeBinary(t *testing.T) {
 
 //
 /////////////////////////////////////////////////////////////////////////////////////
+
+/////////////////////////////////////////////////////////////////////////////////////
+// TestExecSession constructs the spec for a primary session with two exec sessions,
+// only one of which is active and should therefore be launched
+//
+
+func TestExecSession(t *testing.T) {
+	_, mocker := testSetup(t)
+	defer testTeardown(t, mocker)
+
+	cfg := executor.ExecutorConfig{
+		Common: executor.Common{
+			ID:   "primary",
+			Name: "tether_test_executor",
+		},
+
+		Sessions: map[string]*executor.SessionConfig{
+			"primary": &executor.SessionConfig{
+				Common: executor.Common{
+					ID:   "primary",
+					Name: "tether_test_session",
+				},
+				Tty: false,
+				Cmd: executor.Cmd{
+					Path: "/bin/sleep",
+					Args: []string{"/bin/sleep", "1"},
+					Env:  []string{},
+					Dir:  "/",
+				},
+			},
+		},
+
+		Execs: map[string]*executor.SessionConfig{
+			"active": &executor.SessionConfig{
+				Common: executor.Common{
+					ID:   "active",
+					Name: "tether_test_exec",
+				},
+				Active: true,
+				Cmd: executor.Cmd{
+					Path: "/bin/true",
+					Args: []string{"/bin/true"},
+					Env:  []string{},
+					Dir:  "/",
+				},
+			},
+			"inactive": &executor.SessionConfig{
+				Common: executor.Common{
+					ID:   "inactive",
+					Name: "tether_test_exec",
+				},
+				Cmd: executor.Cmd{
+					Path: "/bin/true",
+					Args: []string{"/bin/true"},
+					Env:  []string{},
+					Dir:  "/",
+				},
+			},
+		},
+	}
+
+	_, src, err := RunTether(t, &cfg, mocker)
+	assert.NoError(t, err, "Didn't expected error from RunTether")
+
+	result := ExecutorConfig{}
+	extraconfig.Decode(src, &result)
+
+	assert.Equal(t, "true", result.Sessions["primary"].Started, "Expected command to have been started successfully")
+	assert.Equal(t, "true", result.Execs["active"].Started, "Expected exec to have been started successfully")
+	assert.Equal(t, 0, result.Execs["active"].ExitStatus, "Expected exec to have exited cleanly")
+	assert.Equal(t, "", result.Execs["inactive"].Started, "Expected inactive exec not to have been started")
+}
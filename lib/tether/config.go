@@ -41,10 +41,16 @@ type ExecutorConfig struct {
 	// Set of child PIDs created by us.
 	pids map[int]*SessionConfig `vic:"0.1" scope:"read-only" recurse:"depth=0"`
 
+	// Set of healthcheck probe PIDs created by us, with the channel that receives their exit status
+	probes map[int]chan int `vic:"0.1" scope:"read-only" recurse:"depth=0"`
+
 	// Sessions is the set of sessions currently hosted by this executor
 	// These are keyed by session ID
 	Sessions map[string]*SessionConfig `vic:"0.1" scope:"read-only" key:"sessions"`
 
+	// Execs is the set of non-persistent sessions hosted by this executor
+	Execs map[string]*SessionConfig `vic:"0.1" scope:"read-only" key:"execs"`
+
 	// Maps the mount name to the detail mount specification
 	Mounts map[string]executor.MountSpec `vic:"0.1" scope:"read-only" key:"mounts"`
 
@@ -55,6 +61,9 @@ type ExecutorConfig struct {
 	// Key is the host key used during communicate back with the Interaction endpoint if any
 	// Used if the in-guest tether is responsible for authenticating the connection
 	Key []byte `vic:"0.1" scope:"read-only" key:"key"`
+
+	// Paused records whether the session processes are frozen
+	Paused bool `vic:"0.1" scope:"read-write" key:"paused"`
 }
 
 // SessionConfig defines the content of a session - this maps to the root of a process tree
@@ -91,10 +100,32 @@ type SessionConfig struct {
 	// StopSignal is the signal name or number used to stop a container
 	StopSignal string `vic:"0.1" scope:"read-only" key:"stopSignal"`
 
-	// User and group for setuid programs
+	// User and group for setuid programs, as names or ids. User may also be user:group
 	User  string `vic:"0.1" scope:"read-only" key:"user"`
 	Group string `vic:"0.1" scope:"read-only" key:"group"`
 
+	// Healthcheck is the probe run periodically to check the session is working
+	Healthcheck executor.HealthConfig `vic:"0.1" scope:"read-only" key:"healthcheck"`
+
+	// Health is the result of the healthcheck
+	Health executor.HealthState `vic:"0.1" scope:"read-write" key:"health"`
+
+	// Logging is the log driver the session output is forwarded with
+	Logging executor.LogConfig `vic:"0.1" scope:"read-only" key:"logging"`
+
+	// the running healthcheck, if any
+	probe *healthProbe `vic:"0.1" scope:"read-only" recurse:"depth=0"`
+
+	// Active indicates that the session should be launched - only meaningful for execs
+	Active bool `vic:"0.1" scope:"read-only" key:"active"`
+
+	// RunBlock delays launch of the session until ClearToLaunch is closed by an attach
+	RunBlock      bool          `vic:"0.1" scope:"read-only" key:"runblock"`
+	ClearToLaunch chan struct{} `vic:"0.1" scope:"read-only" recurse:"depth=0"`
+
+	// the extraconfig prefix under which the session status is published
+	prefix string `vic:"0.1" scope:"read-only" recurse:"depth=0"`
+
 	// if there's a pty then we need additional management data
 	Pty       *os.File
 	Outwriter dio.DynamicMultiWriter `vic:"0.1" scope:"read-only" recurse:"depth=0"`
@@ -116,6 +147,15 @@ type NetworkEndpoint struct {
 	// Actual IP address assigned
 	Assigned net.IPNet `vic:"0.1" scope:"read-write" key:"assigned"`
 
+	// Whether this endpoint's IPv6 address was specified by the client (true if it was)
+	Static6 bool `vic:"0.1" scope:"read-only" key:"static6"`
+
+	// IPv6 address to assign on a dual-stack network
+	IP6 *net.IPNet `vic:"0.1" scope:"read-only" key:"ip6"`
+
+	// Actual IPv6 address assigned, either IP6 or one autoconfigured from router advertisements
+	Assigned6 net.IPNet `vic:"0.1" scope:"read-write" key:"assigned6"`
+
 	// The network in which this information should be interpreted. This is embedded directly rather than
 	// as a pointer so that we can ensure the data is consistent
 	Network executor.ContainerNetwork `vic:"0.1" scope:"read-only" key:"network"`
@@ -65,6 +65,7 @@ func TestToExtraConfig(t *testing.T) {
 					Gateway:     net.IPNet{IP: gateway, Mask: gmask.Mask},
 					Nameservers: []net.IP{},
 					Pools:       []ip.Range{},
+					Pools6:      []ip.Range{},
 					Aliases:     []string{},
 				},
 			},
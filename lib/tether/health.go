@@ -0,0 +1,301 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tether
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/vmware/vic/lib/config/executor"
+	"github.com/vmware/vic/pkg/trace"
+	"github.com/vmware/vic/pkg/vsphere/extraconfig"
+)
+
+const (
+	// the defaults for unset healthcheck settings, as used by docker
+	defaultProbeInterval = 30 * time.Second
+	defaultProbeTimeout  = 30 * time.Second
+	defaultProbeRetries  = 3
+
+	// the number of probe results kept in the health log of a session
+	maxHealthLogEntries = 5
+
+	// probe output beyond this length is discarded
+	maxProbeOutputLen = 4096
+)
+
+// healthProbe is the running healthcheck of a session
+type healthProbe struct {
+	config executor.HealthConfig
+	stop   chan struct{}
+}
+
+// startHealthcheck starts running the healthcheck of the session, if it has one. The caller
+// must hold the session lock.
+func (t *tether) startHealthcheck(session *SessionConfig) {
+	test := session.Healthcheck.Test
+	if session.probe != nil || len(test) == 0 || test[0] == "NONE" {
+		return
+	}
+
+	config := session.Healthcheck
+	if config.Interval <= 0 {
+		config.Interval = defaultProbeInterval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultProbeTimeout
+	}
+	if config.Retries <= 0 {
+		config.Retries = defaultProbeRetries
+	}
+
+	log.Infof("Starting healthcheck for session %s: %s every %s", session.ID, test, config.Interval)
+
+	// results from a previous run of the session do not carry over
+	session.Health = executor.HealthState{Status: executor.HealthStarting}
+	session.probe = &healthProbe{
+		config: config,
+		stop:   make(chan struct{}),
+	}
+
+	go t.runHealthcheck(session, session.probe)
+}
+
+// stopHealthcheck stops the healthcheck of the session. The caller must hold the session lock.
+func (t *tether) stopHealthcheck(session *SessionConfig) {
+	if session.probe == nil {
+		return
+	}
+
+	close(session.probe.stop)
+	session.probe = nil
+}
+
+// runHealthcheck probes the session at the configured interval, publishing each result, until
+// the healthcheck is stopped
+func (t *tether) runHealthcheck(session *SessionConfig, probe *healthProbe) {
+	defer trace.End(trace.Begin("healthcheck for session " + session.ID))
+
+	for {
+		select {
+		case <-probe.stop:
+			return
+		case <-time.After(probe.config.Interval):
+		}
+
+		// the session cannot respond while its processes are frozen
+		if t.isPaused() {
+			continue
+		}
+
+		result := t.probe(session, probe.config)
+
+		session.m.Lock()
+		if session.probe != probe {
+			// stopped while the probe was running
+			session.m.Unlock()
+			return
+		}
+
+		previous := session.Health.Status
+		recordHealthResult(&session.Health, result, probe.config.Retries)
+		if session.Health.Status != previous {
+			log.Infof("Session %s is %s", session.ID, session.Health.Status)
+		}
+
+		extraconfig.EncodeWithPrefix(t.sink, session, session.prefix)
+		session.m.Unlock()
+	}
+}
+
+// recordHealthResult adds the probe result to the health log and updates the status, which
+// becomes unhealthy after retries consecutive failures
+func recordHealthResult(health *executor.HealthState, result executor.HealthResult, retries int) {
+	health.Log = append(health.Log, result)
+	if len(health.Log) > maxHealthLogEntries {
+		health.Log = health.Log[len(health.Log)-maxHealthLogEntries:]
+	}
+
+	if result.ExitCode == 0 {
+		health.Status = executor.HealthHealthy
+		health.FailingStreak = 0
+		return
+	}
+
+	health.FailingStreak++
+	if health.FailingStreak >= retries {
+		health.Status = executor.HealthUnhealthy
+	}
+}
+
+// probe runs the healthcheck test once in the environment of the session
+func (t *tether) probe(session *SessionConfig, config executor.HealthConfig) executor.HealthResult {
+	result := executor.HealthResult{
+		Start:    time.Now().UTC(),
+		ExitCode: -1,
+	}
+
+	var args []string
+	switch config.Test[0] {
+	case "CMD":
+		args = config.Test[1:]
+	case "CMD-SHELL":
+		args = []string{"/bin/sh", "-c", strings.Join(config.Test[1:], " ")}
+	default:
+		result.End = time.Now().UTC()
+		result.Output = fmt.Sprintf("Unknown healthcheck type %q", config.Test[0])
+		return result
+	}
+
+	if len(args) == 0 {
+		result.End = time.Now().UTC()
+		result.Output = "No healthcheck command specified"
+		return result
+	}
+
+	session.m.Lock()
+	env := session.Cmd.Env
+	dir := session.Cmd.Dir
+	uname := session.User
+	gname := session.Group
+	session.m.Unlock()
+
+	output := &limitedBuffer{max: maxProbeOutputLen}
+	cmd := &exec.Cmd{
+		Args:   args,
+		Env:    env,
+		Dir:    dir,
+		Stdout: output,
+		Stderr: output,
+	}
+	if uname != "" || gname != "" {
+		u, err := lookupUser(uname, gname)
+		if err != nil {
+			result.End = time.Now().UTC()
+			result.ExitCode = -1
+			result.Output = err.Error()
+			return result
+		}
+		cmd.SysProcAttr = getUserSysProcAttr(u)
+	}
+	cmd.SysProcAttr = probeProcAttr(cmd.SysProcAttr)
+
+	var err error
+	if cmd.Path, err = lookPath(args[0], env, dir); err == nil {
+		result.ExitCode, err = t.runProbe(cmd, config.Timeout)
+	}
+
+	result.End = time.Now().UTC()
+	result.Output = string(output.buf)
+	if err != nil {
+		result.ExitCode = -1
+		result.Output = err.Error()
+	}
+
+	log.Debugf("Healthcheck probe for session %s exited with %d", session.ID, result.ExitCode)
+	return result
+}
+
+// runProbe runs the probe command and returns its exit status, killing it and the processes
+// it started if it runs for longer than timeout
+func (t *tether) runProbe(cmd *exec.Cmd, timeout time.Duration) (int, error) {
+	// the child reaper may collect the exit status before cmd.Wait, in which case it is
+	// delivered on this channel
+	exited := make(chan int, 1)
+
+	err := func() error {
+		t.config.pidMutex.Lock()
+		defer t.config.pidMutex.Unlock()
+
+		if err := cmd.Start(); err != nil {
+			return err
+		}
+
+		t.config.probes[cmd.Process.Pid] = exited
+		return nil
+	}()
+	if err != nil {
+		return -1, err
+	}
+	defer t.removeProbePid(cmd.Process.Pid)
+
+	// Wait also completes the copying of the output
+	waited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(waited)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	status := -1
+	reaped := false
+	for {
+		select {
+		case status = <-exited:
+			reaped = true
+		case <-waited:
+			waited = nil
+			if cmd.ProcessState != nil {
+				return cmd.ProcessState.Sys().(syscall.WaitStatus).ExitStatus(), nil
+			}
+		case <-timer.C:
+			// a shell probe runs its command as a child, so the whole process group is killed
+			if err := killProcessGroup(cmd.Process); err != nil {
+				log.Warnf("Unable to kill healthcheck probe %d: %s", cmd.Process.Pid, err)
+				cmd.Process.Kill()
+			}
+			return -1, fmt.Errorf("Health check exceeded timeout (%s)", timeout)
+		}
+
+		if reaped && waited == nil {
+			return status, nil
+		}
+	}
+}
+
+// removeProbePid is a synchronized accessor for the probe map the deletes the entry and returns the value
+func (t *tether) removeProbePid(pid int) (chan int, bool) {
+	t.config.pidMutex.Lock()
+	defer t.config.pidMutex.Unlock()
+
+	ch, ok := t.config.probes[pid]
+	delete(t.config.probes, pid)
+	return ch, ok
+}
+
+// limitedBuffer keeps the first max bytes written to it and discards the rest
+type limitedBuffer struct {
+	buf []byte
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.max - len(b.buf); remaining > 0 {
+		if len(p) > remaining {
+			b.buf = append(b.buf, p[:remaining]...)
+		} else {
+			b.buf = append(b.buf, p...)
+		}
+	}
+
+	return len(p), nil
+}
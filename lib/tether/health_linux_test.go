@@ -0,0 +1,74 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tether
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware/vic/lib/config/executor"
+)
+
+// the processes started by a shell probe are killed with it when it times out
+func TestProbeTimeoutKillsChildren(t *testing.T) {
+	tthr := New(nil, nil, nil).(*tether)
+
+	session := &SessionConfig{}
+	session.ID = "probe"
+	session.Cmd.Env = os.Environ()
+
+	pidFile := filepath.Join(os.TempDir(), fmt.Sprintf("probe-%d", os.Getpid()))
+	defer os.Remove(pidFile)
+
+	result := tthr.probe(session, executor.HealthConfig{
+		Test:    []string{"CMD-SHELL", "/bin/sleep 30 & echo $! > " + pidFile + "; wait"},
+		Timeout: 2 * time.Second,
+	})
+	assert.Equal(t, -1, result.ExitCode)
+
+	content, err := ioutil.ReadFile(pidFile)
+	if assert.NoError(t, err) {
+		pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+		if assert.NoError(t, err) {
+			killed := false
+			for i := 0; i < 20 && !killed; i++ {
+				killed = exited(pid)
+				time.Sleep(100 * time.Millisecond)
+			}
+			assert.True(t, killed, "Expected the child of the probe to be killed")
+		}
+	}
+}
+
+// exited reports whether the process has gone or is a zombie. The child of a killed probe is
+// reparented to pid 1, which may not reap it, so it can linger as a zombie once it has died.
+func exited(pid int) bool {
+	stat, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return true
+	}
+
+	// the state follows the command name, which is in parentheses and may contain spaces
+	fields := strings.Fields(string(stat[strings.LastIndex(string(stat), ")")+1:]))
+	return len(fields) == 0 || fields[0] == "Z" || fields[0] == "X"
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tether
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware/vic/lib/config/executor"
+)
+
+func TestRecordHealthResult(t *testing.T) {
+	health := &executor.HealthState{Status: executor.HealthStarting}
+
+	failed := executor.HealthResult{ExitCode: 1}
+	passed := executor.HealthResult{ExitCode: 0}
+
+	// failures short of the retries leave the status unchanged
+	recordHealthResult(health, failed, 2)
+	assert.Equal(t, executor.HealthStarting, health.Status)
+	assert.Equal(t, 1, health.FailingStreak)
+
+	recordHealthResult(health, failed, 2)
+	assert.Equal(t, executor.HealthUnhealthy, health.Status)
+	assert.Equal(t, 2, health.FailingStreak)
+
+	recordHealthResult(health, passed, 2)
+	assert.Equal(t, executor.HealthHealthy, health.Status)
+	assert.Equal(t, 0, health.FailingStreak)
+
+	for i := 0; i < maxHealthLogEntries; i++ {
+		recordHealthResult(health, passed, 2)
+	}
+	assert.Len(t, health.Log, maxHealthLogEntries)
+}
+
+func TestProbe(t *testing.T) {
+	tthr := New(nil, nil, nil).(*tether)
+
+	session := &SessionConfig{}
+	session.ID = "probe"
+	session.Cmd.Env = os.Environ()
+
+	result := tthr.probe(session, executor.HealthConfig{
+		Test:    []string{"CMD-SHELL", "echo healthy"},
+		Timeout: 10 * time.Second,
+	})
+	assert.Equal(t, 0, result.ExitCode)
+	assert.Equal(t, "healthy\n", result.Output)
+
+	result = tthr.probe(session, executor.HealthConfig{
+		Test:    []string{"CMD", "/bin/sh", "-c", "exit 3"},
+		Timeout: 10 * time.Second,
+	})
+	assert.Equal(t, 3, result.ExitCode)
+
+	result = tthr.probe(session, executor.HealthConfig{
+		Test:    []string{"CMD", "/bin/sleep", "10"},
+		Timeout: 100 * time.Millisecond,
+	})
+	assert.Equal(t, -1, result.ExitCode)
+	assert.True(t, strings.Contains(result.Output, "exceeded timeout"), result.Output)
+
+	// output is truncated
+	result = tthr.probe(session, executor.HealthConfig{
+		Test:    []string{"CMD-SHELL", "head -c 10000 /dev/zero"},
+		Timeout: 10 * time.Second,
+	})
+	assert.Equal(t, 0, result.ExitCode)
+	assert.Len(t, result.Output, maxProbeOutputLen)
+
+	assert.Empty(t, tthr.config.probes)
+}
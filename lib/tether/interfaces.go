@@ -17,6 +17,7 @@ package tether
 import (
 	"context"
 	"io"
+	"net/url"
 
 	"github.com/vmware/vic/pkg/dio"
 )
@@ -33,10 +34,15 @@ type Operations interface {
 
 	SetHostname(hostname string, aliases ...string) error
 	Apply(endpoint *NetworkEndpoint) error
+	// Unapply removes the configuration of an endpoint that is no longer part of the config
+	Unapply(endpoint *NetworkEndpoint) error
 	MountLabel(ctx context.Context, label, target string) error
+	// MountTarget mounts a network share, such as an NFS export, on target
+	MountTarget(ctx context.Context, source url.URL, target string, mountOptions string) error
 	Fork() error
 
-	SessionLog(session *SessionConfig) (dio.DynamicMultiWriter, error)
+	// SessionLog returns the writers that persist the stdout and stderr of the session
+	SessionLog(session *SessionConfig) (dio.DynamicMultiWriter, dio.DynamicMultiWriter, error)
 	// Returns a function to invoke after the session state has been persisted
 	HandleSessionExit(config *ExecutorConfig, session *SessionConfig) func()
 	ProcessEnv(env []string) []string
@@ -58,6 +64,12 @@ type Extension interface {
 	Stop() error
 }
 
+// tetherExtension is implemented by extensions that act on the tether itself rather than
+// only on its configuration
+type tetherExtension interface {
+	bind(t *tether)
+}
+
 type Config interface {
 	UpdateNetworkEndpoint(e *NetworkEndpoint) error
 	Flush() error
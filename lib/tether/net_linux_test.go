@@ -17,9 +17,11 @@
 package tether
 
 import (
+	"errors"
 	"io/ioutil"
 	"net"
 	"strconv"
+	"syscall"
 	"testing"
 
 	"github.com/vishvananda/netlink"
@@ -27,6 +29,8 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/vmware/vic/lib/config/executor"
+	"github.com/vmware/vic/lib/dhcp"
+	"github.com/vmware/vic/lib/dhcp/client"
 	"github.com/vmware/vic/lib/etcconf"
 )
 
@@ -155,3 +159,225 @@ func TestSetIpAddress(t *testing.T) {
 
 	assert.Equal(t, 1, len(eIface.Addrs), "Expected one address on external interface")
 }
+
+func TestRemoveEndpoint(t *testing.T) {
+	_, mocker := testSetup(t)
+
+	mocker.Base.dynEndpoints = make(map[string][]*NetworkEndpoint)
+	mocker.Base.dhcpLoops = make(map[string]chan bool)
+
+	bridge := AddInterface("eth1", mocker)
+
+	firstIP, _ := netlink.ParseIPNet("172.16.0.10/24")
+	secondIP, _ := netlink.ParseIPNet("172.17.0.10/24")
+	first := &NetworkEndpoint{
+		Common: executor.Common{
+			ID: bridge,
+		},
+		Network: executor.ContainerNetwork{
+			Common: executor.Common{
+				Name: "bridge",
+			},
+		},
+		Static: true,
+		IP:     firstIP,
+	}
+	second := &NetworkEndpoint{
+		Common: executor.Common{
+			ID: bridge,
+		},
+		Network: executor.ContainerNetwork{
+			Common: executor.Common{
+				Name: "cnet",
+			},
+		},
+		Static: true,
+		IP:     secondIP,
+	}
+
+	for _, e := range []*NetworkEndpoint{first, second} {
+		if !assert.NoError(t, mocker.Apply(e)) {
+			return
+		}
+	}
+
+	iface, _ := mocker.Interfaces["eth1"].(*Interface)
+	assert.Equal(t, 2, len(iface.Addrs), "Expected two addresses on the shared interface")
+
+	// removing one of the networks sharing the NIC only removes its address
+	assert.NoError(t, mocker.Unapply(second))
+	if assert.Equal(t, 1, len(iface.Addrs), "Expected one address after removing an endpoint") {
+		assert.True(t, iface.Addrs[0].IP.Equal(firstIP.IP), "Expected the address of the remaining endpoint")
+	}
+}
+
+func TestDualStackEndpoint(t *testing.T) {
+	_, mocker := testSetup(t)
+
+	mocker.Base.dynEndpoints = make(map[string][]*NetworkEndpoint)
+	mocker.Base.dhcpLoops = make(map[string]chan bool)
+
+	bridge := AddInterface("eth1", mocker)
+
+	addr, _ := netlink.ParseIPNet("172.16.0.10/24")
+	addr6, _ := netlink.ParseIPNet("fd00:1::10/64")
+	gw6, _ := netlink.ParseIPNet("fd00:1::1/64")
+	e := &NetworkEndpoint{
+		Common: executor.Common{
+			ID: bridge,
+		},
+		Network: executor.ContainerNetwork{
+			Common: executor.Common{
+				Name: "dual",
+			},
+			IPv6:     true,
+			Gateway6: *gw6,
+		},
+		Static: true,
+		IP:     addr,
+		IP6:    addr6,
+	}
+
+	if !assert.NoError(t, mocker.Apply(e)) {
+		return
+	}
+
+	iface, _ := mocker.Interfaces["eth1"].(*Interface)
+	assert.True(t, iface.IPv6, "Expected IPv6 to be enabled on the interface")
+	assert.False(t, iface.Autoconf, "Expected no autoconfiguration for an assigned address")
+	assert.Equal(t, 2, len(iface.Addrs), "Expected IPv4 and IPv6 addresses on the interface")
+	assert.True(t, e.Assigned6.IP.Equal(addr6.IP), "Expected the IPv6 address to be recorded as assigned")
+
+	assert.NoError(t, mocker.Unapply(e))
+	assert.Equal(t, 0, len(iface.Addrs), "Expected both addresses to be removed")
+}
+
+func TestIPv6Autoconf(t *testing.T) {
+	_, mocker := testSetup(t)
+
+	bridge := AddInterface("eth1", mocker)
+	iface, _ := mocker.Interfaces["eth1"].(*Interface)
+
+	// the link local and tentative addresses are not usable
+	linkLocal, _ := netlink.ParseIPNet("fe80::1/64")
+	tentative, _ := netlink.ParseIPNet("2001:db8::2/64")
+	global, _ := netlink.ParseIPNet("2001:db8::1/64")
+	iface.Addrs = []netlink.Addr{
+		{IPNet: linkLocal},
+		{IPNet: tentative, Flags: syscall.IFA_F_TENTATIVE},
+		{IPNet: global},
+	}
+
+	e := &NetworkEndpoint{
+		Common: executor.Common{
+			ID: bridge,
+		},
+		Network: executor.ContainerNetwork{
+			Common: executor.Common{
+				Name: "external",
+			},
+			IPv6: true,
+		},
+	}
+
+	assert.NoError(t, applyIPv6(mocker, &mocker.Base, iface, e))
+	assert.True(t, iface.Autoconf, "Expected autoconfiguration for a dynamic endpoint")
+	assert.True(t, e.Assigned6.IP.Equal(global.IP), "Expected the autoconfigured address to be recorded")
+}
+
+// leaseReply returns a DHCPv6 reply leasing addr, as it is read from the network
+func leaseReply(t *testing.T, addr string) *dhcp.Packet6 {
+	iaaddr := append(net.ParseIP(addr).To16(), 0, 0, 0x0e, 0x10, 0, 0, 0x1c, 0x20)
+	iana := append([]byte{0, 0, 0, 1, 0, 0, 0x07, 0x08, 0, 0, 0x0b, 0x40, 0, 5, 0, byte(len(iaaddr))}, iaaddr...)
+
+	reply := &dhcp.Packet6{
+		Type:    dhcp.Reply6,
+		Options: dhcp.Options6{dhcp.OptionServerID6: {0, 1, 2, 3}, dhcp.OptionIANA6: iana},
+	}
+
+	b, err := reply.MarshalBinary()
+	assert.NoError(t, err)
+	reply, err = dhcp.NewPacket6(b)
+	assert.NoError(t, err)
+
+	return reply
+}
+
+// setupDHCPv6 gives the tether a DHCP client, and client IDs that do not depend on the VM UUID
+func setupDHCPv6(mocker *Mocker, dc *mockDHCPClient) func() {
+	mocker.Base.dhcpClient = dc
+	mocker.Base.dhcp6Loops = make(map[string]chan bool)
+
+	newClientID = func(ifindex int, hw net.HardwareAddr) (client.ID, error) {
+		return client.ID{IfIndex: ifindex, HardwareAddr: hw}, nil
+	}
+
+	return func() {
+		newClientID = client.NewID
+	}
+}
+
+func TestIPv6DHCP(t *testing.T) {
+	_, mocker := testSetup(t)
+
+	bridge := AddInterface("eth1", mocker)
+	iface, _ := mocker.Interfaces["eth1"].(*Interface)
+
+	// a network with only stateful configuration leaves the link with its link local address
+	linkLocal, _ := netlink.ParseIPNet("fe80::1/64")
+	iface.Addrs = []netlink.Addr{{IPNet: linkLocal}}
+
+	dc := &mockDHCPClient{reply6: leaseReply(t, "2001:db8::10")}
+	defer setupDHCPv6(mocker, dc)()
+
+	e := &NetworkEndpoint{
+		Common: executor.Common{
+			ID: bridge,
+		},
+		Network: executor.ContainerNetwork{
+			Common: executor.Common{
+				Name: "external",
+			},
+			IPv6: true,
+		},
+	}
+
+	assert.NoError(t, applyIPv6(mocker, &mocker.Base, iface, e))
+	assert.Equal(t, "2001:db8::10/128", e.Assigned6.String(), "Expected the leased address to be recorded")
+	assert.Equal(t, "2001:db8::10/128", iface.Addrs[len(iface.Addrs)-1].IPNet.String(), "Expected the leased address to be assigned")
+
+	// the lease is released when the tether is done with it
+	assert.NoError(t, mocker.Base.Cleanup())
+	assert.True(t, dc.released6, "Expected the lease to be released")
+}
+
+func TestIPv6AutoconfUnavailable(t *testing.T) {
+	_, mocker := testSetup(t)
+
+	bridge := AddInterface("eth1", mocker)
+	iface, _ := mocker.Interfaces["eth1"].(*Interface)
+
+	// a network with only stateful configuration leaves the link with its link local address
+	linkLocal, _ := netlink.ParseIPNet("fe80::1/64")
+	iface.Addrs = []netlink.Addr{{IPNet: linkLocal}}
+
+	e := &NetworkEndpoint{
+		Common: executor.Common{
+			ID: bridge,
+		},
+		Network: executor.ContainerNetwork{
+			Common: executor.Common{
+				Name: "external",
+			},
+			IPv6: true,
+		},
+	}
+
+	dc := &mockDHCPClient{err: errors.New("no DHCPv6 server")}
+	defer setupDHCPv6(mocker, dc)()
+
+	err := applyIPv6(mocker, &mocker.Base, iface, e)
+	if assert.Error(t, err, "Expected a network without autoconfiguration or DHCPv6 to be rejected") {
+		assert.Contains(t, err.Error(), "no DHCPv6 server")
+	}
+}
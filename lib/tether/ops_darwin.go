@@ -18,13 +18,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
-	"os/user"
-	"strconv"
 	"strings"
 	"syscall"
 
-	log "github.com/Sirupsen/logrus"
+	"github.com/opencontainers/runc/libcontainer/user"
 
 	"github.com/vmware/vic/pkg/trace"
 )
@@ -45,6 +44,13 @@ func (t *BaseOperations) Apply(endpoint *NetworkEndpoint) error {
 	return errors.New("not implemented on OSX")
 }
 
+// Unapply removes the network endpoint configuration from the system
+func (t *BaseOperations) Unapply(endpoint *NetworkEndpoint) error {
+	defer trace.End(trace.Begin("removing endpoint configuration for " + endpoint.Network.Name))
+
+	return errors.New("not implemented on OSX")
+}
+
 // MountLabel performs a mount with the source treated as a disk label
 // This assumes that /dev/disk/by-label is being populated, probably by udev
 func (t *BaseOperations) MountLabel(ctx context.Context, label, target string) error {
@@ -53,10 +59,17 @@ func (t *BaseOperations) MountLabel(ctx context.Context, label, target string) e
 	return errors.New("not implemented on OSX")
 }
 
+// MountTarget performs a mount of a network share on target
+func (t *BaseOperations) MountTarget(ctx context.Context, source url.URL, target string, mountOptions string) error {
+	defer trace.End(trace.Begin(fmt.Sprintf("Mounting %s on %s", source.String(), target)))
+
+	return errors.New("not implemented on OSX")
+}
+
 // ProcessEnv does OS specific checking and munging on the process environment prior to launch
 func (t *BaseOperations) ProcessEnv(env []string) []string {
-	// TODO: figure out how we're going to specify user and pass all the settings along
-	// in the meantime, hardcode HOME to /root
+	// HOME has already been set for sessions that run as a specific user, so default it to
+	// the home directory of root
 	homeIndex := -1
 	for i, tuple := range env {
 		if strings.HasPrefix(tuple, "HOME=") {
@@ -87,24 +100,20 @@ func (t *BaseOperations) Cleanup() error {
 	return nil
 }
 
-// Need to put this here because Windows does not
-// support SysProcAttr.Credential
-func getUserSysProcAttr(uname string) *syscall.SysProcAttr {
-	uinfo, err := user.Lookup(uname)
-	if err != nil {
-		detail := fmt.Sprintf("Unable to find user %s: %s", uname, err)
-		log.Error(detail)
-		return nil
-	} else {
-		u, _ := strconv.Atoi(uinfo.Uid)
-		g, _ := strconv.Atoi(uinfo.Gid)
-		// Unfortunately lookup GID by name is currently unsupported in Go.
-		return &syscall.SysProcAttr{
-			Credential: &syscall.Credential{
-				Uid: uint32(u),
-				Gid: uint32(g),
-			},
-			Setsid: true,
-		}
+// getUserSysProcAttr returns the attributes that run a process as the resolved user. It is
+// here because Windows does not support SysProcAttr.Credential
+func getUserSysProcAttr(u *user.ExecUser) *syscall.SysProcAttr {
+	groups := make([]uint32, len(u.Sgids))
+	for i, gid := range u.Sgids {
+		groups[i] = uint32(gid)
+	}
+
+	return &syscall.SysProcAttr{
+		Credential: &syscall.Credential{
+			Uid:    uint32(u.Uid),
+			Gid:    uint32(u.Gid),
+			Groups: groups,
+		},
+		Setsid: true,
 	}
 }
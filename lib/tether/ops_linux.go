@@ -20,8 +20,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net"
+	"net/url"
 	"os"
-	"os/user"
 	"path"
 	"path/filepath"
 	"strconv"
@@ -30,10 +30,12 @@ import (
 	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/opencontainers/runc/libcontainer/user"
 	"github.com/vishvananda/netlink"
 
 	"github.com/vmware/vic/lib/dhcp"
 	"github.com/vmware/vic/lib/dhcp/client"
+	"github.com/vmware/vic/pkg/fs"
 	"github.com/vmware/vic/pkg/ip"
 	"github.com/vmware/vic/pkg/trace"
 	"github.com/vmware/vmw-guestinfo/rpcout"
@@ -42,15 +44,31 @@ import (
 var (
 	hostnameFile = "/etc/hostname"
 	byLabelDir   = "/dev/disk/by-label"
+
+	// the DHCP client ID of a link is derived from the UUID of the VM
+	newClientID = client.NewID
 )
 
 const (
 	pciDevPath = "/sys/bus/pci/devices"
+
+	// how long to wait for a hot-added NIC to be enumerated by the kernel
+	linkWaitTimeout  = 10 * time.Second
+	linkWaitInterval = 100 * time.Millisecond
+
+	// how long to wait for an IPv6 address to be autoconfigured from router advertisements
+	autoconfWaitTimeout = 5 * time.Second
+
+	// how long to wait before retrying a failed renewal of a DHCPv6 lease
+	dhcp6RetryInterval = time.Minute
+
+	ipv6ConfPath = "/proc/sys/net/ipv6/conf"
 )
 
 type BaseOperations struct {
 	dhcpClient   client.Client
 	dhcpLoops    map[string]chan bool
+	dhcp6Loops   map[string]chan bool
 	dynEndpoints map[string][]*NetworkEndpoint
 	config       Config
 }
@@ -71,6 +89,7 @@ type Netlink interface {
 	// Not quite netlink, but tightly associated
 
 	LinkBySlot(slot int32) (netlink.Link, error)
+	LinkSetIPv6(link netlink.Link, autoconf bool) error
 }
 
 func (t *BaseOperations) LinkByName(name string) (netlink.Link, error) {
@@ -128,6 +147,31 @@ func (t *BaseOperations) LinkBySlot(slot int32) (netlink.Link, error) {
 	return t.LinkByName(name)
 }
 
+// LinkSetIPv6 enables IPv6 on the link and, if autoconf is set, the autoconfiguration of addresses
+// from router advertisements
+func (t *BaseOperations) LinkSetIPv6(link netlink.Link, autoconf bool) error {
+	conf := path.Join(ipv6ConfPath, link.Attrs().Name)
+
+	enable := "0"
+	if autoconf {
+		enable = "1"
+	}
+
+	settings := []struct{ name, value string }{
+		{"disable_ipv6", "0"},
+		{"accept_ra", enable},
+		{"autoconf", enable},
+	}
+
+	for _, s := range settings {
+		if err := ioutil.WriteFile(path.Join(conf, s.name), []byte(s.value), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // SetHostname sets both the kernel hostname and /etc/hostname to the specified string
 func (t *BaseOperations) SetHostname(hostname string, aliases ...string) error {
 	defer trace.End(trace.Begin("setting hostname to " + hostname))
@@ -354,25 +398,153 @@ func updateDefaultRoute(t Netlink, link netlink.Link, endpoint *NetworkEndpoint)
 		return nil
 	}
 
-	_, defaultNet, _ := net.ParseCIDR("0.0.0.0/0")
-	// delete default route first
-	if err := t.RouteDel(&netlink.Route{LinkIndex: link.Attrs().Index, Dst: defaultNet}); err != nil {
-		if errno, ok := err.(syscall.Errno); !ok || errno != syscall.ESRCH {
-			return fmt.Errorf("could not update default route: %s", err)
+	gateways := []net.IP{endpoint.Network.Gateway.IP}
+	if !ip.IsUnspecifiedIP(endpoint.Network.Gateway6.IP) {
+		gateways = append(gateways, endpoint.Network.Gateway6.IP)
+	}
+
+	for _, gw := range gateways {
+		defaultNet := &net.IPNet{IP: net.IPv4zero, Mask: net.CIDRMask(0, 8*net.IPv4len)}
+		if ip.IsIPv6(gw) {
+			defaultNet = &net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(0, 8*net.IPv6len)}
+		}
+
+		// delete default route first
+		if err := t.RouteDel(&netlink.Route{LinkIndex: link.Attrs().Index, Dst: defaultNet}); err != nil {
+			if errno, ok := err.(syscall.Errno); !ok || errno != syscall.ESRCH {
+				return fmt.Errorf("could not update default route: %s", err)
+			}
+		}
+
+		log.Infof("Setting default gateway to %s", gw)
+		route := &netlink.Route{LinkIndex: link.Attrs().Index, Dst: defaultNet, Gw: gw}
+		if err := t.RouteAdd(route); err != nil {
+			detail := fmt.Sprintf("failed to add gateway route for endpoint %s: %s", endpoint.Network.Name, err)
+			return errors.New(detail)
 		}
+
+		log.Infof("updated default route to %s interface, gateway: %s", endpoint.Network.Name, gw)
 	}
 
-	log.Infof("Setting default gateway to %s", endpoint.Network.Gateway.IP)
-	route := &netlink.Route{LinkIndex: link.Attrs().Index, Dst: defaultNet, Gw: endpoint.Network.Gateway.IP}
-	if err := t.RouteAdd(route); err != nil {
-		detail := fmt.Sprintf("failed to add gateway route for endpoint %s: %s", endpoint.Network.Name, err)
-		return errors.New(detail)
+	return nil
+}
+
+// applyIPv6 configures IPv6 on the link of an endpoint on a network with IPv6 enabled. The
+// endpoint either has an IPv6 address to assign, on a dual-stack network, or an address is
+// autoconfigured from router advertisements or, on a network that only offers stateful
+// configuration, leased by DHCPv6.
+func applyIPv6(nl Netlink, t *BaseOperations, link netlink.Link, endpoint *NetworkEndpoint) error {
+	if !endpoint.Network.IPv6 {
+		return nil
 	}
 
-	log.Infof("updated default route to %s interface, gateway: %s", endpoint.Network.Name, endpoint.Network.Gateway.IP)
+	static := endpoint.IP6 != nil && !ip.IsUnspecifiedIP(endpoint.IP6.IP)
+	autoconf := !static && endpoint.IsDynamic()
+	if err := nl.LinkSetIPv6(link, autoconf); err != nil {
+		return fmt.Errorf("unable to enable IPv6 on link %s: %s", link.Attrs().Name, err)
+	}
+
+	if static {
+		var old *net.IPNet
+		if !ip.IsUnspecifiedIP(endpoint.Assigned6.IP) {
+			old = &endpoint.Assigned6
+		}
+
+		if err := linkAddrUpdate(old, endpoint.IP6, nl, link); err != nil {
+			return err
+		}
+
+		endpoint.Assigned6 = *endpoint.IP6
+		return nil
+	}
+
+	if !autoconf {
+		// the address of an IPv6 only network is the primary address of the endpoint
+		return nil
+	}
+
+	addr, err := waitForAutoconf(nl, link)
+	if err != nil {
+		log.Infof("no IPv6 address autoconfigured for %s, requesting one by DHCPv6: %s", endpoint.Network.Name, err)
+		return leaseIPv6(nl, t, link, endpoint)
+	}
+
+	log.Infof("IPv6 address %s autoconfigured for %s", addr, endpoint.Network.Name)
+	endpoint.Assigned6 = *addr
 	return nil
 }
 
+// leaseIPv6 assigns the endpoint an IPv6 address leased by DHCPv6, and starts the renewal of the
+// lease. Only the address is leased, the routes of the network still come from router
+// advertisements.
+func leaseIPv6(nl Netlink, t *BaseOperations, link netlink.Link, endpoint *NetworkEndpoint) error {
+	id, err := newClientID(link.Attrs().Index, link.Attrs().HardwareAddr)
+	if err != nil {
+		return err
+	}
+
+	reply, err := t.dhcpClient.Request6(id)
+	if err != nil {
+		return fmt.Errorf("no IPv6 address autoconfigured or leased by DHCPv6 for %s: %s", endpoint.Network.Name, err)
+	}
+
+	log.Infof("DHCPv6 response: IP=%s, Lease Time=%s", reply.Address(), reply.LeaseTime())
+	if err = assignIPv6(nl, link, endpoint, reply); err != nil {
+		t.dhcpClient.Release6(id, reply)
+		return err
+	}
+
+	if _, ok := t.dhcp6Loops[endpoint.ID]; !ok {
+		stop := make(chan bool)
+		go t.dhcp6Loop(stop, link, endpoint, reply, id)
+		t.dhcp6Loops[endpoint.ID] = stop
+	}
+
+	return nil
+}
+
+// assignIPv6 assigns the address leased by DHCPv6 to the link in place of the previous address
+// of the endpoint. The lease is of a single address, rather than of a prefix.
+func assignIPv6(nl Netlink, link netlink.Link, endpoint *NetworkEndpoint, reply *dhcp.Packet6) error {
+	addr := &net.IPNet{IP: reply.Address(), Mask: net.CIDRMask(8*net.IPv6len, 8*net.IPv6len)}
+
+	var old *net.IPNet
+	if !ip.IsUnspecifiedIP(endpoint.Assigned6.IP) {
+		old = &endpoint.Assigned6
+	}
+
+	if err := linkAddrUpdate(old, addr, nl, link); err != nil {
+		return err
+	}
+
+	endpoint.Assigned6 = *addr
+	return nil
+}
+
+// waitForAutoconf returns the global IPv6 address autoconfigured on the link, polling for it while
+// router solicitation and duplicate address detection complete
+func waitForAutoconf(nl Netlink, link netlink.Link) (*net.IPNet, error) {
+	deadline := time.Now().Add(autoconfWaitTimeout)
+	for {
+		addrs, err := nl.AddrList(link, netlink.FAMILY_V6)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, a := range addrs {
+			if a.IPNet != nil && ip.IsIPv6(a.IP) && a.IP.IsGlobalUnicast() && a.Flags&syscall.IFA_F_TENTATIVE == 0 {
+				return a.IPNet, nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for router advertisements on link %s", link.Attrs().Name)
+		}
+
+		time.Sleep(linkWaitInterval)
+	}
+}
+
 func (t *BaseOperations) updateHosts(endpoint *NetworkEndpoint) error {
 	log.Debugf("%+v", endpoint)
 	// Add /etc/hosts entry
@@ -380,7 +552,12 @@ func (t *BaseOperations) updateHosts(endpoint *NetworkEndpoint) error {
 		return nil
 	}
 
-	Sys.Hosts.SetHost(fmt.Sprintf("%s.localhost", endpoint.Network.Name), endpoint.Assigned.IP)
+	addr := endpoint.Assigned.IP
+	if ip.IsUnspecifiedIP(addr) {
+		addr = endpoint.Assigned6.IP
+	}
+
+	Sys.Hosts.SetHost(fmt.Sprintf("%s.localhost", endpoint.Network.Name), addr)
 
 	if err := Sys.Hosts.Save(); err != nil {
 		return err
@@ -420,7 +597,7 @@ func apply(nl Netlink, t *BaseOperations, endpoint *NetworkEndpoint) error {
 		detail := fmt.Sprintf("endpoint ID must be a base10 numeric pci slot identifier: %s", err)
 		return errors.New(detail)
 	}
-	link, err := nl.LinkBySlot(int32(slot))
+	link, err := waitForLink(nl, int32(slot))
 	if err != nil {
 		detail := fmt.Sprintf("unable to acquire reference to link %s: %s", endpoint.ID, err)
 		return errors.New(detail)
@@ -454,7 +631,17 @@ func apply(nl Netlink, t *BaseOperations, endpoint *NetworkEndpoint) error {
 		if endpoint.DHCP == nil {
 			ack, err = getDynamicIP(nl, link, t.dhcpClient)
 			if err != nil {
-				return err
+				if !endpoint.Network.IPv6 {
+					return err
+				}
+
+				// an IPv6 only network need not have a DHCP server
+				log.Warnf("no DHCP lease for %s, relying on IPv6 autoconfiguration: %s", endpoint.Network.Name, err)
+				if err = applyIPv6(nl, t, link, endpoint); err != nil {
+					return err
+				}
+
+				return t.updateHosts(endpoint)
 			}
 
 			endpoint.DHCP = &DHCPInfo{
@@ -466,7 +653,7 @@ func apply(nl Netlink, t *BaseOperations, endpoint *NetworkEndpoint) error {
 		newIP = &endpoint.DHCP.Assigned
 	} else {
 		newIP = endpoint.IP
-		if newIP.IP.Equal(net.IPv4zero) {
+		if ip.IsUnspecifiedIP(newIP.IP) {
 			// managed externally
 			return nil
 		}
@@ -483,6 +670,10 @@ func apply(nl Netlink, t *BaseOperations, endpoint *NetworkEndpoint) error {
 
 	updateEndpoint(newIP, endpoint)
 
+	if err = applyIPv6(nl, t, link, endpoint); err != nil {
+		return err
+	}
+
 	if err = updateDefaultRoute(nl, link, endpoint); err != nil {
 		return err
 	}
@@ -529,6 +720,102 @@ func apply(nl Netlink, t *BaseOperations, endpoint *NetworkEndpoint) error {
 	return nil
 }
 
+// waitForLink returns the link in the PCI slot, polling for it for a while as a NIC that has
+// been hot-added to a running containerVM may not have been enumerated yet
+func waitForLink(nl Netlink, slot int32) (netlink.Link, error) {
+	deadline := time.Now().Add(linkWaitTimeout)
+	for {
+		link, err := nl.LinkBySlot(slot)
+		if err == nil || time.Now().After(deadline) {
+			return link, err
+		}
+
+		log.Debugf("waiting for link in slot %d: %s", slot, err)
+		time.Sleep(linkWaitInterval)
+	}
+}
+
+// Unapply removes the network endpoint configuration from the system
+func (t *BaseOperations) Unapply(endpoint *NetworkEndpoint) error {
+	defer trace.End(trace.Begin("removing endpoint configuration for " + endpoint.Network.Name))
+
+	return unapply(t, t, endpoint)
+}
+
+func unapply(nl Netlink, t *BaseOperations, endpoint *NetworkEndpoint) error {
+	// endpoints sharing a NIC share its DHCP lease, which is only released with the last of them
+	shared := false
+	if endpoint.IsDynamic() {
+		var eps []*NetworkEndpoint
+		for _, e := range t.dynEndpoints[endpoint.ID] {
+			if e != endpoint {
+				eps = append(eps, e)
+			}
+		}
+
+		if len(eps) > 0 {
+			shared = true
+			t.dynEndpoints[endpoint.ID] = eps
+		} else {
+			delete(t.dynEndpoints, endpoint.ID)
+			if stop, ok := t.dhcpLoops[endpoint.ID]; ok {
+				stop <- true
+				delete(t.dhcpLoops, endpoint.ID)
+			}
+			if stop, ok := t.dhcp6Loops[endpoint.ID]; ok {
+				stop <- true
+				delete(t.dhcp6Loops, endpoint.ID)
+			}
+		}
+	}
+
+	// the NIC has gone with the endpoint unless it is shared with another network, in which
+	// case only the address of this endpoint is removed from it
+	var addrs []net.IPNet
+	if !shared && !ip.IsUnspecifiedIP(endpoint.Assigned.IP) {
+		addrs = append(addrs, endpoint.Assigned)
+	}
+
+	// an autoconfigured IPv6 address is shared like a DHCP lease, unlike an assigned one
+	if (!shared || endpoint.IP6 != nil) && !ip.IsUnspecifiedIP(endpoint.Assigned6.IP) {
+		addrs = append(addrs, endpoint.Assigned6)
+	}
+
+	if len(addrs) > 0 {
+		slot, err := strconv.Atoi(endpoint.ID)
+		if err != nil {
+			detail := fmt.Sprintf("endpoint ID must be a base10 numeric pci slot identifier: %s", err)
+			return errors.New(detail)
+		}
+
+		if link, err := nl.LinkBySlot(int32(slot)); err == nil && link != nil {
+			for i := range addrs {
+				log.Infof("removing ip address %s from link %s", addrs[i].String(), link.Attrs().Name)
+				if err := nl.AddrDel(link, &netlink.Addr{IPNet: &addrs[i]}); err != nil {
+					if errno, ok := err.(syscall.Errno); !ok || errno != syscall.EADDRNOTAVAIL {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	if endpoint.Network.Name != "" {
+		Sys.Hosts.RemoveHost(fmt.Sprintf("%s.localhost", endpoint.Network.Name))
+		if err := Sys.Hosts.Save(); err != nil {
+			return err
+		}
+	}
+
+	// nameservers in use by the remaining endpoints are added back when they are applied
+	Sys.ResolvConf.RemoveNameservers(endpoint.Network.Nameservers...)
+	if len(endpoint.Network.Nameservers) == 0 && !ip.IsUnspecifiedIP(endpoint.Network.Gateway.IP) {
+		Sys.ResolvConf.RemoveNameservers(endpoint.Network.Gateway.IP)
+	}
+
+	return Sys.ResolvConf.Save()
+}
+
 func (t *BaseOperations) dhcpLoop(stop chan bool, e *NetworkEndpoint, ack *dhcp.Packet, id client.ID) {
 	exp := time.After(ack.LeaseTime() / 2)
 	for {
@@ -580,6 +867,51 @@ func (t *BaseOperations) dhcpLoop(stop chan bool, e *NetworkEndpoint, ack *dhcp.
 	}
 }
 
+// dhcp6Loop renews the DHCPv6 lease of the endpoint until it is stopped, when the lease is released
+func (t *BaseOperations) dhcp6Loop(stop chan bool, link netlink.Link, e *NetworkEndpoint, reply *dhcp.Packet6, id client.ID) {
+	// a lease that does not expire is never renewed
+	var exp <-chan time.Time
+	if renew := reply.RenewTime(); renew > 0 {
+		exp = time.After(renew)
+	}
+
+	for {
+		select {
+		case <-stop:
+			log.Infof("releasing IPv6 address for network %s", e.Name)
+			t.dhcpClient.Release6(id, reply)
+			return
+
+		case <-exp:
+			log.Infof("renewing IPv6 address for network %s", e.Name)
+			newreply, err := t.dhcpClient.Renew6(id, reply)
+			if err != nil {
+				log.Errorf("failed to renew IPv6 address for network %s: %s", e.Name, err)
+				exp = time.After(dhcp6RetryInterval)
+				continue
+			}
+
+			reply = newreply
+			log.Infof("successfully renewed IPv6 address: IP=%s, Lease Time=%s", reply.Address(), reply.LeaseTime())
+
+			if !reply.Address().Equal(e.Assigned6.IP) {
+				if err = assignIPv6(t, link, e, reply); err != nil {
+					log.Errorf("failed to assign renewed IPv6 address for network %s: %s", e.Name, err)
+				} else if err = t.config.UpdateNetworkEndpoint(e); err != nil {
+					log.Error(err)
+				}
+
+				t.config.Flush()
+			}
+
+			exp = nil
+			if renew := reply.RenewTime(); renew > 0 {
+				exp = time.After(renew)
+			}
+		}
+	}
+}
+
 // MountLabel performs a mount with the label and target being absolute paths
 func (t *BaseOperations) MountLabel(ctx context.Context, label, target string) error {
 	defer trace.End(trace.Begin(fmt.Sprintf("Mounting %s on %s", label, target)))
@@ -616,10 +948,43 @@ func (t *BaseOperations) MountLabel(ctx context.Context, label, target string) e
 	return nil
 }
 
+// MountTarget performs a mount of the network share at source on target. Only NFS exports
+// are supported, and mountOptions is the volume mode, e.g. "ro" for a read only mount.
+func (t *BaseOperations) MountTarget(ctx context.Context, source url.URL, target string, mountOptions string) error {
+	defer trace.End(trace.Begin(fmt.Sprintf("Mounting %s on %s", source.String(), target)))
+
+	if source.Scheme != fs.NFSScheme {
+		return fmt.Errorf("unsupported mount source %s", source.String())
+	}
+
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return fmt.Errorf("unable to create mount point %s: %s", target, err)
+	}
+
+	src, opts, err := fs.NFSMountArgs(&source)
+	if err != nil {
+		return fmt.Errorf("unable to mount %s: %s", source.String(), err)
+	}
+
+	var flags uintptr
+	for _, opt := range strings.Split(mountOptions, ",") {
+		if opt == "ro" {
+			flags |= syscall.MS_RDONLY
+		}
+	}
+
+	if err := Sys.Syscall.Mount(src, target, "nfs", flags, opts); err != nil {
+		detail := fmt.Sprintf("mounting %s on %s failed: %s", source.String(), target, err)
+		return errors.New(detail)
+	}
+
+	return nil
+}
+
 // ProcessEnv does OS specific checking and munging on the process environment prior to launch
 func (t *BaseOperations) ProcessEnv(env []string) []string {
-	// TODO: figure out how we're going to specify user and pass all the settings along
-	// in the meantime, hardcode HOME to /root
+	// HOME has already been set for sessions that run as a specific user, so default it to
+	// the home directory of root
 	homeIndex := -1
 	for i, tuple := range env {
 		if strings.HasPrefix(tuple, "HOME=") {
@@ -699,6 +1064,7 @@ func (t *BaseOperations) Setup(config Config) error {
 
 	t.dynEndpoints = make(map[string][]*NetworkEndpoint)
 	t.dhcpLoops = make(map[string]chan bool)
+	t.dhcp6Loops = make(map[string]chan bool)
 	t.dhcpClient = c
 	t.config = config
 
@@ -710,27 +1076,27 @@ func (t *BaseOperations) Cleanup() error {
 		stop <- true
 	}
 
+	for _, stop := range t.dhcp6Loops {
+		stop <- true
+	}
+
 	return nil
 }
 
-// Need to put this here because Windows does not
-// support SysProcAttr.Credential
-func getUserSysProcAttr(uname string) *syscall.SysProcAttr {
-	uinfo, err := user.Lookup(uname)
-	if err != nil {
-		detail := fmt.Sprintf("Unable to find user %s: %s", uname, err)
-		log.Error(detail)
-		return nil
-	} else {
-		u, _ := strconv.Atoi(uinfo.Uid)
-		g, _ := strconv.Atoi(uinfo.Gid)
-		// Unfortunately lookup GID by name is currently unsupported in Go.
-		return &syscall.SysProcAttr{
-			Credential: &syscall.Credential{
-				Uid: uint32(u),
-				Gid: uint32(g),
-			},
-			Setsid: true,
-		}
+// getUserSysProcAttr returns the attributes that run a process as the resolved user. It is
+// here because Windows does not support SysProcAttr.Credential
+func getUserSysProcAttr(u *user.ExecUser) *syscall.SysProcAttr {
+	groups := make([]uint32, len(u.Sgids))
+	for i, gid := range u.Sgids {
+		groups[i] = uint32(gid)
+	}
+
+	return &syscall.SysProcAttr{
+		Credential: &syscall.Credential{
+			Uid:    uint32(u.Uid),
+			Gid:    uint32(u.Gid),
+			Groups: groups,
+		},
+		Setsid: true,
 	}
 }
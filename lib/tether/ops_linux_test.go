@@ -17,14 +17,22 @@
 package tether
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
 	"path"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/vishvananda/netlink"
 
+	"github.com/vmware/vic/lib/dhcp"
+	"github.com/vmware/vic/lib/dhcp/client"
+	"github.com/vmware/vic/lib/system"
 	"github.com/vmware/vic/pkg/trace"
 )
 
@@ -32,6 +40,9 @@ type Interface struct {
 	netlink.LinkAttrs
 	Up    bool
 	Addrs []netlink.Addr
+
+	IPv6     bool
+	Autoconf bool
 }
 
 func (t *Interface) Attrs() *netlink.LinkAttrs {
@@ -153,6 +164,50 @@ func (t *Mocker) LinkBySlot(slot int32) (netlink.Link, error) {
 	return nil, errors.New("no such interface")
 }
 
+func (t *Mocker) LinkSetIPv6(link netlink.Link, autoconf bool) error {
+	defer trace.End(trace.Begin(fmt.Sprintf("Enabling IPv6 on %s, autoconf=%t", link.Attrs().Name, autoconf)))
+
+	iface := link.(*Interface)
+	iface.IPv6 = true
+	iface.Autoconf = autoconf
+	return nil
+}
+
+// mockDHCPClient leases a fixed DHCPv6 reply, or fails with err
+type mockDHCPClient struct {
+	reply6 *dhcp.Packet6
+	err    error
+
+	released6 bool
+}
+
+func (c *mockDHCPClient) SetTimeout(t time.Duration) {}
+
+func (c *mockDHCPClient) Request(id client.ID) (*dhcp.Packet, error) {
+	return nil, errors.New("no DHCP server")
+}
+
+func (c *mockDHCPClient) Renew(id client.ID, ack *dhcp.Packet) (*dhcp.Packet, error) {
+	return nil, errors.New("no DHCP server")
+}
+
+func (c *mockDHCPClient) Release(ack *dhcp.Packet) error {
+	return nil
+}
+
+func (c *mockDHCPClient) Request6(id client.ID) (*dhcp.Packet6, error) {
+	return c.reply6, c.err
+}
+
+func (c *mockDHCPClient) Renew6(id client.ID, reply *dhcp.Packet6) (*dhcp.Packet6, error) {
+	return c.reply6, c.err
+}
+
+func (c *mockDHCPClient) Release6(id client.ID, reply *dhcp.Packet6) error {
+	c.released6 = true
+	return nil
+}
+
 func TestSlotToPciPath(t *testing.T) {
 	var tests = []struct {
 		slot int32
@@ -180,3 +235,49 @@ func TestSlotToPciPath(t *testing.T) {
 		}
 	}
 }
+
+// mountSyscall records the arguments of mount calls
+type mountSyscall struct {
+	MockSyscall
+
+	source, target, fstype, data string
+	flags                        uintptr
+}
+
+func (m *mountSyscall) Mount(source string, target string, fstype string, flags uintptr, data string) error {
+	m.source, m.target, m.fstype, m.flags, m.data = source, target, fstype, flags, data
+	return nil
+}
+
+func TestMountTarget(t *testing.T) {
+	mock := &mountSyscall{}
+	defer func(s system.Syscall) { Sys.Syscall = s }(Sys.Syscall)
+	Sys.Syscall = mock
+
+	target, err := ioutil.TempDir("", "mount-target-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(target)
+
+	ops := &BaseOperations{}
+	source := url.URL{Scheme: "nfs", Host: "127.0.0.1", Path: "/exports/volumes/vol1", RawQuery: "vers=4"}
+	if err = ops.MountTarget(context.Background(), source, target, "ro"); err != nil {
+		t.Fatal(err)
+	}
+
+	if mock.source != "127.0.0.1:/exports/volumes/vol1" || mock.target != target || mock.fstype != "nfs" {
+		t.Errorf("unexpected mount of %s on %s as %s", mock.source, mock.target, mock.fstype)
+	}
+	if mock.flags&syscall.MS_RDONLY == 0 {
+		t.Errorf("read only volume was mounted with flags %#x", mock.flags)
+	}
+	if mock.data != "addr=127.0.0.1,vers=4" {
+		t.Errorf("unexpected mount options %q", mock.data)
+	}
+
+	source.Scheme = "label"
+	if err = ops.MountTarget(context.Background(), source, target, "rw"); err == nil {
+		t.Error("mounting a source other than an nfs export should fail")
+	}
+}
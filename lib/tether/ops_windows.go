@@ -18,9 +18,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"syscall"
 
+	"github.com/opencontainers/runc/libcontainer/user"
+
 	"github.com/vmware/vic/pkg/trace"
 )
 
@@ -42,6 +45,13 @@ func (t *BaseOperations) Apply(endpoint *NetworkEndpoint) error {
 	return errors.New("not implemented on windows")
 }
 
+// Unapply removes the network endpoint configuration from the system
+func (t *BaseOperations) Unapply(endpoint *NetworkEndpoint) error {
+	defer trace.End(trace.Begin("removing endpoint configuration for " + endpoint.Network.Name))
+
+	return errors.New("not implemented on windows")
+}
+
 // MountLabel performs a mount with the source treated as a disk label
 // This assumes that /dev/disk/by-label is being populated, probably by udev
 func (t *BaseOperations) MountLabel(ctx context.Context, label, target string) error {
@@ -50,6 +60,13 @@ func (t *BaseOperations) MountLabel(ctx context.Context, label, target string) e
 	return errors.New("not implemented on windows")
 }
 
+// MountTarget performs a mount of a network share on target
+func (t *BaseOperations) MountTarget(ctx context.Context, source url.URL, target string, mountOptions string) error {
+	defer trace.End(trace.Begin(fmt.Sprintf("Mounting %s on %s", source.String(), target)))
+
+	return errors.New("not implemented on windows")
+}
+
 // processEnvOS does OS specific checking and munging on the process environment prior to launch
 func (t *BaseOperations) ProcessEnv(env []string) []string {
 	return env
@@ -73,6 +90,6 @@ func (t *BaseOperations) Cleanup() error {
 }
 
 // Uid/Gid is not supported in Windows
-func getUserSysProcAttr(uname string) *syscall.SysProcAttr {
+func getUserSysProcAttr(u *user.ExecUser) *syscall.SysProcAttr {
 	return nil
 }
@@ -0,0 +1,134 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows,!darwin
+
+package tether
+
+import (
+	"fmt"
+	"syscall"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/vmware/vic/lib/tether/ps"
+	"github.com/vmware/vic/pkg/trace"
+)
+
+// pause freezes the process trees of all sessions with SIGSTOP. The containerVM has no cgroup
+// freezer, so processes forked while the tree is being walked are caught by walking it again.
+func (t *tether) pause() error {
+	defer trace.End(trace.Begin(""))
+
+	stopped := make(map[int]bool)
+	for {
+		pids, err := t.sessionTree()
+		if err != nil {
+			return err
+		}
+
+		count := len(stopped)
+		for _, pid := range pids {
+			if stopped[pid] {
+				continue
+			}
+
+			if err := syscall.Kill(pid, syscall.SIGSTOP); err != nil && err != syscall.ESRCH {
+				t.resume(stopped)
+				return fmt.Errorf("failed to stop process %d: %s", pid, err)
+			}
+			stopped[pid] = true
+		}
+
+		if len(stopped) == count {
+			break
+		}
+	}
+
+	log.Infof("Paused %d processes", len(stopped))
+	t.setPaused(true)
+	return nil
+}
+
+// unpause thaws the process trees of all sessions
+func (t *tether) unpause() error {
+	defer trace.End(trace.Begin(""))
+
+	pids, err := t.sessionTree()
+	if err != nil {
+		return err
+	}
+
+	resumed := make(map[int]bool)
+	for _, pid := range pids {
+		resumed[pid] = true
+	}
+	t.resume(resumed)
+
+	log.Infof("Unpaused %d processes", len(pids))
+	t.setPaused(false)
+	return nil
+}
+
+// resume sends SIGCONT to the processes
+func (t *tether) resume(pids map[int]bool) {
+	for pid := range pids {
+		if err := syscall.Kill(pid, syscall.SIGCONT); err != nil && err != syscall.ESRCH {
+			log.Warnf("Failed to continue process %d: %s", pid, err)
+		}
+	}
+}
+
+// sessionTree returns the pids of the session processes and all of their descendants.
+// Healthcheck probes are not included as they are not children of a session.
+func (t *tether) sessionTree() ([]int, error) {
+	t.config.pidMutex.Lock()
+	roots := make([]int, 0, len(t.config.pids))
+	for pid := range t.config.pids {
+		roots = append(roots, pid)
+	}
+	t.config.pidMutex.Unlock()
+
+	processes, _, err := ps.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %s", err)
+	}
+
+	children := make(map[int][]int)
+	for _, p := range processes {
+		children[p.PPID] = append(children[p.PPID], p.PID)
+	}
+
+	return descendants(roots, children), nil
+}
+
+// descendants returns the roots and all of their descendants, given the children of each pid
+func descendants(roots []int, children map[int][]int) []int {
+	var pids []int
+	seen := make(map[int]bool)
+
+	for len(roots) > 0 {
+		pid := roots[0]
+		roots = roots[1:]
+		if seen[pid] {
+			continue
+		}
+		seen[pid] = true
+
+		pids = append(pids, pid)
+		roots = append(roots, children[pid]...)
+	}
+
+	return pids
+}
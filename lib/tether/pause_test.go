@@ -0,0 +1,101 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package tether
+
+import (
+	"os/exec"
+	"sort"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware/vic/lib/tether/ps"
+	"github.com/vmware/vic/pkg/vsphere/extraconfig"
+)
+
+func TestDescendants(t *testing.T) {
+	children := map[int][]int{
+		1:  {10, 11},
+		10: {20},
+		20: {30},
+		40: {41},
+	}
+
+	pids := descendants([]int{10, 11}, children)
+	sort.Ints(pids)
+	assert.Equal(t, []int{10, 11, 20, 30}, pids)
+
+	assert.Equal(t, []int{50}, descendants([]int{50}, children))
+}
+
+func TestPause(t *testing.T) {
+	store := make(map[string]string)
+	tthr := New(nil, extraconfig.MapSink(store), nil).(*tether)
+
+	// a session with a child of its own
+	cmd := exec.Command("/bin/sh", "-c", "sleep 30 & wait")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill()
+	tthr.config.pids[cmd.Process.Pid] = &SessionConfig{}
+
+	// wait for the child to appear
+	var pids []int
+	for i := 0; i < 50 && len(pids) < 2; i++ {
+		time.Sleep(100 * time.Millisecond)
+		var err error
+		if pids, err = tthr.sessionTree(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !assert.Len(t, pids, 2) {
+		return
+	}
+	defer func() {
+		for _, pid := range pids {
+			syscall.Kill(pid, syscall.SIGKILL)
+		}
+	}()
+
+	states := func() []string {
+		processes, _, err := ps.List()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var states []string
+		for _, p := range processes {
+			for _, pid := range pids {
+				if p.PID == pid {
+					states = append(states, p.State)
+				}
+			}
+		}
+		return states
+	}
+
+	assert.NoError(t, tthr.pause())
+	assert.True(t, tthr.isPaused())
+	assert.Equal(t, []string{"T", "T"}, states())
+
+	assert.NoError(t, tthr.unpause())
+	assert.False(t, tthr.isPaused())
+	assert.NotContains(t, states(), "T")
+}
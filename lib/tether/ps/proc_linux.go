@@ -0,0 +1,228 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ps
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	procRoot = "/proc"
+
+	// clockTicks is USER_HZ, which is fixed at 100 on the architectures we run on
+	clockTicks = 100
+
+	// kthreadd is the parent of all kernel threads
+	kthreadd = 2
+)
+
+// List returns the user processes running in the containerVM, omitting kernel threads
+func List() ([]*Process, *System, error) {
+	boot, err := bootTime()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sys := &System{
+		Now:      time.Now(),
+		MemTotal: memTotal(),
+		Users:    users("/etc/passwd"),
+	}
+
+	entries, err := ioutil.ReadDir(procRoot)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var processes []*Process
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		p, err := readProcess(pid, boot)
+		if err != nil {
+			// the process may have exited since the directory was read
+			continue
+		}
+
+		if p.PID == kthreadd || p.PPID == kthreadd {
+			continue
+		}
+		processes = append(processes, p)
+	}
+
+	return processes, sys, nil
+}
+
+func readProcess(pid int, boot time.Time) (*Process, error) {
+	dir := filepath.Join(procRoot, strconv.Itoa(pid))
+
+	stat, err := ioutil.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := parseStat(stat, boot)
+	if err != nil {
+		return nil, err
+	}
+
+	if cmdline, err := ioutil.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		cmdline = bytes.TrimRight(cmdline, "\x00")
+		if len(cmdline) > 0 {
+			p.Args = strings.Split(string(cmdline), "\x00")
+		}
+	}
+
+	if status, err := ioutil.ReadFile(filepath.Join(dir, "status")); err == nil {
+		p.UID = parseUID(status)
+	}
+
+	return p, nil
+}
+
+// parseStat extracts the process details from the content of /proc/<pid>/stat
+func parseStat(stat []byte, boot time.Time) (*Process, error) {
+	// the command is in parentheses and may itself contain spaces and parentheses
+	open := bytes.IndexByte(stat, '(')
+	closing := bytes.LastIndexByte(stat, ')')
+	if open < 0 || closing < open {
+		return nil, fmt.Errorf("unexpected stat format: %s", stat)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(stat[:open])))
+	if err != nil {
+		return nil, err
+	}
+
+	// fields from the state onwards, so fields[0] is field 3 in proc(5)
+	fields := strings.Fields(string(stat[closing+1:]))
+	if len(fields) < 22 {
+		return nil, fmt.Errorf("unexpected stat format: %s", stat)
+	}
+
+	num := func(i int) uint64 {
+		n, _ := strconv.ParseUint(fields[i], 10, 64)
+		return n
+	}
+
+	ppid, _ := strconv.Atoi(fields[1])
+	tty, _ := strconv.Atoi(fields[4])
+	ticks := num(11) + num(12)
+	start := num(19)
+
+	return &Process{
+		PID:     pid,
+		PPID:    ppid,
+		State:   fields[0],
+		TTY:     tty,
+		Command: string(stat[open+1 : closing]),
+		CPUTime: time.Duration(ticks) * time.Second / clockTicks,
+		Start:   boot.Add(time.Duration(start) * time.Second / clockTicks),
+		VSZ:     num(20),
+		RSS:     num(21) * uint64(os.Getpagesize()),
+	}, nil
+}
+
+// parseUID returns the effective uid from the content of /proc/<pid>/status
+func parseUID(status []byte) int {
+	scanner := bufio.NewScanner(bytes.NewReader(status))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 2 && fields[0] == "Uid:" {
+			uid, _ := strconv.Atoi(fields[2])
+			return uid
+		}
+	}
+	return 0
+}
+
+func bootTime() (time.Time, error) {
+	f, err := os.Open(filepath.Join(procRoot, "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "btime" {
+			secs, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Unix(secs, 0), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unable to determine boot time")
+}
+
+func memTotal() uint64 {
+	f, err := os.Open(filepath.Join(procRoot, "meminfo"))
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, _ := strconv.ParseUint(fields[1], 10, 64)
+			return kb * 1024
+		}
+	}
+	return 0
+}
+
+// users returns the user names from the passwd file at path, keyed by uid
+func users(path string) map[int]string {
+	names := make(map[int]string)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return names
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 3 {
+			continue
+		}
+		uid, err := strconv.Atoi(fields[2])
+		if err != nil {
+			continue
+		}
+		if _, ok := names[uid]; !ok {
+			names[uid] = fields[0]
+		}
+	}
+
+	return names
+}
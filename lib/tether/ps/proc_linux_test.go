@@ -0,0 +1,64 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ps
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStat(t *testing.T) {
+	boot := time.Unix(1000, 0)
+	stat := "42 (my (odd) cmd) S 1 42 42 34816 42 4194304 100 0 0 0 250 50 0 0 20 0 1 0 500 4096000 300 18446744073709551615"
+
+	p, err := parseStat([]byte(stat), boot)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 42, p.PID)
+	assert.Equal(t, 1, p.PPID)
+	assert.Equal(t, "S", p.State)
+	assert.Equal(t, "my (odd) cmd", p.Command)
+	assert.Equal(t, "pts/0", TTYName(p.TTY))
+	assert.Equal(t, 3*time.Second, p.CPUTime)
+	assert.Equal(t, boot.Add(5*time.Second), p.Start)
+	assert.Equal(t, uint64(4096000), p.VSZ)
+	assert.Equal(t, uint64(300*os.Getpagesize()), p.RSS)
+
+	_, err = parseStat([]byte("42 (truncated) S 1"), boot)
+	assert.Error(t, err)
+}
+
+func TestList(t *testing.T) {
+	processes, sys, err := List()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NotZero(t, sys.MemTotal)
+
+	for _, p := range processes {
+		if p.PID == os.Getpid() {
+			assert.Equal(t, os.Getppid(), p.PPID)
+			assert.NotEmpty(t, p.Args)
+			return
+		}
+	}
+	t.Errorf("test process %d not listed", os.Getpid())
+}
@@ -0,0 +1,286 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ps produces a ps style listing of the processes in a containerVM. containerVMs
+// do not necessarily have a ps binary, so the listing is generated by tether directly.
+package ps
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Process describes a process running in the containerVM
+type Process struct {
+	PID  int
+	PPID int
+	UID  int
+
+	// State is the single character process state, e.g. R or S
+	State string
+	// TTY is the controlling terminal device number
+	TTY int
+
+	// Command is the executable name and Args the full command line
+	Command string
+	Args    []string
+
+	CPUTime time.Duration
+	Start   time.Time
+
+	// VSZ and RSS are in bytes
+	VSZ uint64
+	RSS uint64
+}
+
+// System holds the containerVM wide figures that processes are reported against
+type System struct {
+	Now      time.Time
+	MemTotal uint64
+	Users    map[int]string
+}
+
+type column struct {
+	title string
+	value func(p *Process, s *System) string
+}
+
+var columns = map[string]column{
+	"pid":     {"PID", func(p *Process, s *System) string { return strconv.Itoa(p.PID) }},
+	"ppid":    {"PPID", func(p *Process, s *System) string { return strconv.Itoa(p.PPID) }},
+	"uid":     {"UID", func(p *Process, s *System) string { return strconv.Itoa(p.UID) }},
+	"user":    {"USER", user},
+	"c":       {"C", func(p *Process, s *System) string { return fmt.Sprintf("%d", int(cpuPercent(p, s))) }},
+	"%cpu":    {"%CPU", func(p *Process, s *System) string { return fmt.Sprintf("%.1f", cpuPercent(p, s)) }},
+	"%mem":    {"%MEM", memPercent},
+	"vsz":     {"VSZ", func(p *Process, s *System) string { return strconv.FormatUint(p.VSZ/1024, 10) }},
+	"rss":     {"RSS", func(p *Process, s *System) string { return strconv.FormatUint(p.RSS/1024, 10) }},
+	"tty":     {"TTY", func(p *Process, s *System) string { return TTYName(p.TTY) }},
+	"stat":    {"STAT", func(p *Process, s *System) string { return p.State }},
+	"stime":   {"STIME", startTime},
+	"start":   {"START", startTime},
+	"time":    {"TIME", func(p *Process, s *System) string { return cumulative(p.CPUTime) }},
+	"etime":   {"ELAPSED", func(p *Process, s *System) string { return elapsed(s.Now.Sub(p.Start)) }},
+	"comm":    {"COMMAND", func(p *Process, s *System) string { return p.Command }},
+	"cmd":     {"CMD", commandLine},
+	"args":    {"COMMAND", commandLine},
+	"command": {"COMMAND", commandLine},
+}
+
+// aliases for the column keywords accepted by -o
+var aliases = map[string]string{
+	"pcpu": "%cpu",
+	"pmem": "%mem",
+	"tt":   "tty",
+	"ucmd": "comm",
+}
+
+var (
+	defaultFormat = []string{"pid", "tty", "time", "cmd"}
+	fullFormat    = []string{"uid", "pid", "ppid", "c", "stime", "tty", "time", "cmd"}
+	userFormat    = []string{"user", "pid", "%cpu", "%mem", "vsz", "rss", "tty", "stat", "start", "time", "command"}
+)
+
+// Format returns the columns selected by the supplied ps arguments. Process selection
+// options are accepted but have no effect as every process in the containerVM is listed.
+func Format(args []string) ([]string, error) {
+	format := defaultFormat
+	var custom []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(arg, "-") {
+			// BSD style options
+			for _, opt := range arg {
+				switch opt {
+				case 'a', 'x', 'e':
+				case 'u':
+					format = userFormat
+				default:
+					return nil, fmt.Errorf("unsupported ps option %q", opt)
+				}
+			}
+			continue
+		}
+
+		opts := arg[1:]
+		for j, opt := range opts {
+			switch opt {
+			case 'e', 'A', 'a', 'x':
+			case 'f':
+				format = fullFormat
+			case 'o':
+				list := opts[j+1:]
+				if list == "" {
+					i++
+					if i == len(args) {
+						return nil, fmt.Errorf("format specification must follow -o")
+					}
+					list = args[i]
+				}
+
+				for _, name := range strings.Split(list, ",") {
+					name = strings.ToLower(name)
+					if alias, ok := aliases[name]; ok {
+						name = alias
+					}
+					if _, ok := columns[name]; !ok {
+						return nil, fmt.Errorf("unsupported ps format keyword %q", name)
+					}
+					custom = append(custom, name)
+				}
+			default:
+				return nil, fmt.Errorf("unsupported ps option %q", opt)
+			}
+
+			if opt == 'o' {
+				break
+			}
+		}
+	}
+
+	if len(custom) > 0 {
+		return custom, nil
+	}
+	return format, nil
+}
+
+// Rows returns the column titles and the values of those columns for each of the processes
+func Rows(format []string, processes []*Process, sys *System) ([]string, [][]string) {
+	titles := make([]string, len(format))
+	for i, name := range format {
+		titles[i] = columns[name].title
+	}
+
+	rows := make([][]string, 0, len(processes))
+	for _, p := range processes {
+		values := make([]string, len(format))
+		for i, name := range format {
+			values[i] = columns[name].value(p, sys)
+		}
+		rows = append(rows, values)
+	}
+
+	return titles, rows
+}
+
+// Top returns a ps style listing of the processes in the containerVM as column titles and
+// one row of values per process. Processes for which exclude returns true are omitted.
+func Top(args []string, exclude func(p *Process) bool) ([]string, [][]string, error) {
+	format, err := Format(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	processes, sys, err := List()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var listed []*Process
+	for _, p := range processes {
+		if exclude == nil || !exclude(p) {
+			listed = append(listed, p)
+		}
+	}
+
+	titles, rows := Rows(format, listed, sys)
+	return titles, rows, nil
+}
+
+// TTYName returns the name of the terminal with the given device number
+func TTYName(dev int) string {
+	major := (dev >> 8) & 0xfff
+	minor := (dev & 0xff) | ((dev >> 12) & 0xfff00)
+
+	switch {
+	case dev == 0:
+		return "?"
+	case major >= 136 && major <= 143:
+		return fmt.Sprintf("pts/%d", (major-136)*256+minor)
+	case major == 4 && minor < 64:
+		return fmt.Sprintf("tty%d", minor)
+	case major == 4:
+		return fmt.Sprintf("ttyS%d", minor-64)
+	default:
+		return "?"
+	}
+}
+
+func user(p *Process, s *System) string {
+	if name, ok := s.Users[p.UID]; ok {
+		return name
+	}
+	return strconv.Itoa(p.UID)
+}
+
+func commandLine(p *Process, s *System) string {
+	if len(p.Args) == 0 {
+		return fmt.Sprintf("[%s]", p.Command)
+	}
+	return strings.Join(p.Args, " ")
+}
+
+func cpuPercent(p *Process, s *System) float64 {
+	lifetime := s.Now.Sub(p.Start)
+	if lifetime <= 0 {
+		return 0
+	}
+	return float64(p.CPUTime) / float64(lifetime) * 100
+}
+
+func memPercent(p *Process, s *System) string {
+	if s.MemTotal == 0 {
+		return "0.0"
+	}
+	return fmt.Sprintf("%.1f", float64(p.RSS)/float64(s.MemTotal)*100)
+}
+
+func startTime(p *Process, s *System) string {
+	if p.Start.YearDay() == s.Now.YearDay() && p.Start.Year() == s.Now.Year() {
+		return p.Start.Format("15:04")
+	}
+	return p.Start.Format("Jan02")
+}
+
+// cumulative formats a duration as [DD-]HH:MM:SS
+func cumulative(d time.Duration) string {
+	secs := int(d.Seconds())
+	days := secs / 86400
+	secs %= 86400
+
+	clock := fmt.Sprintf("%02d:%02d:%02d", secs/3600, (secs%3600)/60, secs%60)
+	if days > 0 {
+		return fmt.Sprintf("%d-%s", days, clock)
+	}
+	return clock
+}
+
+// elapsed formats a duration as [[DD-]HH:]MM:SS
+func elapsed(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	if d < time.Hour {
+		secs := int(d.Seconds())
+		return fmt.Sprintf("%02d:%02d", secs/60, secs%60)
+	}
+	return cumulative(d)
+}
@@ -0,0 +1,95 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ps
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		args   []string
+		format []string
+	}{
+		{nil, defaultFormat},
+		{[]string{"-ef"}, fullFormat},
+		{[]string{"-e", "-f"}, fullFormat},
+		{[]string{"aux"}, userFormat},
+		{[]string{"-o", "pid,comm"}, []string{"pid", "comm"}},
+		{[]string{"-eopid,PCPU"}, []string{"pid", "%cpu"}},
+	}
+
+	for _, test := range tests {
+		format, err := Format(test.args)
+		if assert.NoError(t, err, "args: %v", test.args) {
+			assert.Equal(t, test.format, format, "args: %v", test.args)
+		}
+	}
+
+	for _, args := range [][]string{{"-L"}, {"-o"}, {"-o", "pid,wchan"}, {"auxw"}} {
+		_, err := Format(args)
+		assert.Error(t, err, "args: %v", args)
+	}
+}
+
+func TestRows(t *testing.T) {
+	now := time.Now()
+	sys := &System{
+		Now:      now,
+		MemTotal: 1024 * 1024 * 1024,
+		Users:    map[int]string{0: "root"},
+	}
+
+	processes := []*Process{
+		{
+			PID:     12,
+			PPID:    1,
+			State:   "S",
+			TTY:     136<<8 | 1,
+			Command: "sh",
+			Args:    []string{"/bin/sh", "-c", "sleep 1000"},
+			CPUTime: 90 * time.Second,
+			Start:   now.Add(-3 * time.Minute),
+			RSS:     10 * 1024 * 1024,
+		},
+		{
+			PID:     13,
+			PPID:    12,
+			UID:     1000,
+			State:   "R",
+			Command: "sleep",
+			Start:   now,
+		},
+	}
+
+	titles, rows := Rows(userFormat, processes, sys)
+	assert.Equal(t, []string{"USER", "PID", "%CPU", "%MEM", "VSZ", "RSS", "TTY", "STAT", "START", "TIME", "COMMAND"}, titles)
+	if assert.Len(t, rows, 2) {
+		assert.Equal(t, []string{"root", "12", "50.0", "1.0", "0", "10240", "pts/1", "S", now.Add(-3 * time.Minute).Format("15:04"), "00:01:30", "/bin/sh -c sleep 1000"}, rows[0])
+		assert.Equal(t, "1000", rows[1][0])
+		assert.Equal(t, "[sleep]", rows[1][10])
+	}
+}
+
+func TestTTYName(t *testing.T) {
+	assert.Equal(t, "?", TTYName(0))
+	assert.Equal(t, "pts/0", TTYName(136<<8))
+	assert.Equal(t, "pts/257", TTYName(137<<8|1))
+	assert.Equal(t, "tty1", TTYName(4<<8|1))
+	assert.Equal(t, "ttyS0", TTYName(4<<8|64))
+}
@@ -0,0 +1,283 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scp
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/pkg/symlink"
+)
+
+// ErrNotDirectory is returned when the extraction point of an archive is not a directory
+var ErrNotDirectory = fmt.Errorf("extraction point is not a directory")
+
+// Archive writes a tar stream of the file or directory at path to w. Entries are named
+// relative to the parent of path so the archive has a single top level entry, matching
+// the layout docker cp expects.
+func Archive(w io.Writer, path string) error {
+	path = filepath.Clean(path)
+	base := filepath.Dir(path)
+
+	tw := tar.NewWriter(w)
+
+	err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		}
+
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+
+		name, err := filepath.Rel(base, file)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(name)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// ResolvePath returns the location of path within the filesystem rooted at root. Symlinks in
+// the parent directories of path are resolved as they would be from inside that filesystem, so
+// an absolute link cannot lead out of root, while a symlink in the final component is left for
+// the caller to handle.
+func ResolvePath(root, path string) (string, error) {
+	path = filepath.Clean("/" + path)
+	if path == "/" {
+		return filepath.Clean(root), nil
+	}
+
+	parent, err := symlink.FollowSymlinkInScope(filepath.Join(root, filepath.Dir(path)), root)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(parent, filepath.Base(path)), nil
+}
+
+// inScope returns true if path is root or a descendant of it
+func inScope(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
+
+// Extract unpacks the tar stream read from r into the directory dir of the filesystem rooted
+// at root. Symlinks are resolved within root, whether they were already present or created by
+// earlier entries of the archive. If noOverwriteDirNonDir is set it is an error for an entry to
+// replace an existing directory with a non-directory or vice versa.
+func Extract(r io.Reader, root, dir string, noOverwriteDirNonDir bool) error {
+	root = filepath.Clean(root)
+
+	dir, err := symlink.FollowSymlinkInScope(filepath.Join(root, filepath.Clean("/"+dir)), root)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return ErrNotDirectory
+	}
+
+	// entry returns the location of the named archive entry, rejecting entries that would
+	// escape the extraction point or whose parent resolves outside of root
+	entry := func(name string) (string, error) {
+		name = filepath.Clean("/" + name)
+		if name == "/" {
+			return dir, nil
+		}
+
+		parent, err := symlink.FollowSymlinkInScope(filepath.Join(dir, filepath.Dir(name)), root)
+		if err != nil {
+			return "", err
+		}
+		if !inScope(root, parent) {
+			return "", fmt.Errorf("archive entry %s is outside of %s", name, root)
+		}
+
+		return filepath.Join(parent, filepath.Base(name)), nil
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := entry(hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		if existing, err := os.Lstat(target); err == nil {
+			isDir := hdr.Typeflag == tar.TypeDir
+			if noOverwriteDirNonDir && existing.IsDir() != isDir {
+				return fmt.Errorf("cannot overwrite %s with %s", describe(existing.IsDir()), describe(isDir))
+			}
+
+			// directories are merged, everything else is replaced
+			if !(existing.IsDir() && isDir) {
+				if err = os.RemoveAll(target); err != nil {
+					return err
+				}
+			}
+		}
+
+		var link string
+		if hdr.Typeflag == tar.TypeLink {
+			if link, err = entry(hdr.Linkname); err != nil {
+				return err
+			}
+		}
+
+		if err = extractEntry(tr, hdr, target, link); err != nil {
+			return err
+		}
+	}
+}
+
+func describe(dir bool) string {
+	if dir {
+		return "directory"
+	}
+	return "non-directory"
+}
+
+func extractEntry(r io.Reader, hdr *tar.Header, target, link string) error {
+	mode := os.FileMode(hdr.Mode).Perm()
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if err := os.MkdirAll(target, mode); err != nil {
+			return err
+		}
+	case tar.TypeReg, tar.TypeRegA:
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, r)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	case tar.TypeSymlink:
+		return os.Symlink(hdr.Linkname, target)
+	case tar.TypeLink:
+		return os.Link(link, target)
+	default:
+		// devices, fifos and the like are not supported by docker cp either
+		return nil
+	}
+
+	// the umask may have stripped bits from the requested mode
+	if err := os.Chmod(target, mode); err != nil {
+		return err
+	}
+
+	os.Lchown(target, hdr.Uid, hdr.Gid)
+	return os.Chtimes(target, hdr.AccessTime, hdr.ModTime)
+}
+
+// ArchiveSource prepares to stream a tar archive of path over the request channel
+func (scp *Request) ArchiveSource(path string) (ok bool, payload []byte) {
+	if _, err := os.Lstat(path); err != nil {
+		return false, []byte(err.Error())
+	}
+
+	scp.pendingFn = func() {
+		defer scp.ch.Close()
+
+		if err := Archive(scp.ch, path); err != nil {
+			fmt.Fprintf(scp.ch.Stderr(), "%s", err)
+			return
+		}
+
+		scp.ch.CloseWrite()
+	}
+	return true, nil
+}
+
+// ArchiveDestination prepares to extract a tar archive read from the request channel into
+// the directory at path
+func (scp *Request) ArchiveDestination(path string, noOverwriteDirNonDir bool) (ok bool, payload []byte) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, []byte(err.Error())
+	}
+	if !info.IsDir() {
+		return false, []byte(ErrNotDirectory.Error())
+	}
+
+	scp.pendingFn = func() {
+		defer scp.ch.Close()
+
+		if err := Extract(scp.ch, "/", path, noOverwriteDirNonDir); err != nil {
+			fmt.Fprintf(scp.ch.Stderr(), "%s", err)
+			return
+		}
+
+		scp.ch.CloseWrite()
+	}
+	return true, nil
+}
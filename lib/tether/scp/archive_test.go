@@ -0,0 +1,206 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scp
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiveExtract(t *testing.T) {
+	src, err := ioutil.TempDir("", "archive-src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	dst, err := ioutil.TempDir("", "archive-dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+
+	dir := filepath.Join(src, "data")
+	if err = os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "sub", "file"), []byte("contents"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Symlink("sub/file", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = Archive(&buf, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = Extract(&buf, dst, "/", false); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(dst, "data", "sub", "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != "contents" {
+		t.Errorf("unexpected file contents: %q", contents)
+	}
+
+	info, err := os.Stat(filepath.Join(dst, "data", "sub", "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("unexpected file mode: %s", info.Mode())
+	}
+
+	link, err := os.Readlink(filepath.Join(dst, "data", "link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link != "sub/file" {
+		t.Errorf("unexpected link target: %s", link)
+	}
+}
+
+func TestExtractNoOverwriteDirNonDir(t *testing.T) {
+	src, err := ioutil.TempDir("", "archive-src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	dst, err := ioutil.TempDir("", "archive-dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+
+	if err = ioutil.WriteFile(filepath.Join(src, "data"), []byte("contents"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Mkdir(filepath.Join(dst, "data"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = Archive(&buf, filepath.Join(src, "data")); err != nil {
+		t.Fatal(err)
+	}
+	archive := buf.Bytes()
+
+	if err = Extract(bytes.NewReader(archive), dst, "/", true); err == nil {
+		t.Fatal("expected directory to be protected from replacement")
+	}
+
+	// without the restriction the directory is replaced
+	if err = Extract(bytes.NewReader(archive), dst, "/", false); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dst, "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.IsDir() {
+		t.Error("expected directory to be replaced by file")
+	}
+
+	// the extraction point itself must be a directory
+	if err = Extract(bytes.NewReader(archive), dst, "/data", false); err != ErrNotDirectory {
+		t.Errorf("expected %s, got %v", ErrNotDirectory, err)
+	}
+}
+
+func TestExtractSymlinkInScope(t *testing.T) {
+	root, err := ioutil.TempDir("", "archive-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	outside, err := ioutil.TempDir("", "archive-outside")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outside)
+
+	// an absolute link in the filesystem, such as /var/run -> /run in an image
+	if err = os.MkdirAll(filepath.Join(root, "var"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Symlink(outside, filepath.Join(root, "var", "run")); err != nil {
+		t.Fatal(err)
+	}
+
+	// an archive that adds its own absolute link and then writes through it
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	entries := []struct {
+		hdr  tar.Header
+		body string
+	}{
+		{tar.Header{Name: "x", Typeflag: tar.TypeSymlink, Linkname: outside, Mode: 0777}, ""},
+		{tar.Header{Name: "x/file", Typeflag: tar.TypeReg, Mode: 0644, Size: 4}, "evil"},
+		{tar.Header{Name: "var/run/file", Typeflag: tar.TypeReg, Mode: 0644, Size: 4}, "evil"},
+	}
+	for _, e := range entries {
+		hdr := e.hdr
+		if err = tw.WriteHeader(&hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = Extract(&buf, root, "/", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = os.Stat(filepath.Join(outside, "file")); !os.IsNotExist(err) {
+		t.Fatalf("archive wrote outside of the root filesystem: %v", err)
+	}
+
+	// both links are resolved within the root filesystem
+	if _, err = os.Stat(filepath.Join(root, outside, "file")); err != nil {
+		t.Error(err)
+	}
+
+	path, err := ResolvePath(root, "/var/run/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(root, outside, "file") {
+		t.Errorf("unexpected resolved path: %s", path)
+	}
+
+	// the final component is not followed
+	path, err = ResolvePath(root, "/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(root, "x") {
+		t.Errorf("unexpected resolved path: %s", path)
+	}
+}
@@ -33,6 +33,7 @@ import (
 
 	"github.com/vmware/vic/lib/system"
 	"github.com/vmware/vic/pkg/dio"
+	"github.com/vmware/vic/pkg/fs"
 	"github.com/vmware/vic/pkg/serial"
 	"github.com/vmware/vic/pkg/trace"
 	"github.com/vmware/vic/pkg/vsphere/extraconfig"
@@ -58,6 +59,15 @@ type tether struct {
 	// config holds the main configuration for the executor
 	config *ExecutorConfig
 
+	// the name the hostname was last set for and the network endpoints that have been applied,
+	// so that a reload only repeats the setup for what has changed
+	hostname string
+	applied  map[string]bool
+
+	// the mount points of the volumes that have been mounted, so that a reload does not mount
+	// them again
+	mounted map[string]bool
+
 	// a set of extensions that get to operate on the config
 	extensions map[string]Extension
 
@@ -73,9 +83,12 @@ func New(src extraconfig.DataSource, sink extraconfig.DataSink, ops Operations)
 		ops:    ops,
 		reload: make(chan bool, 1),
 		config: &ExecutorConfig{
-			pids: make(map[int]*SessionConfig),
+			pids:   make(map[int]*SessionConfig),
+			probes: make(map[int]chan int),
 		},
 		extensions: make(map[string]Extension),
+		applied:    make(map[string]bool),
+		mounted:    make(map[string]bool),
 		src:        src,
 		sink:       sink,
 		incoming:   make(chan os.Signal, 32),
@@ -101,6 +114,23 @@ func (t *tether) lenChildPid() int {
 	return len(t.config.pids)
 }
 
+// setPaused records the paused state in the config and publishes it
+func (t *tether) setPaused(paused bool) {
+	t.config.pidMutex.Lock()
+	t.config.Paused = paused
+	t.config.pidMutex.Unlock()
+
+	extraconfig.Encode(t.sink, t.config)
+}
+
+// isPaused returns true if the sessions are paused
+func (t *tether) isPaused() bool {
+	t.config.pidMutex.Lock()
+	defer t.config.pidMutex.Unlock()
+
+	return t.config.Paused
+}
+
 func (t *tether) setup() error {
 	defer trace.End(trace.Begin("main tether setup"))
 
@@ -116,7 +146,8 @@ func (t *tether) setup() error {
 
 	t.reload = make(chan bool, 1)
 	t.config = &ExecutorConfig{
-		pids: make(map[int]*SessionConfig),
+		pids:   make(map[int]*SessionConfig),
+		probes: make(map[int]chan int),
 	}
 
 	if err := t.childReaper(); err != nil {
@@ -124,6 +155,8 @@ func (t *tether) setup() error {
 		return err
 	}
 
+	go t.reloadHandler()
+
 	if err := t.ops.Setup(t); err != nil {
 		log.Errorf("Failed tether setup: %s", err)
 		return err
@@ -173,6 +206,41 @@ func (t *tether) cleanup() {
 	t.ops.Cleanup()
 }
 
+// removedEndpoints drops the network endpoints that are no longer in the config and returns
+// them. Decoding in place merges maps, so removed endpoints would otherwise be kept.
+func (t *tether) removedEndpoints() map[string]*NetworkEndpoint {
+	var current struct {
+		Networks map[string]*NetworkEndpoint `vic:"0.1" scope:"read-only" key:"networks"`
+	}
+	extraconfig.Decode(t.src, &current)
+
+	removed := make(map[string]*NetworkEndpoint)
+	for name, endpoint := range t.config.Networks {
+		if _, ok := current.Networks[name]; !ok {
+			removed[name] = endpoint
+			delete(t.config.Networks, name)
+		}
+	}
+
+	return removed
+}
+
+// removeExecs drops the exec sessions that are no longer in the config, so that they are not
+// written back to it. As with endpoints, decoding in place would otherwise keep them.
+func (t *tether) removeExecs() {
+	var current struct {
+		Execs map[string]*SessionConfig `vic:"0.1" scope:"read-only" key:"execs"`
+	}
+	extraconfig.Decode(t.src, &current)
+
+	for id := range t.config.Execs {
+		if _, ok := current.Execs[id]; !ok {
+			log.Debugf("Dropping removed exec session %s", id)
+			delete(t.config.Execs, id)
+		}
+	}
+}
+
 func (t *tether) Start() error {
 	defer trace.End(trace.Begin("main tether loop"))
 
@@ -181,10 +249,21 @@ func (t *tether) Start() error {
 
 	// initial entry, so seed this
 	t.reload <- true
-	for range t.reload {
+	for first := true; ; first = false {
+		if _, ok := <-t.reload; !ok {
+			break
+		}
+
 		log.Info("Loading main configuration")
 		// load the config - this modifies the structure values in place
 		extraconfig.Decode(t.src, t.config)
+		removed := t.removedEndpoints()
+		t.removeExecs()
+
+		// processes are not frozen when the containerVM boots
+		if t.lenChildPid() == 0 {
+			t.config.Paused = false
+		}
 
 		// TODO: move all of this into an extension.Pre() block when we move to that model
 		// adjust the logging level appropriately
@@ -212,34 +291,84 @@ func (t *tether) Start() error {
 			short = short[:shortLen]
 		}
 
-		if err := t.ops.SetHostname(short, t.config.Name); err != nil {
-			detail := fmt.Sprintf("failed to set hostname: %s", err)
-			log.Error(detail)
-			// we don't attempt to recover from this - it's a fundamental misconfiguration
-			// so just exit
-			return errors.New(detail)
+		// the hostname only needs setting again if the container has been renamed
+		if first || t.config.Name != t.hostname {
+			if err := t.ops.SetHostname(short, t.config.Name); err != nil {
+				detail := fmt.Sprintf("failed to set hostname: %s", err)
+				log.Error(detail)
+				// we don't attempt to recover from this - it's a fundamental misconfiguration
+				// so just exit
+				if first {
+					return errors.New(detail)
+				}
+			} else {
+				t.hostname = t.config.Name
+			}
+		}
+
+		// remove the endpoints that are no longer configured before applying the remainder, as
+		// they may share a NIC or nameservers with the removed endpoints
+		for name, v := range removed {
+			log.Infof("Removing network endpoint %s", name)
+			delete(t.applied, name)
+			if err := t.ops.Unapply(v); err != nil {
+				log.Errorf("failed to remove network endpoint config for %s: %s", name, err)
+			}
 		}
 
-		// process the networks then publish any dynamic data
-		for _, v := range t.config.Networks {
+		// process the networks that have yet to be applied then publish any dynamic data
+		for name, v := range t.config.Networks {
+			if t.applied[name] {
+				continue
+			}
+
 			if err := t.ops.Apply(v); err != nil {
 				detail := fmt.Sprintf("failed to apply network endpoint config: %s", err)
 				log.Error(detail)
-				return errors.New(detail)
+				if first {
+					return errors.New(detail)
+				}
+				continue
 			}
+			t.applied[name] = true
 		}
 		extraconfig.Encode(t.sink, t.config)
 
-		//process the filesystem mounts - this is performed after networks to allow for network mounts
+		//process the filesystem mounts - this is performed after networks to allow for network mounts.
+		// Each volume is mounted once; a reload only retries those that are not yet mounted.
 		for k, v := range t.config.Mounts {
-			if v.Source.Scheme != "label" {
+			if t.mounted[v.Path] {
+				continue
+			}
+
+			switch v.Source.Scheme {
+			case "label":
+				// this could block indefinitely while waiting for a volume to present
+				if err := t.ops.MountLabel(context.Background(), v.Source.Path, v.Path); err != nil {
+					log.Errorf("failed to mount volume %s: %s", k, err)
+					continue
+				}
+			case fs.NFSScheme:
+				// a container must not start against the empty mount point if the export is
+				// unavailable, but a running container is not stopped if a retry fails
+				if err := t.ops.MountTarget(context.Background(), v.Source, v.Path, v.Mode); err != nil {
+					detail := fmt.Sprintf("failed to mount volume %s: %s", k, err)
+					log.Error(detail)
+					if first {
+						return errors.New(detail)
+					}
+					continue
+				}
+			default:
 				detail := fmt.Sprintf("unsupported volume mount type for %s: %s", k, v.Source.Scheme)
 				log.Error(detail)
-				return errors.New(detail)
+				if first {
+					return errors.New(detail)
+				}
+				continue
 			}
 
-			// this could block indefinitely while waiting for a volume to present
-			t.ops.MountLabel(context.Background(), v.Source.Path, v.Path)
+			t.mounted[v.Path] = true
 		}
 
 		// process the sessions and launch if needed
@@ -247,6 +376,10 @@ func (t *tether) Start() error {
 			log.Debugf("Processing config for session %s", session.ID)
 			var proc = session.Cmd.Process
 
+			// FIXME: we cannot have this embedded knowledge of the extraconfig encoding pattern, but not
+			// currently sure how to expose it neatly via a utility function
+			session.prefix = fmt.Sprintf("guestinfo.vice..sessions|%s", session.ID)
+
 			// check if session is alive and well
 			if proc != nil && proc.Signal(syscall.Signal(0)) == nil {
 				log.Debugf("Process for session %s is already running (pid: %d)", session.ID, proc.Pid)
@@ -261,9 +394,7 @@ func (t *tether) Start() error {
 					session.m.Lock()
 					session.Diagnostics.ResurrectionCount++
 
-					// FIXME: we cannot have this embedded knowledge of the extraconfig encoding pattern, but not
-					// currently sure how to expose it neatly via a utility function
-					extraconfig.EncodeWithPrefix(t.sink, session, fmt.Sprintf("guestinfo.vice..sessions|%s", session.ID))
+					extraconfig.EncodeWithPrefix(t.sink, session, session.prefix)
 					log.Warnf("Re-launching process for session %s (count: %d)", session.ID, session.Diagnostics.ResurrectionCount)
 					session.Cmd = *restartableCmd(&session.Cmd)
 					session.m.Unlock()
@@ -285,6 +416,27 @@ func (t *tether) Start() error {
 			log.Warnf("Process for session %s has exited (%d) and is not configured for restart", session.ID, session.ExitStatus)
 		}
 
+		// process the exec sessions - these are launched at most once, asynchronously, as they
+		// may be blocked waiting for an attach
+		for _, session := range t.config.Execs {
+			if !session.Active || session.ClearToLaunch != nil {
+				continue
+			}
+
+			log.Infof("Launching process for exec session %s", session.ID)
+			session.prefix = fmt.Sprintf("guestinfo.vice..execs|%s", session.ID)
+			session.ClearToLaunch = make(chan struct{})
+			if !session.RunBlock {
+				close(session.ClearToLaunch)
+			}
+
+			go func(session *SessionConfig) {
+				if err := t.launch(session); err != nil {
+					log.Errorf("failed to launch %s for exec session %s: %s", session.Cmd.Path, session.ID, err)
+				}
+			}(session)
+		}
+
 		for name, ext := range t.extensions {
 			log.Info("Passing config to " + name)
 			err := ext.Reload(t.config)
@@ -318,6 +470,9 @@ func (t *tether) Reload() {
 func (t *tether) Register(name string, extension Extension) {
 	log.Infof("Registering tether extension " + name)
 
+	if e, ok := extension.(tetherExtension); ok {
+		e.bind(t)
+	}
 	t.extensions[name] = extension
 }
 
@@ -329,6 +484,8 @@ func (t *tether) handleSessionExit(session *SessionConfig) {
 	session.m.Lock()
 	defer session.m.Unlock()
 
+	t.stopHealthcheck(session)
+
 	// stdio must be closed before calling wait or Wait hangs indefinitely
 	session.Reader.Close()
 	session.Cmd.Wait()
@@ -347,9 +504,7 @@ func (t *tether) handleSessionExit(session *SessionConfig) {
 	// this returns an arbitrary closure for invocation after the session status update
 	f := t.ops.HandleSessionExit(t.config, session)
 
-	// FIXME: we cannot have this embedded knowledge of the extraconfig encoding pattern, but not
-	// currently sure how to expose it neatly via a utility function
-	extraconfig.EncodeWithPrefix(t.sink, session, fmt.Sprintf("guestinfo.vice..sessions|%s", session.ID))
+	extraconfig.EncodeWithPrefix(t.sink, session, session.prefix)
 
 	if f != nil {
 		f()
@@ -366,28 +521,46 @@ func (t *tether) launch(session *SessionConfig) error {
 
 	// encode the result whether success or error
 	defer func() {
-		extraconfig.EncodeWithPrefix(t.sink, session, fmt.Sprintf("guestinfo.vice..sessions|%s", session.ID))
+		extraconfig.EncodeWithPrefix(t.sink, session, session.prefix)
 	}()
 
-	logwriter, err := t.ops.SessionLog(session)
-	if err != nil {
-		detail := fmt.Sprintf("failed to get log writer for session: %s", err)
-		log.Error(detail)
-		session.Started = detail
+	var stdout, stderr dio.DynamicMultiWriter
+	if session.ClearToLaunch != nil {
+		// exec sessions are not persistently logged - output is only available via attach
+		stdout = dio.MultiWriter()
+		stderr = dio.MultiWriter()
+	} else {
+		var err error
+		stdout, stderr, err = t.ops.SessionLog(session)
+		if err != nil {
+			detail := fmt.Sprintf("failed to get log writer for session: %s", err)
+			log.Error(detail)
+			session.Started = detail
 
-		return errors.New(detail)
+			return errors.New(detail)
+		}
 	}
 
 	// we store these outside of the session.Cmd struct so that there's consistent
 	// handling between tty & non-tty paths
-	session.Outwriter = logwriter
-	session.Errwriter = logwriter
+	session.Outwriter = stdout
+	session.Errwriter = stderr
 	session.Reader = dio.MultiReader()
 
 	// Special case here because UID/GID lookup need to be done
 	// on the appliance...
-	if len(session.User) > 0 {
-		session.Cmd.SysProcAttr = getUserSysProcAttr(session.User)
+	if len(session.User) > 0 || len(session.Group) > 0 {
+		u, err := lookupUser(session.User, session.Group)
+		if err != nil {
+			detail := fmt.Sprintf("failed to resolve user for session: %s", err)
+			log.Error(detail)
+			session.Started = detail
+
+			return errors.New(detail)
+		}
+
+		session.Cmd.SysProcAttr = getUserSysProcAttr(u)
+		session.Cmd.Env = userEnv(session.Cmd.Env, u)
 	}
 
 	session.Cmd.Env = t.ops.ProcessEnv(session.Cmd.Env)
@@ -395,6 +568,14 @@ func (t *tether) launch(session *SessionConfig) error {
 	session.Cmd.Stderr = session.Errwriter
 	session.Cmd.Stdin = session.Reader
 
+	if session.ClearToLaunch != nil {
+		// release the lock while waiting so that the attach can bind to the session
+		session.m.Unlock()
+		log.Debugf("Waiting for clear to launch signal for session %s", session.ID)
+		<-session.ClearToLaunch
+		session.m.Lock()
+	}
+
 	resolved, err := lookPath(session.Cmd.Path, session.Cmd.Env, session.Cmd.Dir)
 	if err != nil {
 		log.Errorf("Path lookup failed for %s: %s", session.Cmd.Path, err)
@@ -443,6 +624,11 @@ func (t *tether) launch(session *SessionConfig) error {
 	// Set the Started key to "true" - this indicates a successful launch
 	session.Started = "true"
 
+	// exec sessions are not health checked
+	if session.ClearToLaunch == nil {
+		t.startHealthcheck(session)
+	}
+
 	// Write the PID to the associated PID file
 	cmdname := path.Base(session.Cmd.Path)
 	if err = os.MkdirAll(PIDFileDir(), 0755); err != nil {
@@ -474,6 +660,43 @@ func logConfig(config *ExecutorConfig) {
 	}
 }
 
+// reloadHandler triggers a reload of the configuration on receipt of SIGHUP
+func (t *tether) reloadHandler() {
+	defer trace.End(trace.Begin("start reload trigger handler"))
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Println("Recovered in reloadHandler", r)
+		}
+	}()
+
+	incoming := make(chan os.Signal, 1)
+	signal.Notify(incoming, syscall.SIGHUP)
+
+	for range incoming {
+		log.Info("Received SIGHUP - triggering reload of config")
+
+		select {
+		case t.reload <- true:
+		default:
+			log.Debug("Reload already pending")
+		}
+	}
+}
+
+// ReloadConfig signals the current process, which triggers the signal handler
+// to reload the config
+func ReloadConfig() error {
+	defer trace.End(trace.Begin(""))
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		return err
+	}
+
+	return p.Signal(syscall.SIGHUP)
+}
+
 func (t *tether) forkHandler() {
 	defer trace.End(trace.Begin("start fork trigger handler"))
 
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"os"
 	"strings"
+	"syscall"
 
 	"golang.org/x/crypto/ssh"
 
@@ -78,3 +79,11 @@ func establishPty(session *SessionConfig) error {
 func signalProcess(process *os.Process, sig ssh.Signal) error {
 	return errors.New("unimplemented on OSX")
 }
+
+func probeProcAttr(attr *syscall.SysProcAttr) *syscall.SysProcAttr {
+	return attr
+}
+
+func killProcessGroup(process *os.Process) error {
+	return process.Kill()
+}
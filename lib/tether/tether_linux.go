@@ -111,6 +111,11 @@ func (t *tether) childReaper() error {
 
 					log.Debugf("Reaped process %d, return code: %d", pid, status.ExitStatus())
 
+					if ch, ok := t.removeProbePid(pid); ok {
+						ch <- status.ExitStatus()
+						continue
+					}
+
 					session, ok := t.removeChildPid(pid)
 					log.Debugf("Remove child pid: %d session: %#+v ok: %t", pid, session, ok)
 					if ok {
@@ -233,3 +238,22 @@ func establishPty(session *SessionConfig) error {
 
 	return err
 }
+
+// probeProcAttr places a healthcheck probe in a process group of its own, so that the processes
+// it starts can be killed along with it
+func probeProcAttr(attr *syscall.SysProcAttr) *syscall.SysProcAttr {
+	if attr == nil {
+		attr = &syscall.SysProcAttr{}
+	}
+
+	// a new session is also a new process group, and setpgid fails for a session leader
+	if !attr.Setsid {
+		attr.Setpgid = true
+	}
+	return attr
+}
+
+// killProcessGroup kills the process group led by process
+func killProcessGroup(process *os.Process) error {
+	return syscall.Kill(-process.Pid, syscall.SIGKILL)
+}
@@ -17,6 +17,7 @@ package tether
 import (
 	"errors"
 	"os"
+	"syscall"
 
 	"golang.org/x/crypto/ssh"
 
@@ -47,3 +48,11 @@ func signalProcess(process *os.Process, sig ssh.Signal) error {
 func establishPty(session *SessionConfig) error {
 	return errors.New("unimplemented on windows")
 }
+
+func probeProcAttr(attr *syscall.SysProcAttr) *syscall.SysProcAttr {
+	return attr
+}
+
+func killProcessGroup(process *os.Process) error {
+	return process.Kill()
+}
@@ -40,6 +40,9 @@ type Toolbox struct {
 	}
 
 	stop chan struct{}
+
+	// tether services the requests that act on every session, such as pause
+	tether *tether
 }
 
 // NewToolbox returns a tether.Extension that wraps the vsphere/toolbox service
@@ -104,6 +107,10 @@ func (t *Toolbox) Reload(config *ExecutorConfig) error {
 	return nil
 }
 
+func (t *Toolbox) bind(tthr *tether) {
+	t.tether = tthr
+}
+
 // InContainer configures the toolbox to run within a container VM
 func (t *Toolbox) InContainer() *Toolbox {
 	t.PowerCommand.Halt.Handler = t.halt
@@ -180,6 +187,16 @@ func (t *Toolbox) containerStartCommand(r *toolbox.VixMsgStartProgramRequest) (i
 	switch r.ProgramPath {
 	case "kill":
 		return -1, t.kill(r.Arguments)
+	case "reload":
+		return -1, ReloadConfig()
+	case "pause", "unpause":
+		if t.tether == nil {
+			return -1, fmt.Errorf("%s is not supported", r.ProgramPath)
+		}
+		if r.ProgramPath == "pause" {
+			return -1, t.tether.pause()
+		}
+		return -1, t.tether.unpause()
 	default:
 		return -1, fmt.Errorf("unknown command %q", r.ProgramPath)
 	}
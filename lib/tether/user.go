@@ -0,0 +1,55 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tether
+
+import (
+	"strings"
+
+	"github.com/opencontainers/runc/libcontainer/user"
+)
+
+var (
+	// the account databases of the container filesystem
+	passwdFile = "/etc/passwd"
+	groupFile  = "/etc/group"
+)
+
+// lookupUser resolves the user and group of a session, each given as either a name or an id,
+// against the account databases of the container. The user may also be given in user:group
+// form, in which case a separately specified group takes precedence. Supplementary groups are
+// only resolved when the group is implied by the user, as for docker.
+func lookupUser(uname, gname string) (*user.ExecUser, error) {
+	spec := uname
+	if gname != "" {
+		spec = strings.SplitN(uname, ":", 2)[0] + ":" + gname
+	}
+
+	// ids that are not in the databases are used as is, with the home directory defaulting to /
+	defaults := &user.ExecUser{Home: "/"}
+
+	return user.GetExecUserPath(spec, defaults, passwdFile, groupFile)
+}
+
+// userEnv returns env with HOME set to the home directory of the user, unless the session
+// environment already specifies it
+func userEnv(env []string, u *user.ExecUser) []string {
+	for _, tuple := range env {
+		if strings.HasPrefix(tuple, "HOME=") {
+			return env
+		}
+	}
+
+	return append(env, "HOME="+u.Home)
+}
@@ -0,0 +1,77 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tether
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupUser(t *testing.T) {
+	dir, err := ioutil.TempDir("", "user")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	passwd := "root:x:0:0:root:/root:/bin/sh\nnginx:x:101:101:nginx:/var/lib/nginx:/sbin/nologin\n"
+	group := "root:x:0:\nnginx:x:101:\nwww:x:33:nginx\nadm:x:4:root,nginx\n"
+
+	defer func(p, g string) { passwdFile, groupFile = p, g }(passwdFile, groupFile)
+	passwdFile = filepath.Join(dir, "passwd")
+	groupFile = filepath.Join(dir, "group")
+	require.NoError(t, ioutil.WriteFile(passwdFile, []byte(passwd), 0644))
+	require.NoError(t, ioutil.WriteFile(groupFile, []byte(group), 0644))
+
+	tests := []struct {
+		user, group   string
+		uid, gid      int
+		sgids         []int
+		home          string
+		expectFailure bool
+	}{
+		{user: "nginx", uid: 101, gid: 101, sgids: []int{33, 4}, home: "/var/lib/nginx"},
+		{user: "101", uid: 101, gid: 101, sgids: []int{33, 4}, home: "/var/lib/nginx"},
+		{user: "nginx:www", uid: 101, gid: 33, sgids: []int{}, home: "/var/lib/nginx"},
+		{user: "nginx:0", group: "adm", uid: 101, gid: 4, sgids: []int{}, home: "/var/lib/nginx"},
+		{user: "1000:1000", uid: 1000, gid: 1000, sgids: []int{}, home: "/"},
+		{group: "www", uid: 0, gid: 33, sgids: []int{}, home: "/root"},
+		{user: "nobody", expectFailure: true},
+		{user: "nginx:nogroup", expectFailure: true},
+	}
+
+	for _, test := range tests {
+		u, err := lookupUser(test.user, test.group)
+		if test.expectFailure {
+			assert.Error(t, err, "%s:%s", test.user, test.group)
+			continue
+		}
+
+		require.NoError(t, err, "%s:%s", test.user, test.group)
+		assert.Equal(t, test.uid, u.Uid, "%s:%s", test.user, test.group)
+		assert.Equal(t, test.gid, u.Gid, "%s:%s", test.user, test.group)
+		assert.Equal(t, test.sgids, u.Sgids, "%s:%s", test.user, test.group)
+		assert.Equal(t, test.home, u.Home, "%s:%s", test.user, test.group)
+	}
+
+	// a HOME set for the session takes precedence
+	u, err := lookupUser("nginx", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"HOME=/var/lib/nginx"}, userEnv(nil, u))
+	assert.Equal(t, []string{"HOME=/srv"}, userEnv([]string{"HOME=/srv"}, u))
+}
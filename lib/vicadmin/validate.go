@@ -36,6 +36,7 @@ import (
 	"github.com/vmware/vic/lib/config"
 	"github.com/vmware/vic/lib/install/validate"
 	"github.com/vmware/vic/lib/tether"
+	"github.com/vmware/vic/pkg/fs"
 	"github.com/vmware/vic/pkg/trace"
 	"github.com/vmware/vic/pkg/version"
 	"github.com/vmware/vic/pkg/vsphere/session"
@@ -168,6 +169,10 @@ func (v *Validator) QueryDatastore(ctx context.Context, vch *config.VirtualConta
 	}
 
 	for _, url := range vch.VolumeLocations {
+		// nfs volume stores are not on a datastore
+		if url.Scheme == fs.NFSScheme {
+			continue
+		}
 		dsNames[url.Host] = true
 	}
 
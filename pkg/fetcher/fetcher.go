@@ -48,11 +48,16 @@ const (
 
 // Fetcher interface
 type Fetcher interface {
-	Fetch(ctx context.Context, url *url.URL, toFile bool, po progress.Output, id ...string) (string, error)
-	FetchAuthToken(url *url.URL) (*Token, error)
+	Fetch(ctx context.Context, url *url.URL, reqHdrs *http.Header, toFile bool, po progress.Output, id ...string) (string, error)
+	FetchAuthToken(ctx context.Context, url *url.URL) (*Token, error)
 
 	Head(url *url.URL) (http.Header, error)
 
+	Exists(ctx context.Context, url *url.URL) (bool, error)
+	Post(ctx context.Context, url *url.URL, body io.Reader, reqHdrs http.Header) (http.Header, error)
+	Patch(ctx context.Context, url *url.URL, body io.Reader, reqHdrs http.Header) (http.Header, error)
+	Put(ctx context.Context, url *url.URL, body io.Reader, reqHdrs http.Header) (http.Header, error)
+
 	ExtractOAuthURL(hdr string, repository *url.URL) (*url.URL, error)
 
 	IsStatusUnauthorized() bool
@@ -111,8 +116,9 @@ func NewURLFetcher(options Options) Fetcher {
 	}
 }
 
-// Fetch fetches from a url and stores its content in a temporary file.
-func (u *URLFetcher) Fetch(ctx context.Context, url *url.URL, toFile bool, po progress.Output, ids ...string) (string, error) {
+// Fetch fetches from a url and stores its content in a temporary file. The optional
+// request headers are sent along with the request.
+func (u *URLFetcher) Fetch(ctx context.Context, url *url.URL, reqHdrs *http.Header, toFile bool, po progress.Output, ids ...string) (string, error) {
 	defer trace.End(trace.Begin(url.String()))
 
 	// extract ID from ids. Existence of an ID enables progress reporting
@@ -122,7 +128,7 @@ func (u *URLFetcher) Fetch(ctx context.Context, url *url.URL, toFile bool, po pr
 	}
 
 	// ctx
-	ctx, cancel := context.WithTimeout(context.Background(), u.options.Timeout)
+	ctx, cancel := context.WithTimeout(ctx, u.options.Timeout)
 	defer cancel()
 
 	var data string
@@ -130,9 +136,9 @@ func (u *URLFetcher) Fetch(ctx context.Context, url *url.URL, toFile bool, po pr
 	var retries int
 	for {
 		if toFile {
-			data, err = u.fetchToFile(ctx, url, ID, po)
+			data, err = u.fetchToFile(ctx, url, reqHdrs, ID, po)
 		} else {
-			data, err = u.fetchToString(ctx, url, ID)
+			data, err = u.fetchToString(ctx, url, reqHdrs, ID)
 		}
 		if err == nil {
 			return data, nil
@@ -186,10 +192,10 @@ func (u *URLFetcher) Fetch(ctx context.Context, url *url.URL, toFile bool, po pr
 	}
 }
 
-func (u *URLFetcher) FetchAuthToken(url *url.URL) (*Token, error) {
+func (u *URLFetcher) FetchAuthToken(ctx context.Context, url *url.URL) (*Token, error) {
 	defer trace.End(trace.Begin(url.String()))
 
-	data, err := u.Fetch(context.Background(), url, false, nil)
+	data, err := u.Fetch(ctx, url, nil, false, nil)
 	if err != nil {
 		log.Errorf("Download failed: %v", err)
 		return nil, err
@@ -212,12 +218,18 @@ func (u *URLFetcher) FetchAuthToken(url *url.URL) (*Token, error) {
 	return token, nil
 }
 
-func (u *URLFetcher) fetch(ctx context.Context, url *url.URL, ID string) (io.ReadCloser, http.Header, error) {
+func (u *URLFetcher) fetch(ctx context.Context, url *url.URL, reqHdrs *http.Header, ID string) (io.ReadCloser, http.Header, error) {
 	req, err := http.NewRequest("GET", url.String(), nil)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	if reqHdrs != nil {
+		for k, v := range *reqHdrs {
+			req.Header[k] = v
+		}
+	}
+
 	u.setBasicAuth(req)
 
 	u.setAuthToken(req)
@@ -270,8 +282,8 @@ func (u *URLFetcher) fetch(ctx context.Context, url *url.URL, ID string) (io.Rea
 }
 
 // fetch fetches the given URL using ctxhttp. It also streams back the progress bar only when ID is not an empty string.
-func (u *URLFetcher) fetchToFile(ctx context.Context, url *url.URL, ID string, po progress.Output) (string, error) {
-	rdr, hdrs, err := u.fetch(ctx, url, ID)
+func (u *URLFetcher) fetchToFile(ctx context.Context, url *url.URL, reqHdrs *http.Header, ID string, po progress.Output) (string, error) {
+	rdr, hdrs, err := u.fetch(ctx, url, reqHdrs, ID)
 	if err != nil {
 		return "", err
 	}
@@ -316,8 +328,8 @@ func (u *URLFetcher) fetchToFile(ctx context.Context, url *url.URL, ID string, p
 }
 
 // fetch fetches the given URL using ctxhttp. It also streams back the progress bar only when ID is not an empty string.
-func (u *URLFetcher) fetchToString(ctx context.Context, url *url.URL, ID string) (string, error) {
-	rdr, _, err := u.fetch(ctx, url, ID)
+func (u *URLFetcher) fetchToString(ctx context.Context, url *url.URL, reqHdrs *http.Header, ID string) (string, error) {
+	rdr, _, err := u.fetch(ctx, url, reqHdrs, ID)
 	if err != nil {
 		log.Errorf("Fetch (%s) to string error: %s", url.String(), err)
 		return "", err
@@ -362,6 +374,103 @@ func (u *URLFetcher) head(ctx context.Context, url *url.URL) (http.Header, error
 	return nil, fmt.Errorf("Unexpected http code: %d, URL: %s", u.StatusCode, url)
 }
 
+// Exists sends an authenticated HEAD request to url and reports whether the resource exists
+func (u *URLFetcher) Exists(ctx context.Context, url *url.URL) (bool, error) {
+	defer trace.End(trace.Begin(url.String()))
+
+	res, err := u.send(ctx, "HEAD", url, nil, nil)
+	if err != nil {
+		if u.IsStatusNotFound() {
+			return false, nil
+		}
+		return false, err
+	}
+	res.Body.Close()
+
+	return true, nil
+}
+
+// Post sends body to url in a POST request and returns the response headers
+func (u *URLFetcher) Post(ctx context.Context, url *url.URL, body io.Reader, reqHdrs http.Header) (http.Header, error) {
+	defer trace.End(trace.Begin(url.String()))
+
+	return u.upload(ctx, "POST", url, body, reqHdrs)
+}
+
+// Patch sends body to url in a PATCH request and returns the response headers
+func (u *URLFetcher) Patch(ctx context.Context, url *url.URL, body io.Reader, reqHdrs http.Header) (http.Header, error) {
+	defer trace.End(trace.Begin(url.String()))
+
+	return u.upload(ctx, "PATCH", url, body, reqHdrs)
+}
+
+// Put sends body to url in a PUT request and returns the response headers
+func (u *URLFetcher) Put(ctx context.Context, url *url.URL, body io.Reader, reqHdrs http.Header) (http.Header, error) {
+	defer trace.End(trace.Begin(url.String()))
+
+	return u.upload(ctx, "PUT", url, body, reqHdrs)
+}
+
+func (u *URLFetcher) upload(ctx context.Context, method string, url *url.URL, body io.Reader, reqHdrs http.Header) (http.Header, error) {
+	res, err := u.send(ctx, method, url, body, reqHdrs)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	return res.Header, nil
+}
+
+// send makes an authenticated request to url. Any response other than a success is returned
+// as an error, and the OAuth endpoint is learned from the challenge of an unauthorized one.
+func (u *URLFetcher) send(ctx context.Context, method string, url *url.URL, body io.Reader, reqHdrs http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, url.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range reqHdrs {
+		req.Header[k] = v
+	}
+	// the length is not discoverable from an arbitrary reader
+	if cl := reqHdrs.Get("Content-Length"); cl != "" {
+		if req.ContentLength, err = strconv.ParseInt(cl, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	u.setBasicAuth(req)
+
+	u.setAuthToken(req)
+
+	res, err := ctxhttp.Do(ctx, u.client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	u.StatusCode = res.StatusCode
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res, nil
+	}
+	defer res.Body.Close()
+
+	if u.IsStatusUnauthorized() {
+		hdr := res.Header.Get("www-authenticate")
+		if u.options.Token == nil && strings.HasPrefix(strings.ToLower(hdr), "bearer ") {
+			u.OAuthEndpoint, err = u.ExtractOAuthURL(hdr, url)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, DoNotRetry{Err: fmt.Errorf("Authentication required")}
+	}
+
+	// registries describe the failure in the response body
+	msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 4096))
+	return nil, fmt.Errorf("Unexpected http code: %d, URL: %s: %s", u.StatusCode, url, strings.TrimSpace(string(msg)))
+}
+
 // AuthURL returns the Oauth endpoint URL
 func (u *URLFetcher) AuthURL() *url.URL {
 	return u.OAuthEndpoint
@@ -402,10 +511,8 @@ func (u *URLFetcher) ExtractOAuthURL(hdr string, repository *url.URL) (*url.URL,
 		return nil, fmt.Errorf("www-authenticate header is corrupted")
 	}
 
-	tokens = strings.Split(tokens[1], ",")
-
 	var realm, service, scope string
-	for _, token := range tokens {
+	for _, token := range challengeParams(tokens[1]) {
 		if strings.HasPrefix(token, "realm") {
 			realm = strings.Trim(token[len("realm="):], "\"")
 		}
@@ -442,3 +549,25 @@ func (u *URLFetcher) ExtractOAuthURL(hdr string, repository *url.URL) (*url.URL,
 
 	return auth, nil
 }
+
+// challengeParams splits the parameters of an authentication challenge. Commas within quoted
+// values, such as those of a push scope, do not separate parameters.
+func challengeParams(params string) []string {
+	var result []string
+	var quoted bool
+
+	start := 0
+	for i, c := range params {
+		switch c {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				result = append(result, params[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(result, params[start:])
+}
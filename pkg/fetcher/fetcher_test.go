@@ -0,0 +1,40 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetcher
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestExtractOAuthURLPushScope(t *testing.T) {
+	repository, _ := url.Parse("https://registry.example.com/v2/library/photon/blobs/uploads/")
+
+	u := NewURLFetcher(Options{})
+	auth, err := u.ExtractOAuthURL(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:library/photon:push,pull"`, repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if auth.Host != "auth.example.com" || auth.Path != "/token" {
+		t.Errorf("unexpected realm: %s", auth)
+	}
+	if scope := auth.Query().Get("scope"); scope != "repository:library/photon:push,pull" {
+		t.Errorf("unexpected scope: %s", scope)
+	}
+	if service := auth.Query().Get("service"); service != "registry.example.com" {
+		t.Errorf("unexpected service: %s", service)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fs
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/pkg/mount"
+
+	"github.com/vmware/vic/pkg/trace"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// NFSScheme is the URL scheme of NFS exports, nfs://host[:port]/path[?option=value&...]
+const NFSScheme = "nfs"
+
+// NFS mounts NFS exports with the kernel client, so the nfs-utils mount helper is not needed.
+// NFSv3 mounts are made without the lock manager unless the lock option is given, as there is
+// no statd to run it.
+type NFS struct{}
+
+func NewNFS() *NFS {
+	return &NFS{}
+}
+
+// NFSMountArgs returns the source and options to mount the NFS export at u with. The query
+// parameters of u are passed as mount options, with the version defaulting to NFSv3. The host
+// is resolved as the kernel needs the address of the server.
+func NFSMountArgs(u *url.URL) (string, string, error) {
+	if u.Scheme != NFSScheme {
+		return "", "", fmt.Errorf("%s is not an nfs URL", u.String())
+	}
+
+	host := u.Host
+	port := ""
+	if h, p, err := net.SplitHostPort(u.Host); err == nil {
+		host, port = h, p
+	}
+
+	if host == "" || !path.IsAbs(u.Path) {
+		return "", "", fmt.Errorf("%s is not an nfs export, expected nfs://host/path", u.String())
+	}
+
+	addrs, err := net.LookupIP(host)
+	if err != nil || len(addrs) == 0 {
+		return "", "", fmt.Errorf("unable to resolve nfs server %s: %s", host, err)
+	}
+
+	options := map[string]string{
+		"addr": addrs[0].String(),
+		"vers": "3",
+	}
+	if port != "" {
+		options["port"] = port
+	}
+
+	for k, v := range u.Query() {
+		options[k] = strings.Join(v, ",")
+	}
+
+	// the version may be given as 4.1
+	if strings.HasPrefix(options["vers"], "3") {
+		_, lock := options["lock"]
+		_, nolock := options["nolock"]
+		if !lock && !nolock {
+			options["nolock"] = ""
+		}
+	}
+	delete(options, "lock")
+
+	var opts []string
+	for k, v := range options {
+		if v == "" {
+			opts = append(opts, k)
+			continue
+		}
+		opts = append(opts, k+"="+v)
+	}
+	sort.Strings(opts)
+
+	source := fmt.Sprintf("%s:%s", host, path.Clean(u.Path))
+	return source, strings.Join(opts, ","), nil
+}
+
+// Mount mounts the NFS export at u on targetPath, with the additional options given in the form
+// of the docker mount pkg
+func (n *NFS) Mount(u *url.URL, targetPath string, options []string) error {
+	defer trace.End(trace.Begin(u.String()))
+
+	source, opts, err := NFSMountArgs(u)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Mounting %s to %s", source, targetPath)
+	return mount.Mount(source, targetPath, "nfs", strings.Join(append(options, opts), ","))
+}
+
+// Unmount unmounts the export mounted at path
+func (n *NFS) Unmount(path string) error {
+	defer trace.End(trace.Begin(path))
+	log.Infof("Unmounting %s", path)
+	return mount.Unmount(path)
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fs
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNFSMountArgs(t *testing.T) {
+	tests := []struct {
+		url     string
+		source  string
+		options string
+	}{
+		{"nfs://127.0.0.1/exports/vols", "127.0.0.1:/exports/vols", "addr=127.0.0.1,nolock,vers=3"},
+		{"nfs://127.0.0.1:2049/exports/vols/", "127.0.0.1:/exports/vols", "addr=127.0.0.1,nolock,port=2049,vers=3"},
+		{"nfs://127.0.0.1/exports?vers=4.1", "127.0.0.1:/exports", "addr=127.0.0.1,vers=4.1"},
+		{"nfs://127.0.0.1/exports?lock&timeo=600", "127.0.0.1:/exports", "addr=127.0.0.1,timeo=600,vers=3"},
+		{"nfs://localhost/exports", "localhost:/exports", ""},
+	}
+
+	for _, test := range tests {
+		u, err := url.Parse(test.url)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		source, options, err := NFSMountArgs(u)
+		if assert.NoError(t, err, test.url) {
+			assert.Equal(t, test.source, source, test.url)
+			if test.options != "" {
+				assert.Equal(t, test.options, options, test.url)
+			}
+		}
+	}
+
+	for _, bad := range []string{"ds://datastore1/vols", "nfs:///exports", "nfs://127.0.0.1"} {
+		u, err := url.Parse(bad)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		_, _, err = NFSMountArgs(u)
+		assert.Error(t, err, bad)
+	}
+}
@@ -56,16 +56,26 @@ func (i *Range) Equal(other *Range) bool {
 
 // Network returns the network that this range represents, if any
 func (i *Range) Network() *net.IPNet {
-	// only works for ipv4
-	first := i.FirstIP.To4()
-	last := i.LastIP.To4()
-	diff := net.IPv4(0, 0, 0, 0).To4()
-	for j := 0; j < net.IPv4len; j++ {
+	first, last := i.FirstIP.To4(), i.LastIP.To4()
+	if first == nil || last == nil {
+		// a range cannot span address families
+		if first != nil || last != nil {
+			return nil
+		}
+
+		first, last = i.FirstIP.To16(), i.LastIP.To16()
+		if first == nil || last == nil {
+			return nil
+		}
+	}
+
+	diff := make(net.IP, len(first))
+	for j := range first {
 		diff[j] = first[j] ^ last[j]
 	}
 
 	var m uint
-	for j := net.IPv4len - 1; j >= 0; j-- {
+	for j := len(diff) - 1; j >= 0; j-- {
 		var k uint
 		for ; k < 8; k++ {
 			if diff[j]>>k == 0 {
@@ -83,7 +93,8 @@ func (i *Range) Network() *net.IPNet {
 		return nil
 	}
 
-	mask := net.CIDRMask(32-int(m), 32)
+	bits := len(first) * 8
+	mask := net.CIDRMask(bits-int(m), bits)
 	for j, f := range first {
 		l := f | ^mask[j]
 		if l != last[j] {
@@ -123,6 +134,11 @@ func ParseRange(r string) *Range {
 
 	last = net.ParseIP(comps[1])
 	if last == nil {
+		// the short form, e.g. 10.10.10.10-24, is only supported for IPv4
+		if first.To4() == nil {
+			return nil
+		}
+
 		var end int
 		end, err := strconv.Atoi(comps[1])
 		if err != nil || end <= int(first[15]) || end > math.MaxUint8 {
@@ -197,13 +213,26 @@ func AllZerosAddr(subnet *net.IPNet) net.IP {
 
 // AllOnesAddr returns the all-ones address for a subnet
 func AllOnesAddr(subnet *net.IPNet) net.IP {
-	ones := net.IPv4(0, 0, 0, 0)
 	ip := subnet.IP.To16()
-	for i := range ip[12:] {
-		ones[12+i] = ip[12+i] | ^subnet.Mask[i]
+	if len(subnet.Mask) == net.IPv4len {
+		ip = subnet.IP.To4()
+	}
+
+	if ip == nil || len(ip) != len(subnet.Mask) {
+		return nil
 	}
 
-	return ones
+	ones := make(net.IP, len(ip))
+	for i := range ip {
+		ones[i] = ip[i] | ^subnet.Mask[i]
+	}
+
+	return ones.To16()
+}
+
+// IsIPv6 returns true if the address is an IPv6 address
+func IsIPv6(ip net.IP) bool {
+	return ip.To4() == nil && ip.To16() != nil
 }
 
 func IsRoutableIP(ip net.IP, subnet *net.IPNet) bool {
@@ -56,6 +56,9 @@ func TestRangeUnmarshalText(t *testing.T) {
 		{"10.10.10.10-24", &Range{net.ParseIP("10.10.10.10"), net.ParseIP("10.10.10.24")}, nil},
 		{"10.10.10.10-10.10.10.24", &Range{net.ParseIP("10.10.10.10"), net.ParseIP("10.10.10.24")}, nil},
 		{"10.10.10.0/24", &Range{net.ParseIP("10.10.10.0"), net.ParseIP("10.10.10.255")}, nil},
+		{"fd00::10-24", nil, fmt.Errorf("")},
+		{"fd00::10-fd00::24", &Range{net.ParseIP("fd00::10"), net.ParseIP("fd00::24")}, nil},
+		{"fd00:1::/64", &Range{net.ParseIP("fd00:1::"), net.ParseIP("fd00:1::ffff:ffff:ffff:ffff")}, nil},
 	}
 
 	for _, te := range tests {
@@ -121,6 +124,8 @@ func TestAllOnesAddr(t *testing.T) {
 	}{
 		{&net.IPNet{IP: net.ParseIP("192.168.0.0"), Mask: net.CIDRMask(16, 32)}, net.ParseIP("192.168.255.255")},
 		{&net.IPNet{IP: net.ParseIP("192.168.100.0"), Mask: net.CIDRMask(24, 32)}, net.ParseIP("192.168.100.255")},
+		{&net.IPNet{IP: net.ParseIP("fd00:1::"), Mask: net.CIDRMask(64, 128)}, net.ParseIP("fd00:1::ffff:ffff:ffff:ffff")},
+		{&net.IPNet{IP: net.ParseIP("fd00:1::"), Mask: net.CIDRMask(120, 128)}, net.ParseIP("fd00:1::ff")},
 	}
 
 	for _, te := range tests {
@@ -139,6 +144,9 @@ func TestRangeNetwork(t *testing.T) {
 		{ParseRange("10.10.10.10/24"), &net.IPNet{IP: net.ParseIP("10.10.10.0"), Mask: net.CIDRMask(24, 32)}},
 		{ParseRange("10.10.10.10-10.10.14.11"), nil},
 		{ParseRange("10.10.10.10-10.10.10.11"), &net.IPNet{IP: net.ParseIP("10.10.10.10"), Mask: net.CIDRMask(31, 32)}},
+		{ParseRange("fd00:1::/64"), &net.IPNet{IP: net.ParseIP("fd00:1::"), Mask: net.CIDRMask(64, 128)}},
+		{ParseRange("fd00:1::10-fd00:1::20"), nil},
+		{ParseRange("10.10.10.10-fd00:1::20"), nil},
 	}
 
 	for _, te := range tests {
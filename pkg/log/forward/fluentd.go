@@ -0,0 +1,263 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forward
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/daemon/logger/loggerutils"
+)
+
+// The docker fluentd driver depends on a client library that is not vendored, so this is a
+// minimal client of the fluentd forward protocol with the same options and record format.
+const (
+	fluentdName = "fluentd"
+
+	fluentdDefaultPort       = "24224"
+	fluentdDefaultRetryWait  = time.Second
+	fluentdDefaultMaxRetries = 5
+	fluentdDialTimeout       = 3 * time.Second
+
+	fluentdAddressKey      = "fluentd-address"
+	fluentdRetryWaitKey    = "fluentd-retry-wait"
+	fluentdMaxRetriesKey   = "fluentd-max-retries"
+	fluentdAsyncConnectKey = "fluentd-async-connect"
+)
+
+type fluentd struct {
+	tag           string
+	containerID   string
+	containerName string
+	extra         map[string]string
+
+	address    string
+	retryWait  time.Duration
+	maxRetries int
+	conn       net.Conn
+}
+
+func init() {
+	if err := logger.RegisterLogDriver(fluentdName, newFluentd); err != nil {
+		panic(err)
+	}
+	if err := logger.RegisterLogOptValidator(fluentdName, validateFluentdOpts); err != nil {
+		panic(err)
+	}
+}
+
+func newFluentd(ctx logger.Context) (logger.Logger, error) {
+	address, err := fluentdAddress(ctx.Config[fluentdAddressKey])
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := loggerutils.ParseLogTag(ctx, "docker.{{.ID}}")
+	if err != nil {
+		return nil, err
+	}
+
+	f := &fluentd{
+		tag:           tag,
+		containerID:   ctx.ContainerID,
+		containerName: ctx.ContainerName,
+		extra:         ctx.ExtraAttributes(nil),
+		address:       address,
+		retryWait:     fluentdDefaultRetryWait,
+		maxRetries:    fluentdDefaultMaxRetries,
+	}
+
+	if v := ctx.Config[fluentdRetryWaitKey]; v != "" {
+		if f.retryWait, err = time.ParseDuration(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := ctx.Config[fluentdMaxRetriesKey]; v != "" {
+		if f.maxRetries, err = strconv.Atoi(v); err != nil {
+			return nil, err
+		}
+	}
+
+	async := false
+	if v := ctx.Config[fluentdAsyncConnectKey]; v != "" {
+		if async, err = strconv.ParseBool(v); err != nil {
+			return nil, err
+		}
+	}
+
+	// as for docker, the container fails to start if fluentd cannot be reached
+	if !async {
+		if f.conn, err = net.DialTimeout("tcp", f.address, fluentdDialTimeout); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+func validateFluentdOpts(cfg map[string]string) error {
+	for key, val := range cfg {
+		switch key {
+		case "env", "fluentd-tag", "labels", "tag":
+		case fluentdAddressKey:
+			if _, err := fluentdAddress(val); err != nil {
+				return err
+			}
+		case fluentdRetryWaitKey:
+			if _, err := time.ParseDuration(val); err != nil {
+				return fmt.Errorf("invalid value %q for log opt %s: %s", val, key, err)
+			}
+		case fluentdMaxRetriesKey:
+			if n, err := strconv.Atoi(val); err != nil || n < 0 {
+				return fmt.Errorf("invalid value %q for log opt %s", val, key)
+			}
+		case fluentdAsyncConnectKey:
+			if _, err := strconv.ParseBool(val); err != nil {
+				return fmt.Errorf("invalid value %q for log opt %s", val, key)
+			}
+		default:
+			return fmt.Errorf("unknown log opt '%s' for fluentd log driver", key)
+		}
+	}
+
+	return nil
+}
+
+// fluentdAddress returns the host:port of a fluentd-address, adding the default port if needed
+func fluentdAddress(address string) (string, error) {
+	if address == "" {
+		return "", fmt.Errorf("invalid fluentd-address %q", address)
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		// a host without a port
+		return net.JoinHostPort(address, fluentdDefaultPort), nil
+	}
+
+	if _, err = strconv.ParseUint(port, 10, 16); err != nil || host == "" {
+		return "", fmt.Errorf("invalid fluentd-address %s", address)
+	}
+	return address, nil
+}
+
+func (f *fluentd) Log(msg *logger.Message) error {
+	record := map[string]string{
+		"container_id":   f.containerID,
+		"container_name": f.containerName,
+		"source":         msg.Source,
+		"log":            string(msg.Line),
+	}
+	for k, v := range f.extra {
+		record[k] = v
+	}
+
+	data := encodeFluentdMessage(f.tag, msg.Timestamp, record)
+
+	var err error
+	for attempt := 0; attempt <= f.maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(f.retryWait)
+		}
+
+		if f.conn == nil {
+			if f.conn, err = net.DialTimeout("tcp", f.address, fluentdDialTimeout); err != nil {
+				f.conn = nil
+				continue
+			}
+		}
+
+		if _, err = f.conn.Write(data); err == nil {
+			return nil
+		}
+
+		// reconnect, the connection may have been closed by fluentd
+		f.conn.Close()
+		f.conn = nil
+	}
+
+	return err
+}
+
+func (f *fluentd) Name() string {
+	return fluentdName
+}
+
+func (f *fluentd) Close() error {
+	if f.conn == nil {
+		return nil
+	}
+	return f.conn.Close()
+}
+
+// encodeFluentdMessage returns the msgpack encoding of a forward protocol message, the array
+// [tag, time, record]. Record keys are sorted so the encoding is stable.
+func encodeFluentdMessage(tag string, t time.Time, record map[string]string) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteByte(0x93)
+	msgpackString(&buf, tag)
+
+	buf.WriteByte(0xce)
+	binary.Write(&buf, binary.BigEndian, uint32(t.Unix()))
+
+	keys := make([]string, 0, len(record))
+	for k := range record {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	switch n := len(keys); {
+	case n < 16:
+		buf.WriteByte(0x80 | byte(n))
+	case n < 1<<16:
+		buf.WriteByte(0xde)
+		binary.Write(&buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xdf)
+		binary.Write(&buf, binary.BigEndian, uint32(n))
+	}
+
+	for _, k := range keys {
+		msgpackString(&buf, k)
+		msgpackString(&buf, record[k])
+	}
+
+	return buf.Bytes()
+}
+
+func msgpackString(buf *bytes.Buffer, s string) {
+	switch n := len(s); {
+	case n < 32:
+		buf.WriteByte(0xa0 | byte(n))
+	case n < 1<<8:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(n))
+	case n < 1<<16:
+		buf.WriteByte(0xda)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xdb)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+	buf.WriteString(s)
+}
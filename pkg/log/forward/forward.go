@@ -0,0 +1,306 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package forward sends container output to a remote log service with the docker log drivers
+// that do not need a local daemon: syslog, gelf and fluentd.
+package forward
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/docker/docker/daemon/logger"
+	// registers the drivers with the logger factory
+	_ "github.com/docker/docker/daemon/logger/gelf"
+	_ "github.com/docker/docker/daemon/logger/syslog"
+)
+
+const (
+	// JSONFile is the docker default log driver, recorded on the datastore for containerVMs
+	JSONFile = "json-file"
+
+	// None disables the recording of container output
+	None = "none"
+
+	// DatastoreCopy is the log option that selects whether the output of a container with a
+	// forwarding driver is also recorded on the datastore, so docker logs can show it
+	DatastoreCopy = "datastore-copy"
+
+	// queueSize is the number of lines waiting to be sent before output is dropped
+	queueSize = 1024
+
+	// maxLine is the longest line sent as a single message, matching the docker copier
+	maxLine = 16 * 1024
+)
+
+// Drivers are the log drivers that forward output
+var Drivers = []string{"syslog", "gelf", "fluentd"}
+
+// IsForwarding returns whether driver sends container output to a log service
+func IsForwarding(driver string) bool {
+	for _, d := range Drivers {
+		if d == driver {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseConfig validates the log driver and options of a container. It returns the options to
+// pass to the driver and whether the output should be recorded on the datastore.
+func ParseConfig(driver string, options map[string]string) (map[string]string, bool, error) {
+	switch driver {
+	case "", JSONFile:
+		// the rotation options of json-file do not apply to the datastore log
+		return nil, true, nil
+	case None:
+		return nil, false, nil
+	}
+
+	if !IsForwarding(driver) {
+		return nil, false, fmt.Errorf("log driver %s is not supported, use one of %s, %s or %s", driver, JSONFile, None, strings.Join(Drivers, ", "))
+	}
+
+	datastore := true
+	opts := make(map[string]string)
+	for k, v := range options {
+		switch {
+		case k == DatastoreCopy:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, false, fmt.Errorf("invalid value %q for log option %s", v, k)
+			}
+			datastore = b
+		case strings.HasSuffix(k, "-tls-ca-cert"), strings.HasSuffix(k, "-tls-cert"), strings.HasSuffix(k, "-tls-key"):
+			// the files would have to be present in every containerVM
+			return nil, false, fmt.Errorf("log option %s is not supported", k)
+		default:
+			opts[k] = v
+		}
+	}
+
+	// without an address the drivers log to the containerVM itself
+	address := driver + "-address"
+	if opts[address] == "" {
+		return nil, false, fmt.Errorf("log option %s is required for the %s log driver", address, driver)
+	}
+
+	if err := logger.ValidateLogOpts(driver, opts); err != nil {
+		return nil, false, err
+	}
+
+	return opts, datastore, nil
+}
+
+// Info describes the container whose output is forwarded, for use in tags and log records
+type Info struct {
+	ID         string
+	Name       string
+	ImageID    string
+	ImageName  string
+	Entrypoint string
+	Args       []string
+	Created    time.Time
+	Env        []string
+	Labels     map[string]string
+}
+
+// Forwarder sends the lines written to its streams to a log driver. Lines are queued and sent
+// in the background so that a slow or unreachable log service does not block the container,
+// and are dropped when the queue is full.
+type Forwarder struct {
+	logger   logger.Logger
+	id       string
+	messages chan *logger.Message
+	done     chan struct{}
+
+	m       sync.Mutex
+	streams int
+	closed  bool
+}
+
+// New returns a Forwarder sending output to the named driver
+func New(driver string, options map[string]string, info Info) (*Forwarder, error) {
+	create, err := logger.GetLogDriver(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := create(logger.Context{
+		Config:              options,
+		ContainerID:         info.ID,
+		ContainerName:       "/" + strings.TrimPrefix(info.Name, "/"),
+		ContainerEntrypoint: info.Entrypoint,
+		ContainerArgs:       info.Args,
+		ContainerImageID:    info.ImageID,
+		ContainerImageName:  info.ImageName,
+		ContainerCreated:    info.Created,
+		ContainerEnv:        info.Env,
+		ContainerLabels:     info.Labels,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Forwarder{
+		logger:   l,
+		id:       info.ID,
+		messages: make(chan *logger.Message, queueSize),
+		done:     make(chan struct{}),
+	}
+	go f.send()
+
+	return f, nil
+}
+
+func (f *Forwarder) send() {
+	defer close(f.done)
+
+	for msg := range f.messages {
+		if err := f.logger.Log(msg); err != nil {
+			log.Errorf("failed to forward output with the %s log driver: %s", f.logger.Name(), err)
+		}
+	}
+
+	if err := f.logger.Close(); err != nil {
+		log.Errorf("failed to close the %s log driver: %s", f.logger.Name(), err)
+	}
+}
+
+// Stream returns a writer that forwards the lines written to it as output of the named stream.
+// The forwarder stops once all of its streams are closed.
+func (f *Forwarder) Stream(source string) io.WriteCloser {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.streams++
+	return &streamWriter{forwarder: f, source: source}
+}
+
+// Close stops accepting output and waits for the queued lines to be sent
+func (f *Forwarder) Close() error {
+	f.stop()
+
+	<-f.done
+	return nil
+}
+
+// stop closes the queue, leaving the queued lines to be sent in the background
+func (f *Forwarder) stop() {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if !f.closed {
+		f.closed = true
+		close(f.messages)
+	}
+}
+
+func (f *Forwarder) queue(source string, line []byte) {
+	msg := &logger.Message{
+		ContainerID: f.id,
+		Line:        append([]byte(nil), line...),
+		Source:      source,
+		Timestamp:   time.Now().UTC(),
+	}
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.closed {
+		return
+	}
+
+	select {
+	case f.messages <- msg:
+	default:
+		log.Warnf("dropping %s output of %s, the %s log driver is not keeping up", source, f.id, f.logger.Name())
+	}
+}
+
+// release stops the forwarder when the last stream is closed. It does not wait for the queue
+// to drain, as an unreachable log service must not hold up the exit of the session.
+func (f *Forwarder) release() {
+	f.m.Lock()
+	f.streams--
+	last := f.streams == 0
+	f.m.Unlock()
+
+	if last {
+		f.stop()
+	}
+}
+
+type streamWriter struct {
+	forwarder *Forwarder
+	source    string
+
+	m       sync.Mutex
+	partial []byte
+	closed  bool
+}
+
+// Write queues each complete line of p, without the newline as docker sends them, holding any
+// trailing partial line until it is completed. Errors are not returned so that the other
+// writers of the container output are unaffected.
+func (s *streamWriter) Write(p []byte) (int, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	buf := append(s.partial, p...)
+	for {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			break
+		}
+		if i > 0 {
+			s.forwarder.queue(s.source, buf[:i])
+		}
+		buf = buf[i+1:]
+	}
+
+	for len(buf) >= maxLine {
+		s.forwarder.queue(s.source, buf[:maxLine])
+		buf = buf[maxLine:]
+	}
+	s.partial = append([]byte(nil), buf...)
+
+	return len(p), nil
+}
+
+// Close sends any partial line and releases the stream
+func (s *streamWriter) Close() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	if len(s.partial) > 0 {
+		s.forwarder.queue(s.source, s.partial)
+		s.partial = nil
+	}
+
+	s.forwarder.release()
+	return nil
+}
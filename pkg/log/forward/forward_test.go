@@ -0,0 +1,201 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forward
+
+import (
+	"io/ioutil"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLogger struct {
+	m        sync.Mutex
+	messages []*logger.Message
+	closed   bool
+}
+
+func (l *testLogger) Log(msg *logger.Message) error {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.messages = append(l.messages, msg)
+	return nil
+}
+
+func (l *testLogger) Name() string {
+	return "test"
+}
+
+func (l *testLogger) Close() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.closed = true
+	return nil
+}
+
+// testDriver is the logger most recently created by the test driver
+var testDriver *testLogger
+
+// the tag templates use the short form of the container id
+const testID = "abc1234567890def1234567890abc1234567890def1234567890abc123456789a"
+
+func init() {
+	logger.RegisterLogDriver("test", func(ctx logger.Context) (logger.Logger, error) {
+		testDriver = &testLogger{}
+		return testDriver, nil
+	})
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		driver    string
+		options   map[string]string
+		expected  map[string]string
+		datastore bool
+		err       bool
+	}{
+		{"", nil, nil, true, false},
+		{JSONFile, map[string]string{"max-size": "10m"}, nil, true, false},
+		{None, nil, nil, false, false},
+		{"journald", nil, nil, false, true},
+		{"syslog", nil, nil, false, true},
+		{"syslog", map[string]string{"syslog-address": "tcp+tls://logs:6514", "syslog-format": "rfc5424"},
+			map[string]string{"syslog-address": "tcp+tls://logs:6514", "syslog-format": "rfc5424"}, true, false},
+		{"syslog", map[string]string{"syslog-address": "udp://logs:514", "syslog-tls-ca-cert": "/ca.pem"}, nil, false, true},
+		{"syslog", map[string]string{"syslog-address": "udp://logs:514", "unknown": "x"}, nil, false, true},
+		{"gelf", map[string]string{"gelf-address": "udp://logs:12201", DatastoreCopy: "false"},
+			map[string]string{"gelf-address": "udp://logs:12201"}, false, false},
+		{"gelf", map[string]string{"gelf-address": "udp://logs:12201", DatastoreCopy: "maybe"}, nil, false, true},
+		{"fluentd", map[string]string{"fluentd-address": "logs", "tag": "{{.Name}}"},
+			map[string]string{"fluentd-address": "logs", "tag": "{{.Name}}"}, true, false},
+		{"fluentd", map[string]string{"fluentd-address": "logs:port"}, nil, false, true},
+	}
+
+	for _, test := range tests {
+		options, datastore, err := ParseConfig(test.driver, test.options)
+		if test.err {
+			assert.Error(t, err, "%s %v", test.driver, test.options)
+			continue
+		}
+
+		if assert.NoError(t, err, "%s %v", test.driver, test.options) {
+			assert.Equal(t, test.expected, options, "%s %v", test.driver, test.options)
+			assert.Equal(t, test.datastore, datastore, "%s %v", test.driver, test.options)
+		}
+	}
+}
+
+func TestForwarder(t *testing.T) {
+	f, err := New("test", nil, Info{ID: testID})
+	require.NoError(t, err)
+
+	stdout := f.Stream("stdout")
+	stderr := f.Stream("stderr")
+
+	stdout.Write([]byte("one\ntw"))
+	stderr.Write([]byte("error\n\n"))
+	stdout.Write([]byte("o\nthree"))
+
+	stdout.Close()
+	stderr.Close()
+	f.Close()
+
+	expected := []logger.Message{
+		{ContainerID: testID, Line: []byte("one"), Source: "stdout"},
+		{ContainerID: testID, Line: []byte("error"), Source: "stderr"},
+		{ContainerID: testID, Line: []byte("two"), Source: "stdout"},
+		{ContainerID: testID, Line: []byte("three"), Source: "stdout"},
+	}
+
+	require.Len(t, testDriver.messages, len(expected))
+	for i, msg := range testDriver.messages {
+		assert.Equal(t, expected[i].Line, msg.Line)
+		assert.Equal(t, expected[i].Source, msg.Source)
+		assert.Equal(t, expected[i].ContainerID, msg.ContainerID)
+	}
+	assert.True(t, testDriver.closed)
+
+	// output written after the streams are closed is dropped
+	stdout.Write([]byte("late\n"))
+	assert.Len(t, testDriver.messages, len(expected))
+}
+
+func TestFluentd(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	received := make(chan []byte)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			close(received)
+			return
+		}
+		data, _ := ioutil.ReadAll(conn)
+		received <- data
+	}()
+
+	f, err := New("fluentd", map[string]string{"fluentd-address": l.Addr().String(), "tag": "vic"}, Info{ID: testID, Name: "web"})
+	require.NoError(t, err)
+
+	s := f.Stream("stdout")
+	s.Write([]byte("hi\n"))
+	s.Close()
+	f.Close()
+
+	data := <-received
+	require.NotEmpty(t, data)
+
+	// [tag, time, record] with the record keys in order
+	assert.Equal(t, []byte{0x93, 0xa3, 'v', 'i', 'c', 0xce}, data[:6])
+	sent := time.Unix(int64(uint32(data[6])<<24|uint32(data[7])<<16|uint32(data[8])<<8|uint32(data[9])), 0)
+	assert.WithinDuration(t, time.Now(), sent, time.Minute)
+
+	record := []byte{0x84}
+	for _, kv := range [][2]string{{"container_id", testID}, {"container_name", "/web"}, {"log", "hi"}, {"source", "stdout"}} {
+		for _, s := range kv {
+			if len(s) < 32 {
+				record = append(record, 0xa0|byte(len(s)))
+			} else {
+				record = append(record, 0xd9, byte(len(s)))
+			}
+			record = append(record, s...)
+		}
+	}
+	assert.Equal(t, record, data[10:])
+}
+
+func TestFluentdUnreachable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	l.Close()
+
+	_, err = New("fluentd", map[string]string{"fluentd-address": addr}, Info{ID: testID})
+	assert.Error(t, err)
+
+	// with async connect the failure is reported when output is sent
+	f, err := New("fluentd", map[string]string{"fluentd-address": addr, "fluentd-async-connect": "true"}, Info{ID: testID})
+	assert.NoError(t, err)
+	f.Close()
+}
@@ -0,0 +1,270 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jsonlog reads and writes container output in the docker json-file log format, where
+// each line of output is a JSON record of the line, the stream it was written to and when.
+package jsonlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+const (
+	// Stdout and Stderr are the names of the container output streams
+	Stdout = "stdout"
+	Stderr = "stderr"
+
+	// TimeFormat is the format docker shows log timestamps in
+	TimeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+)
+
+// Entry is the record of a line of output
+type Entry struct {
+	Log    string    `json:"log"`
+	Stream string    `json:"stream"`
+	Time   time.Time `json:"time"`
+}
+
+// Log records the output of several streams in a single log
+type Log struct {
+	m sync.Mutex
+	w io.Writer
+}
+
+// New returns a Log that writes records to w
+func New(w io.Writer) *Log {
+	return &Log{w: w}
+}
+
+// Stream returns a writer that records the output written to it as output of the named stream
+func (l *Log) Stream(name string) io.Writer {
+	return &streamWriter{log: l, name: name}
+}
+
+type streamWriter struct {
+	log  *Log
+	name string
+}
+
+// Write records each line of p, along with any trailing partial line, in a single write to the
+// log so the records of different streams are not interleaved
+func (s *streamWriter) Write(p []byte) (int, error) {
+	var buf bytes.Buffer
+	now := time.Now().UTC()
+
+	for rest := p; len(rest) > 0; {
+		line := rest
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line = rest[:i+1]
+		}
+		rest = rest[len(line):]
+
+		record, err := json.Marshal(&Entry{Log: string(line), Stream: s.name, Time: now})
+		if err != nil {
+			return 0, err
+		}
+		buf.Write(record)
+		buf.WriteByte('\n')
+	}
+
+	s.log.m.Lock()
+	defer s.log.m.Unlock()
+
+	if _, err := s.log.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Decoder reads the entries of a log
+type Decoder struct {
+	r   *bufio.Reader
+	err error
+}
+
+// NewDecoder returns a decoder reading from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode returns the next entry in the log. Lines that are not records, such as the output of
+// containers that predate the format, are returned as stdout entries without a time.
+func (d *Decoder) Decode() (*Entry, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	line, err := d.r.ReadBytes('\n')
+	if err != nil {
+		// return a trailing partial line before the error
+		d.err = err
+		if len(line) == 0 {
+			return nil, err
+		}
+	}
+
+	return parse(line), nil
+}
+
+// parse returns the entry recorded by a line of the log
+func parse(line []byte) *Entry {
+	var entry Entry
+	if len(line) > 0 && line[0] == '{' && json.Unmarshal(line, &entry) == nil && entry.Stream != "" {
+		return &entry
+	}
+
+	return &Entry{Log: string(line), Stream: Stdout}
+}
+
+// ReaderOptions selects the entries a Reader returns and how they are shown
+type ReaderOptions struct {
+	// Stdout and Stderr select the streams to return
+	Stdout bool
+	Stderr bool
+
+	// Since excludes the entries written before it, if it is set
+	Since time.Time
+
+	// Timestamps prefixes each line with the time it was written
+	Timestamps bool
+}
+
+// stream returns the stream to show the entry on, and whether the entry is selected at all
+func (o *ReaderOptions) stream(entry *Entry) (stdcopy.StdType, bool) {
+	var stream stdcopy.StdType
+	switch {
+	case entry.Stream == Stderr && o.Stderr:
+		stream = stdcopy.Stderr
+	case entry.Stream != Stderr && o.Stdout:
+		stream = stdcopy.Stdout
+	default:
+		return stream, false
+	}
+
+	// entries without a time cannot be shown to have been written since
+	if !o.Since.IsZero() && (entry.Time.IsZero() || entry.Time.Before(o.Since)) {
+		return stream, false
+	}
+
+	return stream, true
+}
+
+// tailChunkSize is the size of the chunks a log is read backwards in by Tail
+var tailChunkSize int64 = 16 * 1024
+
+// Tail seeks f to the start of the last n entries of the log that are selected by options, or
+// to the start of the log if there are fewer than n of them. The log is read backwards from
+// the end so only its tail is read.
+func Tail(f io.ReadSeeker, n int, options ReaderOptions) error {
+	end, err := f.Seek(0, io.SeekEnd)
+	if err != nil || n <= 0 {
+		return err
+	}
+
+	// the lines of the log that have been read but not yet parsed, which start with what may be
+	// the end of a line that starts in an earlier chunk
+	var rest []byte
+	for pos := end; pos > 0; {
+		start := pos - tailChunkSize
+		if start < 0 {
+			start = 0
+		}
+
+		if _, err = f.Seek(start, io.SeekStart); err != nil {
+			return err
+		}
+		chunk := make([]byte, pos-start)
+		if _, err = io.ReadFull(f, chunk); err != nil {
+			return err
+		}
+		rest = append(chunk, rest...)
+		pos = start
+
+		// parse the lines that are known to be complete, last first
+		for len(rest) > 0 {
+			i := bytes.LastIndexByte(rest[:len(rest)-1], '\n')
+			if i < 0 && pos > 0 {
+				break
+			}
+
+			line := rest[i+1:]
+			rest = rest[:i+1]
+
+			if _, ok := options.stream(parse(line)); !ok {
+				continue
+			}
+
+			if n--; n == 0 {
+				_, err = f.Seek(pos+int64(i+1), io.SeekStart)
+				return err
+			}
+		}
+	}
+
+	_, err = f.Seek(0, io.SeekStart)
+	return err
+}
+
+// Reader returns the selected entries of a log multiplexed in the docker stream format
+type Reader struct {
+	dec     *Decoder
+	options ReaderOptions
+	buf     bytes.Buffer
+}
+
+// NewReader returns a Reader for the log in r
+func NewReader(r io.Reader, options ReaderOptions) *Reader {
+	return &Reader{
+		dec:     NewDecoder(r),
+		options: options,
+	}
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		entry, err := r.dec.Decode()
+		if err != nil {
+			return 0, err
+		}
+
+		if err = r.write(entry); err != nil {
+			return 0, err
+		}
+	}
+
+	return r.buf.Read(p)
+}
+
+// write adds the entry to the buffer if it is selected
+func (r *Reader) write(entry *Entry) error {
+	stream, ok := r.options.stream(entry)
+	if !ok {
+		return nil
+	}
+
+	line := entry.Log
+	if r.options.Timestamps && !entry.Time.IsZero() {
+		line = entry.Time.Format(TimeFormat) + " " + line
+	}
+
+	_, err := stdcopy.NewStdWriter(&r.buf, stream).Write([]byte(line))
+	return err
+}
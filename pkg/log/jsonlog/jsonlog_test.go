@@ -0,0 +1,153 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAndDecode(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf)
+
+	start := time.Now().UTC()
+	l.Stream(Stdout).Write([]byte("one\ntwo\nthr"))
+	l.Stream(Stderr).Write([]byte("error\n"))
+
+	dec := NewDecoder(&buf)
+	expected := []Entry{
+		{Log: "one\n", Stream: Stdout},
+		{Log: "two\n", Stream: Stdout},
+		{Log: "thr", Stream: Stdout},
+		{Log: "error\n", Stream: Stderr},
+	}
+	for _, e := range expected {
+		entry, err := dec.Decode()
+		require.NoError(t, err)
+		assert.Equal(t, e.Log, entry.Log)
+		assert.Equal(t, e.Stream, entry.Stream)
+		assert.False(t, entry.Time.Before(start), "entry time %s before %s", entry.Time, start)
+	}
+
+	_, err := dec.Decode()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestDecodeRaw(t *testing.T) {
+	// output written before the log format was introduced
+	dec := NewDecoder(bytes.NewBufferString("plain\n{not json\npartial"))
+
+	for _, line := range []string{"plain\n", "{not json\n", "partial"} {
+		entry, err := dec.Decode()
+		require.NoError(t, err)
+		assert.Equal(t, &Entry{Log: line, Stream: Stdout}, entry)
+	}
+
+	_, err := dec.Decode()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReader(t *testing.T) {
+	old := time.Date(2016, 11, 1, 10, 0, 0, 0, time.UTC)
+	recent := old.Add(time.Hour)
+
+	var log bytes.Buffer
+	for _, e := range []Entry{
+		{Log: "old\n", Stream: Stdout, Time: old},
+		{Log: "out\n", Stream: Stdout, Time: recent},
+		{Log: "err\n", Stream: Stderr, Time: recent},
+	} {
+		record, err := json.Marshal(&e)
+		require.NoError(t, err)
+		log.Write(append(record, '\n'))
+	}
+	log.WriteString("raw\n")
+
+	read := func(options ReaderOptions) (string, string) {
+		var stdout, stderr bytes.Buffer
+		data, err := ioutil.ReadAll(NewReader(bytes.NewReader(log.Bytes()), options))
+		require.NoError(t, err)
+		_, err = stdcopy.StdCopy(&stdout, &stderr, bytes.NewReader(data))
+		require.NoError(t, err)
+		return stdout.String(), stderr.String()
+	}
+
+	stdout, stderr := read(ReaderOptions{Stdout: true, Stderr: true})
+	assert.Equal(t, "old\nout\nraw\n", stdout)
+	assert.Equal(t, "err\n", stderr)
+
+	stdout, stderr = read(ReaderOptions{Stderr: true})
+	assert.Empty(t, stdout)
+	assert.Equal(t, "err\n", stderr)
+
+	stdout, stderr = read(ReaderOptions{Stdout: true, Stderr: true, Since: old.Add(time.Minute)})
+	assert.Equal(t, "out\n", stdout)
+	assert.Equal(t, "err\n", stderr)
+
+	stdout, _ = read(ReaderOptions{Stdout: true, Timestamps: true})
+	assert.Equal(t, "2016-11-01T10:00:00.000000000Z old\n2016-11-01T11:00:00.000000000Z out\nraw\n", stdout)
+}
+
+func TestTail(t *testing.T) {
+	defer func(size int64) { tailChunkSize = size }(tailChunkSize)
+	tailChunkSize = 64
+
+	var log bytes.Buffer
+	l := New(&log)
+	for i := 0; i < 20; i++ {
+		l.Stream(Stdout).Write([]byte(fmt.Sprintf("out %d\n", i)))
+	}
+	l.Stream(Stderr).Write([]byte("err\n"))
+	l.Stream(Stdout).Write([]byte("partial"))
+
+	tail := func(n int, options ReaderOptions) string {
+		f := bytes.NewReader(log.Bytes())
+		require.NoError(t, Tail(f, n, options))
+
+		var stdout, stderr bytes.Buffer
+		data, err := ioutil.ReadAll(NewReader(f, options))
+		require.NoError(t, err)
+		_, err = stdcopy.StdCopy(&stdout, &stderr, bytes.NewReader(data))
+		require.NoError(t, err)
+		return stdout.String() + stderr.String()
+	}
+
+	all := ReaderOptions{Stdout: true, Stderr: true}
+	assert.Empty(t, tail(0, all))
+	assert.Equal(t, "partial", tail(1, all))
+	assert.Equal(t, "out 19\npartial"+"err\n", tail(3, all))
+
+	// the stderr entry is not counted when only stdout is selected
+	assert.Equal(t, "out 18\nout 19\npartial", tail(3, ReaderOptions{Stdout: true}))
+	assert.Equal(t, "err\n", tail(3, ReaderOptions{Stderr: true}))
+
+	// fewer entries than asked for
+	assert.Equal(t, 22, strings.Count(tail(100, all), "\n")+1)
+
+	// entries written before since are not counted
+	since := ReaderOptions{Stdout: true, Since: time.Now().Add(time.Hour)}
+	assert.Empty(t, tail(3, since))
+}
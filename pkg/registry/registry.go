@@ -0,0 +1,167 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package registry matches registry addresses against the registry whitelist and blacklist
+// entries of a VCH.
+package registry
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// hostnameChars are the characters permitted in the hostname of an entry, including those of
+// IPv6 addresses and the * wildcard
+const hostnameChars = "abcdefghijklmnopqrstuvwxyz0123456789.-:*"
+
+// Entry is a registry whitelist or blacklist entry
+type Entry interface {
+	// Match returns true if the registry at host, which may include a port, matches the entry
+	Match(host string) bool
+
+	String() string
+}
+
+// cidrEntry matches registries addressed by an IP in the network
+type cidrEntry struct {
+	network *net.IPNet
+}
+
+func (e *cidrEntry) Match(host string) bool {
+	hostname, _ := splitHostPort(host)
+
+	ip := net.ParseIP(hostname)
+	return ip != nil && e.network.Contains(ip)
+}
+
+func (e *cidrEntry) String() string {
+	return e.network.String()
+}
+
+// domainEntry matches registries by hostname, which may contain wildcards, and optionally port
+type domainEntry struct {
+	pattern string
+	port    string
+}
+
+func (e *domainEntry) Match(host string) bool {
+	hostname, port := splitHostPort(strings.ToLower(host))
+	if e.port != "" && e.port != port {
+		return false
+	}
+
+	matched, _ := path.Match(e.pattern, hostname)
+	return matched
+}
+
+func (e *domainEntry) String() string {
+	if e.port == "" {
+		return e.pattern
+	}
+	return net.JoinHostPort(e.pattern, e.port)
+}
+
+// ParseEntry parses a registry entry. An entry is either a network in CIDR notation, such as
+// 10.0.0.0/8, or a hostname or IP with an optional port. Hostnames may contain * wildcards,
+// so *.example.com matches every registry in the example.com domain. A URL is treated as an
+// entry for its host.
+func ParseEntry(s string) (Entry, error) {
+	entry := strings.ToLower(strings.TrimSpace(s))
+
+	if strings.Contains(entry, "://") {
+		u, err := url.Parse(entry)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("%s is not a valid registry URL", s)
+		}
+		entry = u.Host
+	}
+
+	if strings.Contains(entry, "/") {
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid CIDR", s)
+		}
+		return &cidrEntry{network: network}, nil
+	}
+
+	hostname, port := splitHostPort(entry)
+	if hostname == "" {
+		return nil, fmt.Errorf("%s is not a valid registry", s)
+	}
+	if port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+			return nil, fmt.Errorf("%s has an invalid port", s)
+		}
+	}
+	// only * is supported as a wildcard
+	for _, c := range hostname {
+		if !strings.ContainsRune(hostnameChars, c) {
+			return nil, fmt.Errorf("%s is not a valid registry", s)
+		}
+	}
+
+	return &domainEntry{pattern: hostname, port: port}, nil
+}
+
+// Set is a registry whitelist or blacklist
+type Set []Entry
+
+// ParseSet parses each of the registry entries
+func ParseSet(entries []string) (Set, error) {
+	var set Set
+	for _, s := range entries {
+		entry, err := ParseEntry(s)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, entry)
+	}
+
+	return set, nil
+}
+
+// Match returns true if the registry at host matches any entry in the set
+func (s Set) Match(host string) bool {
+	for _, entry := range s {
+		if entry.Match(host) {
+			return true
+		}
+	}
+	return false
+}
+
+// Strings returns the entries of the set in their canonical form
+func (s Set) Strings() []string {
+	strs := make([]string, len(s))
+	for i, entry := range s {
+		strs[i] = entry.String()
+	}
+	return strs
+}
+
+// splitHostPort splits host into hostname and port, where the port is optional
+func splitHostPort(host string) (string, string) {
+	if hostname, port, err := net.SplitHostPort(host); err == nil {
+		return hostname, port
+	}
+
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		return host[1 : len(host)-1], ""
+	}
+	return host, ""
+}
@@ -0,0 +1,62 @@
+// Copyright 2016 VMware, Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEntry(t *testing.T) {
+	valid := map[string]string{
+		"registry.example.com":              "registry.example.com",
+		"Registry.Example.com:5000":         "registry.example.com:5000",
+		"*.example.com":                     "*.example.com",
+		"10.0.0.0/8":                        "10.0.0.0/8",
+		"192.168.1.10:443":                  "192.168.1.10:443",
+		"https://registry.example.com/v2/":  "registry.example.com",
+		"http://registry.example.com:5000/": "registry.example.com:5000",
+		"[fd00::1]:5000":                    "[fd00::1]:5000",
+	}
+	for s, expected := range valid {
+		entry, err := ParseEntry(s)
+		if assert.NoError(t, err, s) {
+			assert.Equal(t, expected, entry.String())
+		}
+	}
+
+	for _, s := range []string{"", "10.0.0.0/33", "registry.example.com:port", "registry:70000", "[a-.example.com", "https://"} {
+		_, err := ParseEntry(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestSetMatch(t *testing.T) {
+	set, err := ParseSet([]string{"*.example.com", "registry.local:5000", "10.10.0.0/16", "docker.io"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, host := range []string{"a.example.com", "a.b.example.com:443", "registry.local:5000", "10.10.1.1", "10.10.1.1:5000", "docker.io"} {
+		assert.True(t, set.Match(host), host)
+	}
+
+	for _, host := range []string{"example.com", "registry.local", "registry.local:5001", "10.11.1.1", "index.docker.io", "evil.com"} {
+		assert.False(t, set.Match(host), host)
+	}
+
+	assert.False(t, Set(nil).Match("docker.io"))
+}